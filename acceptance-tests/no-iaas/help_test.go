@@ -98,8 +98,8 @@ var _ = Describe("bbl", func() {
 			Entry("Up", "up", "--aws-access-key-id", []string{"up", "--help"}),
 			Entry("Destroy", "destroy", "--no-confirm", []string{"help", "destroy"}),
 			Entry("Destroy", "destroy", "--no-confirm", []string{"destroy", "--help"}),
-			Entry("Rotate", "rotate", "Rotates SSH key", []string{"help", "rotate"}),
-			Entry("Rotate", "rotate", "Rotates SSH key", []string{"rotate", "--help"}),
+			Entry("Rotate", "rotate", "Rotates credentials", []string{"help", "rotate"}),
+			Entry("Rotate", "rotate", "Rotates credentials", []string{"rotate", "--help"}),
 			Entry("Version", "version", "Prints version", []string{"help", "version"}),
 			Entry("Version", "version", "Prints version", []string{"version", "--help"}),
 			Entry("Jumpbox Address", "jumpbox-address", "Prints BOSH jumpbox address", []string{"help", "jumpbox-address"}),
@@ -68,9 +68,17 @@ func (a App) execute() error {
 			return err
 		}
 
+		if a.configuration.Global.JSON {
+			return a.executeJSON("version", versionCommand, []string{}, storage.State{})
+		}
+
 		return versionCommand.Execute([]string{}, storage.State{})
 	}
 
+	if a.configuration.Global.JSON {
+		return a.executeJSON(a.configuration.Command, command, a.configuration.SubcommandFlags, a.configuration.State)
+	}
+
 	err = command.CheckFastFails(a.configuration.SubcommandFlags, a.configuration.State)
 	if err != nil {
 		return err
@@ -78,3 +86,17 @@ func (a App) execute() error {
 
 	return command.Execute(a.configuration.SubcommandFlags, a.configuration.State)
 }
+
+func (a App) executeJSON(name string, command commands.Command, subcommandFlags []string, state storage.State) error {
+	jsonCommand, ok := command.(commands.JSONCommand)
+	if !ok {
+		return fmt.Errorf("bbl %s does not support --json", name)
+	}
+
+	err := command.CheckFastFails(subcommandFlags, state)
+	if err != nil {
+		return err
+	}
+
+	return jsonCommand.ExecuteJSON(subcommandFlags, state)
+}
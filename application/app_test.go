@@ -183,6 +183,74 @@ var _ = Describe("App", func() {
 			})
 		})
 
+		Context("when --json is set", func() {
+			var jsonCmd *fakes.JSONCommand
+
+			BeforeEach(func() {
+				jsonCmd = &fakes.JSONCommand{}
+			})
+
+			It("executes the command as json", func() {
+				app = application.New(application.CommandSet{
+					"json": jsonCmd,
+				}, application.Configuration{
+					Command:         "json",
+					SubcommandFlags: []string{"--some-flag"},
+					Global:          application.GlobalConfiguration{JSON: true},
+					State:           storage.State{EnvID: "some-env"},
+				}, usage)
+
+				Expect(app.Run()).To(Succeed())
+
+				Expect(jsonCmd.CheckFastFailsCall.CallCount).To(Equal(1))
+				Expect(jsonCmd.ExecuteCall.CallCount).To(Equal(0))
+				Expect(jsonCmd.ExecuteJSONCall.CallCount).To(Equal(1))
+				Expect(jsonCmd.ExecuteJSONCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+				Expect(jsonCmd.ExecuteJSONCall.Receives.State).To(Equal(storage.State{EnvID: "some-env"}))
+			})
+
+			It("prints the version as json", func() {
+				app = application.New(application.CommandSet{
+					"version": jsonCmd,
+					"some":    someCmd,
+				}, application.Configuration{
+					Command:         "some",
+					SubcommandFlags: []string{"--version"},
+					Global:          application.GlobalConfiguration{JSON: true},
+				}, usage)
+
+				Expect(app.Run()).To(Succeed())
+
+				Expect(someCmd.ExecuteCall.CallCount).To(Equal(0))
+				Expect(jsonCmd.ExecuteJSONCall.CallCount).To(Equal(1))
+				Expect(jsonCmd.ExecuteJSONCall.Receives.SubcommandFlags).To(Equal([]string{}))
+			})
+
+			It("does not run the command when a fast fail occurs", func() {
+				jsonCmd.CheckFastFailsCall.Returns.Error = errors.New("fast failed command")
+				app = application.New(application.CommandSet{
+					"json": jsonCmd,
+				}, application.Configuration{
+					Command: "json",
+					Global:  application.GlobalConfiguration{JSON: true},
+				}, usage)
+
+				Expect(app.Run()).To(MatchError("fast failed command"))
+				Expect(jsonCmd.ExecuteJSONCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the command cannot print json", func() {
+				app = NewAppWithConfiguration(application.Configuration{
+					Command: "some",
+					Global:  application.GlobalConfiguration{JSON: true},
+				})
+
+				Expect(app.Run()).To(MatchError("bbl some does not support --json"))
+				Expect(someCmd.CheckFastFailsCall.CallCount).To(Equal(0))
+				Expect(someCmd.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
 		Context("error cases", func() {
 			Context("when a fast fail occurs", func() {
 				BeforeEach(func() {
@@ -1,10 +1,16 @@
 package application
 
-import "github.com/cloudfoundry/bosh-bootloader/storage"
+import (
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
 
 type GlobalConfiguration struct {
-	StateDir string
-	Debug    bool
+	StateDir   string
+	Workspace  string
+	ConfigFile string
+	Debug      bool
+	JSON       bool
 }
 
 type StringSlice []string
@@ -26,4 +32,5 @@ type Configuration struct {
 	SubcommandFlags StringSlice
 	State           storage.State
 	ShowCommandHelp bool
+	Settings        []commands.Setting
 }
@@ -3,14 +3,17 @@ package azure
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/arm/compute"
+	"github.com/Azure/azure-sdk-for-go/arm/resources/resources"
 	"github.com/Azure/go-autorest/autorest"
 )
 
 type Client struct {
-	azureVMsClient    AzureVMsClient
-	azureGroupsClient AzureGroupsClient
+	azureVMsClient       AzureVMsClient
+	azureGroupsClient    AzureGroupsClient
+	azureProvidersClient AzureProvidersClient
 }
 
 type AzureVMsClient interface {
@@ -21,6 +24,10 @@ type AzureGroupsClient interface {
 	CheckExistence(resourceGroupName string) (autorest.Response, error)
 }
 
+type AzureProvidersClient interface {
+	Get(resourceProviderNamespace string, expand string) (resources.Provider, error)
+}
+
 func (c Client) CheckExists(envID string) (bool, error) {
 	resourceGroupName := fmt.Sprintf("%s-bosh", envID)
 
@@ -73,3 +80,29 @@ func (c Client) ValidateSafeToDelete(networkName string, envID string) error {
 
 	return nil
 }
+
+// ValidateRegion returns an error unless the subscription can create
+// virtual machines in region. Azure lists regions by their display name,
+// such as "East US" for eastus.
+func (c Client) ValidateRegion(region string) error {
+	provider, err := c.azureProvidersClient.Get("Microsoft.Compute", "")
+	if err != nil {
+		return fmt.Errorf("Get Microsoft.Compute locations: %s", err)
+	}
+
+	if provider.ResourceTypes != nil {
+		for _, resourceType := range *provider.ResourceTypes {
+			if resourceType.ResourceType == nil || *resourceType.ResourceType != "virtualMachines" || resourceType.Locations == nil {
+				continue
+			}
+
+			for _, location := range *resourceType.Locations {
+				if strings.ToLower(strings.Replace(location, " ", "", -1)) == strings.ToLower(region) {
+					return nil
+				}
+			}
+		}
+	}
+
+	return fmt.Errorf("Azure region %q does not exist or cannot run virtual machines in this subscription. Check the region name.", region)
+}
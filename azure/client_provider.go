@@ -33,9 +33,14 @@ func NewClient(azureConfig storage.Azure) (Client, error) {
 	groupsClient.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
 	groupsClient.Sender = autorest.CreateSender(autorest.AsIs())
 
+	providersClient := resources.NewProvidersClient(azureConfig.SubscriptionID)
+	providersClient.Authorizer = autorest.NewBearerAuthorizer(servicePrincipalToken)
+	providersClient.Sender = autorest.CreateSender(autorest.AsIs())
+
 	client := Client{
-		azureVMsClient:    vmsClient,
-		azureGroupsClient: groupsClient,
+		azureVMsClient:       vmsClient,
+		azureGroupsClient:    groupsClient,
+		azureProvidersClient: providersClient,
 	}
 
 	_, err = ac.List()
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/Azure/azure-sdk-for-go/arm/compute"
+	"github.com/Azure/azure-sdk-for-go/arm/resources/resources"
 	"github.com/Azure/go-autorest/autorest"
 	"github.com/Azure/go-autorest/autorest/mocks"
 	"github.com/cloudfoundry/bosh-bootloader/azure"
@@ -165,4 +166,42 @@ var _ = Describe("Client", func() {
 			})
 		})
 	})
+
+	Describe("ValidateRegion", func() {
+		var (
+			providersClient *fakes.AzureProvidersClient
+			client          azure.Client
+		)
+
+		BeforeEach(func() {
+			providersClient = &fakes.AzureProvidersClient{}
+			client = azure.NewClientWithInjectedProvidersClient(providersClient)
+
+			availabilitySets, virtualMachines := "availabilitySets", "virtualMachines"
+			providersClient.GetCall.Returns.Provider = resources.Provider{
+				ResourceTypes: &[]resources.ProviderResourceType{
+					{ResourceType: &availabilitySets, Locations: &[]string{"Central US"}},
+					{ResourceType: &virtualMachines, Locations: &[]string{"East US", "West Europe"}},
+				},
+			}
+		})
+
+		It("accepts a region that can run virtual machines", func() {
+			err := client.ValidateRegion("westeurope")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(providersClient.GetCall.Receives.Namespace).To(Equal("Microsoft.Compute"))
+		})
+
+		It("returns an error for an unknown region", func() {
+			err := client.ValidateRegion("centralus")
+			Expect(err).To(MatchError(`Azure region "centralus" does not exist or cannot run virtual machines in this subscription. Check the region name.`))
+		})
+
+		It("returns an error when the locations cannot be listed", func() {
+			providersClient.GetCall.Returns.Error = errors.New("kiwi")
+
+			err := client.ValidateRegion("eastus")
+			Expect(err).To(MatchError("Get Microsoft.Compute locations: kiwi"))
+		})
+	})
 })
@@ -11,3 +11,9 @@ func NewClientWithInjectedGroupsClient(azureGroupsClient AzureGroupsClient) Clie
 		azureGroupsClient: azureGroupsClient,
 	}
 }
+
+func NewClientWithInjectedProvidersClient(azureProvidersClient AzureProvidersClient) Client {
+	return Client{
+		azureProvidersClient: azureProvidersClient,
+	}
+}
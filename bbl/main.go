@@ -22,6 +22,8 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/ssh"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
+	"github.com/cloudfoundry/bosh-bootloader/tunnel"
+	"github.com/cloudfoundry/bosh-bootloader/workspace"
 	proxy "github.com/cloudfoundry/socks5-proxy"
 	"github.com/spf13/afero"
 
@@ -48,32 +50,65 @@ var Version = "dev"
 func main() {
 	log.SetFlags(0)
 
-	logger := application.NewLogger(os.Stdout, os.Stdin)
-	stderrLogger := application.NewLogger(os.Stderr, os.Stdin)
-	stateBootstrap := storage.NewStateBootstrap(stderrLogger, Version)
-
 	globals, _, err := config.ParseArgs(os.Args)
 	if err != nil {
 		log.Fatalf("\n\n%s\n", err)
 	}
-	if globals.NoConfirm {
-		logger.NoConfirm()
-	}
 
 	// File IO
 	fs := afero.NewOsFs()
 	afs := &afero.Afero{Fs: fs}
 
+	stateCipher, err := storage.LoadCipher(globals.StatePassphrase, globals.StateKeyFile, afs)
+	if err != nil {
+		log.Fatalf("\n\n%s\n", err)
+	}
+	encryptedFs := storage.NewEncryptedFs(afs, stateCipher)
+
+	// Commands that change the environment log what they print, and what
+	// terraform and bosh print, in the state directory. The vars stores
+	// are read through encryptedFs to redact their secrets.
+	runLog := storage.NewRunLog(globals.StateDir, encryptedFs, Version)
+
+	logger := application.NewLogger(io.MultiWriter(os.Stdout, runLog.Writer("bbl")), os.Stdin)
+	stderrLogger := application.NewLogger(io.MultiWriter(os.Stderr, runLog.Writer("bbl")), os.Stdin)
+	if globals.NoConfirm {
+		logger.NoConfirm()
+	}
+
+	// With --json, stdout carries only the JSON document.
+	terraformLogger := logger
+	if globals.JSON {
+		terraformLogger = stderrLogger
+	}
+
 	// bbl Configuration
+	stateEncryptor := storage.NewEncryptor(globals.StateDir, afs, stateCipher)
+	stateBootstrap := storage.NewStateBootstrap(stderrLogger, Version, stateCipher)
+
+	stateBackend, err := storage.NewBackend(storage.BackendConfig{
+		URL:             globals.StateBackend,
+		Endpoint:        globals.StateBackendEndpoint,
+		Region:          globals.StateBackendRegion,
+		AccessKeyID:     globals.StateBackendAccessKeyID,
+		SecretAccessKey: globals.StateBackendSecretAccessKey,
+	}, afs, stateCipher)
+	if err != nil {
+		log.Fatalf("\n\n%s\n", err)
+	}
 	garbageCollector := storage.NewGarbageCollector(afs)
-	stateStore := storage.NewStore(globals.StateDir, afs, garbageCollector)
-	stateMigrator := storage.NewMigrator(stateStore, afs)
-	newConfig := config.NewConfig(stateBootstrap, stateMigrator, stderrLogger, afs)
+	stateSnapshots := storage.NewSnapshots(afs, stateCipher)
+	stateStore := storage.NewStore(globals.StateDir, encryptedFs, garbageCollector, stateBackend, stateSnapshots)
+	stateMigrator := storage.NewMigrator(stateStore, encryptedFs)
+	remoteStateBootstrap := storage.NewRemoteStateBootstrap(stateBootstrap, stateBackend)
+	newConfig := config.NewConfig(remoteStateBootstrap, stateMigrator, stderrLogger, afs)
 
 	appConfig, err := newConfig.Bootstrap(os.Args)
 	if err != nil {
 		log.Fatalf("\n\n%s\n", err)
 	}
+	stateLoader := config.NewStateLoader(newConfig, os.Args)
+	pruningStateLoader := config.NewPruningStateLoader(newConfig, os.Args, remoteStateBootstrap)
 
 	needsIAASCreds := config.NeedsIAASCreds(appConfig.Command) && !appConfig.ShowCommandHelp
 	if needsIAASCreds {
@@ -88,25 +123,25 @@ func main() {
 	stateValidator := application.NewStateValidator(appConfig.Global.StateDir)
 	certificateValidator := certs.NewValidator()
 	lbArgsHandler := commands.NewLBArgsHandler(certificateValidator)
-	sshCmd := ssh.NewCmd(os.Stdin, os.Stdout, os.Stderr)
 
 	// Terraform
 	terraformOutputBuffer := bytes.NewBuffer([]byte{})
+	terraformOutput := io.MultiWriter(terraformOutputBuffer, runLog.Writer("terraform"))
 	dotTerraformDir := filepath.Join(appConfig.Global.StateDir, "terraform", ".terraform")
-	bufferingCmd := terraform.NewCmd(terraformOutputBuffer, terraformOutputBuffer, dotTerraformDir)
+	bufferingCmd := terraform.NewCmd(terraformOutput, terraformOutput, dotTerraformDir)
 	var (
 		terraformCmd terraform.Cmd
 		out          io.Writer
 	)
 	if appConfig.Global.Debug {
-		errBuffer := io.MultiWriter(os.Stderr, terraformOutputBuffer)
-		terraformCmd = terraform.NewCmd(errBuffer, terraformOutputBuffer, dotTerraformDir)
+		errBuffer := io.MultiWriter(os.Stderr, terraformOutput)
+		terraformCmd = terraform.NewCmd(errBuffer, terraformOutput, dotTerraformDir)
 		out = os.Stdout
 	} else {
 		terraformCmd = bufferingCmd
 		out = ioutil.Discard
 	}
-	terraformExecutor := terraform.NewExecutor(terraformCmd, bufferingCmd, stateStore, afs, appConfig.Global.Debug, out)
+	terraformExecutor := terraform.NewExecutor(terraformCmd, bufferingCmd, stateStore, encryptedFs, appConfig.Global.Debug, out)
 
 	// BOSH
 	hostKey := proxy.NewHostKey()
@@ -115,11 +150,12 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	boshCommand := bosh.NewCmd(os.Stderr, boshPath)
-	boshExecutor := bosh.NewExecutor(boshCommand, afs)
-	sshKeyGetter := bosh.NewSSHKeyGetter(stateStore, afs)
+	boshCommand := bosh.NewCmd(io.MultiWriter(os.Stderr, runLog.Writer("bosh")), boshPath)
+	boshExecutor := bosh.NewExecutor(boshCommand, afs, io.MultiWriter(os.Stdout, runLog.Writer("bosh")), io.MultiWriter(os.Stderr, runLog.Writer("bosh")))
+	sshKeyGetter := bosh.NewSSHKeyGetter(stateStore, encryptedFs)
+	sshClient := ssh.NewClient(os.Stdin, os.Stdout, os.Stderr, ssh.NewKnownHosts(stateStore, encryptedFs))
 	allProxyGetter := bosh.NewAllProxyGetter(sshKeyGetter, afs)
-	credhubGetter := bosh.NewCredhubGetter(stateStore, afs)
+	credhubGetter := bosh.NewCredhubGetter(stateStore, encryptedFs)
 	boshManager := bosh.NewManager(boshExecutor, logger, stateStore, sshKeyGetter, afs)
 	boshClientProvider := bosh.NewClientProvider(socks5Proxy, sshKeyGetter)
 
@@ -203,7 +239,7 @@ func main() {
 		templateGenerator = awsterraform.NewTemplateGenerator()
 		inputGenerator = awsterraform.NewInputGenerator(availabilityZoneRetriever)
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, terraformLogger)
 
 		cloudConfigOpsGenerator = awscloudconfig.NewOpsGenerator(terraformManager, availabilityZoneRetriever)
 
@@ -212,7 +248,7 @@ func main() {
 		templateGenerator = azureterraform.NewTemplateGenerator()
 		inputGenerator = azureterraform.NewInputGenerator()
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, terraformLogger)
 
 		cloudConfigOpsGenerator = azurecloudconfig.NewOpsGenerator(terraformManager)
 
@@ -221,7 +257,7 @@ func main() {
 		templateGenerator = gcpterraform.NewTemplateGenerator()
 		inputGenerator = gcpterraform.NewInputGenerator()
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, terraformLogger)
 
 		cloudConfigOpsGenerator = gcpcloudconfig.NewOpsGenerator(terraformManager)
 
@@ -230,7 +266,7 @@ func main() {
 		templateGenerator = vsphereterraform.NewTemplateGenerator()
 		inputGenerator = vsphereterraform.NewInputGenerator()
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, terraformLogger)
 
 		cloudConfigOpsGenerator = vspherecloudconfig.NewOpsGenerator(terraformManager)
 
@@ -238,7 +274,7 @@ func main() {
 		templateGenerator = openstackterraform.NewTemplateGenerator()
 		inputGenerator = openstackterraform.NewInputGenerator()
 
-		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, logger)
+		terraformManager = terraform.NewManager(terraformExecutor, templateGenerator, inputGenerator, terraformOutputBuffer, terraformLogger)
 
 		cloudConfigOpsGenerator = openstackcloudconfig.NewOpsGenerator(terraformManager)
 	}
@@ -251,19 +287,62 @@ func main() {
 		envIDManager = helpers.NewEnvIDManager(envIDGenerator, networkClient)
 	}
 	plan := commands.NewPlan(boshManager, cloudConfigManager, stateStore, envIDManager, terraformManager, lbArgsHandler, stderrLogger, Version)
-	up := commands.NewUp(plan, boshManager, cloudConfigManager, stateStore, terraformManager)
+	fingerprinter := storage.NewFingerprinter(encryptedFs, appConfig.Global.StateDir)
+	up := commands.NewUp(plan, boshManager, cloudConfigManager, stateStore, terraformManager, fingerprinter, logger)
 	usage := commands.NewUsage(logger)
 
+	locked := func(name string, command commands.Command) commands.Command {
+		return commands.NewLocked(commands.NewLogged(command, name, runLog), name, stateStore, stateLoader)
+	}
+	mutating := func(name string, command commands.Command) commands.Command {
+		return commands.NewLocked(commands.NewLogged(commands.NewSealed(command, name, stateEncryptor), name, runLog), name, stateStore, pruningStateLoader)
+	}
+
 	commandSet := application.CommandSet{}
 	commandSet["help"] = usage
 	commandSet["version"] = commands.NewVersion(Version, logger)
 	commandSet["outputs"] = commands.NewOutputs(logger, terraformManager, stateValidator)
-	commandSet["up"] = up
-	commandSet["plan"] = plan
-	sshKeyDeleter := bosh.NewSSHKeyDeleter(stateStore, afs)
-	commandSet["rotate"] = commands.NewRotate(stateValidator, sshKeyDeleter, up)
-	commandSet["destroy"] = commands.NewDestroy(plan, logger, boshManager, stateStore, stateValidator, terraformManager, networkDeletionValidator)
+	commandSet["up"] = mutating("up", up)
+	commandSet["plan"] = mutating("plan", plan)
+	credentialRotator := bosh.NewCredentialRotator(stateStore, encryptedFs)
+	commandSet["rotate"] = mutating("rotate", commands.NewRotate(stateValidator, credentialRotator, boshManager, stateStore, up, stateLoader, logger))
+	commandSet["destroy"] = mutating("destroy", commands.NewDestroy(plan, logger, boshManager, stateStore, stateValidator, terraformManager, networkDeletionValidator))
 	commandSet["down"] = commandSet["destroy"]
+	commandSet["unlock"] = commands.NewUnlock(logger, stateStore)
+	commandSet["drift"] = commands.NewDrift(logger, stateValidator, terraformManager, cloudConfigManager)
+	commandSet["certs"] = commands.NewCerts(logger, stateValidator, bosh.NewCertificateGetter(stateStore, encryptedFs))
+	commandSet["versions"] = commands.NewDeploymentVersions(logger, stateValidator, bosh.NewVersionGetter(), Version)
+	varsEditor := bosh.NewVarsEditor(stateStore, encryptedFs)
+	commandSet["vars"] = commands.NewGroup("vars", commands.VarsCommandUsage, map[string]commands.Command{
+		"list":   commands.NewVarsList(logger, stateValidator, varsEditor),
+		"get":    commands.NewVarsGet(logger, stateValidator, varsEditor),
+		"set":    mutating("vars set", commands.NewVarsSet(logger, stateValidator, varsEditor, stateStore)),
+		"delete": mutating("vars delete", commands.NewVarsDelete(logger, stateValidator, varsEditor, stateStore)),
+	})
+	bblExecutable, err := os.Executable()
+	if err != nil {
+		bblExecutable = os.Args[0]
+	}
+	environments := workspace.New(appConfig.Global.Workspace, afs, stateBootstrap)
+	commandSet["env"] = commands.NewGroup("env", commands.EnvCommandUsage, map[string]commands.Command{
+		"list":    commands.NewEnvList(logger, environments, appConfig.Global.Workspace),
+		"foreach": commands.NewEnvForeach(logger, environments, workspace.NewRunner(bblExecutable)),
+	})
+	commandSet["init"] = commands.NewInit(logger, os.Stdin, newConfig, lbArgsHandler, afs, stateCipher, appConfig.Global.StateDir, appConfig.Global.ConfigFile)
+	commandSet["completion"] = commands.NewCompletion(logger, commandSet, config.CompletionFlags(), environments)
+	commandSet["config"] = commands.NewGroup("config", commands.ConfigCommandUsage, map[string]commands.Command{
+		"show": commands.NewConfigShow(logger, appConfig.Settings),
+	})
+	commandSet["doctor"] = locked("doctor", commands.NewDoctor(logger, stateValidator, stateStore, terraformManager, boshManager, cloudConfigManager, afs, appConfig.Global.StateDir))
+	commandSet["state"] = commands.NewGroup("state", commands.StateCommandUsage, map[string]commands.Command{
+		"show":     commands.NewStateShow(logger, stateValidator, terraformManager),
+		"encrypt":  locked("state encrypt", commands.NewStateEncrypt(logger, stateValidator, stateEncryptor)),
+		"decrypt":  locked("state decrypt", commands.NewStateDecrypt(logger, stateValidator, stateEncryptor)),
+		"export":   commands.NewStateExport(logger, stateValidator, storage.NewBundler(encryptedFs, stateCipher), afs, appConfig.Global.StateDir),
+		"import":   mutating("state import", commands.NewStateImport(logger, storage.NewBundler(encryptedFs, stateCipher), stateMigrator, boshManager, afs, appConfig.Global.StateDir)),
+		"history":  commands.NewStateHistory(logger, stateValidator, stateStore),
+		"rollback": mutating("state rollback", commands.NewStateRollback(logger, stateValidator, stateStore)),
+	})
 	commandSet["cleanup-leftovers"] = commands.NewCleanupLeftovers(leftovers)
 	commandSet["leftovers"] = commandSet["cleanup-leftovers"]
 	commandSet["lbs"] = commands.NewLBs(lbsCmd, stateValidator)
@@ -276,12 +355,19 @@ func main() {
 	commandSet["director-ssh-key"] = commands.NewDirectorSSHKey(logger, stateValidator, sshKeyGetter)
 	commandSet["env-id"] = commands.NewStateQuery(logger, stateValidator, terraformManager, commands.EnvIDPropertyName)
 	commandSet["latest-error"] = commands.NewLatestError(logger, stateValidator)
+	commandSet["logs"] = commands.NewLogs(logger, runLog)
 	commandSet["print-env"] = commands.NewPrintEnv(logger, stderrLogger, stateValidator, allProxyGetter, credhubGetter, terraformManager, afs)
-	commandSet["ssh"] = commands.NewSSH(sshCmd, sshKeyGetter, afs, ssh.RandomPort{})
+	commandSet["ssh"] = commands.NewSSH(sshClient, sshKeyGetter)
+	commandSet["scp"] = commands.NewSCP(sshClient, sshKeyGetter)
+	commandSet["tunnel"] = commands.NewTunnel(logger, sshKeyGetter, tunnel.NewServer(socks5Proxy, os.Stderr), tunnel.Process{}, afs, appConfig.Global.StateDir, config.ChildEnv(globals, os.Environ()))
 
 	app := application.New(commandSet, appConfig, usage)
 
 	err = app.Run()
+	if exitErr, ok := err.(commands.ExitError); ok {
+		log.Printf("\n\n%s\n", exitErr)
+		os.Exit(exitErr.Code)
+	}
 	if err != nil {
 		log.Fatalf("\n\n%s\n", err)
 	}
@@ -30,8 +30,21 @@ func (a AllProxyGetter) GeneratePrivateKey() (string, error) {
 		return "", err
 	}
 
-	privateKeyPath := filepath.Join(dir, "bosh_jumpbox_private.key")
+	return a.writePrivateKey(filepath.Join(dir, "bosh_jumpbox_private.key"))
+}
+
+// WritePrivateKey writes the jumpbox private key to a path chosen by the
+// caller instead of a temp dir, and returns the absolute path.
+func (a AllProxyGetter) WritePrivateKey(path string) (string, error) {
+	privateKeyPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err // not tested
+	}
+
+	return a.writePrivateKey(privateKeyPath)
+}
 
+func (a AllProxyGetter) writePrivateKey(privateKeyPath string) (string, error) {
 	privateKeyContents, err := a.sshKeyGetter.Get("jumpbox")
 	if err != nil {
 		return "", err
@@ -42,7 +55,7 @@ func (a AllProxyGetter) GeneratePrivateKey() (string, error) {
 		return "", err
 	}
 
-	return privateKeyPath, err
+	return privateKeyPath, nil
 }
 
 func (a AllProxyGetter) BoshAllProxy(jumpboxURL, privateKeyPath string) string {
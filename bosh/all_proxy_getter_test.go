@@ -2,6 +2,7 @@ package bosh_test
 
 import (
 	"errors"
+	"os"
 	"path/filepath"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
@@ -67,6 +68,35 @@ var _ = Describe("AllProxyGetter", func() {
 
 	})
 
+	Describe("WritePrivateKey", func() {
+		It("writes the private key to the given path", func() {
+			key, err := allProxyGetter.WritePrivateKey("/some/dir/jumpbox.key")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(key).To(Equal("/some/dir/jumpbox.key"))
+			Expect(fs.TempDirCall.CallCount).To(Equal(0))
+			Expect(fs.WriteFileCall.Receives[0].Filename).To(Equal("/some/dir/jumpbox.key"))
+			Expect(fs.WriteFileCall.Receives[0].Contents).To(Equal([]byte("some-private-key")))
+			Expect(fs.WriteFileCall.Receives[0].Mode).To(Equal(os.FileMode(0600)))
+		})
+
+		It("returns an absolute path", func() {
+			key, err := allProxyGetter.WritePrivateKey("jumpbox.key")
+			Expect(err).NotTo(HaveOccurred())
+
+			workingDir, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal(filepath.Join(workingDir, "jumpbox.key")))
+		})
+
+		It("returns an error when the private key can't be written", func() {
+			fs.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("mango")}}
+
+			_, err := allProxyGetter.WritePrivateKey("/some/dir/jumpbox.key")
+			Expect(err).To(MatchError("mango"))
+		})
+	})
+
 	Describe("BoshAllProxy", func() {
 		It("interpolates a string!", func() {
 			result := allProxyGetter.BoshAllProxy("jumpbox-url", "private-key-file")
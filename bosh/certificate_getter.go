@@ -0,0 +1,59 @@
+package bosh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+type CertificateGetter struct {
+	stateStore stateStore
+	fReader    fileio.FileReader
+}
+
+func NewCertificateGetter(stateStore stateStore, fReader fileio.FileReader) CertificateGetter {
+	return CertificateGetter{
+		stateStore: stateStore,
+		fReader:    fReader,
+	}
+}
+
+// Get returns the certificates in the deployment's vars store by variable
+// name. A deployment that was never created has none.
+func (c CertificateGetter) Get(deployment string) (map[string]string, error) {
+	varsDir, err := c.stateStore.GetVarsDir()
+	if err != nil {
+		return nil, fmt.Errorf("Get vars directory: %s", err)
+	}
+
+	varsStore, err := c.fReader.ReadFile(filepath.Join(varsDir, fmt.Sprintf("%s-vars-store.yml", deployment)))
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Read %s vars file: %s", deployment, err)
+	}
+
+	var vars map[string]interface{}
+	err = yaml.Unmarshal(varsStore, &vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s variables: %s", deployment, err)
+	}
+
+	certificates := map[string]string{}
+	for name, value := range vars {
+		variable, ok := value.(map[interface{}]interface{})
+		if !ok {
+			continue
+		}
+		if certificate, ok := variable["certificate"].(string); ok && certificate != "" {
+			certificates[name] = certificate
+		}
+	}
+
+	return certificates, nil
+}
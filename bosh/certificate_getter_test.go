@@ -0,0 +1,83 @@
+package bosh_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CertificateGetter", func() {
+	var (
+		stateStore        *fakes.StateStore
+		fileIO            *fakes.FileIO
+		certificateGetter bosh.CertificateGetter
+	)
+
+	BeforeEach(func() {
+		stateStore = &fakes.StateStore{}
+		stateStore.GetVarsDirCall.Returns.Directory = "some-vars-dir"
+		fileIO = &fakes.FileIO{}
+		fileIO.ReadFileCall.Returns.Contents = []byte(`admin_password: some-password
+default_ca:
+  ca: some-ca
+  certificate: some-ca
+  private_key: some-ca-key
+director_ssl:
+  ca: some-ca
+  certificate: some-certificate
+  private_key: some-key
+jumpbox_ssh:
+  private_key: some-ssh-key
+`)
+
+		certificateGetter = bosh.NewCertificateGetter(stateStore, fileIO)
+	})
+
+	It("returns the certificates in the vars store", func() {
+		certificates, err := certificateGetter.Get("director")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fileIO.ReadFileCall.Receives.Filename).To(Equal(filepath.Join("some-vars-dir", "director-vars-store.yml")))
+		Expect(certificates).To(Equal(map[string]string{
+			"default_ca":   "some-ca",
+			"director_ssl": "some-certificate",
+		}))
+	})
+
+	It("returns no certificates when the vars store does not exist", func() {
+		fileIO.ReadFileCall.Returns.Error = &os.PathError{Op: "open", Path: "director-vars-store.yml", Err: os.ErrNotExist}
+
+		certificates, err := certificateGetter.Get("director")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certificates).To(BeEmpty())
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the vars dir can't be accessed", func() {
+			stateStore.GetVarsDirCall.Returns.Error = errors.New("potato")
+
+			_, err := certificateGetter.Get("director")
+			Expect(err).To(MatchError("Get vars directory: potato"))
+		})
+
+		It("returns an error when the vars store can't be read", func() {
+			fileIO.ReadFileCall.Returns.Error = errors.New("carrot")
+
+			_, err := certificateGetter.Get("jumpbox")
+			Expect(err).To(MatchError("Read jumpbox vars file: carrot"))
+		})
+
+		It("returns an error when the vars store is invalid YAML", func() {
+			fileIO.ReadFileCall.Returns.Contents = []byte("invalid yaml")
+
+			_, err := certificateGetter.Get("jumpbox")
+			Expect(err).To(MatchError(ContainSubstring("jumpbox variables: yaml: unmarshal errors:")))
+		})
+	})
+})
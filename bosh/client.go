@@ -17,6 +17,7 @@ import (
 
 type Client interface {
 	UpdateCloudConfig(yaml []byte) error
+	CloudConfig() (string, error)
 	Info() (Info, error)
 }
 
@@ -79,14 +80,70 @@ func (c client) UpdateCloudConfig(yaml []byte) error {
 	}
 	request.Header.Set("Content-Type", "text/yaml")
 
-	urlParts, err := url.Parse(c.directorAddress)
+	httpClient, err := c.uaaClient()
 	if err != nil {
 		return err //not tested
 	}
 
+	response, err := makeRequests(httpClient, request)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	return nil
+}
+
+// CloudConfig returns the cloud config the director uses now, or an empty
+// string when none has been uploaded.
+func (c client) CloudConfig() (string, error) {
+	request, err := http.NewRequest("GET", fmt.Sprintf("%s/configs?type=cloud&latest=true", c.directorAddress), strings.NewReader(""))
+	if err != nil {
+		return "", err
+	}
+
+	httpClient, err := c.uaaClient()
+	if err != nil {
+		return "", err //not tested
+	}
+
+	response, err := makeRequests(httpClient, request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
+	}
+
+	var configs []struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&configs); err != nil {
+		return "", err
+	}
+
+	if len(configs) == 0 {
+		return "", nil
+	}
+
+	return configs[0].Content, nil
+}
+
+// uaaClient authenticates requests with a token from the director's UAA.
+func (c client) uaaClient() (*http.Client, error) {
+	urlParts, err := url.Parse(c.directorAddress)
+	if err != nil {
+		return nil, err
+	}
+
 	boshHost, _, err := net.SplitHostPort(urlParts.Host)
 	if err != nil {
-		return err //not tested
+		return nil, err
 	}
 
 	ctx := context.Background()
@@ -98,17 +155,7 @@ func (c client) UpdateCloudConfig(yaml []byte) error {
 		TokenURL:     fmt.Sprintf("https://%s:8443/oauth/token", boshHost),
 	}
 
-	httpClient := conf.Client(ctx)
-	response, err := makeRequests(httpClient, request)
-	if err != nil {
-		return err
-	}
-
-	if response.StatusCode != http.StatusCreated {
-		return fmt.Errorf("unexpected http response %d %s", response.StatusCode, http.StatusText(response.StatusCode))
-	}
-
-	return nil
+	return conf.Client(ctx), nil
 }
 
 func makeRequests(httpClient *http.Client, request *http.Request) (*http.Response, error) {
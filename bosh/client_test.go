@@ -86,6 +86,16 @@ var _ = Describe("Client", func() {
 				var err error
 				cloudConfig, err = ioutil.ReadAll(req.Body)
 				Expect(err).NotTo(HaveOccurred())
+			case "/configs":
+				if failStatus != 0 {
+					w.WriteHeader(failStatus)
+					return
+				}
+
+				token = req.Header.Get("Authorization")
+
+				Expect(req.URL.RawQuery).To(Equal("type=cloud&latest=true"))
+				w.Write([]byte(`[{"id": "1", "type": "cloud", "name": "default", "content": "cloud: config"}]`))
 			default:
 				dump, err := httputil.DumpRequest(req, true)
 				Expect(err).NotTo(HaveOccurred())
@@ -215,4 +225,45 @@ var _ = Describe("Client", func() {
 			})
 		})
 	})
+
+	Describe("CloudConfig", func() {
+		var dialer *fakes.Socks5Client
+
+		BeforeEach(func() {
+			dialer = &fakes.Socks5Client{}
+			dialer.DialCall.Stub = func(network, addr string) (net.Conn, error) {
+				u, _ := url.Parse(fakeBOSH.URL)
+				return net.Dial(network, u.Host)
+			}
+
+			httpClient = &http.Client{
+				Transport: &http.Transport{
+					Dial:            dialer.Dial,
+					TLSClientConfig: tlsConfig,
+				},
+			}
+		})
+
+		It("returns the latest cloud config", func() {
+			fakeBOSH.StartTLS()
+
+			client := bosh.NewClient(httpClient, fakeBOSH.URL, "some-username", "some-password", string(ca))
+
+			cloudConfig, err := client.CloudConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(token).To(Equal("Bearer some-uaa-token"))
+			Expect(cloudConfig).To(Equal("cloud: config"))
+		})
+
+		It("returns an error when the response is not StatusOK", func() {
+			failStatus = http.StatusNotFound
+			fakeBOSH.StartTLS()
+
+			client := bosh.NewClient(httpClient, fakeBOSH.URL, "some-username", "some-password", string(ca))
+
+			_, err := client.CloudConfig()
+			Expect(err).To(MatchError("unexpected http response 404 Not Found"))
+		})
+	})
 })
@@ -0,0 +1,308 @@
+package bosh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+type deleterFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.TempDirer
+}
+
+type rotatorFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.Remover
+	fileio.AllMkdirer
+}
+
+type credential struct {
+	deployment string
+	variables  []string
+}
+
+// credentials are replaced in one bbl up: the vars store entries are
+// removed and bosh create-env generates new ones.
+var credentials = map[string]credential{
+	"jumpbox-ssh":     {deployment: "jumpbox", variables: []string{"jumpbox_ssh"}},
+	"director-ssh":    {deployment: "director", variables: []string{"jumpbox_ssh"}},
+	"director-admin":  {deployment: "director", variables: []string{"admin_password"}},
+	"uaa-clients":     {deployment: "director", variables: []string{"uaa_admin_client_secret", "uaa_login_client_secret", "uaa_clients_director_to_credhub"}},
+	"credhub-admin":   {deployment: "director", variables: []string{"credhub_admin_client_secret"}},
+	"nats-certs":      {deployment: "director", variables: []string{"nats_server_tls", "nats_clients_director_tls", "nats_clients_health_monitor_tls"}},
+	"blobstore-certs": {deployment: "director", variables: []string{"blobstore_server_tls"}},
+}
+
+type twoPhaseCredential struct {
+	// replaced are regenerated in the first phase. Their old values are
+	// kept as <name>_old so the ops below can keep trusting them.
+	replaced []string
+	// reissued are regenerated in the second phase, which also drops the
+	// old values.
+	reissued []string
+	ops      string
+}
+
+// twoPhaseCredentials are replaced over two bbl rotate runs so that
+// deployments keep working while they move to the new values.
+var twoPhaseCredentials = map[string]twoPhaseCredential{
+	"ca": {
+		replaced: []string{"default_ca", "nats_ca", "credhub_ca"},
+		reissued: []string{
+			"director_ssl", "mbus_bootstrap_ssl", "uaa_ssl", "uaa_service_provider_ssl", "blobstore_server_tls",
+			"nats_server_tls", "nats_clients_director_tls", "nats_clients_health_monitor_tls",
+			"credhub_tls",
+		},
+		ops: CARotationOps,
+	},
+	"credhub-encryption": {
+		replaced: []string{"credhub_encryption_password"},
+		ops:      CredHubEncryptionRotationOps,
+	},
+}
+
+// CredentialRotationOpsFile is added to create-director.sh while a
+// two-phase rotation is between its phases.
+const CredentialRotationOpsFile = "credential-rotation.yml"
+
+type CredentialRotator struct {
+	stateStore stateStore
+	fs         rotatorFs
+}
+
+func NewCredentialRotator(stateStore stateStore, fs rotatorFs) CredentialRotator {
+	return CredentialRotator{
+		stateStore: stateStore,
+		fs:         fs,
+	}
+}
+
+// Credentials returns the names bbl rotate --credentials accepts.
+func Credentials() []string {
+	names := []string{}
+	for name := range credentials {
+		names = append(names, name)
+	}
+	for name := range twoPhaseCredentials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rotate removes the named credentials from the vars stores so that the
+// next bbl up generates new ones. A two-phase credential moves to its
+// next phase each time it is rotated. Certificates that a two-phase
+// rotation reissues are left to it while it is in progress. Rotate reports
+// what it did.
+func (c CredentialRotator) Rotate(names []string) ([]storage.Rotation, error) {
+	varsDir, err := c.stateStore.GetVarsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	deployments := []string{"jumpbox", "director"}
+	stores := map[string]*varsStore{}
+	for _, deployment := range deployments {
+		stores[deployment], err = c.readVarsStore(filepath.Join(varsDir, fmt.Sprintf("%s-vars-store.yml", deployment)))
+		if err != nil {
+			return nil, fmt.Errorf("%s variables: %s", strings.Title(deployment), err)
+		}
+	}
+
+	rotations := []storage.Rotation{}
+	for _, name := range names {
+		if cred, ok := credentials[name]; ok {
+			issuer, reissued := reissuedBy(cred)
+			if reissued && contains(names, issuer) {
+				continue
+			}
+			if reissued && stores["director"].rotating(twoPhaseCredentials[issuer]) {
+				return nil, fmt.Errorf("Cannot rotate %s while %s is being rotated: run `bbl rotate --credentials=%s` to finish rotating %s first.", name, issuer, issuer, issuer)
+			}
+
+			stores[cred.deployment].delete(cred.variables...)
+			rotations = append(rotations, storage.Rotation{Credential: name})
+			continue
+		}
+
+		cred, ok := twoPhaseCredentials[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown credential %q. Choose from: %s.", name, strings.Join(Credentials(), ", "))
+		}
+
+		director := stores["director"]
+		if director.rotating(cred) {
+			director.delete(oldNames(cred.replaced)...)
+			director.delete(cred.reissued...)
+			rotations = append(rotations, storage.Rotation{Credential: name, Phase: 2})
+		} else {
+			for _, variable := range cred.replaced {
+				director.rename(variable, variable+"_old")
+			}
+			rotations = append(rotations, storage.Rotation{Credential: name, Phase: 1})
+		}
+	}
+
+	for _, deployment := range deployments {
+		err = c.writeVarsStore(stores[deployment])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = c.writeOps(stores["director"])
+	if err != nil {
+		return nil, err
+	}
+
+	return rotations, nil
+}
+
+// writeOps writes the ops for the two-phase rotations between their
+// phases, or removes the ops file when there are none.
+func (c CredentialRotator) writeOps(director *varsStore) error {
+	opsDir := filepath.Join(c.stateStore.GetStateDir(), "bbl-ops-files")
+	opsFile := filepath.Join(opsDir, CredentialRotationOpsFile)
+
+	ops := ""
+	for _, name := range Credentials() {
+		cred, ok := twoPhaseCredentials[name]
+		if ok && director.rotating(cred) {
+			ops += cred.ops
+		}
+	}
+
+	if ops == "" {
+		err := c.fs.Remove(opsFile)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Remove credential rotation ops file: %s", err)
+		}
+		return nil
+	}
+
+	err := c.fs.MkdirAll(opsDir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("Create ops file dir: %s", err) //not tested
+	}
+
+	err = c.fs.WriteFile(opsFile, []byte("---"+ops), storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Write credential rotation ops file: %s", err)
+	}
+
+	return nil
+}
+
+type varsStore struct {
+	path    string
+	vars    map[string]interface{}
+	exists  bool
+	changed bool
+}
+
+func (c CredentialRotator) readVarsStore(path string) (*varsStore, error) {
+	store := &varsStore{path: path, vars: map[string]interface{}{}}
+
+	contents, err := c.fs.ReadFile(path)
+	if err != nil {
+		return store, nil
+	}
+	store.exists = true
+
+	err = yaml.Unmarshal(contents, &store.vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+func (c CredentialRotator) writeVarsStore(store *varsStore) error {
+	if !store.exists || !store.changed {
+		return nil
+	}
+
+	contents, err := yaml.Marshal(store.vars)
+	if err != nil {
+		return err // not tested
+	}
+
+	err = c.fs.WriteFile(store.path, contents, storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Write %s: %s", filepath.Base(store.path), err)
+	}
+
+	return nil
+}
+
+func (s *varsStore) delete(names ...string) {
+	for _, name := range names {
+		if _, ok := s.vars[name]; ok {
+			delete(s.vars, name)
+			s.changed = true
+		}
+	}
+}
+
+func (s *varsStore) rename(from, to string) {
+	if value, ok := s.vars[from]; ok {
+		s.vars[to] = value
+		delete(s.vars, from)
+		s.changed = true
+	}
+}
+
+// rotating reports whether the first phase of cred has run.
+func (s *varsStore) rotating(cred twoPhaseCredential) bool {
+	for _, name := range oldNames(cred.replaced) {
+		if _, ok := s.vars[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// reissuedBy returns the two-phase credential that reissues the
+// variables of cred in its second phase.
+func reissuedBy(cred credential) (string, bool) {
+	if cred.deployment != "director" {
+		return "", false
+	}
+
+	for name, twoPhase := range twoPhaseCredentials {
+		for _, variable := range cred.variables {
+			if contains(twoPhase.reissued, variable) {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func oldNames(names []string) []string {
+	old := []string{}
+	for _, name := range names {
+		old = append(old, name+"_old")
+	}
+	return old
+}
@@ -0,0 +1,248 @@
+package bosh_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var _ = Describe("CredentialRotator", func() {
+	Describe("Rotate", func() {
+		var (
+			credentialRotator bosh.CredentialRotator
+			stateStore        *fakes.StateStore
+			fileIO            *fakes.FileIO
+
+			files map[string]string
+
+			jumpboxVarsStore  string
+			directorVarsStore string
+			opsFile           string
+		)
+
+		BeforeEach(func() {
+			stateStore = &fakes.StateStore{}
+			stateStore.GetVarsDirCall.Returns.Directory = "some-vars-dir"
+			stateStore.GetStateDirCall.Returns.Directory = "some-state-dir"
+
+			jumpboxVarsStore = filepath.Join("some-vars-dir", "jumpbox-vars-store.yml")
+			directorVarsStore = filepath.Join("some-vars-dir", "director-vars-store.yml")
+			opsFile = filepath.Join("some-state-dir", "bbl-ops-files", "credential-rotation.yml")
+
+			files = map[string]string{
+				jumpboxVarsStore:  "foo: bar\njumpbox_ssh:\n  private_key: some-private-key",
+				directorVarsStore: "admin_password: some-password\ndefault_ca: some-ca\ndirector_ssl: some-cert\njumpbox_ssh: some-key\n",
+			}
+
+			fileIO = &fakes.FileIO{}
+			fileIO.ReadFileCall.Fake = func(path string) ([]byte, error) {
+				contents, ok := files[path]
+				if !ok {
+					return nil, &os.PathError{Op: "open", Path: path, Err: os.ErrNotExist}
+				}
+				return []byte(contents), nil
+			}
+
+			credentialRotator = bosh.NewCredentialRotator(stateStore, fileIO)
+		})
+
+		written := func(path string) map[string]interface{} {
+			for _, receive := range fileIO.WriteFileCall.Receives {
+				if receive.Filename == path {
+					vars := map[string]interface{}{}
+					Expect(yaml.Unmarshal(receive.Contents, &vars)).To(Succeed())
+					return vars
+				}
+			}
+			Fail("nothing written to " + path)
+			return nil
+		}
+
+		It("deletes the jumpbox key from the jumpbox vars store", func() {
+			rotations, err := credentialRotator.Rotate([]string{"jumpbox-ssh"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotations).To(Equal([]storage.Rotation{{Credential: "jumpbox-ssh"}}))
+
+			Expect(fileIO.WriteFileCall.Receives).To(HaveLen(1))
+			Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal(jumpboxVarsStore))
+			Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(Equal("foo: bar\n"))
+		})
+
+		It("deletes director credentials from the director vars store", func() {
+			_, err := credentialRotator.Rotate([]string{"director-admin", "director-ssh"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(written(directorVarsStore)).To(Equal(map[string]interface{}{
+				"default_ca":   "some-ca",
+				"director_ssl": "some-cert",
+			}))
+		})
+
+		It("deletes the blobstore certificate from the director vars store", func() {
+			files[directorVarsStore] = "default_ca: some-ca\nblobstore_server_tls: some-cert\n"
+
+			rotations, err := credentialRotator.Rotate([]string{"blobstore-certs"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rotations).To(Equal([]storage.Rotation{{Credential: "blobstore-certs"}}))
+
+			Expect(written(directorVarsStore)).To(Equal(map[string]interface{}{
+				"default_ca": "some-ca",
+			}))
+		})
+
+		It("removes the ops file when no rotation is in progress", func() {
+			_, err := credentialRotator.Rotate([]string{"jumpbox-ssh"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.RemoveCall.Receives).To(Equal([]fakes.RemoveReceive{{Name: opsFile}}))
+		})
+
+		Context("when a vars store doesn't contain the credential", func() {
+			BeforeEach(func() {
+				files[jumpboxVarsStore] = "foo: bar\n"
+			})
+
+			It("does nothing", func() {
+				_, err := credentialRotator.Rotate([]string{"jumpbox-ssh"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the vars stores do not exist on disk", func() {
+			BeforeEach(func() {
+				files = map[string]string{}
+			})
+
+			It("does not write them to disk", func() {
+				rotations, err := credentialRotator.Rotate([]string{"jumpbox-ssh", "director-admin"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rotations).To(HaveLen(2))
+
+				Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when rotating the CAs", func() {
+			It("keeps the old CA and writes the rotation ops file in the first phase", func() {
+				rotations, err := credentialRotator.Rotate([]string{"ca"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rotations).To(Equal([]storage.Rotation{{Credential: "ca", Phase: 1}}))
+
+				Expect(written(directorVarsStore)).To(Equal(map[string]interface{}{
+					"admin_password": "some-password",
+					"default_ca_old": "some-ca",
+					"director_ssl":   "some-cert",
+					"jumpbox_ssh":    "some-key",
+				}))
+
+				Expect(fileIO.MkdirAllCall.Receives.Dir).To(Equal(filepath.Join("some-state-dir", "bbl-ops-files")))
+				receive := fileIO.WriteFileCall.Receives[len(fileIO.WriteFileCall.Receives)-1]
+				Expect(receive.Filename).To(Equal(opsFile))
+				Expect(string(receive.Contents)).To(Equal("---" + bosh.CARotationOps))
+			})
+
+			It("leaves the certificates it reissues to the second phase", func() {
+				files[directorVarsStore] = "default_ca: some-ca\nnats_server_tls: some-cert\nblobstore_server_tls: some-cert\n"
+
+				rotations, err := credentialRotator.Rotate([]string{"ca", "nats-certs", "blobstore-certs"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rotations).To(Equal([]storage.Rotation{{Credential: "ca", Phase: 1}}))
+
+				Expect(written(directorVarsStore)).To(Equal(map[string]interface{}{
+					"default_ca_old":       "some-ca",
+					"nats_server_tls":      "some-cert",
+					"blobstore_server_tls": "some-cert",
+				}))
+			})
+
+			Context("when the first phase has run", func() {
+				BeforeEach(func() {
+					files[directorVarsStore] = "default_ca: new-ca\ndefault_ca_old: some-ca\ndirector_ssl: some-cert\nadmin_password: some-password\n"
+				})
+
+				It("drops the old CA, reissues the certificates and removes the ops file", func() {
+					rotations, err := credentialRotator.Rotate([]string{"ca"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(rotations).To(Equal([]storage.Rotation{{Credential: "ca", Phase: 2}}))
+
+					Expect(written(directorVarsStore)).To(Equal(map[string]interface{}{
+						"admin_password": "some-password",
+						"default_ca":     "new-ca",
+					}))
+					Expect(fileIO.RemoveCall.Receives).To(Equal([]fakes.RemoveReceive{{Name: opsFile}}))
+				})
+
+				It("does not rotate the certificates the second phase reissues", func() {
+					_, err := credentialRotator.Rotate([]string{"nats-certs"})
+					Expect(err).To(MatchError("Cannot rotate nats-certs while ca is being rotated: run `bbl rotate --credentials=ca` to finish rotating ca first."))
+
+					_, err = credentialRotator.Rotate([]string{"blobstore-certs"})
+					Expect(err).To(MatchError("Cannot rotate blobstore-certs while ca is being rotated: run `bbl rotate --credentials=ca` to finish rotating ca first."))
+					Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+				})
+
+				It("keeps the ops file while rotating other credentials", func() {
+					_, err := credentialRotator.Rotate([]string{"director-admin"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fileIO.RemoveCall.CallCount).To(Equal(0))
+					receive := fileIO.WriteFileCall.Receives[len(fileIO.WriteFileCall.Receives)-1]
+					Expect(receive.Filename).To(Equal(opsFile))
+					Expect(string(receive.Contents)).To(Equal("---" + bosh.CARotationOps))
+				})
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error for an unknown credential", func() {
+				_, err := credentialRotator.Rotate([]string{"kiwi"})
+				Expect(err).To(MatchError(ContainSubstring(`Unknown credential "kiwi". Choose from: blobstore-certs, ca, credhub-admin,`)))
+			})
+
+			It("returns an error when the jumpbox variables are invalid YAML", func() {
+				files[jumpboxVarsStore] = "invalid yaml"
+
+				_, err := credentialRotator.Rotate([]string{"jumpbox-ssh"})
+				Expect(err).To(MatchError(ContainSubstring("Jumpbox variables: yaml: unmarshal errors:")))
+			})
+
+			It("returns an error when the vars dir can't be accessed", func() {
+				stateStore.GetVarsDirCall.Returns.Error = errors.New("potato")
+
+				_, err := credentialRotator.Rotate([]string{"jumpbox-ssh"})
+				Expect(err).To(MatchError("potato"))
+			})
+
+			It("returns an error when a vars store can't be written", func() {
+				fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("tomato")}}
+
+				_, err := credentialRotator.Rotate([]string{"jumpbox-ssh"})
+				Expect(err).To(MatchError("Write jumpbox-vars-store.yml: tomato"))
+			})
+
+			It("returns an error when the ops file can't be removed", func() {
+				fileIO.RemoveCall.Returns = []fakes.RemoveReturn{{Error: errors.New("turnip")}}
+
+				_, err := credentialRotator.Rotate([]string{"jumpbox-ssh"})
+				Expect(err).To(MatchError("Remove credential rotation ops file: turnip"))
+			})
+
+			It("returns an error when the ops file can't be written", func() {
+				fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{}, {Error: errors.New("parsnip")}}
+
+				_, err := credentialRotator.Rotate([]string{"ca"})
+				Expect(err).To(MatchError("Write credential rotation ops file: parsnip"))
+			})
+		})
+	})
+})
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -24,6 +25,8 @@ type executorFs interface {
 type Executor struct {
 	command command
 	fs      executorFs
+	stdout  io.Writer
+	stderr  io.Writer
 }
 
 type DirInput struct {
@@ -48,10 +51,12 @@ var (
 	boshDeploymentRepo    = "vendor/github.com/cloudfoundry/bosh-deployment"
 )
 
-func NewExecutor(cmd command, fs executorFs) Executor {
+func NewExecutor(cmd command, fs executorFs, stdout, stderr io.Writer) Executor {
 	return Executor{
 		command: cmd,
 		fs:      fs,
+		stdout:  stdout,
+		stderr:  stderr,
 	}
 }
 
@@ -105,6 +110,13 @@ func (e Executor) PlanJumpbox(input DirInput, deploymentDir, iaas string) error
 		}
 	}
 
+	hostKeyOpsPath := filepath.Join(deploymentDir, "jumpbox-host-key.yml")
+	sharedArgs = append(sharedArgs, "-o", hostKeyOpsPath)
+	err := e.fs.WriteFile(hostKeyOpsPath, []byte(JumpboxHostKeyOps), storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Jumpbox write host key ops file: %s", err) //not tested
+	}
+
 	jumpboxState := filepath.Join(input.VarsDir, "jumpbox-state.json")
 
 	boshArgs := append([]string{filepath.Join(deploymentDir, "jumpbox.yml"), "--state", jumpboxState}, sharedArgs...)
@@ -144,7 +156,7 @@ func (e Executor) PlanJumpbox(input DirInput, deploymentDir, iaas string) error
 
 	createEnvCmd := []byte(formatScript(boshPath, input.StateDir, "create-env", boshArgs))
 	createJumpboxScript := filepath.Join(input.StateDir, "create-jumpbox.sh")
-	err := e.fs.WriteFile(createJumpboxScript, createEnvCmd, 0750)
+	err = e.fs.WriteFile(createJumpboxScript, createEnvCmd, 0750)
 	if err != nil {
 		return err
 	}
@@ -179,6 +191,12 @@ func (e Executor) getDirectorSetupFiles(stateDir, deploymentDir, iaas string) []
 		})
 	}
 
+	files = append(files, setupFile{
+		source:   "director-host-key.yml",
+		dest:     filepath.Join(stateDir, "bbl-ops-files", "director-host-key.yml"),
+		contents: []byte(DirectorHostKeyOps),
+	})
+
 	return files
 }
 
@@ -198,6 +216,7 @@ func (e Executor) getDirectorOpsFiles(stateDir, deploymentDir, iaas string) []st
 	} else if iaas == "vsphere" {
 		files = append(files, filepath.Join(deploymentDir, "vsphere", "resource-pool.yml"))
 	}
+	files = append(files, filepath.Join(stateDir, "bbl-ops-files", "director-host-key.yml"))
 	return files
 }
 
@@ -222,6 +241,11 @@ func (e Executor) PlanDirector(input DirInput, deploymentDir, iaas string) error
 		sharedArgs = append(sharedArgs, "-o", f)
 	}
 
+	rotationOps := filepath.Join(input.StateDir, "bbl-ops-files", CredentialRotationOpsFile)
+	if _, err := e.fs.Stat(rotationOps); err == nil {
+		sharedArgs = append(sharedArgs, "-o", rotationOps)
+	}
+
 	boshState := filepath.Join(input.VarsDir, "bosh-state.json")
 
 	boshArgs := append([]string{filepath.Join(deploymentDir, "bosh.yml"), "--state", boshState}, sharedArgs...)
@@ -326,8 +350,8 @@ func (e Executor) CreateEnv(input DirInput, state storage.State) (string, error)
 	}
 
 	cmd := exec.Command(createEnvScript)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = e.stdout
+	cmd.Stderr = e.stderr
 
 	err = cmd.Run()
 	if err != nil {
@@ -376,8 +400,8 @@ func (e Executor) DeleteEnv(input DirInput, state storage.State) error {
 	}
 
 	cmd := exec.Command(deleteEnvScript)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = e.stdout
+	cmd.Stderr = e.stderr
 
 	err = cmd.Run()
 	if err != nil {
@@ -387,6 +411,118 @@ func (e Executor) DeleteEnv(input DirInput, state storage.State) error {
 	return nil
 }
 
+// Interpolate runs the deployment's create-env script as bosh interpolate
+// and returns the manifest it prints. The vars store is left out, so
+// generated credentials stay as ((placeholders)), and the BBL_ variables
+// that hold IaaS credentials are passed as <BBL_NAME> placeholders.
+func (e Executor) Interpolate(input DirInput, state storage.State) (string, error) {
+	createEnvScript := filepath.Join(input.StateDir, fmt.Sprintf("create-%s-override.sh", input.Deployment))
+	_, err := e.fs.Stat(createEnvScript)
+	if err != nil {
+		createEnvScript = strings.Replace(createEnvScript, "-override", "", -1)
+	}
+
+	contents, err := e.fs.ReadFile(createEnvScript)
+	if err != nil {
+		return "", fmt.Errorf("Read %s: %s", createEnvScript, err)
+	}
+
+	iaasEnv, cleanup, err := interpolateIAASEnv(state)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	env := append([]string{"BBL_STATE_DIR=" + input.StateDir}, iaasEnv...)
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "BBL_") {
+			env = append(env, v)
+		}
+	}
+
+	stdout := bytes.NewBuffer([]byte{})
+	stderr := bytes.NewBuffer([]byte{})
+	cmd := exec.Command("sh", "-c", interpolateScript(string(contents)))
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("Interpolate %s manifest: %s: %s", input.Deployment, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// interpolateIAASEnv returns the BBL_ variables that the create-env
+// scripts pass to bosh for the IaaS, with placeholders for credentials.
+// The GCP service account key is read from a file, so a placeholder file
+// is written that cleanup removes.
+func interpolateIAASEnv(state storage.State) ([]string, func(), error) {
+	cleanup := func() {}
+
+	switch state.IAAS {
+	case "aws":
+		return []string{
+			"BBL_AWS_ACCESS_KEY_ID=" + credentialPlaceholder("BBL_AWS_ACCESS_KEY_ID"),
+			"BBL_AWS_SECRET_ACCESS_KEY=" + credentialPlaceholder("BBL_AWS_SECRET_ACCESS_KEY"),
+		}, cleanup, nil
+	case "azure":
+		return []string{
+			"BBL_AZURE_CLIENT_ID=" + state.Azure.ClientID,
+			"BBL_AZURE_CLIENT_SECRET=" + credentialPlaceholder("BBL_AZURE_CLIENT_SECRET"),
+			"BBL_AZURE_SUBSCRIPTION_ID=" + state.Azure.SubscriptionID,
+			"BBL_AZURE_TENANT_ID=" + state.Azure.TenantID,
+		}, cleanup, nil
+	case "gcp":
+		keyFile, err := ioutil.TempFile("", "bbl-interpolate")
+		if err != nil {
+			return nil, nil, fmt.Errorf("Create service account key placeholder: %s", err)
+		}
+		cleanup = func() { os.Remove(keyFile.Name()) }
+
+		_, err = keyFile.WriteString(credentialPlaceholder("BBL_GCP_SERVICE_ACCOUNT_KEY"))
+		keyFile.Close()
+		if err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("Write service account key placeholder: %s", err) // not tested
+		}
+
+		return []string{
+			"BBL_GCP_SERVICE_ACCOUNT_KEY_PATH=" + keyFile.Name(),
+			"BBL_GCP_ZONE=" + state.GCP.Zone,
+			"BBL_GCP_PROJECT_ID=" + state.GCP.ProjectID,
+		}, cleanup, nil
+	case "vsphere":
+		return []string{
+			"BBL_VSPHERE_VCENTER_USER=" + state.VSphere.VCenterUser,
+			"BBL_VSPHERE_VCENTER_PASSWORD=" + credentialPlaceholder("BBL_VSPHERE_VCENTER_PASSWORD"),
+		}, cleanup, nil
+	case "openstack":
+		return []string{
+			"BBL_OPENSTACK_USERNAME=" + state.OpenStack.Username,
+			"BBL_OPENSTACK_PASSWORD=" + credentialPlaceholder("BBL_OPENSTACK_PASSWORD"),
+		}, cleanup, nil
+	}
+
+	return nil, cleanup, nil
+}
+
+func credentialPlaceholder(name string) string {
+	return fmt.Sprintf("<%s>", name)
+}
+
+var (
+	createEnvCommand = regexp.MustCompile(`\bcreate-env\b`)
+	stateArgs        = regexp.MustCompile(`--(state|vars-store)[ \t=]+\S+`)
+)
+
+func interpolateScript(script string) string {
+	script = createEnvCommand.ReplaceAllString(script, "interpolate")
+	return stateArgs.ReplaceAllString(script, "")
+}
+
 func (e Executor) deploymentExists(varsDir, deployment string) (bool, error) {
 	var deploymentBoshState string
 	switch deployment {
@@ -1,6 +1,7 @@
 package bosh_test
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -62,7 +63,7 @@ var _ = Describe("Executor", func() {
 			StateDir: stateDir,
 		}
 
-		executor = bosh.NewExecutor(cmd, fs)
+		executor = bosh.NewExecutor(cmd, fs, os.Stdout, os.Stderr)
 	})
 
 	Describe("PlanJumpbox", func() {
@@ -86,6 +87,12 @@ var _ = Describe("Executor", func() {
 				Expect(contents).To(Equal(expectedContents))
 			})
 
+			By("writing the host key ops file", func() {
+				opsfile, err := fs.ReadFile(fmt.Sprintf("%s/jumpbox-host-key.yml", deploymentDir))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsfile)).To(Equal(bosh.JumpboxHostKeyOps))
+			})
+
 			By("writing create-env and delete-env scripts", func() {
 				expectedArgs := []string{
 					fmt.Sprintf("%s/jumpbox.yml", relativeDeploymentDir),
@@ -93,6 +100,7 @@ var _ = Describe("Executor", func() {
 					"--vars-store", fmt.Sprintf("%s/jumpbox-vars-store.yml", relativeVarsDir),
 					"--vars-file", fmt.Sprintf("%s/jumpbox-vars-file.yml", relativeVarsDir),
 					"-o", fmt.Sprintf("%s/aws/cpi.yml", relativeDeploymentDir),
+					"-o", fmt.Sprintf("%s/jumpbox-host-key.yml", relativeDeploymentDir),
 					"-v", `access_key_id="${BBL_AWS_ACCESS_KEY_ID}"`,
 					"-v", `secret_access_key="${BBL_AWS_SECRET_ACCESS_KEY}"`,
 				}
@@ -131,6 +139,7 @@ var _ = Describe("Executor", func() {
 					"--vars-store", fmt.Sprintf("%s/jumpbox-vars-store.yml", relativeVarsDir),
 					"--vars-file", fmt.Sprintf("%s/jumpbox-vars-file.yml", relativeVarsDir),
 					"-o", fmt.Sprintf("%s/azure/cpi.yml", relativeDeploymentDir),
+					"-o", fmt.Sprintf("%s/jumpbox-host-key.yml", relativeDeploymentDir),
 					"-v", `subscription_id="${BBL_AZURE_SUBSCRIPTION_ID}"`,
 					"-v", `client_id="${BBL_AZURE_CLIENT_ID}"`,
 					"-v", `client_secret="${BBL_AZURE_CLIENT_SECRET}"`,
@@ -166,6 +175,7 @@ var _ = Describe("Executor", func() {
 					"--vars-store", fmt.Sprintf("%s/jumpbox-vars-store.yml", relativeVarsDir),
 					"--vars-file", fmt.Sprintf("%s/jumpbox-vars-file.yml", relativeVarsDir),
 					"-o", fmt.Sprintf("%s/gcp/cpi.yml", relativeDeploymentDir),
+					"-o", fmt.Sprintf("%s/jumpbox-host-key.yml", relativeDeploymentDir),
 					"--var-file", `gcp_credentials_json="${BBL_GCP_SERVICE_ACCOUNT_KEY_PATH}"`,
 					"-v", `project_id="${BBL_GCP_PROJECT_ID}"`,
 					"-v", `zone="${BBL_GCP_ZONE}"`,
@@ -202,6 +212,7 @@ var _ = Describe("Executor", func() {
 					"-o", fmt.Sprintf("%s/vsphere/cpi.yml", relativeDeploymentDir),
 					"-o", fmt.Sprintf("%s/vsphere/resource-pool.yml", relativeDeploymentDir),
 					"-o", fmt.Sprintf("%s/vsphere-jumpbox-network.yml", relativeDeploymentDir),
+					"-o", fmt.Sprintf("%s/jumpbox-host-key.yml", relativeDeploymentDir),
 					"-v", `vcenter_user="${BBL_VSPHERE_VCENTER_USER}"`,
 					"-v", `vcenter_password="${BBL_VSPHERE_VCENTER_PASSWORD}"`,
 				}
@@ -243,6 +254,7 @@ var _ = Describe("Executor", func() {
 					"--vars-file", fmt.Sprintf("%s/jumpbox-vars-file.yml", relativeVarsDir),
 					"-o", fmt.Sprintf("%s/openstack/cpi.yml", relativeDeploymentDir),
 					"-o", fmt.Sprintf("%s/openstack-keystone-v3-ops.yml", relativeDeploymentDir),
+					"-o", fmt.Sprintf("%s/jumpbox-host-key.yml", relativeDeploymentDir),
 					"-v", `openstack_username="${BBL_OPENSTACK_USERNAME}"`,
 					"-v", `openstack_password="${BBL_OPENSTACK_PASSWORD}"`,
 				}
@@ -307,6 +319,7 @@ var _ = Describe("Executor", func() {
 					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "aws", "bosh-director-ephemeral-ip-ops.yml"),
 					"-o", filepath.Join(relativeDeploymentDir, "aws", "iam-instance-profile.yml"),
 					"-o", filepath.Join(relativeDeploymentDir, "aws", "encrypted-disk.yml"),
+					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "director-host-key.yml"),
 					"-v", `access_key_id="${BBL_AWS_ACCESS_KEY_ID}"`,
 					"-v", `secret_access_key="${BBL_AWS_SECRET_ACCESS_KEY}"`,
 				}
@@ -314,6 +327,15 @@ var _ = Describe("Executor", func() {
 				behavesLikePlan(expectedArgs, cmd, fs, executor, dirInput, deploymentDir, "aws", stateDir)
 			})
 
+			It("writes the host key ops file", func() {
+				err := executor.PlanDirector(dirInput, deploymentDir, "aws")
+				Expect(err).NotTo(HaveOccurred())
+
+				opsFile, err := fs.ReadFile(filepath.Join(stateDir, "bbl-ops-files", "director-host-key.yml"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(opsFile)).To(Equal(bosh.DirectorHostKeyOps))
+			})
+
 			It("writes aws-specific ops files", func() {
 				err := executor.PlanDirector(dirInput, deploymentDir, "aws")
 				Expect(err).NotTo(HaveOccurred())
@@ -342,6 +364,7 @@ var _ = Describe("Executor", func() {
 					"-o", filepath.Join(relativeDeploymentDir, "uaa.yml"),
 					"-o", filepath.Join(relativeDeploymentDir, "credhub.yml"),
 					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "gcp", "bosh-director-ephemeral-ip-ops.yml"),
+					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "director-host-key.yml"),
 					"--var-file", `gcp_credentials_json="${BBL_GCP_SERVICE_ACCOUNT_KEY_PATH}"`,
 					"-v", `project_id="${BBL_GCP_PROJECT_ID}"`,
 					"-v", `zone="${BBL_GCP_ZONE}"`,
@@ -377,6 +400,7 @@ var _ = Describe("Executor", func() {
 					"-o", filepath.Join(relativeDeploymentDir, "jumpbox-user.yml"),
 					"-o", filepath.Join(relativeDeploymentDir, "uaa.yml"),
 					"-o", filepath.Join(relativeDeploymentDir, "credhub.yml"),
+					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "director-host-key.yml"),
 					"-v", `subscription_id="${BBL_AZURE_SUBSCRIPTION_ID}"`,
 					"-v", `client_id="${BBL_AZURE_CLIENT_ID}"`,
 					"-v", `client_secret="${BBL_AZURE_CLIENT_SECRET}"`,
@@ -399,6 +423,7 @@ var _ = Describe("Executor", func() {
 					"-o", filepath.Join(relativeDeploymentDir, "uaa.yml"),
 					"-o", filepath.Join(relativeDeploymentDir, "credhub.yml"),
 					"-o", filepath.Join(relativeDeploymentDir, "vsphere", "resource-pool.yml"),
+					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "director-host-key.yml"),
 					"-v", `vcenter_user="${BBL_VSPHERE_VCENTER_USER}"`,
 					"-v", `vcenter_password="${BBL_VSPHERE_VCENTER_PASSWORD}"`,
 				}
@@ -418,6 +443,7 @@ var _ = Describe("Executor", func() {
 					"-o", filepath.Join(relativeDeploymentDir, "jumpbox-user.yml"),
 					"-o", filepath.Join(relativeDeploymentDir, "uaa.yml"),
 					"-o", filepath.Join(relativeDeploymentDir, "credhub.yml"),
+					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "director-host-key.yml"),
 					"-v", `openstack_username="${BBL_OPENSTACK_USERNAME}"`,
 					"-v", `openstack_password="${BBL_OPENSTACK_PASSWORD}"`,
 				}
@@ -425,6 +451,34 @@ var _ = Describe("Executor", func() {
 				behavesLikePlan(expectedArgs, cmd, fs, executor, dirInput, deploymentDir, "openstack", stateDir)
 			})
 		})
+
+		Context("when a credential rotation is in progress", func() {
+			BeforeEach(func() {
+				err := fs.WriteFile(filepath.Join(stateDir, "bbl-ops-files", "credential-rotation.yml"), []byte("---"), storage.StateMode)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("adds the credential rotation ops file", func() {
+				expectedArgs := []string{
+					filepath.Join(relativeDeploymentDir, "bosh.yml"),
+					"--state", filepath.Join(relativeVarsDir, "bosh-state.json"),
+					"--vars-store", filepath.Join(relativeVarsDir, "director-vars-store.yml"),
+					"--vars-file", filepath.Join(relativeVarsDir, "director-vars-file.yml"),
+					"-o", filepath.Join(relativeDeploymentDir, "azure", "cpi.yml"),
+					"-o", filepath.Join(relativeDeploymentDir, "jumpbox-user.yml"),
+					"-o", filepath.Join(relativeDeploymentDir, "uaa.yml"),
+					"-o", filepath.Join(relativeDeploymentDir, "credhub.yml"),
+					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "director-host-key.yml"),
+					"-o", filepath.Join(relativeStateDir, "bbl-ops-files", "credential-rotation.yml"),
+					"-v", `subscription_id="${BBL_AZURE_SUBSCRIPTION_ID}"`,
+					"-v", `client_id="${BBL_AZURE_CLIENT_ID}"`,
+					"-v", `client_secret="${BBL_AZURE_CLIENT_SECRET}"`,
+					"-v", `tenant_id="${BBL_AZURE_TENANT_ID}"`,
+				}
+
+				behavesLikePlan(expectedArgs, cmd, fs, executor, dirInput, deploymentDir, "azure", stateDir)
+			})
+		})
 	})
 
 	Describe("WriteDeploymentVars", func() {
@@ -449,6 +503,7 @@ var _ = Describe("Executor", func() {
 			executor bosh.Executor
 
 			createEnvPath string
+			output        *bytes.Buffer
 			varsDir       string
 			stateDir      string
 
@@ -466,7 +521,8 @@ var _ = Describe("Executor", func() {
 			stateDir, err = fs.TempDir("", "")
 			Expect(err).NotTo(HaveOccurred())
 
-			executor = bosh.NewExecutor(cmd, fs)
+			output = bytes.NewBuffer([]byte{})
+			executor = bosh.NewExecutor(cmd, fs, output, output)
 
 			dirInput = bosh.DirInput{
 				Deployment: "some-deployment",
@@ -475,7 +531,7 @@ var _ = Describe("Executor", func() {
 			}
 
 			createEnvPath = filepath.Join(stateDir, "create-some-deployment.sh")
-			createEnvContents := fmt.Sprintf("#!/bin/bash\necho 'some-vars-store-contents' > %s/some-deployment-vars-store.yml\n", varsDir)
+			createEnvContents := fmt.Sprintf("#!/bin/bash\necho 'some-vars-store-contents' > %s/some-deployment-vars-store.yml\necho 'Deploying:'\necho 'Finished deploying' >&2\n", varsDir)
 
 			fs.WriteFile(createEnvPath, []byte(createEnvContents), storage.ScriptMode)
 		})
@@ -511,6 +567,11 @@ var _ = Describe("Executor", func() {
 			Expect(cmd.RunCallCount()).To(Equal(0))
 			Expect(vars).To(ContainSubstring("some-vars-store-contents"))
 
+			By("writing the output of the script", func() {
+				Expect(output.String()).To(ContainSubstring("Deploying:\n"))
+				Expect(output.String()).To(ContainSubstring("Finished deploying\n"))
+			})
+
 			By("setting BBL_STATE_DIR environment variable", func() {
 				bblStateDirEnv := os.Getenv("BBL_STATE_DIR")
 				Expect(bblStateDirEnv).To(Equal(stateDir))
@@ -646,7 +707,7 @@ var _ = Describe("Executor", func() {
 			stateDir, err = fs.TempDir("", "")
 			Expect(err).NotTo(HaveOccurred())
 
-			executor = bosh.NewExecutor(cmd, fs)
+			executor = bosh.NewExecutor(cmd, fs, os.Stdout, os.Stderr)
 
 			dirInput = bosh.DirInput{
 				Deployment: "director",
@@ -841,6 +902,93 @@ var _ = Describe("Executor", func() {
 		})
 	})
 
+	Describe("Interpolate", func() {
+		var (
+			executor bosh.Executor
+			stateDir string
+			dirInput bosh.DirInput
+		)
+
+		BeforeEach(func() {
+			fs = &afero.Afero{Fs: afero.NewOsFs()}
+
+			var err error
+			stateDir, err = fs.TempDir("", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			dirInput = bosh.DirInput{
+				Deployment: "director",
+				StateDir:   stateDir,
+				VarsDir:    filepath.Join(stateDir, "vars"),
+			}
+
+			script := "#!/bin/sh\necho bosh create-env \\\n  bosh.yml \\\n  --state  ${BBL_STATE_DIR}/vars/bosh-state.json \\\n  --vars-store  ${BBL_STATE_DIR}/vars/director-vars-store.yml \\\n  -v secret=\"${BBL_AWS_SECRET_ACCESS_KEY}\"\n"
+			err = fs.WriteFile(filepath.Join(stateDir, "create-director.sh"), []byte(script), storage.ScriptMode)
+			Expect(err).NotTo(HaveOccurred())
+
+			os.Setenv("BBL_AWS_SECRET_ACCESS_KEY", "some-secret")
+
+			executor = bosh.NewExecutor(cmd, fs, os.Stdout, os.Stderr)
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("BBL_AWS_SECRET_ACCESS_KEY")
+			fs.RemoveAll(stateDir)
+		})
+
+		It("runs the create-env script as bosh interpolate without the state, the vars store or the IaaS credentials", func() {
+			manifest, err := executor.Interpolate(dirInput, storage.State{IAAS: "aws"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(manifest).To(Equal("bosh interpolate bosh.yml -v secret=<BBL_AWS_SECRET_ACCESS_KEY>\n"))
+		})
+
+		Context("on gcp", func() {
+			BeforeEach(func() {
+				script := "#!/bin/sh\necho bosh create-env \\\n  bosh.yml \\\n  --state  ${BBL_STATE_DIR}/vars/bosh-state.json \\\n  -v zone=\"${BBL_GCP_ZONE}\" \\\n  -v project_id=\"${BBL_GCP_PROJECT_ID}\" \\\n  -v key=\"$(cat ${BBL_GCP_SERVICE_ACCOUNT_KEY_PATH})\"\n"
+				err := fs.WriteFile(filepath.Join(stateDir, "create-director.sh"), []byte(script), storage.ScriptMode)
+				Expect(err).NotTo(HaveOccurred())
+
+				os.Setenv("BBL_GCP_SERVICE_ACCOUNT_KEY_PATH", "/some/missing/key.json")
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("BBL_GCP_SERVICE_ACCOUNT_KEY_PATH")
+			})
+
+			It("passes a placeholder service account key file and the zone and project from the state", func() {
+				manifest, err := executor.Interpolate(dirInput, storage.State{
+					IAAS: "gcp",
+					GCP: storage.GCP{
+						Zone:      "some-zone",
+						ProjectID: "some-project-id",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(manifest).To(Equal("bosh interpolate bosh.yml -v zone=some-zone -v project_id=some-project-id -v key=<BBL_GCP_SERVICE_ACCOUNT_KEY>\n"))
+			})
+		})
+
+		It("prefers the override script", func() {
+			err := fs.WriteFile(filepath.Join(stateDir, "create-director-override.sh"), []byte("#!/bin/sh\necho override ${BBL_STATE_DIR}\n"), storage.ScriptMode)
+			Expect(err).NotTo(HaveOccurred())
+
+			manifest, err := executor.Interpolate(dirInput, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(manifest).To(Equal(fmt.Sprintf("override %s\n", stateDir)))
+		})
+
+		It("returns an error when the script fails", func() {
+			err := fs.WriteFile(filepath.Join(stateDir, "create-director.sh"), []byte("#!/bin/sh\necho some-error >&2\nexit 1\n"), storage.ScriptMode)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = executor.Interpolate(dirInput, storage.State{})
+			Expect(err).To(MatchError("Interpolate director manifest: exit status 1: some-error"))
+		})
+	})
+
 	Describe("Version", func() {
 		var (
 			cmd      *fakes.BOSHCommand
@@ -853,7 +1001,7 @@ var _ = Describe("Executor", func() {
 				return nil
 			}
 
-			executor = bosh.NewExecutor(cmd, fs)
+			executor = bosh.NewExecutor(cmd, fs, os.Stdout, os.Stderr)
 		})
 
 		It("returns the correctly trimmed version", func() {
@@ -44,6 +44,7 @@ type executor interface {
 	PlanJumpbox(DirInput, string, string) error
 	CreateEnv(DirInput, storage.State) (string, error)
 	DeleteEnv(DirInput, storage.State) error
+	Interpolate(DirInput, storage.State) (string, error)
 	WriteDeploymentVars(DirInput, string) error
 	Path() string
 	Version() (string, error)
@@ -142,28 +143,15 @@ func (m *Manager) CreateJumpbox(state storage.State, terraformOutputs terraform.
 	m.logger.Step("created jumpbox")
 
 	state.Jumpbox = storage.Jumpbox{
-		URL: terraformOutputs.GetString("jumpbox_url"),
+		URL:      terraformOutputs.GetString("jumpbox_url"),
+		Manifest: m.interpolate(dirInput, state),
 	}
 
-	dir, err := m.fs.TempDir("", "bosh-jumpbox")
-	if err != nil {
-		return storage.State{}, fmt.Errorf("Create temp dir for jumpbox private key: %s", err)
-	}
-
-	privateKeyPath := filepath.Join(dir, "bosh_jumpbox_private.key")
-
-	privateKeyContents, err := m.sshKeyGetter.Get("jumpbox")
-	if err != nil {
-		return storage.State{}, fmt.Errorf("Get jumpbox private key: %s", err)
-	}
-
-	err = m.fs.WriteFile(privateKeyPath, []byte(privateKeyContents), 0600)
+	err = m.setJumpboxProxy(state)
 	if err != nil {
-		return storage.State{}, fmt.Errorf("Write jumpbox private key: %s", err)
+		return storage.State{}, err
 	}
 
-	osSetenv("BOSH_ALL_PROXY", fmt.Sprintf("ssh+socks5://jumpbox@%s?private-key=%s", state.Jumpbox.URL, privateKeyPath))
-
 	return state, nil
 }
 
@@ -214,6 +202,15 @@ func (m *Manager) CreateDirector(state storage.State, terraformOutputs terraform
 		return storage.State{}, fmt.Errorf("Write deployment vars: %s", err)
 	}
 
+	// The jumpbox is not created in the same run when bbl up skips or
+	// resumes past the jumpbox phase, so the proxy may not be set yet.
+	if state.Jumpbox.URL != "" {
+		err = m.setJumpboxProxy(state)
+		if err != nil {
+			return storage.State{}, err
+		}
+	}
+
 	variables, err := m.executor.CreateEnv(dirInput, state)
 	if err != nil {
 		state.BOSH = storage.BOSH{
@@ -244,12 +241,57 @@ func (m *Manager) CreateDirector(state storage.State, terraformOutputs terraform
 		DirectorSSLCA:          directorVars.sslCA,
 		DirectorSSLCertificate: directorVars.sslCertificate,
 		DirectorSSLPrivateKey:  directorVars.sslPrivateKey,
+		Manifest:               m.interpolate(dirInput, state),
 	}
 
 	m.logger.Step("created bosh director")
 	return state, nil
 }
 
+// InterpolateJumpbox returns the jumpbox manifest that CreateJumpbox would
+// deploy, with generated credentials left as ((placeholders)).
+func (m *Manager) InterpolateJumpbox(state storage.State, terraformOutputs terraform.Outputs) (string, error) {
+	return m.interpolateDeployment("jumpbox", m.GetJumpboxDeploymentVars(state, terraformOutputs), state)
+}
+
+// InterpolateDirector returns the director manifest that CreateDirector
+// would deploy, with generated credentials left as ((placeholders)).
+func (m *Manager) InterpolateDirector(state storage.State, terraformOutputs terraform.Outputs) (string, error) {
+	return m.interpolateDeployment("director", m.GetDirectorDeploymentVars(state, terraformOutputs), state)
+}
+
+func (m *Manager) interpolateDeployment(deployment, deploymentVars string, state storage.State) (string, error) {
+	varsDir, err := m.stateStore.GetVarsDir()
+	if err != nil {
+		return "", err
+	}
+
+	dirInput := DirInput{
+		Deployment: deployment,
+		StateDir:   m.stateStore.GetStateDir(),
+		VarsDir:    varsDir,
+	}
+
+	err = m.executor.WriteDeploymentVars(dirInput, deploymentVars)
+	if err != nil {
+		return "", fmt.Errorf("Write deployment vars: %s", err)
+	}
+
+	return m.executor.Interpolate(dirInput, state)
+}
+
+// interpolate records the manifest that create-env deployed so that bbl
+// plan --diff can compare against it. A manifest that cannot be
+// interpolated is left out with a warning rather than failing the deploy.
+func (m *Manager) interpolate(dirInput DirInput, state storage.State) string {
+	manifest, err := m.executor.Interpolate(dirInput, state)
+	if err != nil {
+		m.logger.Println(fmt.Sprintf("warning: the %s manifest could not be recorded for bbl plan --diff and bbl versions: %s", dirInput.Deployment, err))
+		return ""
+	}
+	return manifest
+}
+
 func (m *Manager) DeleteDirector(state storage.State, terraformOutputs terraform.Outputs) error {
 	if state.BOSH.IsEmpty() {
 		return nil
@@ -275,25 +317,11 @@ func (m *Manager) DeleteDirector(state storage.State, terraformOutputs terraform
 		return fmt.Errorf("Write deployment vars: %s", err)
 	}
 
-	dir, err := m.fs.TempDir("", "bosh-jumpbox")
-	if err != nil {
-		return fmt.Errorf("Create temp dir for jumpbox private key: %s", err)
-	}
-
-	privateKeyPath := filepath.Join(dir, "bosh_jumpbox_private.key")
-
-	privateKeyContents, err := m.sshKeyGetter.Get("jumpbox")
-	if err != nil {
-		return fmt.Errorf("Get jumpbox private key: %s", err)
-	}
-
-	err = m.fs.WriteFile(privateKeyPath, []byte(privateKeyContents), 0600)
+	err = m.setJumpboxProxy(state)
 	if err != nil {
-		return fmt.Errorf("Write jumpbox private key: %s", err)
+		return err
 	}
 
-	osSetenv("BOSH_ALL_PROXY", fmt.Sprintf("ssh+socks5://jumpbox@%s?private-key=%s", state.Jumpbox.URL, privateKeyPath))
-
 	err = m.executor.DeleteEnv(dirInput, state)
 	if err != nil {
 		return NewManagerDeleteError(state, err)
@@ -398,6 +426,9 @@ func getDirectorVars(v string) directorVars {
 			Certificate string `yaml:"certificate"`
 			PrivateKey  string `yaml:"private_key"`
 		} `yaml:"director_ssl"`
+		DefaultCA struct {
+			Certificate string `yaml:"certificate"`
+		} `yaml:"default_ca"`
 	}
 
 	err := yaml.Unmarshal([]byte(v), &vars)
@@ -405,11 +436,42 @@ func getDirectorVars(v string) directorVars {
 		panic(err) // can't happen
 	}
 
+	// Between the phases of a CA rotation the director certificate is
+	// still signed by the old CA, so clients are given both.
+	sslCA := vars.DirectorSSL.CA
+	if vars.DefaultCA.Certificate != "" && vars.DefaultCA.Certificate != sslCA {
+		sslCA += vars.DefaultCA.Certificate
+	}
+
 	return directorVars{
 		username:       "admin",
 		password:       vars.AdminPassword,
-		sslCA:          vars.DirectorSSL.CA,
+		sslCA:          sslCA,
 		sslCertificate: vars.DirectorSSL.Certificate,
 		sslPrivateKey:  vars.DirectorSSL.PrivateKey,
 	}
 }
+
+// setJumpboxProxy points the bosh cli at the director through the jumpbox.
+func (m *Manager) setJumpboxProxy(state storage.State) error {
+	dir, err := m.fs.TempDir("", "bosh-jumpbox")
+	if err != nil {
+		return fmt.Errorf("Create temp dir for jumpbox private key: %s", err)
+	}
+
+	privateKeyPath := filepath.Join(dir, "bosh_jumpbox_private.key")
+
+	privateKeyContents, err := m.sshKeyGetter.Get("jumpbox")
+	if err != nil {
+		return fmt.Errorf("Get jumpbox private key: %s", err)
+	}
+
+	err = m.fs.WriteFile(privateKeyPath, []byte(privateKeyContents), 0600)
+	if err != nil {
+		return fmt.Errorf("Write jumpbox private key: %s", err)
+	}
+
+	osSetenv("BOSH_ALL_PROXY", fmt.Sprintf("ssh+socks5://jumpbox@%s?private-key=%s", state.Jumpbox.URL, privateKeyPath))
+
+	return nil
+}
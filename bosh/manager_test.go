@@ -164,6 +164,36 @@ director_ssl:
 				}))
 			})
 
+			It("records the deployed manifest", func() {
+				boshExecutor.InterpolateCall.Returns.Manifest = "name: bosh"
+
+				stateWithDirector, err := boshManager.CreateDirector(state, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.InterpolateCall.Receives.DirInput.Deployment).To(Equal("director"))
+				Expect(stateWithDirector.BOSH.Manifest).To(Equal("name: bosh"))
+			})
+
+			It("trusts both CAs while a CA rotation is in progress", func() {
+				boshExecutor.CreateEnvCall.Returns.Variables = boshVars + "default_ca:\n  certificate: some-new-ca\n"
+
+				stateWithDirector, err := boshManager.CreateDirector(state, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateWithDirector.BOSH.DirectorSSLCA).To(Equal("some-casome-new-ca"))
+			})
+
+			It("sets BOSH_ALL_PROXY when the jumpbox was created by an earlier run", func() {
+				fs.TempDirCall.Returns.Name = "/fake/file/bosh-jumpbox"
+				state.Jumpbox = storage.Jumpbox{URL: "some-jumpbox-url:22"}
+
+				_, err := boshManager.CreateDirector(state, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(osSetenvKey).To(Equal("BOSH_ALL_PROXY"))
+				Expect(osSetenvValue).To(Equal("ssh+socks5://jumpbox@some-jumpbox-url:22?private-key=/fake/file/bosh-jumpbox/bosh_jumpbox_private.key"))
+			})
+
 			Context("when an error occurs", func() {
 				Context("when get vars dir fails", func() {
 					It("returns an error", func() {
@@ -294,6 +324,27 @@ director_ssl:
 				}))
 			})
 
+			It("records the deployed manifest", func() {
+				boshExecutor.InterpolateCall.Returns.Manifest = "name: jumpbox"
+
+				state, err := boshManager.CreateJumpbox(state, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshExecutor.InterpolateCall.Receives.DirInput.Deployment).To(Equal("jumpbox"))
+				Expect(boshExecutor.InterpolateCall.Receives.State.EnvID).To(Equal("some-env-id"))
+				Expect(state.Jumpbox.Manifest).To(Equal("name: jumpbox"))
+			})
+
+			It("warns instead of recording a manifest that cannot be interpolated", func() {
+				boshExecutor.InterpolateCall.Returns.Error = errors.New("papaya")
+
+				state, err := boshManager.CreateJumpbox(state, terraformOutputs)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(state.Jumpbox.Manifest).To(Equal(""))
+				Expect(logger.PrintlnMessages()).To(ContainElement("warning: the jumpbox manifest could not be recorded for bbl plan --diff and bbl versions: papaya"))
+			})
+
 			Context("when an error occurs", func() {
 				Context("when geting the jumpbox key fails", func() {
 					BeforeEach(func() {
@@ -509,6 +560,48 @@ director_ssl:
 		})
 	})
 
+	Describe("InterpolateJumpbox", func() {
+		It("writes the deployment vars and interpolates the jumpbox manifest", func() {
+			boshExecutor.InterpolateCall.Returns.Manifest = "name: jumpbox"
+
+			manifest, err := boshManager.InterpolateJumpbox(storage.State{IAAS: "gcp"}, terraform.Outputs{Map: map[string]interface{}{
+				"jumpbox__key": "some-jumpbox-value",
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest).To(Equal("name: jumpbox"))
+
+			Expect(boshExecutor.WriteDeploymentVarsCall.Receives.DirInput).To(Equal(bosh.DirInput{
+				Deployment: "jumpbox",
+				StateDir:   "some-state-dir",
+				VarsDir:    "some-bbl-vars-dir",
+			}))
+			Expect(boshExecutor.WriteDeploymentVarsCall.Receives.DeploymentVars).To(MatchYAML("key: some-jumpbox-value"))
+			Expect(boshExecutor.InterpolateCall.Receives.DirInput.Deployment).To(Equal("jumpbox"))
+			Expect(boshExecutor.InterpolateCall.Receives.State.IAAS).To(Equal("gcp"))
+		})
+
+		It("returns an error when the deployment vars cannot be written", func() {
+			boshExecutor.WriteDeploymentVarsCall.Returns.Error = errors.New("guava")
+
+			_, err := boshManager.InterpolateJumpbox(storage.State{}, terraform.Outputs{})
+			Expect(err).To(MatchError("Write deployment vars: guava"))
+		})
+	})
+
+	Describe("InterpolateDirector", func() {
+		It("interpolates the director manifest", func() {
+			boshExecutor.InterpolateCall.Returns.Error = errors.New("quince")
+
+			_, err := boshManager.InterpolateDirector(storage.State{}, terraform.Outputs{Map: map[string]interface{}{
+				"director__key": "some-director-value",
+			}})
+			Expect(err).To(MatchError("quince"))
+
+			Expect(boshExecutor.WriteDeploymentVarsCall.Receives.DeploymentVars).To(MatchYAML("key: some-director-value"))
+			Expect(boshExecutor.InterpolateCall.Receives.DirInput.Deployment).To(Equal("director"))
+		})
+	})
+
 	Describe("GetJumpboxDeploymentVars", func() {
 		It("removes the jumpbox__ prefix from variable names", func() {
 			vars := boshManager.GetJumpboxDeploymentVars(storage.State{}, terraform.Outputs{Map: map[string]interface{}{
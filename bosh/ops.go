@@ -41,3 +41,101 @@ const OpenStackJumpboxKeystoneV3Ops = `---
   path: /cloud_provider/properties/openstack/human_readable_vm_names?
   value: true
 `
+
+// CARotationOps makes the director trust both the old and the new CAs
+// between the phases of bbl rotate --credentials=ca. The director signs
+// the NATS certificates of new VMs with the new CA.
+const CARotationOps = `
+- type: replace
+  path: /instance_groups/name=bosh/properties/nats/tls/ca
+  value: ((nats_ca_old.certificate))((nats_ca.certificate))
+
+- type: replace
+  path: /instance_groups/name=bosh/properties/nats/tls/client_ca/certificate
+  value: ((nats_ca.certificate))((nats_ca_old.certificate))
+
+- type: replace
+  path: /instance_groups/name=bosh/properties/hm/director_account/ca_cert
+  value: ((default_ca_old.certificate))((default_ca.certificate))
+
+- type: replace
+  path: /instance_groups/name=bosh/jobs/name=credhub/properties/credhub/authentication/uaa/ca_certs
+  value:
+  - ((default_ca_old.certificate))
+  - ((default_ca.certificate))
+
+- type: replace
+  path: /instance_groups/name=bosh/properties/director/config_server/ca_cert
+  value: ((credhub_ca_old.certificate))((credhub_ca.certificate))
+
+- type: replace
+  path: /instance_groups/name=bosh/properties/director/config_server/uaa/ca_cert
+  value: ((default_ca_old.certificate))((default_ca.certificate))
+`
+
+// CredHubEncryptionRotationOps keeps the old CredHub encryption key as an
+// inactive key, so CredHub can re-encrypt its data with the new one.
+const CredHubEncryptionRotationOps = `
+- type: replace
+  path: /instance_groups/name=bosh/jobs/name=credhub/properties/credhub/encryption/keys/-
+  value:
+    provider_name: internal
+    encryption_password: ((credhub_encryption_password_old))
+    active: false
+`
+
+// JumpboxHostKeyOps installs an SSH host key that bosh generates into the
+// jumpbox vars store, so that bbl can verify the jumpbox against it.
+const JumpboxHostKeyOps = `---
+- type: replace
+  path: /instance_groups/name=jumpbox/jobs/-
+  value:
+    name: pre-start-script
+    release: os-conf
+    properties:
+      script: |-
+        #!/bin/bash
+        set -e
+        cat > /etc/ssh/ssh_host_rsa_key <<'KEY'
+        ((jumpbox_host_key.private_key))
+        KEY
+        chmod 0600 /etc/ssh/ssh_host_rsa_key
+        echo '((jumpbox_host_key.public_key))' > /etc/ssh/ssh_host_rsa_key.pub
+        sed -i '/^HostKey /d' /etc/ssh/sshd_config
+        echo 'HostKey /etc/ssh/ssh_host_rsa_key' >> /etc/ssh/sshd_config
+        service ssh restart
+
+- type: replace
+  path: /variables/-
+  value:
+    name: jumpbox_host_key
+    type: ssh
+`
+
+// DirectorHostKeyOps installs an SSH host key that bosh generates into the
+// director vars store, so that bbl can verify the director against it.
+const DirectorHostKeyOps = `---
+- type: replace
+  path: /instance_groups/name=bosh/jobs/-
+  value:
+    name: pre-start-script
+    release: os-conf
+    properties:
+      script: |-
+        #!/bin/bash
+        set -e
+        cat > /etc/ssh/ssh_host_rsa_key <<'KEY'
+        ((director_host_key.private_key))
+        KEY
+        chmod 0600 /etc/ssh/ssh_host_rsa_key
+        echo '((director_host_key.public_key))' > /etc/ssh/ssh_host_rsa_key.pub
+        sed -i '/^HostKey /d' /etc/ssh/sshd_config
+        echo 'HostKey /etc/ssh/ssh_host_rsa_key' >> /etc/ssh/sshd_config
+        service ssh restart
+
+- type: replace
+  path: /variables/-
+  value:
+    name: director_host_key
+    type: ssh
+`
@@ -0,0 +1,320 @@
+package bosh
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+type varsEditorFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.Stater
+}
+
+// Var is one variable in a deployment's vars files. Regenerated vars are
+// rewritten by every bbl plan, so bbl vars does not change them.
+type Var struct {
+	Name        string `json:"name"`
+	File        string `json:"file"`
+	Regenerated bool   `json:"regenerated"`
+}
+
+type varsFile struct {
+	name        string
+	regenerated bool
+}
+
+var deploymentVarsFiles = map[string][]varsFile{
+	"jumpbox": {
+		{name: "jumpbox-vars-store.yml"},
+		{name: "jumpbox-vars-file.yml", regenerated: true},
+	},
+	"director": {
+		{name: "director-vars-store.yml"},
+		{name: "director-vars-file.yml", regenerated: true},
+	},
+	"cloud-config": {
+		{name: "cloud-config-vars.yml", regenerated: true},
+	},
+}
+
+// VarsDeployments returns the deployments bbl vars --deployment accepts.
+func VarsDeployments() []string {
+	return []string{"director", "jumpbox", "cloud-config"}
+}
+
+type VarsEditor struct {
+	stateStore stateStore
+	fs         varsEditorFs
+}
+
+func NewVarsEditor(stateStore stateStore, fs varsEditorFs) VarsEditor {
+	return VarsEditor{
+		stateStore: stateStore,
+		fs:         fs,
+	}
+}
+
+// List returns the variables of the deployment sorted by name.
+func (v VarsEditor) List(deployment string) ([]Var, error) {
+	files, err := v.varsFiles(deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []Var{}
+	for _, file := range files {
+		contents, err := v.read(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range contents {
+			vars = append(vars, Var{Name: fmt.Sprint(item.Key), File: file.name, Regenerated: file.regenerated})
+		}
+	}
+
+	sort.SliceStable(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars, nil
+}
+
+// Get returns the value of the variable, as YAML unless it is a string.
+func (v VarsEditor) Get(deployment, name string) (string, error) {
+	files, err := v.varsFiles(deployment)
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range files {
+		contents, err := v.read(file)
+		if err != nil {
+			return "", err
+		}
+		for _, item := range contents {
+			if item.Key != name {
+				continue
+			}
+			if value, ok := item.Value.(string); ok {
+				return value, nil
+			}
+			value, err := yaml.Marshal(item.Value)
+			if err != nil {
+				return "", err // not tested
+			}
+			return string(value), nil
+		}
+	}
+
+	return "", fmt.Errorf("%s is not set for the %s.", name, deployment)
+}
+
+// Set stores value, parsed as YAML, in the deployment's vars store. The
+// variable has to be used by the manifest or ops files bbl deploys.
+func (v VarsEditor) Set(deployment, name, value string) error {
+	store, err := v.store(deployment, name)
+	if err != nil {
+		return err
+	}
+
+	defined, err := v.defined(deployment, name)
+	if err != nil {
+		return err
+	}
+	if !defined {
+		return fmt.Errorf("%s is not used by the %s manifest or its ops files.", name, deployment)
+	}
+
+	var parsed interface{}
+	err = yaml.Unmarshal([]byte(value), &parsed)
+	if err != nil {
+		return fmt.Errorf("Value is not valid YAML: %s", err)
+	}
+
+	contents, err := v.read(store)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, item := range contents {
+		if item.Key == name {
+			contents[i].Value = parsed
+			replaced = true
+		}
+	}
+	if !replaced {
+		contents = append(contents, yaml.MapItem{Key: name, Value: parsed})
+	}
+
+	return v.write(store, contents)
+}
+
+// Delete removes the variable from the deployment's vars store, so that
+// the next bbl up generates it again.
+func (v VarsEditor) Delete(deployment, name string) error {
+	store, err := v.store(deployment, name)
+	if err != nil {
+		return err
+	}
+
+	contents, err := v.read(store)
+	if err != nil {
+		return err
+	}
+
+	remaining := yaml.MapSlice{}
+	for _, item := range contents {
+		if item.Key != name {
+			remaining = append(remaining, item)
+		}
+	}
+	if len(remaining) == len(contents) {
+		return fmt.Errorf("%s is not set for the %s.", name, deployment)
+	}
+
+	return v.write(store, remaining)
+}
+
+func (v VarsEditor) varsFiles(deployment string) ([]varsFile, error) {
+	files, ok := deploymentVarsFiles[deployment]
+	if !ok {
+		return nil, fmt.Errorf("Unknown deployment %q: use %s.", deployment, strings.Join(VarsDeployments(), ", "))
+	}
+	return files, nil
+}
+
+// store returns the vars file bbl vars may change name in, refusing the
+// vars that bbl plan regenerates.
+func (v VarsEditor) store(deployment, name string) (varsFile, error) {
+	files, err := v.varsFiles(deployment)
+	if err != nil {
+		return varsFile{}, err
+	}
+
+	var store *varsFile
+	for i, file := range files {
+		if !file.regenerated {
+			store = &files[i]
+			continue
+		}
+
+		contents, err := v.read(file)
+		if err != nil {
+			return varsFile{}, err
+		}
+		for _, item := range contents {
+			if item.Key == name {
+				return varsFile{}, fmt.Errorf("%s is regenerated from bbl state by every bbl plan and cannot be changed in %s.", name, file.name)
+			}
+		}
+	}
+
+	if store == nil {
+		return varsFile{}, fmt.Errorf("The %s vars are regenerated by every bbl plan and cannot be changed. Add an ops file to the cloud-config directory instead.", deployment)
+	}
+
+	return *store, nil
+}
+
+func (v VarsEditor) read(file varsFile) (yaml.MapSlice, error) {
+	varsDir, err := v.stateStore.GetVarsDir()
+	if err != nil {
+		return nil, fmt.Errorf("Get vars directory: %s", err)
+	}
+
+	contents, err := v.fs.ReadFile(filepath.Join(varsDir, file.name))
+	if os.IsNotExist(err) {
+		return yaml.MapSlice{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Read %s: %s", file.name, err)
+	}
+
+	vars := yaml.MapSlice{}
+	err = yaml.Unmarshal(contents, &vars)
+	if err != nil {
+		return nil, fmt.Errorf("Parse %s: %s", file.name, err)
+	}
+
+	return vars, nil
+}
+
+func (v VarsEditor) write(file varsFile, vars yaml.MapSlice) error {
+	varsDir, err := v.stateStore.GetVarsDir()
+	if err != nil {
+		return fmt.Errorf("Get vars directory: %s", err) // not tested
+	}
+
+	contents, err := yaml.Marshal(vars)
+	if err != nil {
+		return err // not tested
+	}
+
+	err = v.fs.WriteFile(filepath.Join(varsDir, file.name), contents, storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Write %s: %s", file.name, err)
+	}
+
+	return nil
+}
+
+// defined reports whether the manifest or an ops file in the deployment's
+// create script refers to the variable.
+func (v VarsEditor) defined(deployment, name string) (bool, error) {
+	stateDir := v.stateStore.GetStateDir()
+
+	script := filepath.Join(stateDir, fmt.Sprintf("create-%s-override.sh", deployment))
+	if _, err := v.fs.Stat(script); err != nil {
+		script = filepath.Join(stateDir, fmt.Sprintf("create-%s.sh", deployment))
+	}
+
+	contents, err := v.fs.ReadFile(script)
+	if err != nil {
+		return false, fmt.Errorf("Read %s: %s. Run bbl plan first.", filepath.Base(script), err)
+	}
+
+	reference := regexp.MustCompile(`\(\(` + regexp.QuoteMeta(name) + `(\.[^)]*)?\)\)`)
+	for _, path := range scriptManifests(string(contents), stateDir) {
+		manifest, err := v.fs.ReadFile(path)
+		if err != nil {
+			return false, fmt.Errorf("Read %s: %s", path, err)
+		}
+		if reference.Match(manifest) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// scriptManifests returns the manifest and ops files a create-env script
+// passes to bosh.
+func scriptManifests(script, stateDir string) []string {
+	fields := strings.Fields(strings.Replace(script, "\\\n", " ", -1))
+
+	paths := []string{}
+	for i, field := range fields {
+		if i > 0 && fields[i-1] == "create-env" {
+			paths = append(paths, field)
+		}
+		if (field == "-o" || field == "--ops-file") && i+1 < len(fields) {
+			paths = append(paths, fields[i+1])
+		}
+	}
+
+	for i, path := range paths {
+		path = strings.Trim(path, `"'`)
+		path = strings.Replace(path, "${BBL_STATE_DIR}", stateDir, -1)
+		paths[i] = strings.Replace(path, "$BBL_STATE_DIR", stateDir, -1)
+	}
+
+	return paths
+}
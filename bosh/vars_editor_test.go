@@ -0,0 +1,236 @@
+package bosh_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VarsEditor", func() {
+	var (
+		stateStore *fakes.StateStore
+		fs         *afero.Afero
+		varsEditor bosh.VarsEditor
+	)
+
+	BeforeEach(func() {
+		stateStore = &fakes.StateStore{}
+		stateStore.GetStateDirCall.Returns.Directory = "/state"
+		stateStore.GetVarsDirCall.Returns.Directory = "/state/vars"
+
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		fs.WriteFile("/state/vars/director-vars-store.yml", []byte(`admin_password: some-password
+director_ssl:
+  ca: some-ca
+  certificate: some-certificate
+`), 0600)
+		fs.WriteFile("/state/vars/director-vars-file.yml", []byte(`director_name: some-director
+internal_ip: 10.0.0.6
+`), 0600)
+		fs.WriteFile("/state/vars/cloud-config-vars.yml", []byte("az1_name: some-az\n"), 0600)
+		fs.WriteFile("/state/create-director.sh", []byte(`#!/bin/sh
+bosh create-env \
+  ${BBL_STATE_DIR}/bosh-deployment/bosh.yml \
+  --state  ${BBL_STATE_DIR}/vars/bosh-state.json \
+  --vars-store  ${BBL_STATE_DIR}/vars/director-vars-store.yml \
+  --vars-file  ${BBL_STATE_DIR}/vars/director-vars-file.yml \
+  -o  ${BBL_STATE_DIR}/bosh-deployment/uaa.yml \
+  -o  ${BBL_STATE_DIR}/bbl-ops-files/aws/bosh-director-ephemeral-ip-ops.yml
+`), 0700)
+		fs.WriteFile("/state/bosh-deployment/bosh.yml", []byte(`instance_groups:
+- name: bosh
+  properties:
+    director:
+      name: ((director_name))
+      ssl:
+        cert: ((director_ssl.certificate))
+    users:
+    - password: ((admin_password))
+`), 0600)
+		fs.WriteFile("/state/bosh-deployment/uaa.yml", []byte(`- type: replace
+  path: /instance_groups/name=bosh/properties/uaa/clients/admin/secret
+  value: ((uaa_admin_client_secret))
+`), 0600)
+		fs.WriteFile("/state/bbl-ops-files/aws/bosh-director-ephemeral-ip-ops.yml", []byte("[]\n"), 0600)
+
+		varsEditor = bosh.NewVarsEditor(stateStore, fs)
+	})
+
+	Describe("List", func() {
+		It("lists the vars of the deployment and the ones bbl regenerates", func() {
+			vars, err := varsEditor.List("director")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(vars).To(Equal([]bosh.Var{
+				{Name: "admin_password", File: "director-vars-store.yml"},
+				{Name: "director_name", File: "director-vars-file.yml", Regenerated: true},
+				{Name: "director_ssl", File: "director-vars-store.yml"},
+				{Name: "internal_ip", File: "director-vars-file.yml", Regenerated: true},
+			}))
+		})
+
+		It("lists nothing when the vars files do not exist yet", func() {
+			vars, err := varsEditor.List("jumpbox")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vars).To(BeEmpty())
+		})
+
+		Context("when the deployment is unknown", func() {
+			It("returns an error", func() {
+				_, err := varsEditor.List("concourse")
+				Expect(err).To(MatchError(`Unknown deployment "concourse": use director, jumpbox, cloud-config.`))
+			})
+		})
+
+		Context("when the vars store cannot be parsed", func() {
+			It("returns an error", func() {
+				fs.WriteFile("/state/vars/director-vars-store.yml", []byte("%%%"), 0600)
+
+				_, err := varsEditor.List("director")
+				Expect(err).To(MatchError(ContainSubstring("Parse director-vars-store.yml:")))
+			})
+		})
+
+		Context("when the vars directory cannot be found", func() {
+			It("returns an error", func() {
+				stateStore.GetVarsDirCall.Returns.Error = errors.New("nope")
+
+				_, err := varsEditor.List("director")
+				Expect(err).To(MatchError("Get vars directory: nope"))
+			})
+		})
+	})
+
+	Describe("Get", func() {
+		It("returns strings as they are", func() {
+			value, err := varsEditor.Get("director", "admin_password")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("some-password"))
+		})
+
+		It("returns other values as YAML", func() {
+			value, err := varsEditor.Get("director", "director_ssl")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("ca: some-ca\ncertificate: some-certificate\n"))
+		})
+
+		It("returns regenerated vars", func() {
+			value, err := varsEditor.Get("cloud-config", "az1_name")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("some-az"))
+		})
+
+		Context("when the var is not set", func() {
+			It("returns an error", func() {
+				_, err := varsEditor.Get("director", "missing")
+				Expect(err).To(MatchError("missing is not set for the director."))
+			})
+		})
+	})
+
+	Describe("Set", func() {
+		It("replaces the value in the vars store and keeps the order of the other vars", func() {
+			err := varsEditor.Set("director", "admin_password", "new-password")
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fs.ReadFile("/state/vars/director-vars-store.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal(`admin_password: new-password
+director_ssl:
+  ca: some-ca
+  certificate: some-certificate
+`))
+		})
+
+		It("adds vars used by an ops file and parses the value as YAML", func() {
+			err := varsEditor.Set("director", "uaa_admin_client_secret", "{value: some-secret}")
+			Expect(err).NotTo(HaveOccurred())
+
+			value, err := varsEditor.Get("director", "uaa_admin_client_secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value).To(Equal("value: some-secret\n"))
+		})
+
+		It("uses the override script when there is one", func() {
+			fs.WriteFile("/state/create-director-override.sh", []byte("bosh create-env ${BBL_STATE_DIR}/bosh-deployment/uaa.yml\n"), 0700)
+
+			err := varsEditor.Set("director", "admin_password", "new-password")
+			Expect(err).To(MatchError("admin_password is not used by the director manifest or its ops files."))
+		})
+
+		Context("when the var is not used by the manifest or an ops file", func() {
+			It("returns an error and does not change the vars store", func() {
+				err := varsEditor.Set("director", "admin", "new-password")
+				Expect(err).To(MatchError("admin is not used by the director manifest or its ops files."))
+
+				contents, err := fs.ReadFile("/state/vars/director-vars-store.yml")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).NotTo(ContainSubstring("admin:"))
+			})
+		})
+
+		Context("when bbl regenerates the var", func() {
+			It("returns an error", func() {
+				err := varsEditor.Set("director", "director_name", "other-director")
+				Expect(err).To(MatchError("director_name is regenerated from bbl state by every bbl plan and cannot be changed in director-vars-file.yml."))
+			})
+		})
+
+		Context("when the deployment is the cloud-config", func() {
+			It("returns an error", func() {
+				err := varsEditor.Set("cloud-config", "az1_name", "other-az")
+				Expect(err).To(MatchError("az1_name is regenerated from bbl state by every bbl plan and cannot be changed in cloud-config-vars.yml."))
+
+				err = varsEditor.Set("cloud-config", "vm_type", "small")
+				Expect(err).To(MatchError("The cloud-config vars are regenerated by every bbl plan and cannot be changed. Add an ops file to the cloud-config directory instead."))
+			})
+		})
+
+		Context("when bbl plan has not run", func() {
+			It("returns an error", func() {
+				fs.Remove("/state/create-director.sh")
+
+				err := varsEditor.Set("director", "admin_password", "new-password")
+				Expect(err).To(MatchError(ContainSubstring("Run bbl plan first.")))
+			})
+		})
+
+		Context("when the value is not valid YAML", func() {
+			It("returns an error", func() {
+				err := varsEditor.Set("director", "admin_password", "{")
+				Expect(err).To(MatchError(ContainSubstring("Value is not valid YAML:")))
+			})
+		})
+	})
+
+	Describe("Delete", func() {
+		It("removes the var from the vars store", func() {
+			err := varsEditor.Delete("director", "director_ssl")
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := fs.ReadFile(filepath.Join("/state/vars", "director-vars-store.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("admin_password: some-password\n"))
+		})
+
+		Context("when the var is not in the vars store", func() {
+			It("returns an error", func() {
+				err := varsEditor.Delete("director", "missing")
+				Expect(err).To(MatchError("missing is not set for the director."))
+			})
+		})
+
+		Context("when bbl regenerates the var", func() {
+			It("returns an error", func() {
+				err := varsEditor.Delete("director", "internal_ip")
+				Expect(err).To(MatchError(ContainSubstring("internal_ip is regenerated")))
+			})
+		})
+	})
+})
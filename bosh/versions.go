@@ -0,0 +1,167 @@
+package bosh
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Versions are the stemcell and release versions of a deployment.
+type Versions struct {
+	Stemcell string
+	Releases map[string]string
+}
+
+type release struct {
+	Name    string      `yaml:"name"`
+	Version interface{} `yaml:"version"`
+	URL     string      `yaml:"url"`
+}
+
+type stemcell struct {
+	URL string `yaml:"url"`
+}
+
+type VersionGetter struct{}
+
+func NewVersionGetter() VersionGetter {
+	return VersionGetter{}
+}
+
+// Shipped returns the versions this bbl deploys for the jumpbox or the
+// director on iaas, read from the embedded deployment repos.
+func (VersionGetter) Shipped(deployment, iaas string) (Versions, error) {
+	var manifest string
+	var opsFiles []string
+	switch deployment {
+	case "jumpbox":
+		manifest = filepath.Join(jumpboxDeploymentRepo, "jumpbox.yml")
+		opsFiles = []string{filepath.Join(jumpboxDeploymentRepo, iaas, "cpi.yml")}
+		if iaas == "vsphere" {
+			opsFiles = append(opsFiles, filepath.Join(jumpboxDeploymentRepo, "vsphere", "resource-pool.yml"))
+		}
+	case "director":
+		manifest = filepath.Join(boshDeploymentRepo, "bosh.yml")
+		opsFiles = Executor{}.getDirectorOpsFiles("", boshDeploymentRepo, iaas)
+	default:
+		return Versions{}, fmt.Errorf("Unknown deployment %q", deployment)
+	}
+
+	contents, err := Asset(manifest)
+	if err != nil {
+		return Versions{}, err
+	}
+
+	versions, err := parseVersions(string(contents))
+	if err != nil {
+		return Versions{}, fmt.Errorf("%s: %s", path.Base(manifest), err) // not tested
+	}
+
+	for _, opsFile := range opsFiles {
+		contents, err := Asset(opsFile)
+		if err != nil {
+			continue // bbl writes the rest of the ops files itself
+		}
+
+		err = applyVersionOps(&versions, contents)
+		if err != nil {
+			return Versions{}, fmt.Errorf("%s: %s", path.Base(opsFile), err) // not tested
+		}
+	}
+
+	return versions, nil
+}
+
+// Deployed returns the versions in the manifest bbl up last deployed.
+func (VersionGetter) Deployed(manifest string) (Versions, error) {
+	return parseVersions(manifest)
+}
+
+func parseVersions(manifest string) (Versions, error) {
+	var m struct {
+		Releases      []release `yaml:"releases"`
+		ResourcePools []struct {
+			Stemcell stemcell `yaml:"stemcell"`
+		} `yaml:"resource_pools"`
+	}
+
+	err := yaml.Unmarshal([]byte(manifest), &m)
+	if err != nil {
+		return Versions{}, err
+	}
+
+	versions := Versions{Releases: map[string]string{}}
+	for _, r := range m.Releases {
+		versions.Releases[r.Name] = r.version()
+	}
+	for _, pool := range m.ResourcePools {
+		if pool.Stemcell.URL != "" {
+			versions.Stemcell = versionFromURL(pool.Stemcell.URL)
+		}
+	}
+
+	return versions, nil
+}
+
+func applyVersionOps(versions *Versions, contents []byte) error {
+	var ops []struct {
+		Type  string      `yaml:"type"`
+		Path  string      `yaml:"path"`
+		Value interface{} `yaml:"value"`
+	}
+
+	err := yaml.Unmarshal(contents, &ops)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range ops {
+		if op.Type != "replace" {
+			continue
+		}
+
+		value, err := yaml.Marshal(op.Value)
+		if err != nil {
+			return err // not tested
+		}
+
+		switch {
+		case op.Path == "/releases/-" || strings.HasPrefix(op.Path, "/releases/name="):
+			var r release
+			if yaml.Unmarshal(value, &r) == nil && r.Name != "" {
+				versions.Releases[r.Name] = r.version()
+			}
+		case strings.HasSuffix(strings.TrimSuffix(op.Path, "?"), "/stemcell"):
+			var s stemcell
+			if yaml.Unmarshal(value, &s) == nil && s.URL != "" {
+				versions.Stemcell = versionFromURL(s.URL)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r release) version() string {
+	if r.Version != nil {
+		return fmt.Sprint(r.Version)
+	}
+	return versionFromURL(r.URL)
+}
+
+// versionFromURL reads the version from a bosh.io URL such as
+// https://bosh.io/d/stemcells/bosh-aws-xen-hvm-ubuntu-trusty-go_agent?v=3541.10.
+func versionFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if v := u.Query().Get("v"); v != "" {
+		return v
+	}
+	return path.Base(u.Path)
+}
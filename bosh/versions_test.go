@@ -0,0 +1,74 @@
+package bosh_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VersionGetter", func() {
+	var versionGetter bosh.VersionGetter
+
+	BeforeEach(func() {
+		versionGetter = bosh.NewVersionGetter()
+	})
+
+	Describe("Shipped", func() {
+		It("returns the director versions from bosh-deployment", func() {
+			versions, err := versionGetter.Shipped("director", "aws")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(versions.Stemcell).To(Equal("3541.10"))
+			Expect(versions.Releases).To(HaveKeyWithValue("bosh", "265.2.0"))
+			Expect(versions.Releases).To(HaveKeyWithValue("bosh-aws-cpi", "69"))
+			Expect(versions.Releases).To(HaveKeyWithValue("uaa", "52.8"))
+			Expect(versions.Releases).To(HaveKeyWithValue("credhub", "1.6.5"))
+		})
+
+		It("returns the jumpbox versions from jumpbox-deployment", func() {
+			versions, err := versionGetter.Shipped("jumpbox", "aws")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(versions.Stemcell).To(Equal("3468.17"))
+			Expect(versions.Releases).To(HaveKeyWithValue("os-conf", "13"))
+			Expect(versions.Releases).To(HaveKeyWithValue("bosh-aws-cpi", "69"))
+		})
+
+		It("returns an error for an unknown deployment", func() {
+			_, err := versionGetter.Shipped("concourse", "aws")
+			Expect(err).To(MatchError(`Unknown deployment "concourse"`))
+		})
+	})
+
+	Describe("Deployed", func() {
+		It("returns the versions in the manifest", func() {
+			versions, err := versionGetter.Deployed(`---
+name: jumpbox
+releases:
+- name: os-conf
+  version: 12
+- name: bosh-aws-cpi
+  url: https://bosh.io/d/github.com/cloudfoundry-incubator/bosh-aws-cpi-release?v=68
+resource_pools:
+- name: vms
+  stemcell:
+    url: https://bosh.io/d/stemcells/bosh-aws-xen-hvm-ubuntu-trusty-go_agent?v=3468.11
+`)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(versions).To(Equal(bosh.Versions{
+				Stemcell: "3468.11",
+				Releases: map[string]string{
+					"os-conf":      "12",
+					"bosh-aws-cpi": "68",
+				},
+			}))
+		})
+
+		It("returns an error when the manifest is invalid YAML", func() {
+			_, err := versionGetter.Deployed("%%%")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
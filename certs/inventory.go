@@ -0,0 +1,112 @@
+package certs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// Certificate describes one certificate bbl manages, for bbl certs.
+type Certificate struct {
+	Source   string    `json:"source"`
+	Name     string    `json:"name"`
+	Subject  string    `json:"subject"`
+	SANs     []string  `json:"sans"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"notAfter"`
+}
+
+// Parse describes every certificate in the PEM contents, so that a chain
+// or a CA bundle yields one Certificate each.
+func Parse(source, name string, contents []byte) ([]Certificate, error) {
+	certificates := []Certificate{}
+
+	for {
+		var block *pem.Block
+		block, contents = pem.Decode(contents)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		sans := []string{}
+		sans = append(sans, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+
+		certificates = append(certificates, Certificate{
+			Source:   source,
+			Name:     name,
+			Subject:  cert.Subject.String(),
+			SANs:     sans,
+			Issuer:   cert.Issuer.String(),
+			NotAfter: cert.NotAfter.UTC(),
+		})
+	}
+
+	if len(certificates) == 0 {
+		return nil, errors.New("no PEM encoded certificate found")
+	}
+
+	return certificates, nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the first certificate in
+// the PEM contents, formatted like openssl x509 -fingerprint -sha256.
+func Fingerprint(contents []byte) (string, error) {
+	for {
+		var block *pem.Block
+		block, contents = pem.Decode(contents)
+		if block == nil {
+			return "", errors.New("no PEM encoded certificate found")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		sum := sha256.Sum256(block.Bytes)
+		hex := make([]string, len(sum))
+		for i, b := range sum {
+			hex[i] = fmt.Sprintf("%02X", b)
+		}
+		return strings.Join(hex, ":"), nil
+	}
+}
+
+// PFXToPEM returns the certificates in base64 encoded PKCS#12 contents as
+// PEM. bbl stores the Azure load balancer certificate that way.
+func PFXToPEM(contents []byte, password string) ([]byte, error) {
+	pfx, err := base64.StdEncoding.DecodeString(string(contents))
+	if err != nil {
+		return nil, fmt.Errorf("decode PFX: %s", err)
+	}
+
+	blocks, err := pkcs12.ToPEM(pfx, password)
+	if err != nil {
+		return nil, err
+	}
+
+	var certificates bytes.Buffer
+	for _, block := range blocks {
+		if block.Type == "CERTIFICATE" {
+			certificates.Write(pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: block.Bytes}))
+		}
+	}
+	return certificates.Bytes(), nil
+}
@@ -0,0 +1,87 @@
+package certs_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/certs"
+	"github.com/cloudfoundry/bosh-bootloader/testhelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parse", func() {
+	It("describes the certificate", func() {
+		notAfter := time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)
+		cert, err := testhelpers.GenerateCertificate("some-director", notAfter)
+		Expect(err).NotTo(HaveOccurred())
+
+		certificates, err := certs.Parse("director", "director_ssl", []byte(cert))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(certificates).To(Equal([]certs.Certificate{{
+			Source:   "director",
+			Name:     "director_ssl",
+			Subject:  "CN=some-director",
+			SANs:     []string{"some-director", "10.0.0.6"},
+			Issuer:   "CN=some-director",
+			NotAfter: notAfter,
+		}}))
+	})
+
+	It("describes every certificate in a chain", func() {
+		certificates, err := certs.Parse("lb", "lb", []byte(testhelpers.BBL_CERT+"\n"+testhelpers.BBL_CHAIN))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(certificates).To(HaveLen(2))
+		Expect(certificates[0].Subject).To(Equal("CN=bbl-intermediate"))
+		Expect(certificates[0].Issuer).To(Equal("CN=bbl-ca"))
+		Expect(certificates[1].Subject).To(Equal("CN=bbl-ca"))
+	})
+
+	It("returns an error when there is no certificate", func() {
+		_, err := certs.Parse("lb", "lb", []byte(testhelpers.BBL_KEY))
+		Expect(err).To(MatchError("no PEM encoded certificate found"))
+	})
+
+	It("returns an error when the certificate is invalid", func() {
+		_, err := certs.Parse("lb", "lb", []byte("-----BEGIN CERTIFICATE-----\naW52YWxpZA==\n-----END CERTIFICATE-----\n"))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Fingerprint", func() {
+	It("returns the SHA-256 fingerprint of the certificate", func() {
+		fingerprint, err := certs.Fingerprint([]byte(testhelpers.BBL_CERT + "\n" + testhelpers.BBL_CHAIN))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fingerprint).To(Equal("47:5F:CF:E6:F4:B0:1A:10:71:74:10:21:A6:9E:84:55:9D:33:4E:7D:1E:7C:CA:51:8C:27:D3:3B:D8:B9:1B:0A"))
+	})
+
+	It("returns an error when there is no certificate", func() {
+		_, err := certs.Fingerprint([]byte(testhelpers.BBL_KEY))
+		Expect(err).To(MatchError("no PEM encoded certificate found"))
+	})
+})
+
+var _ = Describe("PFXToPEM", func() {
+	It("returns the certificates in the pfx as PEM", func() {
+		contents, err := certs.PFXToPEM([]byte(testhelpers.PFX_BASE64), testhelpers.PFX_PASSWORD)
+		Expect(err).NotTo(HaveOccurred())
+
+		certificates, err := certs.Parse("lb", "lb", contents)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(certificates).To(HaveLen(1))
+		Expect(certificates[0].Subject).To(Equal("CN=azure.example.com"))
+		Expect(string(contents)).NotTo(ContainSubstring("PRIVATE KEY"))
+	})
+
+	It("returns an error when the contents are not base64 encoded", func() {
+		_, err := certs.PFXToPEM([]byte("%%%"), testhelpers.PFX_PASSWORD)
+		Expect(err).To(MatchError(ContainSubstring("decode PFX: ")))
+	})
+
+	It("returns an error when the password is wrong", func() {
+		_, err := certs.PFXToPEM([]byte(testhelpers.PFX_BASE64), "some-wrong-password")
+		Expect(err).To(MatchError("pkcs12: decryption password incorrect"))
+	})
+})
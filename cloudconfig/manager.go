@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
@@ -13,6 +14,7 @@ import (
 )
 
 type fs interface {
+	fileio.FileReader
 	fileio.FileWriter
 	fileio.DirReader
 	fileio.Stater
@@ -91,6 +93,35 @@ func (m Manager) Initialize(state storage.State) error {
 	return nil
 }
 
+// Modified returns the files written by Initialize that no longer match
+// what bbl would generate for the given state.
+func (m Manager) Modified(state storage.State) ([]string, error) {
+	cloudConfigDir, err := m.stateStore.GetCloudConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	ops, err := m.opsGenerator.Generate(state)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := []string{}
+	for name, expected := range map[string]string{"cloud-config.yml": BaseCloudConfig, "ops.yml": ops} {
+		contents, err := m.fs.ReadFile(filepath.Join(cloudConfigDir, name))
+		if err != nil {
+			continue
+		}
+
+		if string(contents) != expected {
+			modified = append(modified, name)
+		}
+	}
+	sort.Strings(modified)
+
+	return modified, nil
+}
+
 func (m Manager) GenerateVars(state storage.State) error {
 	varsDir, err := m.stateStore.GetVarsDir()
 	if err != nil {
@@ -201,5 +232,51 @@ func (m Manager) Update(state storage.State) error {
 		return err
 	}
 
+	varsDir, err := m.stateStore.GetVarsDir()
+	if err != nil {
+		return err
+	}
+
+	err = m.fs.WriteFile(filepath.Join(varsDir, "cloud-config-applied.yml"), []byte(cloudConfig), storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Write applied cloud config: %s", err)
+	}
+
 	return nil
 }
+
+// Applied returns the cloud config the last bbl up uploaded, or an empty
+// string when it was not recorded.
+func (m Manager) Applied() (string, error) {
+	varsDir, err := m.stateStore.GetVarsDir()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(varsDir, "cloud-config-applied.yml")
+	if _, err := m.fs.Stat(path); err != nil {
+		return "", nil
+	}
+
+	contents, err := m.fs.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Read applied cloud config: %s", err)
+	}
+
+	return string(contents), nil
+}
+
+// Current returns the cloud config the director uses now.
+func (m Manager) Current(state storage.State) (string, error) {
+	boshClient, err := m.boshClientProvider.Client(state.Jumpbox, state.BOSH.DirectorAddress, state.BOSH.DirectorUsername, state.BOSH.DirectorPassword, state.BOSH.DirectorSSLCA)
+	if err != nil {
+		return "", err // not tested
+	}
+
+	cloudConfig, err := boshClient.CloudConfig()
+	if err != nil {
+		return "", fmt.Errorf("Get cloud config: %s", err)
+	}
+
+	return cloudConfig, nil
+}
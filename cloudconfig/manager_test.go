@@ -245,6 +245,45 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("Modified", func() {
+		BeforeEach(func() {
+			opsGenerator.GenerateCall.Returns.OpsYAML = "some-ops"
+			fileIO.ReadFileCall.Fake = func(name string) ([]byte, error) {
+				switch filepath.Base(name) {
+				case "cloud-config.yml":
+					return []byte(cloudconfig.BaseCloudConfig), nil
+				case "ops.yml":
+					return []byte("some-hand-edited-ops"), nil
+				}
+				return nil, errors.New("not found")
+			}
+		})
+
+		It("returns the files that differ from what bbl generates", func() {
+			modified, err := manager.Modified(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(modified).To(Equal([]string{"ops.yml"}))
+			Expect(opsGenerator.GenerateCall.Receives.State).To(Equal(incomingState))
+		})
+
+		It("ignores files that do not exist", func() {
+			fileIO.ReadFileCall.Fake = func(name string) ([]byte, error) {
+				return nil, errors.New("not found")
+			}
+
+			modified, err := manager.Modified(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(modified).To(BeEmpty())
+		})
+
+		It("returns an error when the ops cannot be generated", func() {
+			opsGenerator.GenerateCall.Returns.Error = errors.New("kiwi")
+
+			_, err := manager.Modified(incomingState)
+			Expect(err).To(MatchError("kiwi"))
+		})
+	})
+
 	Describe("IsPresentCloudConfigVars", func() {
 		Context("when cloud config vars file exists in the vars dir", func() {
 			BeforeEach(func() {
@@ -384,6 +423,17 @@ var _ = Describe("Manager", func() {
 			Expect(boshClient.UpdateCloudConfigCall.Receives.Yaml).To(Equal([]byte("some-cloud-config")))
 		})
 
+		It("records the cloud config it applied", func() {
+			err := manager.Update(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.WriteFileCall.Receives).To(ContainElement(fakes.WriteFileReceive{
+				Filename: filepath.Join(varsDir, "cloud-config-applied.yml"),
+				Contents: []byte("some-cloud-config"),
+				Mode:     storage.StateMode,
+			}))
+		})
+
 		Context("failure cases", func() {
 			Context("when manager generate's command fails to run", func() {
 				BeforeEach(func() {
@@ -406,6 +456,64 @@ var _ = Describe("Manager", func() {
 					Expect(err).To(MatchError("failed to update"))
 				})
 			})
+
+			Context("when the applied cloud config cannot be recorded", func() {
+				BeforeEach(func() {
+					fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{}, {Error: errors.New("fig")}}
+				})
+
+				It("returns an error", func() {
+					err := manager.Update(storage.State{})
+					Expect(err).To(MatchError("Write applied cloud config: fig"))
+				})
+			})
+		})
+	})
+
+	Describe("Current", func() {
+		It("returns the cloud config from the director", func() {
+			boshClient.CloudConfigCall.Returns.CloudConfig = "some-current-cloud-config"
+
+			cloudConfig, err := manager.Current(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cloudConfig).To(Equal("some-current-cloud-config"))
+
+			Expect(boshClientProvider.ClientCall.Receives.DirectorAddress).To(Equal("some-director-address"))
+		})
+
+		It("returns an error when the director cannot be reached", func() {
+			boshClient.CloudConfigCall.Returns.Error = errors.New("failed to get")
+
+			_, err := manager.Current(incomingState)
+			Expect(err).To(MatchError("Get cloud config: failed to get"))
+		})
+	})
+
+	Describe("Applied", func() {
+		It("returns the cloud config the last bbl up uploaded", func() {
+			fileIO.ReadFileCall.Returns.Contents = []byte("some-applied-cloud-config")
+
+			cloudConfig, err := manager.Applied()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cloudConfig).To(Equal("some-applied-cloud-config"))
+
+			Expect(fileIO.ReadFileCall.Receives.Filename).To(Equal(filepath.Join(varsDir, "cloud-config-applied.yml")))
+		})
+
+		It("returns nothing when no cloud config was recorded", func() {
+			fileIO.StatCall.Returns.Error = os.ErrNotExist
+
+			cloudConfig, err := manager.Applied()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cloudConfig).To(Equal(""))
+			Expect(fileIO.ReadFileCall.CallCount).To(Equal(0))
+		})
+
+		It("returns an error when the recorded cloud config cannot be read", func() {
+			fileIO.ReadFileCall.Returns.Error = errors.New("grape")
+
+			_, err := manager.Applied()
+			Expect(err).To(MatchError("Read applied cloud config: grape"))
 		})
 	})
 })
@@ -38,7 +38,7 @@ var _ = Describe("AWSLBs", func() {
 						Type: "cf",
 					},
 				}
-				terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
 					"cf_router_lb_name": "some-router-lb-name",
 					"cf_router_lb_url":  "some-router-lb-url",
 					"cf_ssh_lb_name":    "some-ssh-lb-name",
@@ -52,7 +52,7 @@ var _ = Describe("AWSLBs", func() {
 				err := command.Execute([]string{}, incomingState)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
 				Expect(logger.PrintfCall.Messages).To(ConsistOf([]string{
 					"CF Router LB: some-router-lb-name [some-router-lb-url]\n",
 					"CF SSH Proxy LB: some-ssh-lb-name [some-ssh-lb-url]\n",
@@ -64,14 +64,14 @@ var _ = Describe("AWSLBs", func() {
 				BeforeEach(func() {
 					incomingState.LB.Domain = "some-domain"
 
-					terraformManager.GetOutputsCall.Returns.Outputs.Map["env_dns_zone_name_servers"] = []string{"name-server-1.", "name-server-2."}
+					terraformManager.CachedOutputsCall.Returns.Outputs.Map["env_dns_zone_name_servers"] = []string{"name-server-1.", "name-server-2."}
 				})
 
 				It("prints LB names, URLs, and DNS servers", func() {
 					err := command.Execute([]string{}, incomingState)
 					Expect(err).NotTo(HaveOccurred())
 
-					Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+					Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
 					Expect(logger.PrintfCall.Messages).To(ConsistOf([]string{
 						"CF Router LB: some-router-lb-name [some-router-lb-url]\n",
 						"CF SSH Proxy LB: some-ssh-lb-name [some-ssh-lb-url]\n",
@@ -82,21 +82,20 @@ var _ = Describe("AWSLBs", func() {
 
 				Context("when the json flag is provided", func() {
 					It("prints LB names, URLs, and DNS servers in json format", func() {
-						incomingState.LB = storage.LB{
-							Type:   "cf",
-							Domain: "some-domain",
-						}
 						err := command.Execute([]string{"--json"}, incomingState)
 						Expect(err).NotTo(HaveOccurred())
 
 						Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
-								"cf_router_lb": "some-router-lb-name",
-								"cf_router_lb_url": "some-router-lb-url",
-								"cf_ssh_proxy_lb": "some-ssh-lb-name",
-								"cf_ssh_proxy_lb_url": "some-ssh-lb-url",
-								"cf_tcp_lb": "some-tcp-lb-name",
-								"cf_tcp_lb_url":  "some-tcp-lb-url",
-								"env_dns_zone_name_servers": [
+								"version": 1,
+								"iaas": "aws",
+								"type": "cf",
+								"domain": "some-domain",
+								"endpoints": [
+									{"purpose": "router", "name": "some-router-lb-name", "address": "some-router-lb-url"},
+									{"purpose": "ssh-proxy", "name": "some-ssh-lb-name", "address": "some-ssh-lb-url"},
+									{"purpose": "tcp-router", "name": "some-tcp-lb-name", "address": "some-tcp-lb-url"}
+								],
+								"dnsNameServers": [
 									"name-server-1.",
 									"name-server-2."
 								]
@@ -114,7 +113,7 @@ var _ = Describe("AWSLBs", func() {
 						Type: "concourse",
 					},
 				}
-				terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
 					"concourse_lb_name": "some-concourse-lb-name",
 					"concourse_lb_url":  "some-concourse-lb-url",
 				}}
@@ -124,7 +123,7 @@ var _ = Describe("AWSLBs", func() {
 				err := command.Execute([]string{}, incomingState)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
 				Expect(logger.PrintfCall.Messages).To(ConsistOf([]string{
 					"Concourse LB: some-concourse-lb-name [some-concourse-lb-url]\n",
 				}))
@@ -154,7 +153,7 @@ var _ = Describe("AWSLBs", func() {
 
 			Context("when terraform manager fails", func() {
 				It("returns an error", func() {
-					terraformManager.GetOutputsCall.Returns.Error = errors.New("terraform manager failed")
+					terraformManager.CachedOutputsCall.Returns.Error = errors.New("terraform manager failed")
 					err := command.Execute([]string{}, incomingState)
 
 					Expect(err).To(MatchError("terraform manager failed"))
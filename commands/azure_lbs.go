@@ -19,19 +19,45 @@ func NewAzureLBs(terraformManager terraformManager, logger logger) AzureLBs {
 }
 
 func (l AzureLBs) Execute(subcommandFlags []string, state storage.State) error {
-	terraformOutputs, err := l.terraformManager.GetOutputs()
+	format, err := parseLBFormat(subcommandFlags)
 	if err != nil {
 		return err
 	}
 
+	terraformOutputs, err := l.terraformManager.CachedOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	var endpoints []LBEndpoint
 	switch state.LB.Type {
 	case "cf":
-		l.logger.Printf("CF LB: %s\n", terraformOutputs.GetString("cf_app_gateway_name"))
+		// The application gateway serves both router and websocket traffic.
+		endpoints = []LBEndpoint{
+			{Purpose: "router", Name: terraformOutputs.GetString("cf_app_gateway_name")},
+		}
 	case "concourse":
-		l.logger.Printf("Concourse LB: %s (%s)\n", terraformOutputs.GetString("concourse_lb_name"), terraformOutputs.GetString("concourse_lb_ip"))
+		endpoints = []LBEndpoint{
+			{Purpose: "concourse", Name: terraformOutputs.GetString("concourse_lb_name"), Address: terraformOutputs.GetString("concourse_lb_ip")},
+		}
 	default:
 		return errors.New("no lbs found")
 	}
 
+	if format != "" {
+		description, err := newLBDescription(state, endpoints, nil)
+		if err != nil {
+			return err
+		}
+		return printLBDescription(l.logger, format, description)
+	}
+
+	switch state.LB.Type {
+	case "cf":
+		l.logger.Printf("CF LB: %s\n", terraformOutputs.GetString("cf_app_gateway_name"))
+	case "concourse":
+		l.logger.Printf("Concourse LB: %s (%s)\n", terraformOutputs.GetString("concourse_lb_name"), terraformOutputs.GetString("concourse_lb_ip"))
+	}
+
 	return nil
 }
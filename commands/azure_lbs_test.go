@@ -7,6 +7,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
+	"github.com/cloudfoundry/bosh-bootloader/testhelpers"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -38,7 +39,7 @@ var _ = Describe("Azure LBs", func() {
 						Type: "cf",
 					},
 				}
-				terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
 					"cf_app_gateway_name": "some-app-gateway-name",
 				}}
 			})
@@ -47,7 +48,7 @@ var _ = Describe("Azure LBs", func() {
 				err := command.Execute([]string{}, incomingState)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
 				Expect(logger.PrintfCall.Messages).To(ConsistOf([]string{
 					"CF LB: some-app-gateway-name\n",
 				}))
@@ -62,7 +63,7 @@ var _ = Describe("Azure LBs", func() {
 						Type: "concourse",
 					},
 				}
-				terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
 					"concourse_lb_name": "some-load-balancer-name",
 					"concourse_lb_ip":   "5.6.7.8",
 				}}
@@ -72,11 +73,53 @@ var _ = Describe("Azure LBs", func() {
 				err := command.Execute([]string{}, incomingState)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
 				Expect(logger.PrintfCall.Messages).To(ConsistOf([]string{
 					"Concourse LB: some-load-balancer-name (5.6.7.8)\n",
 				}))
 			})
+
+			It("prints the LB description with the fingerprint of the pfx certificate in json format", func() {
+				incomingState.LB.Cert = testhelpers.PFX_BASE64
+				incomingState.LB.Key = testhelpers.PFX_PASSWORD
+
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+					"version": 1,
+					"iaas": "azure",
+					"type": "concourse",
+					"endpoints": [
+						{"purpose": "concourse", "name": "some-load-balancer-name", "address": "5.6.7.8"}
+					],
+					"dnsNameServers": [],
+					"certificateFingerprint": "0D:9B:27:2F:C7:61:0A:70:6B:7E:BD:35:44:89:02:14:F0:36:29:D4:1C:61:0C:AF:96:A3:57:1C:7C:4C:17:F4"
+				}`))
+			})
+
+			It("returns an error when the pfx certificate cannot be decrypted", func() {
+				incomingState.LB.Cert = testhelpers.PFX_BASE64
+				incomingState.LB.Key = "some-wrong-password"
+
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).To(MatchError("LB certificate fingerprint: pkcs12: decryption password incorrect"))
+			})
+
+			It("prints the LB description in yaml format", func() {
+				err := command.Execute([]string{"--yaml"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{`version: 1
+iaas: azure
+type: concourse
+endpoints:
+- purpose: concourse
+  name: some-load-balancer-name
+  address: 5.6.7.8
+dnsNameServers: []
+`}))
+			})
 		})
 
 		Context("when lb type is not cf or concourse", func() {
@@ -100,9 +143,21 @@ var _ = Describe("Azure LBs", func() {
 				incomingState = storage.State{}
 			})
 
+			It("returns an error when both --json and --yaml are provided", func() {
+				err := command.Execute([]string{"--json", "--yaml"}, incomingState)
+				Expect(err).To(MatchError("--json and --yaml cannot be used together."))
+			})
+
+			It("returns an error when the LB certificate is invalid", func() {
+				incomingState.LB = storage.LB{Type: "cf", Cert: "not a certificate"}
+
+				err := command.Execute([]string{"--json"}, incomingState)
+				Expect(err).To(MatchError("LB certificate fingerprint: no PEM encoded certificate found"))
+			})
+
 			Context("when terraform manager fails", func() {
 				It("returns an error", func() {
-					terraformManager.GetOutputsCall.Returns.Error = errors.New("terraform manager failed")
+					terraformManager.CachedOutputsCall.Returns.Error = errors.New("terraform manager failed")
 					err := command.Execute([]string{}, incomingState)
 
 					Expect(err).To(MatchError("terraform manager failed"))
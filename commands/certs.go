@@ -0,0 +1,240 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/certs"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// CertsExitCode is the exit code of bbl certs when a certificate expires
+// within the window, so that monitoring can tell it apart from a failure.
+const CertsExitCode = 2
+
+const defaultExpiresWithin = 30
+
+type certificateGetter interface {
+	Get(deployment string) (map[string]string, error)
+}
+
+type Certs struct {
+	logger            logger
+	stateValidator    stateValidator
+	certificateGetter certificateGetter
+}
+
+type certsReport struct {
+	ExpiresWithinDays int                      `json:"expiresWithinDays"`
+	Expiring          bool                     `json:"expiring"`
+	Certificates      []certificateStatus      `json:"certificates"`
+	Unparseable       []unparseableCertificate `json:"unparseable"`
+}
+
+type certificateStatus struct {
+	certs.Certificate
+	DaysRemaining int  `json:"daysRemaining"`
+	Expiring      bool `json:"expiring"`
+}
+
+type unparseableCertificate struct {
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Error  string `json:"error"`
+}
+
+func NewCerts(logger logger, stateValidator stateValidator, certificateGetter certificateGetter) Certs {
+	return Certs{
+		logger:            logger,
+		stateValidator:    stateValidator,
+		certificateGetter: certificateGetter,
+	}
+}
+
+func (c Certs) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := parseExpiresWithin(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	return c.stateValidator.Validate()
+}
+
+func (c Certs) Execute(subcommandFlags []string, state storage.State) error {
+	report, err := c.inventory(subcommandFlags, state)
+	if err != nil {
+		return err
+	}
+
+	if len(report.Certificates) == 0 && len(report.Unparseable) == 0 {
+		c.logger.Println("No certificates found.")
+		return nil
+	}
+
+	for _, cert := range report.Unparseable {
+		c.logger.Println(fmt.Sprintf("warning: %s certificate %s could not be parsed: %s", cert.Source, cert.Name, cert.Error))
+	}
+
+	if len(report.Certificates) == 0 {
+		return certsError(report)
+	}
+
+	c.logger.Printf("%-10s %-6s %-9s %-32s %-30s %-30s %s\n", "EXPIRES", "DAYS", "SOURCE", "NAME", "SUBJECT", "ISSUER", "SANS")
+	for _, cert := range report.Certificates {
+		days := fmt.Sprintf("%d", cert.DaysRemaining)
+		if cert.Expiring {
+			days += "!"
+		}
+		c.logger.Printf("%-10s %-6s %-9s %-32s %-30s %-30s %s\n", cert.NotAfter.Format("2006-01-02"), days, cert.Source, cert.Name, cert.Subject, cert.Issuer, strings.Join(cert.SANs, ","))
+	}
+
+	return certsError(report)
+}
+
+func (c Certs) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	report, err := c.inventory(subcommandFlags, state)
+	if err != nil {
+		return err
+	}
+
+	err = printJSON(c.logger, report)
+	if err != nil {
+		return err
+	}
+
+	return certsError(report)
+}
+
+// inventory parses the certificates in the jumpbox and director vars
+// stores and the load balancer certificate, soonest to expire first. A
+// certificate that cannot be parsed is reported rather than failing the
+// others.
+func (c Certs) inventory(subcommandFlags []string, state storage.State) (certsReport, error) {
+	expiresWithin, err := parseExpiresWithin(subcommandFlags)
+	if err != nil {
+		return certsReport{}, err
+	}
+
+	found := []certs.Certificate{}
+	unparseable := []unparseableCertificate{}
+	for _, deployment := range []string{"jumpbox", "director"} {
+		pems, err := c.certificateGetter.Get(deployment)
+		if err != nil {
+			return certsReport{}, err
+		}
+
+		names := []string{}
+		for name := range pems {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			parsed, err := certs.Parse(deployment, name, []byte(pems[name]))
+			if err != nil {
+				unparseable = append(unparseable, unparseableCertificate{Source: deployment, Name: name, Error: err.Error()})
+				continue
+			}
+			found = append(found, parsed...)
+		}
+	}
+
+	if state.LB.Cert != "" {
+		parsed, err := parseLBCertificate(state)
+		if err != nil {
+			unparseable = append(unparseable, unparseableCertificate{Source: "lb", Name: "lb-cert", Error: err.Error()})
+		}
+		found = append(found, parsed...)
+	}
+
+	if state.LB.Chain != "" {
+		parsed, err := certs.Parse("lb", "lb-chain", []byte(state.LB.Chain))
+		if err != nil {
+			unparseable = append(unparseable, unparseableCertificate{Source: "lb", Name: "lb-chain", Error: err.Error()})
+		}
+		found = append(found, parsed...)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].NotAfter.Before(found[j].NotAfter)
+	})
+
+	now := time.Now()
+	deadline := now.Add(time.Duration(expiresWithin) * 24 * time.Hour)
+	report := certsReport{ExpiresWithinDays: expiresWithin, Certificates: []certificateStatus{}, Unparseable: unparseable}
+	for _, cert := range found {
+		status := certificateStatus{
+			Certificate:   cert,
+			DaysRemaining: int(cert.NotAfter.Sub(now).Hours() / 24),
+			Expiring:      cert.NotAfter.Before(deadline),
+		}
+		report.Expiring = report.Expiring || status.Expiring
+		report.Certificates = append(report.Certificates, status)
+	}
+
+	return report, nil
+}
+
+func parseLBCertificate(state storage.State) ([]certs.Certificate, error) {
+	cert, err := lbCertificate(state)
+	if err != nil {
+		return nil, err
+	}
+	return certs.Parse("lb", "lb-cert", cert)
+}
+
+func certsFlags(expiresWithin *int) flags.Flags {
+	f := flags.New("certs")
+	f.Int(expiresWithin, "expires-within", defaultExpiresWithin)
+	return f
+}
+
+func (c Certs) Flags() []flags.Flags {
+	var expiresWithin int
+	return []flags.Flags{certsFlags(&expiresWithin)}
+}
+
+func parseExpiresWithin(subcommandFlags []string) (int, error) {
+	expiresWithin := defaultExpiresWithin
+	err := certsFlags(&expiresWithin).Parse(subcommandFlags)
+	if err != nil {
+		return 0, err
+	}
+
+	if expiresWithin < 0 {
+		return 0, fmt.Errorf("--expires-within must not be negative, got %d.", expiresWithin)
+	}
+
+	return expiresWithin, nil
+}
+
+// certsError returns the exit error for expiring certificates and, when
+// none expire, an error naming the certificates that could not be parsed.
+func certsError(report certsReport) error {
+	if !report.Expiring {
+		if len(report.Unparseable) == 0 {
+			return nil
+		}
+
+		names := []string{}
+		for _, cert := range report.Unparseable {
+			names = append(names, fmt.Sprintf("%s/%s", cert.Source, cert.Name))
+		}
+		return fmt.Errorf("Certificates could not be parsed: %s.", strings.Join(names, ", "))
+	}
+
+	names := []string{}
+	for _, cert := range report.Certificates {
+		if cert.Expiring {
+			names = append(names, fmt.Sprintf("%s/%s", cert.Source, cert.Name))
+		}
+	}
+
+	return ExitError{
+		Code:    CertsExitCode,
+		Message: fmt.Sprintf("Certificates expire within %d days: %s.", report.ExpiresWithinDays, strings.Join(names, ", ")),
+	}
+}
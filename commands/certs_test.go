@@ -0,0 +1,220 @@
+package commands_test
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/testhelpers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Certs", func() {
+	var (
+		logger            *fakes.Logger
+		stateValidator    *fakes.StateValidator
+		certificateGetter *fakes.CertificateGetter
+
+		command commands.Certs
+		state   storage.State
+
+		directorCert string
+		jumpboxCert  string
+		lbCert       string
+	)
+
+	generate := func(commonName string, notAfter time.Time) string {
+		cert, err := testhelpers.GenerateCertificate(commonName, notAfter)
+		Expect(err).NotTo(HaveOccurred())
+		return cert
+	}
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		certificateGetter = &fakes.CertificateGetter{}
+
+		now := time.Now().UTC().Truncate(time.Second)
+		directorCert = generate("some-director", now.Add(400*24*time.Hour))
+		jumpboxCert = generate("some-jumpbox", now.Add(200*24*time.Hour))
+		lbCert = generate("some-lb", now.Add(100*24*time.Hour))
+
+		certificateGetter.GetCall.Fake = func(deployment string) (map[string]string, error) {
+			if deployment == "director" {
+				return map[string]string{"director_ssl": directorCert}, nil
+			}
+			return map[string]string{"jumpbox_ssl": jumpboxCert}, nil
+		}
+
+		state = storage.State{LB: storage.LB{Cert: lbCert}}
+
+		command = commands.NewCerts(logger, stateValidator, certificateGetter)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when there is no bbl state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("no state"))
+		})
+
+		It("returns an error when --expires-within is invalid", func() {
+			err := command.CheckFastFails([]string{"--expires-within", "-1"}, state)
+			Expect(err).To(MatchError("--expires-within must not be negative, got -1."))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("lists the certificates soonest to expire first", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(HaveLen(4))
+			Expect(logger.PrintfCall.Messages[0]).To(MatchRegexp(`^EXPIRES\s+DAYS\s+SOURCE\s+NAME\s+SUBJECT\s+ISSUER\s+SANS`))
+			Expect(logger.PrintfCall.Messages[1]).To(MatchRegexp(`^\S+\s+99\s+lb\s+lb-cert\s+CN=some-lb\s+CN=some-lb\s+some-lb,10.0.0.6`))
+			Expect(logger.PrintfCall.Messages[2]).To(MatchRegexp(`^\S+\s+199\s+jumpbox\s+jumpbox_ssl\s+CN=some-jumpbox`))
+			Expect(logger.PrintfCall.Messages[3]).To(MatchRegexp(`^\S+\s+399\s+director\s+director_ssl\s+CN=some-director`))
+		})
+
+		It("reports certificates expiring within the window", func() {
+			err := command.Execute([]string{"--expires-within", "250"}, state)
+			Expect(err).To(Equal(commands.ExitError{
+				Code:    2,
+				Message: "Certificates expire within 250 days: lb/lb-cert, jumpbox/jumpbox_ssl.",
+			}))
+
+			Expect(logger.PrintfCall.Messages[1]).To(MatchRegexp(`^\S+\s+99!\s+lb`))
+			Expect(logger.PrintfCall.Messages[3]).To(MatchRegexp(`^\S+\s+399\s+director`))
+		})
+
+		It("reports when there are no certificates", func() {
+			certificateGetter.GetCall.Fake = nil
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"No certificates found."}))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the vars store cannot be read", func() {
+				certificateGetter.GetCall.Fake = nil
+				certificateGetter.GetCall.Returns.Error = errors.New("kiwi")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("kiwi"))
+			})
+
+			It("lists the other certificates and returns an error when a certificate cannot be parsed", func() {
+				directorCert = "not a certificate"
+				state.LB.Chain = "not a certificate"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Certificates could not be parsed: director/director_ssl, lb/lb-chain."))
+
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					"warning: director certificate director_ssl could not be parsed: no PEM encoded certificate found",
+					"warning: lb certificate lb-chain could not be parsed: no PEM encoded certificate found",
+				}))
+				Expect(logger.PrintfCall.Messages).To(HaveLen(3))
+				Expect(logger.PrintfCall.Messages[1]).To(MatchRegexp(`^\S+\s+99\s+lb\s+lb-cert`))
+				Expect(logger.PrintfCall.Messages[2]).To(MatchRegexp(`^\S+\s+199\s+jumpbox\s+jumpbox_ssl`))
+			})
+
+			It("reports the expiring certificates when another cannot be parsed", func() {
+				directorCert = "not a certificate"
+
+				err := command.Execute([]string{"--expires-within", "150"}, state)
+				Expect(err).To(MatchError("Certificates expire within 150 days: lb/lb-cert."))
+			})
+		})
+
+		Context("on azure", func() {
+			BeforeEach(func() {
+				certificateGetter.GetCall.Fake = nil
+				state = storage.State{
+					IAAS: "azure",
+					LB: storage.LB{
+						Cert: testhelpers.PFX_BASE64,
+						Key:  testhelpers.PFX_PASSWORD,
+					},
+				}
+			})
+
+			It("lists the certificate in the load balancer pfx", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Certificates expire within 30 days: lb/lb-cert."))
+
+				Expect(logger.PrintfCall.Messages).To(HaveLen(2))
+				Expect(logger.PrintfCall.Messages[1]).To(MatchRegexp(`^2020-03-19\s+\S+\s+lb\s+lb-cert\s+CN=azure.example.com`))
+			})
+
+			It("reports the load balancer certificate when the pfx cannot be decrypted", func() {
+				state.LB.Key = "some-wrong-password"
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Certificates could not be parsed: lb/lb-cert."))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					"warning: lb certificate lb-cert could not be parsed: pkcs12: decryption password incorrect",
+				}))
+			})
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the certificates", func() {
+			certificateGetter.GetCall.Fake = nil
+			state.LB.Cert = generate("some-lb", time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC))
+
+			err := command.ExecuteJSON([]string{"--expires-within=0"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"expiresWithinDays": 0,
+				"expiring": false,
+				"certificates": [{
+					"source": "lb",
+					"name": "lb-cert",
+					"subject": "CN=some-lb",
+					"sans": ["some-lb", "10.0.0.6"],
+					"issuer": "CN=some-lb",
+					"notAfter": "2030-01-02T03:04:05Z",
+					"daysRemaining": ` + strconv.Itoa(int(time.Until(time.Date(2030, time.January, 2, 3, 4, 5, 0, time.UTC)).Hours()/24)) + `,
+					"expiring": false
+				}],
+				"unparseable": []
+			}`))
+		})
+
+		It("prints the certificates that cannot be parsed", func() {
+			certificateGetter.GetCall.Fake = nil
+			state.LB.Cert = "not a certificate"
+
+			err := command.ExecuteJSON([]string{}, state)
+			Expect(err).To(MatchError("Certificates could not be parsed: lb/lb-cert."))
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"expiresWithinDays": 30,
+				"expiring": false,
+				"certificates": [],
+				"unparseable": [{
+					"source": "lb",
+					"name": "lb-cert",
+					"error": "no PEM encoded certificate found"
+				}]
+			}`))
+		})
+
+		It("returns the exit error when a certificate is expiring", func() {
+			err := command.ExecuteJSON([]string{"--expires-within=150"}, state)
+			Expect(err).To(MatchError("Certificates expire within 150 days: lb/lb-cert."))
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring(`"expiring": true`))
+		})
+	})
+})
@@ -27,16 +27,27 @@ func (l CleanupLeftovers) CheckFastFails(subcommandFlags []string, state storage
 	return nil
 }
 
-func (l CleanupLeftovers) Execute(subcommandFlags []string, state storage.State) error {
+func cleanupLeftoversFlags(filter *string, dryRun *bool) flags.Flags {
+	f := flags.New("cleanup-leftovers")
+	f.String(filter, "filter", "")
+	f.Bool(dryRun, "dry-run")
+	return f
+}
+
+func (l CleanupLeftovers) Flags() []flags.Flags {
 	var (
 		filter string
 		dryRun bool
 	)
-	f := flags.New("cleanup-leftovers")
-	f.String(&filter, "filter", "")
-	f.Bool(&dryRun, "dry-run")
+	return []flags.Flags{cleanupLeftoversFlags(&filter, &dryRun)}
+}
 
-	err := f.Parse(subcommandFlags)
+func (l CleanupLeftovers) Execute(subcommandFlags []string, state storage.State) error {
+	var (
+		filter string
+		dryRun bool
+	)
+	err := cleanupLeftoversFlags(&filter, &dryRun).Parse(subcommandFlags)
 	if err != nil {
 		return fmt.Errorf("Parsing cleanup-leftovers args: %s", err)
 	}
@@ -1,9 +1,91 @@
 package commands
 
-import "github.com/cloudfoundry/bosh-bootloader/storage"
+import (
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
 
 type Command interface {
 	CheckFastFails(subcommandFlags []string, state storage.State) error
 	Execute(subcommandFlags []string, state storage.State) error
 	Usage() string
 }
+
+// JSONCommand is implemented by commands that can print their result as a
+// single JSON document when bbl is run with --json.
+type JSONCommand interface {
+	ExecuteJSON(subcommandFlags []string, state storage.State) error
+}
+
+// jsonCommand returns command as a JSONCommand when it can print JSON,
+// looking through Logged and Sealed, which always have an ExecuteJSON.
+func jsonCommand(command Command) (JSONCommand, bool) {
+	var wrapped Command
+	switch wrapper := command.(type) {
+	case Logged:
+		wrapped = wrapper.command
+	case Sealed:
+		wrapped = wrapper.command
+	}
+
+	if wrapped != nil {
+		if _, ok := jsonCommand(wrapped); !ok {
+			return nil, false
+		}
+	}
+
+	j, ok := command.(JSONCommand)
+	return j, ok
+}
+
+// secretArgsCommand is implemented by commands that take secrets as
+// arguments, so that they are left out of the run log.
+type secretArgsCommand interface {
+	RedactArgs(subcommandFlags []string) []string
+}
+
+// redactArgs returns subcommandFlags with the secrets that command takes
+// redacted, looking through Logged and Sealed.
+func redactArgs(command Command, subcommandFlags []string) []string {
+	switch wrapper := command.(type) {
+	case Logged:
+		return redactArgs(wrapper.command, subcommandFlags)
+	case Sealed:
+		return redactArgs(wrapper.command, subcommandFlags)
+	}
+
+	if s, ok := command.(secretArgsCommand); ok {
+		return s.RedactArgs(subcommandFlags)
+	}
+	return subcommandFlags
+}
+
+// flagsCommand is implemented by commands with flags of their own, so that
+// bbl completion offers the flags that the command parses.
+type flagsCommand interface {
+	Flags() []flags.Flags
+}
+
+// commandFlags returns the names of the flags that command parses, looking
+// through Locked, Logged and Sealed.
+func commandFlags(command Command) []string {
+	switch wrapper := command.(type) {
+	case Locked:
+		return commandFlags(wrapper.command)
+	case Logged:
+		return commandFlags(wrapper.command)
+	case Sealed:
+		return commandFlags(wrapper.command)
+	}
+
+	f, ok := command.(flagsCommand)
+	if !ok {
+		return nil
+	}
+
+	names := []string{}
+	for _, set := range f.Flags() {
+		names = append(names, set.Names()...)
+	}
+	return names
+}
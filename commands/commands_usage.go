@@ -58,12 +58,15 @@ const (
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --diff                     Show what the next bbl up will change and exit 2 if it changes anything (optional)
 `
 
 	UpCommandUsage = `Deploys BOSH director on an IAAS
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --only                     Run only these phases: terraform, jumpbox, director, cloud-config (optional)
+  --skip                     Skip these phases (optional)
 `
 
 	DestroyCommandUsage = `Tears down BOSH director infrastructure
@@ -74,7 +77,10 @@ const (
 
   --filter            Only delete resources with this string in their name`
 
-	LBsCommandUsage = "Prints attached load balancer(s)"
+	LBsCommandUsage = `Prints attached load balancer(s)
+
+  [--json]                 Prints the load balancers as JSON
+  [--yaml]                 Prints the load balancers as YAML`
 
 	OutputsCommandUsage = "Prints the outputs from terraform."
 
@@ -92,9 +98,34 @@ const (
 
   --jumpbox                Open a connection to the jumpbox
   --director               Open a connection to the director
+  [--cmd]                  Run a command instead of opening a shell
+`
+
+	SCPCommandUsage = `Copies a file to or from the director or the jumpbox.
+
+  bbl scp <source> <destination>
+
+  Prefix the remote path with jumpbox: or director:, for example:
+    bbl scp ./manifest.yml jumpbox:/tmp/
+    bbl scp director:/var/vcap/sys/log/director/current ./director.log
 `
 
-	RotateCommandUsage = "Rotates SSH key for the jumpbox user."
+	TunnelCommandUsage = `Starts a SOCKS5 proxy to the environment through the jumpbox.
+
+  [--port]                 Local port for the proxy (defaults to a random port)
+  [--daemon]               Run the proxy in the background
+
+  bbl tunnel stop          Stops the proxy started with --daemon
+  bbl tunnel status        Prints the address of the running proxy
+`
+
+	RotateCommandUsage = `Rotates credentials and redeploys the jumpbox and director. Rotates the SSH key for the jumpbox user by default.
+
+  [--credentials]          Comma-separated credentials to rotate: blobstore-certs, ca, credhub-admin, credhub-encryption,
+                           director-admin, director-ssh, jumpbox-ssh, nats-certs, uaa-clients
+  [--all]                  Rotate every credential
+
+  ca and credhub-encryption are rotated in two phases. Recreate your deployments between the two runs of bbl rotate.`
 
 	JumpboxAddressCommandUsage = "Prints BOSH jumpbox address"
 
@@ -106,9 +137,118 @@ const (
 
 	DirectorCACertCommandUsage = "Prints BOSH director CA certificate"
 
-	PrintEnvCommandUsage = "Prints required BOSH environment variables"
+	PrintEnvCommandUsage = `Prints required BOSH environment variables
+
+  [--shell]                Format of the output: bash, fish, powershell, dotenv or json (Defaults to bash)
+  [--jumpbox-key-path]     Write the jumpbox private key to this path instead of a temp dir`
 
 	LatestErrorCommandUsage = "Prints the output from the latest call to terraform"
+
+	LogsCommandUsage = `Lists the logged runs of the commands that change the environment, or prints one
+
+  [--last]                 Print the log of the latest run
+  [--id]                   Print the log of the run with this ID`
+
+	StateCommandUsage = "Manages the state directory"
+
+	StateShowCommandUsage = `Prints the environment as a JSON document
+
+  [--show-secrets]         Include the director password, LB private key and secret terraform outputs instead of redacting them`
+
+	StateEncryptCommandUsage = "Encrypts bbl-state.json, the vars stores and the create-env state files"
+
+	StateDecryptCommandUsage = "Decrypts the state directory in place"
+
+	StateExportCommandUsage = `Writes the state directory to a portable, checksummed bundle
+
+  [--output]               Path of the bundle (Defaults to <env-id>.bbl.tgz)
+  [--strip-secrets]        Leave out the vars stores, create-env and terraform state and LB private key`
+
+	StateImportCommandUsage = `Unpacks a bundle from bbl state export into an empty state directory
+
+  <bundle>                 Path of the bundle`
+
+	StateHistoryCommandUsage = "Lists snapshots of bbl-state.json and the vars files"
+
+	StateRollbackCommandUsage = `Restores bbl-state.json and the vars files from a snapshot
+
+  <id>                     ID of the snapshot, as listed by bbl state history`
+
+	DoctorCommandUsage = `Checks the state directory for missing or inconsistent files
+
+  [--fix]                  Repair the problems that can be fixed without losing data`
+
+	DriftCommandUsage = `Reports infrastructure and cloud config changed outside of bbl since the last bbl up
+
+  Exits 0 when nothing drifted, 2 when something drifted and 1 when the check fails.
+  With --json, prints the drifted resources as a JSON document.`
+
+	CertsCommandUsage = `Lists the jumpbox, director and load balancer certificates with their subject, SANs, issuer and expiry
+
+  [--expires-within]       Number of days before expiry to report a certificate as expiring (defaults to 30)
+
+  Exits 0 when no certificate is expiring, 2 when one is and 1 when the check fails.
+  With --json, prints the certificates as a JSON document.`
+
+	DeploymentVersionsCommandUsage = `Compares the stemcell and releases of the jumpbox and director with the ones this bbl deploys
+
+  Lists each component as current, upgrade, new, removed or ahead, and flags environments deployed by an
+  older bbl. With --json, prints the comparison as a JSON document.`
+
+	VarsCommandUsage = `Inspects and edits the vars stores of the director, jumpbox and cloud-config
+
+  [--deployment]           director, jumpbox or cloud-config (Defaults to director)`
+
+	VarsListCommandUsage = `Lists the vars of a deployment and whether bbl vars can change them
+
+  [--deployment]           director, jumpbox or cloud-config (Defaults to director)`
+
+	VarsGetCommandUsage = `Prints the value of a var, as YAML unless it is a string
+
+  <name>                   Name of the var
+  [--deployment]           director, jumpbox or cloud-config (Defaults to director)`
+
+	VarsSetCommandUsage = `Sets a var used by the manifest or ops files in the vars store
+
+  <name>                   Name of the var
+  <value>                  Value of the var, parsed as YAML
+  [--deployment]           director or jumpbox (Defaults to director)`
+
+	VarsDeleteCommandUsage = `Deletes a var from the vars store so that the next bbl up generates it again
+
+  <name>                   Name of the var
+  [--deployment]           director or jumpbox (Defaults to director)`
+
+	EnvCommandUsage = "Manages the environments of a workspace, a directory with one state directory per environment"
+
+	EnvListCommandUsage = "Lists the environments in the workspace with their IaaS, env ID, bbl version, director address and last operation"
+
+	EnvForeachCommandUsage = `Runs a bbl command in every environment of the workspace and reports the results
+
+  [--parallel]             Number of environments to run the command in at a time (Defaults to 4)
+  [--envs]                 Comma separated names of the environments to run the command in (Defaults to all)
+  -- <command>             The bbl command and its flags`
+
+	CompletionCommandUsage = `Prints a shell completion script for bbl's commands, flags, load balancer types and environment names
+
+  <shell>                  bash, zsh or fish
+
+  Load it with: source <(bbl completion bash), or bbl completion fish | source`
+
+	InitCommandUsage = `Asks for the IaaS and its settings, checks them and writes bbl.yml for a new environment
+
+  [--answers]              YAML file of answers, keyed like bbl.yml, to use instead of asking
+  [--skip-checks]          Write bbl.yml without checking the credentials and the load balancer certificate`
+
+	ConfigCommandUsage = "Shows the configuration bbl runs with, merged from flags, BBL_* environment variables, bbl.yml and the state"
+
+	ConfigShowCommandUsage = `Prints every setting with its effective value and where it came from: flag, env, file or state
+
+  [--show-secrets]         Print secret values instead of REDACTED`
+
+	UnlockCommandUsage = `Removes a stale lock from the state directory
+
+  [--force]                Remove the lock even if the run holding it may still be active`
 )
 
 func (Up) Usage() string {
@@ -139,6 +279,50 @@ func (PrintEnv) Usage() string { return PrintEnvCommandUsage }
 
 func (LatestError) Usage() string { return LatestErrorCommandUsage }
 
+func (Logs) Usage() string { return LogsCommandUsage }
+
+func (Unlock) Usage() string { return UnlockCommandUsage }
+
+func (Doctor) Usage() string { return DoctorCommandUsage }
+
+func (Drift) Usage() string { return DriftCommandUsage }
+
+func (Certs) Usage() string { return CertsCommandUsage }
+
+func (DeploymentVersions) Usage() string { return DeploymentVersionsCommandUsage }
+
+func (EnvList) Usage() string { return EnvListCommandUsage }
+
+func (EnvForeach) Usage() string { return EnvForeachCommandUsage }
+
+func (Completion) Usage() string { return CompletionCommandUsage }
+
+func (Init) Usage() string { return InitCommandUsage }
+
+func (ConfigShow) Usage() string { return ConfigShowCommandUsage }
+
+func (VarsList) Usage() string { return VarsListCommandUsage }
+
+func (VarsGet) Usage() string { return VarsGetCommandUsage }
+
+func (VarsSet) Usage() string { return VarsSetCommandUsage }
+
+func (VarsDelete) Usage() string { return VarsDeleteCommandUsage }
+
+func (StateShow) Usage() string { return StateShowCommandUsage }
+
+func (StateEncrypt) Usage() string { return StateEncryptCommandUsage }
+
+func (StateDecrypt) Usage() string { return StateDecryptCommandUsage }
+
+func (StateExport) Usage() string { return StateExportCommandUsage }
+
+func (StateImport) Usage() string { return StateImportCommandUsage }
+
+func (StateHistory) Usage() string { return StateHistoryCommandUsage }
+
+func (StateRollback) Usage() string { return StateRollbackCommandUsage }
+
 func (s SSHKey) Usage() string {
 	if s.Director {
 		return DirectorSSHKeyCommandUsage
@@ -150,6 +334,14 @@ func (s SSH) Usage() string {
 	return SSHCommandUsage
 }
 
+func (s SCP) Usage() string {
+	return SCPCommandUsage
+}
+
+func (t Tunnel) Usage() string {
+	return TunnelCommandUsage
+}
+
 func (s StateQuery) Usage() string {
 	switch s.propertyName {
 	case EnvIDPropertyName:
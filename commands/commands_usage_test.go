@@ -20,6 +20,8 @@ var _ = Describe("Commands Usage", func() {
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --only                     Run only these phases: terraform, jumpbox, director, cloud-config (optional)
+  --skip                     Skip these phases (optional)
 
   --aws-access-key-id                AWS Access Key ID                env: $BBL_AWS_ACCESS_KEY_ID
   --aws-secret-access-key            AWS Secret Access Key            env: $BBL_AWS_SECRET_ACCESS_KEY
@@ -77,6 +79,7 @@ var _ = Describe("Commands Usage", func() {
 
   --iaas                     IAAS to deploy your BOSH director onto: "aws", "azure", "gcp", "vsphere"   env: $BBL_IAAS
   --name                     Name to assign to your BOSH director (optional)                            env: $BBL_ENV_NAME
+  --diff                     Show what the next bbl up will change and exit 2 if it changes anything (optional)
 %s%s`, commands.Credentials, commands.LBUsage)))
 			})
 		})
@@ -113,7 +116,13 @@ var _ = Describe("Commands Usage", func() {
 			It("returns string describing usage", func() {
 				command := commands.Rotate{}
 				usageText := command.Usage()
-				Expect(usageText).To(Equal(fmt.Sprintf(`Rotates SSH key for the jumpbox user.
+				Expect(usageText).To(Equal(fmt.Sprintf(`Rotates credentials and redeploys the jumpbox and director. Rotates the SSH key for the jumpbox user by default.
+
+  [--credentials]          Comma-separated credentials to rotate: blobstore-certs, ca, credhub-admin, credhub-encryption,
+                           director-admin, director-ssh, jumpbox-ssh, nats-certs, uaa-clients
+  [--all]                  Rotate every credential
+
+  ca and credhub-encryption are rotated in two phases. Recreate your deployments between the two runs of bbl rotate.
 
   Credentials for your IaaS are required:%s`, commands.Credentials)))
 			})
@@ -129,6 +138,41 @@ var _ = Describe("Commands Usage", func() {
 
   --jumpbox                Open a connection to the jumpbox
   --director               Open a connection to the director
+  [--cmd]                  Run a command instead of opening a shell
+`))
+			})
+		})
+	})
+
+	Describe("Tunnel", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.Tunnel{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Starts a SOCKS5 proxy to the environment through the jumpbox.
+
+  [--port]                 Local port for the proxy (defaults to a random port)
+  [--daemon]               Run the proxy in the background
+
+  bbl tunnel stop          Stops the proxy started with --daemon
+  bbl tunnel status        Prints the address of the running proxy
+`))
+			})
+		})
+	})
+
+	Describe("SCP", func() {
+		Describe("Usage", func() {
+			It("returns string describing usage", func() {
+				command := commands.SCP{}
+				usageText := command.Usage()
+				Expect(usageText).To(Equal(`Copies a file to or from the director or the jumpbox.
+
+  bbl scp <source> <destination>
+
+  Prefix the remote path with jumpbox: or director:, for example:
+    bbl scp ./manifest.yml jumpbox:/tmp/
+    bbl scp director:/var/vcap/sys/log/director/current ./director.log
 `))
 			})
 		})
@@ -148,7 +192,10 @@ var _ = Describe("Commands Usage", func() {
 		usageText := command.Usage()
 		Expect(usageText).To(Equal(expectedDescription))
 	},
-		Entry("LBs", commands.LBs{}, "Prints attached load balancer(s)"),
+		Entry("LBs", commands.LBs{}, `Prints attached load balancer(s)
+
+  [--json]                 Prints the load balancers as JSON
+  [--yaml]                 Prints the load balancers as YAML`),
 		Entry("outputs", commands.Outputs{}, "Prints the outputs from terraform."),
 		Entry("jumpbox-address", newStateQuery("jumpbox address"), "Prints BOSH jumpbox address"),
 		Entry("director-address", newStateQuery("director address"), "Prints BOSH director address"),
@@ -158,7 +205,10 @@ var _ = Describe("Commands Usage", func() {
 		Entry("env-id", newStateQuery("environment id"), "Prints environment ID"),
 		Entry("ssh-key", commands.SSHKey{}, "Prints SSH private key for the jumpbox."),
 		Entry("director-ssh-key", commands.SSHKey{Director: true}, "Prints SSH private key for the director."),
-		Entry("print-env", commands.PrintEnv{}, "Prints required BOSH environment variables"),
+		Entry("print-env", commands.PrintEnv{}, `Prints required BOSH environment variables
+
+  [--shell]                Format of the output: bash, fish, powershell, dotenv or json (Defaults to bash)
+  [--jumpbox-key-path]     Write the jumpbox private key to this path instead of a temp dir`),
 		Entry("latest-error", commands.LatestError{}, "Prints the output from the latest call to terraform"),
 		Entry("version", commands.Version{}, "Prints version"),
 	)
@@ -0,0 +1,397 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// valueFlags are the flags whose values bbl completes, by asking
+// bbl completion values while the user types.
+var valueFlags = []string{"deployment", "env", "iaas", "lb-type"}
+
+// contextFlags choose the state directory and the IaaS, so the completion
+// scripts pass them on to bbl completion values.
+var contextFlags = []string{"state-dir", "env", "workspace", "config", "iaas"}
+
+// CompletionFlag is a global flag as the completion scripts see it.
+type CompletionFlag struct {
+	Long       string
+	Short      string
+	TakesValue bool
+}
+
+type subcommander interface {
+	Subcommands() map[string]Command
+}
+
+type Completion struct {
+	logger       logger
+	commands     map[string]Command
+	globalFlags  []CompletionFlag
+	environments environmentLister
+}
+
+type completionCommand struct {
+	name        string
+	summary     string
+	flags       []string
+	subcommands []completionCommand
+}
+
+func NewCompletion(logger logger, commands map[string]Command, globalFlags []CompletionFlag, environments environmentLister) Completion {
+	return Completion{
+		logger:       logger,
+		commands:     commands,
+		globalFlags:  globalFlags,
+		environments: environments,
+	}
+}
+
+func (c Completion) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) == 0 {
+		return fmt.Errorf("Usage: bbl completion bash|zsh|fish")
+	}
+
+	switch subcommandFlags[0] {
+	case "bash", "zsh", "fish":
+		return nil
+	case "values":
+		if len(subcommandFlags) != 2 {
+			return fmt.Errorf("Usage: bbl completion values %s", strings.Join(prefixed(valueFlags), "|"))
+		}
+		return nil
+	}
+
+	return fmt.Errorf("Unknown shell %q: use bash, zsh or fish.", subcommandFlags[0])
+}
+
+func (c Completion) Execute(subcommandFlags []string, state storage.State) error {
+	err := c.CheckFastFails(subcommandFlags, state)
+	if err != nil {
+		return err
+	}
+
+	switch subcommandFlags[0] {
+	case "bash":
+		c.logger.Printf("%s", c.bash())
+	case "zsh":
+		c.logger.Printf("%s", c.zsh())
+	case "fish":
+		c.logger.Printf("%s", c.fish())
+	case "values":
+		values, err := c.values(strings.TrimPrefix(subcommandFlags[1], "--"), state)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			c.logger.Println(value)
+		}
+	}
+
+	return nil
+}
+
+// values returns the values of flag that make sense for the environment.
+func (c Completion) values(flag string, state storage.State) ([]string, error) {
+	switch flag {
+	case "deployment":
+		return bosh.VarsDeployments(), nil
+	case "iaas":
+		return []string{"aws", "azure", "gcp", "vsphere", "openstack"}, nil
+	case "lb-type":
+		if state.IAAS == "" {
+			return lbTypes("aws"), nil
+		}
+		return lbTypes(state.IAAS), nil
+	case "env":
+		environments, err := c.environments.Environments()
+		if err != nil {
+			return nil, err
+		}
+		names := []string{}
+		for _, env := range environments {
+			names = append(names, env.Name)
+		}
+		return names, nil
+	}
+
+	return nil, fmt.Errorf("No values to complete for --%s.", flag)
+}
+
+// tree returns every command with the flags that it parses, in name order.
+func (c Completion) tree() []completionCommand {
+	tree := []completionCommand{}
+	for _, name := range sortedNames(c.commands) {
+		command := c.commands[name]
+		node := completionCommand{name: name, summary: summary(command)}
+
+		if group, ok := command.(subcommander); ok {
+			for _, subname := range sortedNames(group.Subcommands()) {
+				subcommand := group.Subcommands()[subname]
+				node.subcommands = append(node.subcommands, completionCommand{
+					name:    subname,
+					summary: summary(subcommand),
+					flags:   completionFlags(subcommand),
+				})
+			}
+		} else {
+			node.flags = completionFlags(command)
+		}
+
+		tree = append(tree, node)
+	}
+	return tree
+}
+
+func sortedNames(commands map[string]Command) []string {
+	names := []string{}
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func summary(command Command) string {
+	return strings.TrimSpace(strings.SplitN(command.Usage(), "\n", 2)[0])
+}
+
+func completionFlags(command Command) []string {
+	flags := prefixed(commandFlags(command))
+	sort.Strings(flags)
+	return flags
+}
+
+func (c Completion) bash() string {
+	var names []string
+	var subcommandCases, flagCases []string
+
+	for _, command := range c.tree() {
+		names = append(names, command.name)
+
+		if len(command.subcommands) == 0 {
+			if len(command.flags) > 0 {
+				flagCases = append(flagCases, fmt.Sprintf("    %s) flags=%q ;;", command.name, strings.Join(command.flags, " ")))
+			}
+			continue
+		}
+
+		var subnames []string
+		for _, subcommand := range command.subcommands {
+			subnames = append(subnames, subcommand.name)
+			if len(subcommand.flags) > 0 {
+				flagCases = append(flagCases, fmt.Sprintf("    %s/%s) flags=%q ;;", command.name, subcommand.name, strings.Join(subcommand.flags, " ")))
+			}
+		}
+		subcommandCases = append(subcommandCases, fmt.Sprintf("    %s) subcommands=%q ;;", command.name, strings.Join(subnames, " ")))
+	}
+
+	globals, valued := []string{}, []string{}
+	for _, flag := range c.globalFlags {
+		globals = append(globals, "--"+flag.Long)
+		if flag.TakesValue {
+			valued = append(valued, "--"+flag.Long)
+			if flag.Short != "" {
+				valued = append(valued, "-"+flag.Short)
+			}
+		}
+	}
+
+	return fmt.Sprintf(bashCompletion,
+		strings.Join(append(prefixed(contextFlags), "-s"), "|"),
+		strings.Join(suffixed(prefixed(contextFlags), "=*"), "|"),
+		strings.Join(prefixed(valueFlags), "|"),
+		strings.Join(valued, "|"),
+		strings.Join(globals, " "),
+		strings.Join(names, " "),
+		strings.Join(subcommandCases, "\n"),
+		strings.Join(flagCases, "\n"),
+	)
+}
+
+func (c Completion) zsh() string {
+	return fmt.Sprintf("#compdef bbl\n\nautoload -U +X bashcompinit && bashcompinit\n\n%s", c.bash())
+}
+
+func (c Completion) fish() string {
+	lines := []string{
+		fmt.Sprintf(fishValues, strings.Join(append(prefixed(contextFlags), "-s"), " "), strings.Join(suffixed(prefixed(contextFlags), "=*'"), " '")),
+		"complete -c bbl -f",
+	}
+
+	for _, flag := range c.globalFlags {
+		line := fishFlag("", flag.Long)
+		if flag.Short != "" {
+			line += " -s " + flag.Short
+		}
+		if flag.TakesValue && !strings.Contains(line, " -x ") {
+			line += " -r -F"
+		}
+		lines = append(lines, line)
+	}
+
+	for _, command := range c.tree() {
+		lines = append(lines, fmt.Sprintf("complete -c bbl -n __fish_use_subcommand -a %s -d %s", command.name, fishQuote(command.summary)))
+
+		for _, flag := range command.flags {
+			lines = append(lines, fishFlag(fmt.Sprintf("__fish_seen_subcommand_from %s", command.name), strings.TrimPrefix(flag, "--")))
+		}
+
+		var subnames []string
+		for _, subcommand := range command.subcommands {
+			subnames = append(subnames, subcommand.name)
+		}
+		for _, subcommand := range command.subcommands {
+			condition := fmt.Sprintf("__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s", command.name, strings.Join(subnames, " "))
+			lines = append(lines, fmt.Sprintf("complete -c bbl -n %s -a %s -d %s", fishQuote(condition), subcommand.name, fishQuote(subcommand.summary)))
+
+			for _, flag := range subcommand.flags {
+				condition := fmt.Sprintf("__fish_seen_subcommand_from %s; and __fish_seen_subcommand_from %s", command.name, subcommand.name)
+				lines = append(lines, fishFlag(condition, strings.TrimPrefix(flag, "--")))
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func fishFlag(condition, flag string) string {
+	line := "complete -c bbl"
+	if condition != "" {
+		line += " -n " + fishQuote(condition)
+	}
+	line += " -l " + flag
+
+	for _, valueFlag := range valueFlags {
+		if flag == valueFlag {
+			line += fmt.Sprintf(" -x -a '(__bbl_values %s)'", flag)
+		}
+	}
+	return line
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.Replace(strings.Replace(s, `\`, `\\`, -1), "'", `\'`, -1) + "'"
+}
+
+func prefixed(flags []string) []string {
+	result := []string{}
+	for _, flag := range flags {
+		result = append(result, "--"+flag)
+	}
+	return result
+}
+
+func suffixed(flags []string, suffix string) []string {
+	result := []string{}
+	for _, flag := range flags {
+		result = append(result, flag+suffix)
+	}
+	return result
+}
+
+const bashCompletion = `# bash completion for bbl, generated by bbl completion bash
+
+_bbl_values() {
+  local context=() i word
+  for ((i = 1; i < COMP_CWORD; i++)); do
+    word="${COMP_WORDS[i]}"
+    case "$word" in
+    %s)
+      context+=("$word" "${COMP_WORDS[i+1]}")
+      i=$((i + 1))
+      ;;
+    %s)
+      context+=("$word")
+      ;;
+    esac
+  done
+  bbl "${context[@]}" completion values "$1" 2>/dev/null
+}
+
+_bbl() {
+  local cur="${COMP_WORDS[COMP_CWORD]}"
+  local prev="${COMP_WORDS[COMP_CWORD-1]}"
+  local command="" subcommand="" subcommands="" flags="" i word
+
+  case "$prev" in
+  %s)
+    COMPREPLY=($(compgen -W "$(_bbl_values "${prev#--}")" -- "$cur"))
+    return
+    ;;
+  esac
+
+  for ((i = 1; i < COMP_CWORD; i++)); do
+    word="${COMP_WORDS[i]}"
+    case "$word" in
+    %s)
+      i=$((i + 1))
+      continue
+      ;;
+    -*) continue ;;
+    esac
+    if [[ -z "$command" ]]; then
+      command="$word"
+    elif [[ -z "$subcommand" ]]; then
+      subcommand="$word"
+    fi
+  done
+
+  local globals=%q
+
+  if [[ -z "$command" ]]; then
+    if [[ "$cur" == -* ]]; then
+      COMPREPLY=($(compgen -W "$globals" -- "$cur"))
+    else
+      COMPREPLY=($(compgen -W %q -- "$cur"))
+    fi
+    return
+  fi
+
+  case "$command" in
+%s
+  esac
+
+  if [[ -n "$subcommands" && -z "$subcommand" && "$cur" != -* ]]; then
+    COMPREPLY=($(compgen -W "$subcommands" -- "$cur"))
+    return
+  fi
+
+  if [[ -n "$subcommands" ]]; then
+    command="$command/$subcommand"
+  fi
+
+  case "$command" in
+%s
+  esac
+
+  if [[ "$cur" == -* ]]; then
+    COMPREPLY=($(compgen -W "$flags $globals" -- "$cur"))
+  fi
+}
+
+complete -o default -F _bbl bbl
+`
+
+const fishValues = `# fish completion for bbl, generated by bbl completion fish
+
+function __bbl_values
+    set -l context
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    while set -q tokens[1]
+        switch $tokens[1]
+            case %s
+                set context $context $tokens[1..2]
+                set -e tokens[1]
+            case '%s
+                set context $context $tokens[1]
+        end
+        set -e tokens[1]
+    end
+    bbl $context completion values $argv 2>/dev/null
+end`
@@ -0,0 +1,160 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/workspace"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Completion", func() {
+	var (
+		logger       *fakes.Logger
+		environments *fakes.EnvironmentLister
+		command      commands.Completion
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		environments = &fakes.EnvironmentLister{}
+
+		var name, lbType, only string
+		var showSecrets bool
+
+		up := &fakes.FlagsCommand{}
+		up.UsageCall.Returns.Usage = "Deploys a BOSH Director\n\n  --lb-type   Load balancer type\n  [--name]    Name of the environment\n"
+		upFlags := flags.New("up")
+		upFlags.String(&name, "name", "")
+		upFlags.String(&lbType, "lb-type", "")
+		phaseFlags := flags.New("up")
+		phaseFlags.String(&only, "only", "")
+		up.FlagsCall.Returns.Flags = []flags.Flags{upFlags, phaseFlags}
+
+		show := &fakes.FlagsCommand{}
+		show.UsageCall.Returns.Usage = "Prints the state\n\n  [--show-secrets]   Prints the secrets too\n"
+		showFlags := flags.New("state show")
+		showFlags.Bool(&showSecrets, "show-secrets")
+		show.FlagsCall.Returns.Flags = []flags.Flags{showFlags}
+
+		version := &fakes.Command{}
+		version.UsageCall.Returns.Usage = "Prints version\n\n  [--undocumented]   Not a flag of the command\n"
+
+		command = commands.NewCompletion(logger, map[string]commands.Command{
+			"up":      commands.NewLocked(commands.NewLogged(up, "up", &fakes.RunLog{}), "up", &fakes.StateLocker{}, &fakes.StateLoader{}),
+			"state":   commands.NewGroup("state", "Inspects the state", map[string]commands.Command{"show": show}),
+			"version": version,
+		}, []commands.CompletionFlag{
+			{Long: "debug", Short: "d"},
+			{Long: "state-dir", Short: "s", TakesValue: true},
+			{Long: "env", TakesValue: true},
+		}, environments)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("requires a shell", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("Usage: bbl completion bash|zsh|fish"))
+		})
+
+		It("returns an error for an unknown shell", func() {
+			err := command.CheckFastFails([]string{"tcsh"}, storage.State{})
+			Expect(err).To(MatchError(`Unknown shell "tcsh": use bash, zsh or fish.`))
+		})
+
+		It("requires a flag to complete the values of", func() {
+			err := command.CheckFastFails([]string{"values"}, storage.State{})
+			Expect(err).To(MatchError("Usage: bbl completion values --deployment|--env|--iaas|--lb-type"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("prints a bash script for the commands, subcommands and flags", func() {
+			err := command.Execute([]string{"bash"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			script := logger.PrintfCall.Messages[0]
+			Expect(script).To(ContainSubstring(`local globals="--debug --state-dir --env"`))
+			Expect(script).To(ContainSubstring(`COMPREPLY=($(compgen -W "state up version" -- "$cur"))`))
+			Expect(script).To(ContainSubstring(`state) subcommands="show" ;;`))
+			Expect(script).To(ContainSubstring(`state/show) flags="--show-secrets" ;;`))
+			Expect(script).To(ContainSubstring(`up) flags="--lb-type --name --only" ;;`))
+			Expect(script).NotTo(ContainSubstring("--undocumented"))
+			Expect(script).To(ContainSubstring("    --state-dir|--env|--workspace|--config|--iaas|-s)\n      context+=("))
+			Expect(script).To(ContainSubstring("    --state-dir|-s|--env)\n      i=$((i + 1))"))
+			Expect(script).To(ContainSubstring("complete -o default -F _bbl bbl"))
+		})
+
+		It("prints a zsh script that loads the bash one", func() {
+			err := command.Execute([]string{"zsh"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			script := logger.PrintfCall.Messages[0]
+			Expect(script).To(HavePrefix("#compdef bbl\n\nautoload -U +X bashcompinit && bashcompinit\n"))
+			Expect(script).To(ContainSubstring("complete -o default -F _bbl bbl"))
+		})
+
+		It("prints a fish script", func() {
+			err := command.Execute([]string{"fish"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			script := logger.PrintfCall.Messages[0]
+			Expect(script).To(ContainSubstring("complete -c bbl -l debug -s d\n"))
+			Expect(script).To(ContainSubstring("complete -c bbl -l state-dir -s s -r -F\n"))
+			Expect(script).To(ContainSubstring("complete -c bbl -l env -x -a '(__bbl_values env)'\n"))
+			Expect(script).To(ContainSubstring("complete -c bbl -n __fish_use_subcommand -a up -d 'Deploys a BOSH Director'\n"))
+			Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_seen_subcommand_from up' -l lb-type -x -a '(__bbl_values lb-type)'\n"))
+			Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_seen_subcommand_from state; and not __fish_seen_subcommand_from show' -a show -d 'Prints the state'\n"))
+			Expect(script).To(ContainSubstring("complete -c bbl -n '__fish_seen_subcommand_from state; and __fish_seen_subcommand_from show' -l show-secrets\n"))
+		})
+
+		It("completes the flags that a command parses but does not document", func() {
+			command = commands.NewCompletion(logger, map[string]commands.Command{
+				"cleanup-leftovers": commands.NewCleanupLeftovers(&fakes.FilteredDeleter{}),
+			}, []commands.CompletionFlag{}, environments)
+
+			err := command.Execute([]string{"bash"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintfCall.Messages[0]).To(ContainSubstring(`cleanup-leftovers) flags="--dry-run --filter" ;;`))
+		})
+
+		Describe("values", func() {
+			It("prints the load balancer types of the IaaS", func() {
+				err := command.Execute([]string{"values", "lb-type"}, storage.State{IAAS: "gcp"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{"cf", "concourse"}))
+			})
+
+			It("prints no load balancer types when the IaaS has none", func() {
+				err := command.Execute([]string{"values", "--lb-type"}, storage.State{IAAS: "vsphere"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger.PrintlnCall.CallCount).To(Equal(0))
+			})
+
+			It("prints the environments of the workspace", func() {
+				environments.EnvironmentsCall.Returns.Environments = []workspace.Environment{{Name: "dev"}, {Name: "prod"}}
+
+				err := command.Execute([]string{"values", "env"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{"dev", "prod"}))
+			})
+
+			It("returns an error when the workspace cannot be read", func() {
+				environments.EnvironmentsCall.Returns.Error = errors.New("lime")
+
+				err := command.Execute([]string{"values", "env"}, storage.State{})
+				Expect(err).To(MatchError("lime"))
+			})
+
+			It("returns an error for a flag without values", func() {
+				err := command.Execute([]string{"values", "name"}, storage.State{})
+				Expect(err).To(MatchError("No values to complete for --name."))
+			})
+		})
+	})
+})
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// Setting is the effective value of a flag or bbl.yml key and where it
+// came from: flag, env, file or state.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Secret bool   `json:"-"`
+}
+
+type ConfigShow struct {
+	logger   logger
+	settings []Setting
+}
+
+func NewConfigShow(logger logger, settings []Setting) ConfigShow {
+	return ConfigShow{
+		logger:   logger,
+		settings: settings,
+	}
+}
+
+func (c ConfigShow) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := c.parseArgs(subcommandFlags)
+	return err
+}
+
+func (c ConfigShow) Execute(subcommandFlags []string, state storage.State) error {
+	settings, err := c.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if len(settings) == 0 {
+		c.logger.Println("No settings found. Set them with flags, BBL_* environment variables or bbl.yml.")
+		return nil
+	}
+
+	c.logger.Printf("%-34s %-50s %s\n", "KEY", "VALUE", "SOURCE")
+	for _, setting := range settings {
+		c.logger.Printf("%-34s %-50s %s\n", setting.Key, setting.Value, setting.Source)
+	}
+
+	return nil
+}
+
+func (c ConfigShow) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	settings, err := c.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(c.logger, settings)
+}
+
+func configShowFlags(showSecrets *bool) flags.Flags {
+	f := flags.New("config show")
+	f.Bool(showSecrets, "show-secrets")
+	return f
+}
+
+func (c ConfigShow) Flags() []flags.Flags {
+	var showSecrets bool
+	return []flags.Flags{configShowFlags(&showSecrets)}
+}
+
+// parseArgs returns the settings with their secrets redacted unless
+// --show-secrets is given.
+func (c ConfigShow) parseArgs(subcommandFlags []string) ([]Setting, error) {
+	var showSecrets bool
+	err := configShowFlags(&showSecrets).Parse(subcommandFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := []Setting{}
+	for _, setting := range c.settings {
+		if setting.Secret {
+			setting.Value = secret(setting.Value, showSecrets)
+		}
+		settings = append(settings, setting)
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,76 @@
+package commands_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConfigShow", func() {
+	var (
+		logger   *fakes.Logger
+		settings []commands.Setting
+		command  commands.ConfigShow
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		settings = []commands.Setting{
+			{Key: "iaas", Value: "aws", Source: "file"},
+			{Key: "aws-region", Value: "us-west-1", Source: "env"},
+			{Key: "aws-secret-access-key", Value: "some-secret", Source: "state", Secret: true},
+			{Key: "lb-type", Value: "cf", Source: "flag"},
+		}
+
+		command = commands.NewConfigShow(logger, settings)
+	})
+
+	Describe("Execute", func() {
+		It("prints the settings with their sources and secrets redacted", func() {
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"KEY                                VALUE                                              SOURCE\n",
+				"iaas                               aws                                                file\n",
+				"aws-region                         us-west-1                                          env\n",
+				"aws-secret-access-key              REDACTED                                           state\n",
+				"lb-type                            cf                                                 flag\n",
+			}))
+		})
+
+		It("prints the secrets with --show-secrets", func() {
+			err := command.Execute([]string{"--show-secrets"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(ContainElement(
+				"aws-secret-access-key              some-secret                                        state\n",
+			))
+		})
+
+		It("says so when nothing is set", func() {
+			command = commands.NewConfigShow(logger, []commands.Setting{})
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("No settings found. Set them with flags, BBL_* environment variables or bbl.yml."))
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the settings as JSON", func() {
+			err := command.ExecuteJSON([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`[
+				{"key": "iaas", "value": "aws", "source": "file"},
+				{"key": "aws-region", "value": "us-west-1", "source": "env"},
+				{"key": "aws-secret-access-key", "value": "REDACTED", "source": "state"},
+				{"key": "lb-type", "value": "cf", "source": "flag"}
+			]`))
+		})
+	})
+})
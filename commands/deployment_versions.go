@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/coreos/go-semver/semver"
+)
+
+type versionGetter interface {
+	Shipped(deployment, iaas string) (bosh.Versions, error)
+	Deployed(manifest string) (bosh.Versions, error)
+}
+
+// DeploymentVersions compares the stemcell and releases of the jumpbox
+// and director with the ones this bbl deploys.
+type DeploymentVersions struct {
+	logger         logger
+	stateValidator stateValidator
+	versionGetter  versionGetter
+	bblVersion     string
+}
+
+type versionsReport struct {
+	BBLVersion      bblVersionStatus   `json:"bblVersion"`
+	Components      []componentVersion `json:"components"`
+	PendingUpgrades int                `json:"pendingUpgrades"`
+	Skipped         []string           `json:"skipped,omitempty"`
+}
+
+type bblVersionStatus struct {
+	Deployed string `json:"deployed"`
+	Current  string `json:"current"`
+	Outdated bool   `json:"outdated"`
+}
+
+type componentVersion struct {
+	Deployment string `json:"deployment"`
+	Component  string `json:"component"`
+	Deployed   string `json:"deployed"`
+	Shipped    string `json:"shipped"`
+	Status     string `json:"status"`
+}
+
+func NewDeploymentVersions(logger logger, stateValidator stateValidator, versionGetter versionGetter, bblVersion string) DeploymentVersions {
+	return DeploymentVersions{
+		logger:         logger,
+		stateValidator: stateValidator,
+		versionGetter:  versionGetter,
+		bblVersion:     bblVersion,
+	}
+}
+
+func (d DeploymentVersions) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return d.stateValidator.Validate()
+}
+
+func (d DeploymentVersions) Execute(subcommandFlags []string, state storage.State) error {
+	report, err := d.compare(state)
+	if err != nil {
+		return err
+	}
+
+	if report.BBLVersion.Outdated {
+		d.logger.Printf("This environment was deployed by bbl v%s. This is bbl v%s.\n", report.BBLVersion.Deployed, report.BBLVersion.Current)
+	}
+
+	if len(report.Components) > 0 {
+		d.logger.Printf("%-10s %-24s %-12s %-12s %s\n", "DEPLOYMENT", "COMPONENT", "DEPLOYED", "SHIPPED", "STATUS")
+		for _, c := range report.Components {
+			d.logger.Printf("%-10s %-24s %-12s %-12s %s\n", c.Deployment, c.Component, orNone(c.Deployed), orNone(c.Shipped), c.Status)
+		}
+	}
+
+	for _, skipped := range report.Skipped {
+		d.logger.Println(skipped)
+	}
+
+	if report.PendingUpgrades > 0 {
+		d.logger.Println(fmt.Sprintf("%d pending upgrades. Run bbl up to apply them.", report.PendingUpgrades))
+	} else if len(report.Components) > 0 {
+		d.logger.Println("Everything is up to date.")
+	}
+
+	return nil
+}
+
+func (d DeploymentVersions) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	report, err := d.compare(state)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(d.logger, report)
+}
+
+func (d DeploymentVersions) compare(state storage.State) (versionsReport, error) {
+	report := versionsReport{
+		BBLVersion: bblVersionStatus{
+			Deployed: state.BBLVersion,
+			Current:  d.bblVersion,
+			Outdated: olderBBL(state.BBLVersion, d.bblVersion),
+		},
+		Components: []componentVersion{},
+	}
+
+	deployments := []struct {
+		name     string
+		manifest string
+		skip     bool
+	}{
+		{name: "jumpbox", manifest: state.Jumpbox.Manifest},
+		{name: "director", manifest: state.BOSH.Manifest, skip: state.NoDirector},
+	}
+
+	for _, deployment := range deployments {
+		if deployment.skip {
+			continue
+		}
+		if deployment.manifest == "" {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("%s: skipped, run bbl up to record the manifest it deploys", deployment.name))
+			continue
+		}
+
+		deployed, err := d.versionGetter.Deployed(deployment.manifest)
+		if err != nil {
+			return versionsReport{}, fmt.Errorf("Read %s manifest: %s", deployment.name, err)
+		}
+
+		shipped, err := d.versionGetter.Shipped(deployment.name, state.IAAS)
+		if err != nil {
+			return versionsReport{}, fmt.Errorf("Read shipped %s versions: %s", deployment.name, err)
+		}
+
+		components := []componentVersion{{
+			Deployment: deployment.name,
+			Component:  "stemcell",
+			Deployed:   deployed.Stemcell,
+			Shipped:    shipped.Stemcell,
+		}}
+
+		names := []string{}
+		for name := range deployed.Releases {
+			names = append(names, name)
+		}
+		for name := range shipped.Releases {
+			if _, ok := deployed.Releases[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			components = append(components, componentVersion{
+				Deployment: deployment.name,
+				Component:  name,
+				Deployed:   deployed.Releases[name],
+				Shipped:    shipped.Releases[name],
+			})
+		}
+
+		for _, c := range components {
+			c.Status = versionStatus(c.Deployed, c.Shipped)
+			if c.Status != "current" && c.Status != "ahead" {
+				report.PendingUpgrades++
+			}
+			report.Components = append(report.Components, c)
+		}
+	}
+
+	return report, nil
+}
+
+// versionStatus is current when the deployed version is the shipped one,
+// upgrade or ahead when they differ, new when bbl up would add the
+// component and removed when bbl up would drop it.
+func versionStatus(deployed, shipped string) string {
+	switch {
+	case deployed == shipped:
+		return "current"
+	case deployed == "":
+		return "new"
+	case shipped == "":
+		return "removed"
+	case compareVersions(deployed, shipped) > 0:
+		return "ahead"
+	}
+	return "upgrade"
+}
+
+// compareVersions compares dotted numeric versions such as 3541.10. Parts
+// that are not numbers compare as strings.
+func compareVersions(a, b string) int {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		if aErr != nil || bErr != nil {
+			if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if aNum != bNum {
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(aParts) - len(bParts)
+}
+
+func olderBBL(deployed, current string) bool {
+	deployedVersion, err := semver.NewVersion(deployed)
+	if err != nil {
+		return false
+	}
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return false
+	}
+	return deployedVersion.LessThan(*currentVersion)
+}
+
+func orNone(version string) string {
+	if version == "" {
+		return "-"
+	}
+	return version
+}
@@ -0,0 +1,162 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DeploymentVersions", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		versionGetter  *fakes.VersionGetter
+
+		command commands.DeploymentVersions
+		state   storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		versionGetter = &fakes.VersionGetter{}
+
+		versionGetter.DeployedCall.Fake = func(manifest string) (bosh.Versions, error) {
+			if manifest == "name: jumpbox" {
+				return bosh.Versions{Stemcell: "3468.17", Releases: map[string]string{"os-conf": "13"}}, nil
+			}
+			return bosh.Versions{Stemcell: "3468.17", Releases: map[string]string{
+				"bosh":    "264.7.0",
+				"credhub": "1.6.5",
+				"garden":  "1.0",
+			}}, nil
+		}
+		versionGetter.ShippedCall.Fake = func(deployment, iaas string) (bosh.Versions, error) {
+			if deployment == "jumpbox" {
+				return bosh.Versions{Stemcell: "3468.17", Releases: map[string]string{"os-conf": "13"}}, nil
+			}
+			return bosh.Versions{Stemcell: "3541.10", Releases: map[string]string{
+				"bosh":    "265.2.0",
+				"credhub": "1.6.4",
+				"uaa":     "52.8",
+			}}, nil
+		}
+
+		state = storage.State{
+			IAAS:       "aws",
+			BBLVersion: "6.1.0",
+			Jumpbox:    storage.Jumpbox{Manifest: "name: jumpbox"},
+			BOSH:       storage.BOSH{Manifest: "name: bosh"},
+		}
+
+		command = commands.NewDeploymentVersions(logger, stateValidator, versionGetter, "7.0.0")
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when there is no bbl state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("no state"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("lists the deployed and shipped versions", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(versionGetter.ShippedCall.Receives.IAAS).To(Equal("aws"))
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"This environment was deployed by bbl v6.1.0. This is bbl v7.0.0.\n",
+				"DEPLOYMENT COMPONENT                DEPLOYED     SHIPPED      STATUS\n",
+				"jumpbox    stemcell                 3468.17      3468.17      current\n",
+				"jumpbox    os-conf                  13           13           current\n",
+				"director   stemcell                 3468.17      3541.10      upgrade\n",
+				"director   bosh                     264.7.0      265.2.0      upgrade\n",
+				"director   credhub                  1.6.5        1.6.4        ahead\n",
+				"director   garden                   1.0          -            removed\n",
+				"director   uaa                      -            52.8         new\n",
+			}))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+				"4 pending upgrades. Run bbl up to apply them.",
+			}))
+		})
+
+		It("reports when everything is up to date", func() {
+			state.NoDirector = true
+			state.BBLVersion = "7.0.0"
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(HaveLen(3))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{"Everything is up to date."}))
+		})
+
+		It("skips deployments without a recorded manifest", func() {
+			state.Jumpbox.Manifest = ""
+			state.BOSH.Manifest = ""
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(versionGetter.DeployedCall.CallCount).To(Equal(0))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+				"jumpbox: skipped, run bbl up to record the manifest it deploys",
+				"director: skipped, run bbl up to record the manifest it deploys",
+			}))
+		})
+
+		It("does not flag a development build of bbl", func() {
+			command = commands.NewDeploymentVersions(logger, stateValidator, versionGetter, "dev")
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages[0]).To(HavePrefix("DEPLOYMENT"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the manifest cannot be read", func() {
+				versionGetter.DeployedCall.Fake = nil
+				versionGetter.DeployedCall.Returns.Error = errors.New("mango")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Read jumpbox manifest: mango"))
+			})
+
+			It("returns an error when the shipped versions cannot be read", func() {
+				versionGetter.ShippedCall.Fake = nil
+				versionGetter.ShippedCall.Returns.Error = errors.New("papaya")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Read shipped jumpbox versions: papaya"))
+			})
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the comparison", func() {
+			state.NoDirector = true
+
+			err := command.ExecuteJSON([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"bblVersion": {"deployed": "6.1.0", "current": "7.0.0", "outdated": true},
+				"components": [
+					{"deployment": "jumpbox", "component": "stemcell", "deployed": "3468.17", "shipped": "3468.17", "status": "current"},
+					{"deployment": "jumpbox", "component": "os-conf", "deployed": "13", "shipped": "13", "status": "current"}
+				],
+				"pendingUpgrades": 0
+			}`))
+		})
+	})
+})
@@ -0,0 +1,326 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	fixJumpbox     = "regenerate the jumpbox deployment and scripts"
+	fixDirector    = "regenerate the director deployment and scripts"
+	fixCloudConfig = "regenerate the cloud-config"
+	fixJumpboxURL  = "update the jumpbox URL in bbl-state.json"
+)
+
+type doctorFs interface {
+	fileio.FileReader
+	fileio.Stater
+}
+
+type cloudConfigChecker interface {
+	Initialize(state storage.State) error
+	Modified(state storage.State) ([]string, error)
+}
+
+type stateSetter interface {
+	Set(state storage.State) error
+}
+
+type Doctor struct {
+	logger           logger
+	stateValidator   stateValidator
+	stateStore       stateSetter
+	terraformManager terraformManager
+	scriptGenerator  scriptGenerator
+	cloudConfig      cloudConfigChecker
+	fs               doctorFs
+	stateDir         string
+}
+
+type diagnosis struct {
+	problem    string
+	suggestion string
+	fix        string
+}
+
+func NewDoctor(logger logger, stateValidator stateValidator, stateStore stateSetter, terraformManager terraformManager,
+	scriptGenerator scriptGenerator, cloudConfig cloudConfigChecker, fs doctorFs, stateDir string) Doctor {
+	return Doctor{
+		logger:           logger,
+		stateValidator:   stateValidator,
+		stateStore:       stateStore,
+		terraformManager: terraformManager,
+		scriptGenerator:  scriptGenerator,
+		cloudConfig:      cloudConfig,
+		fs:               fs,
+		stateDir:         stateDir,
+	}
+}
+
+func (d Doctor) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return d.stateValidator.Validate()
+}
+
+func doctorFlags(fix *bool) flags.Flags {
+	f := flags.New("doctor")
+	f.Bool(fix, "fix")
+	return f
+}
+
+func (d Doctor) Flags() []flags.Flags {
+	var fix bool
+	return []flags.Flags{doctorFlags(&fix)}
+}
+
+func (d Doctor) Execute(args []string, state storage.State) error {
+	var fix bool
+	err := doctorFlags(&fix).Parse(args)
+	if err != nil {
+		return err
+	}
+
+	diagnoses, jumpboxURL := d.diagnose(state)
+	if len(diagnoses) == 0 {
+		d.logger.Println("No problems found.")
+		return nil
+	}
+
+	remaining := 0
+	fixed := map[string]error{}
+	for _, diagnosis := range diagnoses {
+		d.logger.Printf("* %s\n", diagnosis.problem)
+
+		if fix && diagnosis.fix != "" {
+			fixErr, done := fixed[diagnosis.fix]
+			if !done {
+				fixErr = d.fix(diagnosis.fix, state, jumpboxURL)
+				fixed[diagnosis.fix] = fixErr
+			}
+
+			if fixErr == nil {
+				d.logger.Printf("  Fixed: %s.\n", diagnosis.fix)
+				continue
+			}
+			d.logger.Printf("  Could not %s: %s\n", diagnosis.fix, fixErr)
+		}
+
+		remaining++
+		d.logger.Printf("  %s\n", diagnosis.suggestion)
+		if !fix && diagnosis.fix != "" {
+			d.logger.Printf("  Run `bbl doctor --fix` to %s.\n", diagnosis.fix)
+		}
+	}
+
+	if remaining > 0 {
+		return fmt.Errorf("bbl doctor found %d problem(s) in %s.", remaining, d.stateDir)
+	}
+
+	return nil
+}
+
+func (d Doctor) diagnose(state storage.State) ([]diagnosis, string) {
+	diagnoses := []diagnosis{}
+
+	directorCreated := !state.NoDirector && state.BOSH.DirectorAddress != ""
+	jumpboxCreated := state.Jumpbox.URL != ""
+
+	if directorCreated {
+		diagnoses = append(diagnoses, d.missingVars("director", "bosh-state.json", "director-vars-store.yml")...)
+	}
+
+	if jumpboxCreated {
+		diagnoses = append(diagnoses, d.missingVars("jumpbox", "jumpbox-state.json", "jumpbox-vars-store.yml")...)
+	}
+
+	terraformDiagnoses, jumpboxURL := d.checkTerraform(state)
+	diagnoses = append(diagnoses, terraformDiagnoses...)
+
+	if state.EnvID != "" {
+		diagnoses = append(diagnoses, d.checkDeployment("jumpbox", "jumpbox-deployment", "jumpbox.yml", fixJumpbox)...)
+		if !state.NoDirector {
+			diagnoses = append(diagnoses, d.checkDeployment("director", "bosh-deployment", "bosh.yml", fixDirector)...)
+		}
+
+		diagnoses = append(diagnoses, d.checkCloudConfig(state)...)
+	}
+
+	return diagnoses, jumpboxURL
+}
+
+func (d Doctor) missingVars(deployment string, files ...string) []diagnosis {
+	diagnoses := []diagnosis{}
+	for _, file := range files {
+		if d.exists(filepath.Join("vars", file)) {
+			continue
+		}
+
+		diagnoses = append(diagnoses, diagnosis{
+			problem:    fmt.Sprintf("bbl-state.json says the %s exists, but vars/%s is missing.", deployment, file),
+			suggestion: fmt.Sprintf("Restore vars/%s from `bbl state history` or a backup. Without it bbl cannot update or delete the %s.", file, deployment),
+		})
+	}
+	return diagnoses
+}
+
+func (d Doctor) checkTerraform(state storage.State) ([]diagnosis, string) {
+	if !d.exists(filepath.Join("vars", "terraform.tfstate")) {
+		if state.Jumpbox.URL == "" {
+			return nil, ""
+		}
+
+		return []diagnosis{{
+			problem:    "bbl-state.json says the jumpbox exists, but vars/terraform.tfstate is missing.",
+			suggestion: "Restore vars/terraform.tfstate from `bbl state history` or a backup before running bbl up or bbl down.",
+		}}, ""
+	}
+
+	outputs, err := d.terraformManager.GetOutputs()
+	if err != nil {
+		return []diagnosis{{
+			problem:    fmt.Sprintf("Could not read the terraform outputs: %s", err),
+			suggestion: "Check that terraform is installed and that vars/terraform.tfstate is intact.",
+		}}, ""
+	}
+
+	jumpboxURL := outputs.GetString("jumpbox_url")
+	if jumpboxURL == "" {
+		return []diagnosis{{
+			problem:    "The terraform state has no jumpbox_url output.",
+			suggestion: "Run `bbl plan` and `bbl up` to re-apply the terraform template.",
+		}}, ""
+	}
+
+	if state.Jumpbox.URL != "" && state.Jumpbox.URL != jumpboxURL {
+		return []diagnosis{{
+			problem:    fmt.Sprintf("bbl-state.json has the jumpbox URL %s, but terraform says %s.", state.Jumpbox.URL, jumpboxURL),
+			suggestion: "Run `bbl up` to update the jumpbox.",
+			fix:        fixJumpboxURL,
+		}}, jumpboxURL
+	}
+
+	return nil, jumpboxURL
+}
+
+func (d Doctor) checkDeployment(deployment, dir, manifest, fix string) []diagnosis {
+	diagnoses := []diagnosis{}
+
+	if !d.exists(filepath.Join(dir, manifest)) {
+		diagnoses = append(diagnoses, diagnosis{
+			problem:    fmt.Sprintf("%s/%s is missing.", dir, manifest),
+			suggestion: "Run `bbl plan` to regenerate it.",
+			fix:        fix,
+		})
+	}
+
+	for _, action := range []string{"create", "delete"} {
+		script := fmt.Sprintf("%s-%s.sh", action, deployment)
+		problem := d.checkScript(script)
+		if problem == "" {
+			continue
+		}
+
+		diagnoses = append(diagnoses, diagnosis{
+			problem:    problem,
+			suggestion: "Run `bbl plan` to regenerate it.",
+			fix:        fix,
+		})
+	}
+
+	return diagnoses
+}
+
+func (d Doctor) checkScript(script string) string {
+	path := filepath.Join(d.stateDir, script)
+
+	info, err := d.fs.Stat(path)
+	if err != nil {
+		return fmt.Sprintf("%s is missing.", script)
+	}
+
+	if info.Mode().Perm()&0100 == 0 {
+		return fmt.Sprintf("%s is not executable.", script)
+	}
+
+	contents, err := d.fs.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("%s cannot be read: %s", script, err)
+	}
+
+	lines := strings.SplitN(string(contents), "\n", 3)
+	if len(lines) < 2 {
+		return fmt.Sprintf("%s is empty.", script)
+	}
+
+	boshPath := strings.Fields(lines[1])
+	if len(boshPath) > 0 && filepath.IsAbs(boshPath[0]) {
+		if _, err := d.fs.Stat(boshPath[0]); os.IsNotExist(err) {
+			return fmt.Sprintf("%s runs %s, which does not exist on this machine.", script, boshPath[0])
+		}
+	}
+
+	return ""
+}
+
+func (d Doctor) checkCloudConfig(state storage.State) []diagnosis {
+	diagnoses := []diagnosis{}
+
+	modified, err := d.cloudConfig.Modified(state)
+	if err != nil {
+		return []diagnosis{{
+			problem:    fmt.Sprintf("Could not check the cloud-config: %s", err),
+			suggestion: "Check your IAAS credentials.",
+		}}
+	}
+
+	for _, file := range modified {
+		diagnoses = append(diagnoses, diagnosis{
+			problem:    fmt.Sprintf("cloud-config/%s has been edited by hand.", file),
+			suggestion: "bbl overwrites it on every plan and up. Move your changes to a separate ops file in the cloud-config directory.",
+		})
+	}
+
+	for _, file := range []string{"cloud-config.yml", "ops.yml"} {
+		if d.exists(filepath.Join("cloud-config", file)) {
+			continue
+		}
+
+		missing := diagnosis{
+			problem:    fmt.Sprintf("cloud-config/%s is missing.", file),
+			suggestion: "Run `bbl plan` to regenerate it.",
+		}
+		// Regenerating rewrites both files, so leave it to the user when
+		// the other one holds hand edits.
+		if len(modified) == 0 {
+			missing.fix = fixCloudConfig
+		}
+		diagnoses = append(diagnoses, missing)
+	}
+
+	return diagnoses
+}
+
+func (d Doctor) fix(fix string, state storage.State, jumpboxURL string) error {
+	switch fix {
+	case fixJumpbox:
+		return d.scriptGenerator.InitializeJumpbox(state)
+	case fixDirector:
+		return d.scriptGenerator.InitializeDirector(state)
+	case fixCloudConfig:
+		return d.cloudConfig.Initialize(state)
+	case fixJumpboxURL:
+		state.Jumpbox.URL = jumpboxURL
+		return d.stateStore.Set(state)
+	}
+	return nil
+}
+
+func (d Doctor) exists(path string) bool {
+	_, err := d.fs.Stat(filepath.Join(d.stateDir, path))
+	return err == nil
+}
@@ -0,0 +1,233 @@
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Doctor", func() {
+	var (
+		logger             *fakes.Logger
+		stateValidator     *fakes.StateValidator
+		stateStore         *fakes.StateStore
+		terraformManager   *fakes.TerraformManager
+		boshManager        *fakes.BOSHManager
+		cloudConfigManager *fakes.CloudConfigManager
+		fileIO             *fakes.FileIO
+		files              map[string]string
+		command            commands.Doctor
+		state              storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		stateStore = &fakes.StateStore{}
+		terraformManager = &fakes.TerraformManager{}
+		boshManager = &fakes.BOSHManager{}
+		cloudConfigManager = &fakes.CloudConfigManager{}
+		fileIO = &fakes.FileIO{}
+
+		script := "#!/bin/sh\nbosh create-env \\\n"
+		files = map[string]string{
+			"vars/bosh-state.json":           "{}",
+			"vars/director-vars-store.yml":   "",
+			"vars/jumpbox-state.json":        "{}",
+			"vars/jumpbox-vars-store.yml":    "",
+			"vars/terraform.tfstate":         "",
+			"jumpbox-deployment/jumpbox.yml": "",
+			"bosh-deployment/bosh.yml":       "",
+			"create-jumpbox.sh":              script,
+			"delete-jumpbox.sh":              script,
+			"create-director.sh":             script,
+			"delete-director.sh":             script,
+			"cloud-config/cloud-config.yml":  "",
+			"cloud-config/ops.yml":           "",
+		}
+		fileIO.StatCall.Fake = func(name string) (os.FileInfo, error) {
+			rel, _ := filepath.Rel("/some/state-dir", name)
+			if _, ok := files[rel]; ok {
+				return fakes.FileInfo{FileName: filepath.Base(name)}, nil
+			}
+			return nil, os.ErrNotExist
+		}
+		fileIO.ReadFileCall.Fake = func(name string) ([]byte, error) {
+			rel, _ := filepath.Rel("/some/state-dir", name)
+			return []byte(files[rel]), nil
+		}
+
+		terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
+			"jumpbox_url": "10.0.0.5:22",
+		}}
+
+		state = storage.State{
+			EnvID:   "some-env",
+			Jumpbox: storage.Jumpbox{URL: "10.0.0.5:22"},
+			BOSH:    storage.BOSH{DirectorAddress: "https://10.0.0.6:25555"},
+		}
+
+		command = commands.NewDoctor(logger, stateValidator, stateStore, terraformManager, boshManager, cloudConfigManager, fileIO, "/some/state-dir")
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+			Expect(command.CheckFastFails([]string{}, state)).To(MatchError("no state"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("reports a healthy state directory", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cloudConfigManager.ModifiedCall.Receives.State).To(Equal(state))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("No problems found."))
+		})
+
+		It("reports missing vars files without offering a fix", func() {
+			delete(files, "vars/director-vars-store.yml")
+
+			err := command.Execute([]string{"--fix"}, state)
+			Expect(err).To(MatchError("bbl doctor found 1 problem(s) in /some/state-dir."))
+
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"* bbl-state.json says the director exists, but vars/director-vars-store.yml is missing.\n",
+				"  Restore vars/director-vars-store.yml from `bbl state history` or a backup. Without it bbl cannot update or delete the director.\n",
+			}))
+		})
+
+		It("does not expect director files when there is no director", func() {
+			state.NoDirector = true
+			delete(files, "vars/director-vars-store.yml")
+			delete(files, "bosh-deployment/bosh.yml")
+			delete(files, "create-director.sh")
+
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reports scripts that run a bosh cli that does not exist", func() {
+			files["create-jumpbox.sh"] = "#!/bin/sh\n/usr/local/bin/bosh create-env \\\n"
+
+			err := command.Execute([]string{}, state)
+			Expect(err).To(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(ContainElement(
+				"* create-jumpbox.sh runs /usr/local/bin/bosh, which does not exist on this machine.\n",
+			))
+			Expect(logger.PrintfCall.Messages).To(ContainElement(
+				"  Run `bbl doctor --fix` to regenerate the jumpbox deployment and scripts.\n",
+			))
+		})
+
+		Context("when a terraform output disagrees with bbl-state.json", func() {
+			BeforeEach(func() {
+				terraformManager.GetOutputsCall.Returns.Outputs.Map["jumpbox_url"] = "10.0.0.7:22"
+			})
+
+			It("suggests the fix", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("bbl doctor found 1 problem(s) in /some/state-dir."))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{
+					"* bbl-state.json has the jumpbox URL 10.0.0.5:22, but terraform says 10.0.0.7:22.\n",
+					"  Run `bbl up` to update the jumpbox.\n",
+					"  Run `bbl doctor --fix` to update the jumpbox URL in bbl-state.json.\n",
+				}))
+			})
+
+			It("updates the state with --fix", func() {
+				err := command.Execute([]string{"--fix"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[0].State.Jumpbox.URL).To(Equal("10.0.0.7:22"))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("  Fixed: update the jumpbox URL in bbl-state.json.\n"))
+			})
+		})
+
+		Context("when generated scripts are missing", func() {
+			BeforeEach(func() {
+				delete(files, "create-jumpbox.sh")
+				delete(files, "delete-jumpbox.sh")
+			})
+
+			It("regenerates them once with --fix", func() {
+				err := command.Execute([]string{"--fix"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.InitializeJumpboxCall.CallCount).To(Equal(1))
+				Expect(boshManager.InitializeJumpboxCall.Receives.State).To(Equal(state))
+				Expect(boshManager.InitializeDirectorCall.CallCount).To(Equal(0))
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{
+					"* create-jumpbox.sh is missing.\n",
+					"  Fixed: regenerate the jumpbox deployment and scripts.\n",
+					"* delete-jumpbox.sh is missing.\n",
+					"  Fixed: regenerate the jumpbox deployment and scripts.\n",
+				}))
+			})
+
+			It("keeps the problem when regenerating fails", func() {
+				boshManager.InitializeJumpboxCall.Returns.Error = errors.New("apple")
+
+				err := command.Execute([]string{"--fix"}, state)
+				Expect(err).To(MatchError("bbl doctor found 2 problem(s) in /some/state-dir."))
+
+				Expect(boshManager.InitializeJumpboxCall.CallCount).To(Equal(1))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("  Could not regenerate the jumpbox deployment and scripts: apple\n"))
+			})
+		})
+
+		Context("when the cloud-config has been edited by hand", func() {
+			BeforeEach(func() {
+				cloudConfigManager.ModifiedCall.Returns.Files = []string{"ops.yml"}
+				delete(files, "cloud-config/cloud-config.yml")
+			})
+
+			It("does not regenerate it", func() {
+				err := command.Execute([]string{"--fix"}, state)
+				Expect(err).To(MatchError("bbl doctor found 2 problem(s) in /some/state-dir."))
+
+				Expect(cloudConfigManager.InitializeCall.CallCount).To(Equal(0))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("* cloud-config/ops.yml has been edited by hand.\n"))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("* cloud-config/cloud-config.yml is missing.\n"))
+			})
+		})
+
+		It("regenerates a missing cloud-config with --fix", func() {
+			delete(files, "cloud-config/ops.yml")
+
+			err := command.Execute([]string{"--fix"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cloudConfigManager.InitializeCall.CallCount).To(Equal(1))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--unknown"}, state)
+				Expect(err).To(MatchError("flag provided but not defined: -unknown"))
+			})
+
+			It("reports terraform outputs that cannot be read", func() {
+				terraformManager.GetOutputsCall.Returns.Error = errors.New("banana")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(HaveOccurred())
+
+				Expect(logger.PrintfCall.Messages).To(ContainElement("* Could not read the terraform outputs: banana\n"))
+			})
+		})
+	})
+})
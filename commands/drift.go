@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+// DriftExitCode is the exit code of bbl drift when it finds drift, so that
+// a scheduled job can tell drift apart from a failure to check for it.
+const DriftExitCode = 2
+
+type Drift struct {
+	logger             logger
+	stateValidator     stateValidator
+	terraformManager   terraformManager
+	cloudConfigManager cloudConfigManager
+}
+
+type driftReport struct {
+	Drifted   bool            `json:"drifted"`
+	Resources []driftResource `json:"resources"`
+	Skipped   []string        `json:"skipped,omitempty"`
+}
+
+type driftResource struct {
+	Source  string `json:"source"`
+	Address string `json:"address"`
+	Action  string `json:"action"`
+	Diff    string `json:"diff,omitempty"`
+}
+
+func NewDrift(logger logger, stateValidator stateValidator, terraformManager terraformManager, cloudConfigManager cloudConfigManager) Drift {
+	return Drift{
+		logger:             logger,
+		stateValidator:     stateValidator,
+		terraformManager:   terraformManager,
+		cloudConfigManager: cloudConfigManager,
+	}
+}
+
+func (d Drift) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return d.stateValidator.Validate()
+}
+
+func (d Drift) Execute(args []string, state storage.State) error {
+	report, err := d.check(state)
+	if err != nil {
+		return err
+	}
+
+	terraformDrifted, cloudConfigDrifted := false, false
+	for _, resource := range report.Resources {
+		switch resource.Source {
+		case "terraform":
+			terraformDrifted = true
+			d.logger.Printf("terraform: %s drifted (%s)\n", resource.Address, resource.Action)
+		case "director":
+			cloudConfigDrifted = true
+			d.logger.Printf("%s: drifted (%s)\n%s", resource.Address, resource.Action, resource.Diff)
+		}
+	}
+	if !terraformDrifted {
+		d.logger.Println("terraform: no drift")
+	}
+
+	for _, skipped := range report.Skipped {
+		d.logger.Println(skipped)
+	}
+	if !cloudConfigDrifted && len(report.Skipped) == 0 {
+		d.logger.Println("cloud-config: no drift")
+	}
+
+	return driftError(report)
+}
+
+func (d Drift) ExecuteJSON(args []string, state storage.State) error {
+	report, err := d.check(state)
+	if err != nil {
+		return err
+	}
+
+	err = printJSON(d.logger, report)
+	if err != nil {
+		return err
+	}
+
+	return driftError(report)
+}
+
+// check compares the infrastructure with the terraform state and the
+// cloud config on the director with the one the last bbl up uploaded.
+func (d Drift) check(state storage.State) (driftReport, error) {
+	report := driftReport{Resources: []driftResource{}}
+
+	drift, err := d.terraformManager.Drift(state)
+	if err != nil {
+		return driftReport{}, fmt.Errorf("Terraform drift: %s", err)
+	}
+	for _, resource := range drift {
+		report.Resources = append(report.Resources, driftResource{
+			Source:  "terraform",
+			Address: resource.Address,
+			Action:  resource.Action,
+		})
+	}
+
+	if state.BOSH.DirectorAddress == "" {
+		report.Skipped = append(report.Skipped, "cloud-config: skipped, there is no director")
+		report.Drifted = len(report.Resources) > 0
+		return report, nil
+	}
+
+	applied, err := d.cloudConfigManager.Applied()
+	if err != nil {
+		return driftReport{}, err
+	}
+
+	if applied == "" {
+		report.Skipped = append(report.Skipped, "cloud-config: skipped, run bbl up to record the cloud config it applies")
+	} else {
+		current, err := d.cloudConfigManager.Current(state)
+		if err != nil {
+			return driftReport{}, err
+		}
+
+		if current != applied {
+			action := "update"
+			if current == "" {
+				action = "delete"
+			}
+
+			report.Resources = append(report.Resources, driftResource{
+				Source:  "director",
+				Address: "cloud-config",
+				Action:  action,
+				Diff:    unifiedDiff(applied, current, "cloud-config (bbl up)", "cloud-config (director)"),
+			})
+		}
+	}
+
+	report.Drifted = len(report.Resources) > 0
+	return report, nil
+}
+
+func driftError(report driftReport) error {
+	if !report.Drifted {
+		return nil
+	}
+
+	addresses := []string{}
+	for _, resource := range report.Resources {
+		addresses = append(addresses, resource.Address)
+	}
+
+	return ExitError{
+		Code:    DriftExitCode,
+		Message: fmt.Sprintf("Drift detected: %s.", strings.Join(addresses, ", ")),
+	}
+}
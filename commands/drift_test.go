@@ -0,0 +1,196 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift", func() {
+	var (
+		logger             *fakes.Logger
+		stateValidator     *fakes.StateValidator
+		terraformManager   *fakes.TerraformManager
+		cloudConfigManager *fakes.CloudConfigManager
+
+		command commands.Drift
+		state   storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		terraformManager = &fakes.TerraformManager{}
+		cloudConfigManager = &fakes.CloudConfigManager{}
+
+		state = storage.State{
+			EnvID: "some-env-id",
+			BOSH:  storage.BOSH{DirectorAddress: "some-director-address"},
+		}
+
+		cloudConfigManager.AppliedCall.Returns.CloudConfig = "vm_types:\n- name: small\n"
+		cloudConfigManager.CurrentCall.Returns.CloudConfig = "vm_types:\n- name: small\n"
+
+		command = commands.NewDrift(logger, stateValidator, terraformManager, cloudConfigManager)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when there is no bbl state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+
+			err := command.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("no state"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("reports no drift", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(terraformManager.DriftCall.Receives.BBLState).To(Equal(state))
+			Expect(cloudConfigManager.CurrentCall.Receives.State).To(Equal(state))
+			Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+				"terraform: no drift",
+				"cloud-config: no drift",
+			}))
+		})
+
+		Context("when resources drifted", func() {
+			BeforeEach(func() {
+				terraformManager.DriftCall.Returns.Drift = []terraform.ResourceDrift{
+					{Address: "google_compute_network.some-network", Action: "update"},
+					{Address: "google_compute_firewall.some-firewall", Action: "delete"},
+				}
+				cloudConfigManager.CurrentCall.Returns.CloudConfig = "vm_types:\n- name: large\n"
+			})
+
+			It("reports each resource and exits with the drift exit code", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).To(Equal(commands.ExitError{
+					Code:    2,
+					Message: "Drift detected: google_compute_network.some-network, google_compute_firewall.some-firewall, cloud-config.",
+				}))
+
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{
+					"terraform: google_compute_network.some-network drifted (update)\n",
+					"terraform: google_compute_firewall.some-firewall drifted (delete)\n",
+					`cloud-config: drifted (update)
+--- cloud-config (bbl up)
++++ cloud-config (director)
+@@ -1,2 +1,2 @@
+ vm_types:
+-- name: small
++- name: large
+`,
+				}))
+			})
+		})
+
+		Context("when the cloud config was removed from the director", func() {
+			BeforeEach(func() {
+				cloudConfigManager.CurrentCall.Returns.CloudConfig = ""
+			})
+
+			It("reports the cloud config as deleted", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Drift detected: cloud-config."))
+				Expect(logger.PrintfCall.Messages[0]).To(HavePrefix("cloud-config: drifted (delete)\n"))
+			})
+		})
+
+		Context("when the applied cloud config was not recorded", func() {
+			BeforeEach(func() {
+				cloudConfigManager.AppliedCall.Returns.CloudConfig = ""
+			})
+
+			It("skips the cloud config", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cloudConfigManager.CurrentCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					"terraform: no drift",
+					"cloud-config: skipped, run bbl up to record the cloud config it applies",
+				}))
+			})
+		})
+
+		Context("when there is no director", func() {
+			BeforeEach(func() {
+				state.BOSH = storage.BOSH{}
+			})
+
+			It("skips the cloud config", func() {
+				err := command.Execute([]string{}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(cloudConfigManager.AppliedCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					"terraform: no drift",
+					"cloud-config: skipped, there is no director",
+				}))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when terraform fails", func() {
+				terraformManager.DriftCall.Returns.Error = errors.New("lychee")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Terraform drift: lychee"))
+			})
+
+			It("returns an error when the applied cloud config cannot be read", func() {
+				cloudConfigManager.AppliedCall.Returns.Error = errors.New("mango")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("mango"))
+			})
+
+			It("returns an error when the director cannot be reached", func() {
+				cloudConfigManager.CurrentCall.Returns.Error = errors.New("nectarine")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("nectarine"))
+			})
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the drifted resources", func() {
+			terraformManager.DriftCall.Returns.Drift = []terraform.ResourceDrift{
+				{Address: "google_compute_network.some-network", Action: "update"},
+			}
+
+			err := command.ExecuteJSON([]string{}, state)
+			Expect(err).To(MatchError("Drift detected: google_compute_network.some-network."))
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"drifted": true,
+				"resources": [
+					{"source": "terraform", "address": "google_compute_network.some-network", "action": "update"}
+				]
+			}`))
+		})
+
+		It("prints an empty report when nothing drifted", func() {
+			state.BOSH = storage.BOSH{}
+
+			err := command.ExecuteJSON([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"drifted": false,
+				"resources": [],
+				"skipped": ["cloud-config: skipped, there is no director"]
+			}`))
+		})
+	})
+})
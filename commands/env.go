@@ -0,0 +1,304 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/workspace"
+)
+
+const defaultForeachParallel = 4
+
+type environmentLister interface {
+	Environments() ([]workspace.Environment, error)
+}
+
+type envRunner interface {
+	Run(stateDir string, args []string, out io.Writer) (int, error)
+}
+
+type EnvList struct {
+	logger       logger
+	environments environmentLister
+	workspaceDir string
+}
+
+func NewEnvList(logger logger, environments environmentLister, workspaceDir string) EnvList {
+	return EnvList{
+		logger:       logger,
+		environments: environments,
+		workspaceDir: workspaceDir,
+	}
+}
+
+func (e EnvList) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return nil
+}
+
+func (e EnvList) Execute(subcommandFlags []string, state storage.State) error {
+	environments, err := e.environments.Environments()
+	if err != nil {
+		return err
+	}
+
+	if len(environments) == 0 {
+		e.logger.Println(fmt.Sprintf("No environments found in %s.", e.workspaceDir))
+		return nil
+	}
+
+	e.logger.Printf("%-20s %-9s %-24s %-8s %-26s %s\n", "NAME", "IAAS", "ENV ID", "BBL", "DIRECTOR ADDRESS", "LAST OPERATION")
+	for _, env := range environments {
+		e.logger.Printf("%-20s %-9s %-24s %-8s %-26s %s\n", env.Name, orNone(env.IAAS), orNone(env.EnvID), orNone(env.BBLVersion), orNone(env.DirectorAddress), env.Status)
+	}
+
+	return nil
+}
+
+func (e EnvList) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	environments, err := e.environments.Environments()
+	if err != nil {
+		return err
+	}
+
+	return printJSON(e.logger, environments)
+}
+
+type EnvForeach struct {
+	logger       logger
+	environments environmentLister
+	runner       envRunner
+}
+
+type foreachArgs struct {
+	parallel int
+	envs     []string
+	command  []string
+}
+
+type foreachReport struct {
+	Environments []foreachResult `json:"environments"`
+	Failed       int             `json:"failed"`
+}
+
+type foreachResult struct {
+	Name     string  `json:"name"`
+	ExitCode int     `json:"exitCode"`
+	Duration float64 `json:"durationSeconds"`
+	Output   string  `json:"output"`
+	Error    string  `json:"error,omitempty"`
+}
+
+func (r foreachResult) failed() bool {
+	return r.ExitCode != 0 || r.Error != ""
+}
+
+func NewEnvForeach(logger logger, environments environmentLister, runner envRunner) EnvForeach {
+	return EnvForeach{
+		logger:       logger,
+		environments: environments,
+		runner:       runner,
+	}
+}
+
+func foreachFlags(args *foreachArgs, envs *string) flags.Flags {
+	f := flags.New("env foreach")
+	f.Int(&args.parallel, "parallel", defaultForeachParallel)
+	f.String(envs, "envs", "")
+	return f
+}
+
+func (e EnvForeach) Flags() []flags.Flags {
+	var envs string
+	return []flags.Flags{foreachFlags(&foreachArgs{}, &envs)}
+}
+
+func parseForeachArgs(subcommandFlags []string) (foreachArgs, error) {
+	args := foreachArgs{}
+	var envs string
+
+	f := foreachFlags(&args, &envs)
+	err := f.Parse(subcommandFlags)
+	if err != nil {
+		return foreachArgs{}, err
+	}
+
+	if args.parallel < 1 {
+		return foreachArgs{}, errors.New("--parallel must be at least 1.")
+	}
+
+	if envs != "" {
+		args.envs = strings.Split(envs, ",")
+	}
+
+	args.command = f.Args()
+	if len(args.command) == 0 {
+		return foreachArgs{}, errors.New("bbl env foreach requires a bbl command, for example: bbl env foreach -- print-env")
+	}
+
+	return args, nil
+}
+
+func (e EnvForeach) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := parseForeachArgs(subcommandFlags)
+	return err
+}
+
+func (e EnvForeach) Execute(subcommandFlags []string, state storage.State) error {
+	var mutex sync.Mutex
+	report, err := e.run(subcommandFlags, func(result foreachResult) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if result.Output != "" {
+			for _, line := range strings.Split(strings.TrimSuffix(result.Output, "\n"), "\n") {
+				e.logger.Printf("%s | %s\n", result.Name, line)
+			}
+		}
+		if result.Error != "" {
+			e.logger.Printf("%s | %s\n", result.Name, result.Error)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(report.Environments) == 0 {
+		e.logger.Println("No environments found.")
+		return nil
+	}
+
+	e.logger.Printf("%-20s %-7s %-5s %s\n", "NAME", "RESULT", "EXIT", "DURATION")
+	for _, result := range report.Environments {
+		outcome := "ok"
+		if result.failed() {
+			outcome = "failed"
+		}
+		duration := time.Duration(result.Duration * float64(time.Second)).Round(time.Second)
+		e.logger.Printf("%-20s %-7s %-5d %s\n", result.Name, outcome, result.ExitCode, duration)
+	}
+
+	if report.Failed == 0 {
+		e.logger.Println(fmt.Sprintf("Succeeded in all %d environments.", len(report.Environments)))
+	}
+
+	return foreachError(report)
+}
+
+func (e EnvForeach) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	report, err := e.run(subcommandFlags, func(foreachResult) {})
+	if err != nil {
+		return err
+	}
+
+	err = printJSON(e.logger, report)
+	if err != nil {
+		return err // not tested
+	}
+
+	return foreachError(report)
+}
+
+// run runs the command in every selected environment, at most parallel
+// at a time, calling done as each one finishes. The report lists the
+// environments in name order whatever order they finish in.
+func (e EnvForeach) run(subcommandFlags []string, done func(foreachResult)) (foreachReport, error) {
+	args, err := parseForeachArgs(subcommandFlags)
+	if err != nil {
+		return foreachReport{}, err
+	}
+
+	environments, err := e.environments.Environments()
+	if err != nil {
+		return foreachReport{}, err
+	}
+
+	environments, err = selectEnvironments(environments, args.envs)
+	if err != nil {
+		return foreachReport{}, err
+	}
+
+	results := make([]foreachResult, len(environments))
+	slots := make(chan struct{}, args.parallel)
+	var wg sync.WaitGroup
+
+	for i, env := range environments {
+		wg.Add(1)
+		go func(i int, env workspace.Environment) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			out := bytes.NewBuffer([]byte{})
+			start := time.Now()
+			code, err := e.runner.Run(env.StateDir, args.command, out)
+
+			results[i] = foreachResult{
+				Name:     env.Name,
+				ExitCode: code,
+				Duration: time.Since(start).Seconds(),
+				Output:   out.String(),
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+			done(results[i])
+		}(i, env)
+	}
+	wg.Wait()
+
+	report := foreachReport{Environments: results}
+	for _, result := range results {
+		if result.failed() {
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+func selectEnvironments(environments []workspace.Environment, names []string) ([]workspace.Environment, error) {
+	if len(names) == 0 {
+		return environments, nil
+	}
+
+	byName := map[string]workspace.Environment{}
+	for _, env := range environments {
+		byName[env.Name] = env
+	}
+
+	selected := []workspace.Environment{}
+	for _, name := range names {
+		env, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown environment %q: run bbl env list to see the environments in the workspace.", name)
+		}
+		selected = append(selected, env)
+	}
+
+	return selected, nil
+}
+
+func foreachError(report foreachReport) error {
+	if report.Failed == 0 {
+		return nil
+	}
+
+	failed := []string{}
+	for _, result := range report.Environments {
+		if result.failed() {
+			failed = append(failed, result.Name)
+		}
+	}
+
+	return ExitError{
+		Code:    1,
+		Message: fmt.Sprintf("Failed in %d of %d environments: %s", report.Failed, len(report.Environments), strings.Join(failed, ", ")),
+	}
+}
@@ -0,0 +1,256 @@
+package commands_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/workspace"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EnvList", func() {
+	var (
+		logger       *fakes.Logger
+		environments *fakes.EnvironmentLister
+		command      commands.EnvList
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		environments = &fakes.EnvironmentLister{}
+		environments.EnvironmentsCall.Returns.Environments = []workspace.Environment{
+			{
+				Name:            "prod",
+				StateDir:        "/workspace/prod",
+				IAAS:            "aws",
+				EnvID:           "prod-env",
+				BBLVersion:      "7.0.0",
+				DirectorAddress: "https://10.0.0.6:25555",
+				Status:          "up succeeded",
+				LastOperation:   &storage.Operation{Command: "up", Succeeded: true},
+			},
+			{
+				Name:     "dev",
+				StateDir: "/workspace/dev",
+				Status:   "unreadable",
+				Error:    "cipher: message authentication failed",
+			},
+		}
+
+		command = commands.NewEnvList(logger, environments, "/workspace")
+	})
+
+	Describe("Execute", func() {
+		It("lists the environments", func() {
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"NAME                 IAAS      ENV ID                   BBL      DIRECTOR ADDRESS           LAST OPERATION\n",
+				"prod                 aws       prod-env                 7.0.0    https://10.0.0.6:25555     up succeeded\n",
+				"dev                  -         -                        -        -                          unreadable\n",
+			}))
+		})
+
+		It("says so when the workspace has no environments", func() {
+			environments.EnvironmentsCall.Returns.Environments = []workspace.Environment{}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("No environments found in /workspace."))
+		})
+
+		It("returns an error when the workspace cannot be read", func() {
+			environments.EnvironmentsCall.Returns.Error = errors.New("lime")
+			Expect(command.Execute([]string{}, storage.State{})).To(MatchError("lime"))
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the environments as JSON", func() {
+			err := command.ExecuteJSON([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`[
+				{
+					"name": "prod",
+					"stateDir": "/workspace/prod",
+					"iaas": "aws",
+					"envID": "prod-env",
+					"bblVersion": "7.0.0",
+					"directorAddress": "https://10.0.0.6:25555",
+					"status": "up succeeded",
+					"lastOperation": {
+						"command": "up",
+						"succeeded": true,
+						"startedAt": "0001-01-01T00:00:00Z",
+						"finishedAt": "0001-01-01T00:00:00Z"
+					}
+				},
+				{
+					"name": "dev",
+					"stateDir": "/workspace/dev",
+					"iaas": "",
+					"envID": "",
+					"bblVersion": "",
+					"directorAddress": "",
+					"status": "unreadable",
+					"error": "cipher: message authentication failed"
+				}
+			]`))
+		})
+	})
+})
+
+var _ = Describe("EnvForeach", func() {
+	var (
+		logger       *fakes.Logger
+		environments *fakes.EnvironmentLister
+		runner       *fakes.EnvRunner
+		command      commands.EnvForeach
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		environments = &fakes.EnvironmentLister{}
+		environments.EnvironmentsCall.Returns.Environments = []workspace.Environment{
+			{Name: "dev", StateDir: "/workspace/dev"},
+			{Name: "prod", StateDir: "/workspace/prod"},
+			{Name: "staging", StateDir: "/workspace/staging"},
+		}
+		runner = &fakes.EnvRunner{}
+		runner.RunCall.Fake = func(stateDir string, args []string, out io.Writer) (int, error) {
+			fmt.Fprintf(out, "ran in %s\n", stateDir)
+			return 0, nil
+		}
+
+		command = commands.NewEnvForeach(logger, environments, runner)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("requires a command", func() {
+			err := command.CheckFastFails([]string{"--"}, storage.State{})
+			Expect(err).To(MatchError("bbl env foreach requires a bbl command, for example: bbl env foreach -- print-env"))
+		})
+
+		It("requires at least one at a time", func() {
+			err := command.CheckFastFails([]string{"--parallel", "0", "--", "drift"}, storage.State{})
+			Expect(err).To(MatchError("--parallel must be at least 1."))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("runs the command in every environment and reports the results", func() {
+			err := command.Execute([]string{"--", "print-env", "--json"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runner.RunCall.Receives).To(ConsistOf(
+				fakes.EnvRunnerRunReceive{StateDir: "/workspace/dev", Args: []string{"print-env", "--json"}},
+				fakes.EnvRunnerRunReceive{StateDir: "/workspace/prod", Args: []string{"print-env", "--json"}},
+				fakes.EnvRunnerRunReceive{StateDir: "/workspace/staging", Args: []string{"print-env", "--json"}},
+			))
+			Expect(logger.PrintfCall.Messages).To(ContainElement("prod | ran in /workspace/prod\n"))
+			Expect(logger.PrintfCall.Messages[3:]).To(Equal([]string{
+				"NAME                 RESULT  EXIT  DURATION\n",
+				"dev                  ok      0     0s\n",
+				"prod                 ok      0     0s\n",
+				"staging              ok      0     0s\n",
+			}))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("Succeeded in all 3 environments."))
+		})
+
+		It("runs the command in the selected environments", func() {
+			err := command.Execute([]string{"--envs", "staging,dev", "--", "drift"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runner.RunCall.Receives).To(ConsistOf(
+				fakes.EnvRunnerRunReceive{StateDir: "/workspace/staging", Args: []string{"drift"}},
+				fakes.EnvRunnerRunReceive{StateDir: "/workspace/dev", Args: []string{"drift"}},
+			))
+		})
+
+		It("runs no more than --parallel environments at a time", func() {
+			var running, most int32
+			runner.RunCall.Fake = func(string, []string, io.Writer) (int, error) {
+				now := atomic.AddInt32(&running, 1)
+				for {
+					seen := atomic.LoadInt32(&most)
+					if now <= seen || atomic.CompareAndSwapInt32(&most, seen, now) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return 0, nil
+			}
+
+			err := command.Execute([]string{"--parallel", "2", "--", "drift"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runner.RunCall.CallCount).To(Equal(3))
+			Expect(atomic.LoadInt32(&most)).To(BeNumerically("<=", 2))
+		})
+
+		Context("when the command fails in some environments", func() {
+			BeforeEach(func() {
+				runner.RunCall.Fake = func(stateDir string, args []string, out io.Writer) (int, error) {
+					switch stateDir {
+					case "/workspace/dev":
+						return 2, nil
+					case "/workspace/staging":
+						return 0, errors.New("fork/exec bbl: no such file or directory")
+					}
+					return 0, nil
+				}
+			})
+
+			It("reports them and exits 1", func() {
+				err := command.Execute([]string{"--", "drift"}, storage.State{})
+				Expect(err).To(Equal(commands.ExitError{Code: 1, Message: "Failed in 2 of 3 environments: dev, staging"}))
+
+				Expect(logger.PrintfCall.Messages).To(ContainElement("staging | fork/exec bbl: no such file or directory\n"))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("dev                  failed  2     0s\n"))
+			})
+		})
+
+		Context("when an environment is unknown", func() {
+			It("returns an error without running anything", func() {
+				err := command.Execute([]string{"--envs", "qa", "--", "drift"}, storage.State{})
+				Expect(err).To(MatchError(`Unknown environment "qa": run bbl env list to see the environments in the workspace.`))
+				Expect(runner.RunCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the workspace has no environments", func() {
+			It("says so", func() {
+				environments.EnvironmentsCall.Returns.Environments = []workspace.Environment{}
+
+				err := command.Execute([]string{"--", "drift"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("No environments found."))
+			})
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the results as JSON", func() {
+			environments.EnvironmentsCall.Returns.Environments = []workspace.Environment{
+				{Name: "prod", StateDir: "/workspace/prod"},
+			}
+
+			err := command.ExecuteJSON([]string{"--", "director-address"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring(`"name": "prod"`))
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring(`"output": "ran in /workspace/prod\n"`))
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring(`"failed": 0`))
+		})
+	})
+})
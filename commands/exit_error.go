@@ -0,0 +1,12 @@
+package commands
+
+// ExitError is returned by commands whose exit code tells scripts what
+// happened, such as bbl drift exiting 2 when it finds drift.
+type ExitError struct {
+	Code    int
+	Message string
+}
+
+func (e ExitError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"os"
+	"time"
+)
+
+func SetNotifyInterrupts(f func(chan<- os.Signal)) {
+	notifyInterrupts = f
+}
+
+func SetStopInterrupts(f func(chan<- os.Signal)) {
+	stopInterrupts = f
+}
+
+func SetExit(f func(int)) {
+	exit = f
+}
+
+func SetSealGracePeriod(d time.Duration) {
+	sealGracePeriod = d
+}
+
+func ResetInterrupts() {
+	notifyInterrupts = defaultNotifyInterrupts
+	stopInterrupts = defaultStopInterrupts
+	exit = os.Exit
+	sealGracePeriod = time.Minute
+}
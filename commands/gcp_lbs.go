@@ -1,7 +1,6 @@
 package commands
 
 import (
-	"encoding/json"
 	"errors"
 	"strings"
 
@@ -21,48 +20,55 @@ func NewGCPLBs(terraformManager terraformManager, logger logger) GCPLBs {
 }
 
 func (l GCPLBs) Execute(subcommandFlags []string, state storage.State) error {
-	terraformOutputs, err := l.terraformManager.GetOutputs()
+	format, err := parseLBFormat(subcommandFlags)
 	if err != nil {
 		return err
 	}
 
+	terraformOutputs, err := l.terraformManager.CachedOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	var endpoints []LBEndpoint
+	var dnsServers []string
 	switch state.LB.Type {
 	case "cf":
-		if len(subcommandFlags) > 0 && subcommandFlags[0] == "--json" {
-			lbOutput, err := json.Marshal(struct {
-				RouterLBIP             string   `json:"cf_router_lb,omitempty"`
-				SSHProxyLBIP           string   `json:"cf_ssh_proxy_lb,omitempty"`
-				TCPRouterLBIP          string   `json:"cf_tcp_router_lb,omitempty"`
-				WebSocketLBIP          string   `json:"cf_websocket_lb,omitempty"`
-				SystemDomainDNSServers []string `json:"cf_system_domain_dns_servers,omitempty"`
-			}{
-				RouterLBIP:             terraformOutputs.GetString("router_lb_ip"),
-				SSHProxyLBIP:           terraformOutputs.GetString("ssh_proxy_lb_ip"),
-				TCPRouterLBIP:          terraformOutputs.GetString("tcp_router_lb_ip"),
-				WebSocketLBIP:          terraformOutputs.GetString("ws_lb_ip"),
-				SystemDomainDNSServers: terraformOutputs.GetStringSlice("system_domain_dns_servers"),
-			})
-			if err != nil {
-				// not tested
-				return err
-			}
-
-			l.logger.Println(string(lbOutput))
-		} else {
-			l.logger.Printf("CF Router LB: %s\n", terraformOutputs.GetString("router_lb_ip"))
-			l.logger.Printf("CF SSH Proxy LB: %s\n", terraformOutputs.GetString("ssh_proxy_lb_ip"))
-			l.logger.Printf("CF TCP Router LB: %s\n", terraformOutputs.GetString("tcp_router_lb_ip"))
-			l.logger.Printf("CF WebSocket LB: %s\n", terraformOutputs.GetString("ws_lb_ip"))
-			dnsServers := terraformOutputs.GetStringSlice("system_domain_dns_servers")
-			if len(dnsServers) > 0 {
-				l.logger.Printf("CF System Domain DNS servers: %s\n", strings.Join(dnsServers, " "))
-			}
+		endpoints = []LBEndpoint{
+			{Purpose: "router", Address: terraformOutputs.GetString("router_lb_ip")},
+			{Purpose: "ssh-proxy", Address: terraformOutputs.GetString("ssh_proxy_lb_ip")},
+			{Purpose: "tcp-router", Address: terraformOutputs.GetString("tcp_router_lb_ip")},
+			{Purpose: "websocket", Address: terraformOutputs.GetString("ws_lb_ip")},
 		}
+		dnsServers = terraformOutputs.GetStringSlice("system_domain_dns_servers")
 	case "concourse":
-		l.logger.Printf("Concourse LB: %s\n", terraformOutputs.GetString("concourse_lb_ip"))
+		endpoints = []LBEndpoint{
+			{Purpose: "concourse", Address: terraformOutputs.GetString("concourse_lb_ip")},
+		}
 	default:
 		return errors.New("no lbs found")
 	}
 
+	if format != "" {
+		description, err := newLBDescription(state, endpoints, dnsServers)
+		if err != nil {
+			return err
+		}
+		return printLBDescription(l.logger, format, description)
+	}
+
+	switch state.LB.Type {
+	case "cf":
+		l.logger.Printf("CF Router LB: %s\n", terraformOutputs.GetString("router_lb_ip"))
+		l.logger.Printf("CF SSH Proxy LB: %s\n", terraformOutputs.GetString("ssh_proxy_lb_ip"))
+		l.logger.Printf("CF TCP Router LB: %s\n", terraformOutputs.GetString("tcp_router_lb_ip"))
+		l.logger.Printf("CF WebSocket LB: %s\n", terraformOutputs.GetString("ws_lb_ip"))
+		if len(dnsServers) > 0 {
+			l.logger.Printf("CF System Domain DNS servers: %s\n", strings.Join(dnsServers, " "))
+		}
+	case "concourse":
+		l.logger.Printf("Concourse LB: %s\n", terraformOutputs.GetString("concourse_lb_ip"))
+	}
+
 	return nil
 }
@@ -25,7 +25,7 @@ var _ = Describe("GCPLBs", func() {
 
 	BeforeEach(func() {
 		terraformManager = &fakes.TerraformManager{}
-		terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
+		terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
 			"router_lb_ip":     "some-router-lb-ip",
 			"ssh_proxy_lb_ip":  "some-ssh-proxy-lb-ip",
 			"tcp_router_lb_ip": "some-tcp-router-lb-ip",
@@ -45,7 +45,7 @@ var _ = Describe("GCPLBs", func() {
 			err := command.Execute([]string{}, incomingState)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+			Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
 			Expect(logger.PrintfCall.Messages).To(ConsistOf([]string{
 				"CF Router LB: some-router-lb-ip\n",
 				"CF SSH Proxy LB: some-ssh-proxy-lb-ip\n",
@@ -56,7 +56,7 @@ var _ = Describe("GCPLBs", func() {
 
 		Context("when the domain is specified", func() {
 			BeforeEach(func() {
-				terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{
 					"router_lb_ip":              "some-router-lb-ip",
 					"ssh_proxy_lb_ip":           "some-ssh-proxy-lb-ip",
 					"tcp_router_lb_ip":          "some-tcp-router-lb-ip",
@@ -74,7 +74,7 @@ var _ = Describe("GCPLBs", func() {
 				err := command.Execute([]string{}, incomingState)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
 				Expect(logger.PrintfCall.Messages).To(ConsistOf([]string{
 					"CF Router LB: some-router-lb-ip\n",
 					"CF SSH Proxy LB: some-ssh-proxy-lb-ip\n",
@@ -86,6 +86,7 @@ var _ = Describe("GCPLBs", func() {
 
 			Context("when the json flag is provided", func() {
 				It("prints LB ips for lb type cf in json format", func() {
+					incomingState.IAAS = "gcp"
 					incomingState.LB = storage.LB{
 						Type:   "cf",
 						Domain: "some-domain",
@@ -94,11 +95,17 @@ var _ = Describe("GCPLBs", func() {
 					Expect(err).NotTo(HaveOccurred())
 
 					Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
-							"cf_router_lb": "some-router-lb-ip",
-							"cf_ssh_proxy_lb": "some-ssh-proxy-lb-ip",
-							"cf_tcp_router_lb": "some-tcp-router-lb-ip",
-							"cf_websocket_lb": "some-ws-lb-ip",
-							"cf_system_domain_dns_servers": [
+							"version": 1,
+							"iaas": "gcp",
+							"type": "cf",
+							"domain": "some-domain",
+							"endpoints": [
+								{"purpose": "router", "address": "some-router-lb-ip"},
+								{"purpose": "ssh-proxy", "address": "some-ssh-proxy-lb-ip"},
+								{"purpose": "tcp-router", "address": "some-tcp-router-lb-ip"},
+								{"purpose": "websocket", "address": "some-ws-lb-ip"}
+							],
+							"dnsNameServers": [
 								"name-server-1.",
 								"name-server-2."
 							]
@@ -114,7 +121,7 @@ var _ = Describe("GCPLBs", func() {
 			err := command.Execute([]string{}, incomingState)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+			Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
 			Expect(logger.PrintfCall.Messages).To(ConsistOf([]string{
 				"Concourse LB: some-concourse-lb-ip\n",
 			}))
@@ -123,7 +130,7 @@ var _ = Describe("GCPLBs", func() {
 		Context("failure cases", func() {
 			Context("when terraform output provider fails", func() {
 				BeforeEach(func() {
-					terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to return terraform output")
+					terraformManager.CachedOutputsCall.Returns.Error = errors.New("failed to return terraform output")
 				})
 
 				It("returns an error", func() {
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type Group struct {
+	name        string
+	description string
+	subcommands map[string]Command
+}
+
+func NewGroup(name, description string, subcommands map[string]Command) Group {
+	return Group{
+		name:        name,
+		description: description,
+		subcommands: subcommands,
+	}
+}
+
+func (g Group) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	command, args, err := g.find(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	return command.CheckFastFails(args, state)
+}
+
+func (g Group) Execute(subcommandFlags []string, state storage.State) error {
+	command, args, err := g.find(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	return command.Execute(args, state)
+}
+
+func (g Group) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	command, args, err := g.find(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	jsonCommand, ok := command.(JSONCommand)
+	if !ok {
+		return fmt.Errorf("bbl %s %s does not support --json", g.name, subcommandFlags[0])
+	}
+
+	return jsonCommand.ExecuteJSON(args, state)
+}
+
+func (g Group) Usage() string {
+	lines := []string{g.description, ""}
+	for _, name := range g.names() {
+		summary := strings.SplitN(g.subcommands[name].Usage(), "\n", 2)[0]
+		lines = append(lines, fmt.Sprintf("  %-22s %s", name, summary))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (g Group) Subcommands() map[string]Command {
+	return g.subcommands
+}
+
+func (g Group) find(args []string) (Command, []string, error) {
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("bbl %s requires a subcommand: %s", g.name, strings.Join(g.names(), ", "))
+	}
+
+	command, ok := g.subcommands[args[0]]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown command: %s %s", g.name, args[0])
+	}
+
+	return command, args[1:], nil
+}
+
+func (g Group) names() []string {
+	names := []string{}
+	for name := range g.subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
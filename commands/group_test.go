@@ -0,0 +1,90 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Group", func() {
+	var (
+		encrypt *fakes.Command
+		decrypt *fakes.Command
+		group   commands.Group
+		state   storage.State
+	)
+
+	BeforeEach(func() {
+		encrypt = &fakes.Command{}
+		decrypt = &fakes.Command{}
+		state = storage.State{EnvID: "some-env-id"}
+
+		group = commands.NewGroup("state", "Manages the state directory.", map[string]commands.Command{
+			"encrypt": encrypt,
+			"decrypt": decrypt,
+		})
+	})
+
+	It("dispatches to the named subcommand", func() {
+		err := group.CheckFastFails([]string{"encrypt", "--some-flag"}, state)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(encrypt.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+
+		err = group.Execute([]string{"encrypt", "--some-flag"}, state)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(encrypt.ExecuteCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+		Expect(encrypt.ExecuteCall.Receives.State).To(Equal(state))
+		Expect(decrypt.ExecuteCall.CallCount).To(Equal(0))
+	})
+
+	It("returns the subcommand's error", func() {
+		decrypt.ExecuteCall.Returns.Error = errors.New("apple")
+		Expect(group.Execute([]string{"decrypt"}, state)).To(MatchError("apple"))
+	})
+
+	It("lists the subcommands in its usage", func() {
+		encrypt.UsageCall.Returns.Usage = "Encrypts the state directory\n\n  more detail"
+		decrypt.UsageCall.Returns.Usage = "Decrypts the state directory"
+
+		usage := group.Usage()
+		Expect(usage).To(ContainSubstring("Manages the state directory."))
+		Expect(usage).To(MatchRegexp(`decrypt\s+Decrypts the state directory\n\s+encrypt\s+Encrypts the state directory$`))
+	})
+
+	Context("failure cases", func() {
+		It("requires a subcommand", func() {
+			err := group.CheckFastFails([]string{}, state)
+			Expect(err).To(MatchError("bbl state requires a subcommand: decrypt, encrypt"))
+		})
+
+		It("rejects unknown subcommands", func() {
+			err := group.Execute([]string{"shred"}, state)
+			Expect(err).To(MatchError("unknown command: state shred"))
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("dispatches to a subcommand that prints json", func() {
+			show := &fakes.JSONCommand{}
+			group = commands.NewGroup("state", "Manages the state directory.", map[string]commands.Command{
+				"show": show,
+			})
+
+			err := group.ExecuteJSON([]string{"show", "--some-flag"}, state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(show.ExecuteJSONCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(show.ExecuteJSONCall.Receives.State).To(Equal(state))
+		})
+
+		It("returns an error when the subcommand cannot print json", func() {
+			err := group.ExecuteJSON([]string{"encrypt"}, state)
+			Expect(err).To(MatchError("bbl state encrypt does not support --json"))
+			Expect(encrypt.ExecuteCall.CallCount).To(Equal(0))
+		})
+	})
+})
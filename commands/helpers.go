@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"encoding/json"
 	"errors"
 
 	"github.com/cloudfoundry/bosh-bootloader/helpers"
@@ -18,3 +19,13 @@ func handleTerraformError(err error, state storage.State, stateStore stateStore)
 
 	return errors.New(errorList.Error())
 }
+
+func printJSON(logger logger, document interface{}) error {
+	contents, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err // not tested
+	}
+
+	logger.Println(string(contents))
+	return nil
+}
@@ -0,0 +1,484 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/ssh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+type credentialsChecker interface {
+	CheckCredentials(answers map[string]string) error
+}
+
+type initFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.Stater
+	fileio.AllMkdirer
+}
+
+type Init struct {
+	logger        logger
+	input         *bufio.Reader
+	terminal      *os.File
+	checker       credentialsChecker
+	lbArgsHandler lbArgsHandler
+	fs            initFs
+	cipher        storage.Cipher
+	stateDir      string
+	configFile    string
+}
+
+type initArgs struct {
+	answers    string
+	skipChecks bool
+}
+
+// initQuestion asks for the value of a global flag or bbl up flag, which
+// is also its key in bbl.yml.
+type initQuestion struct {
+	key      string
+	prompt   string
+	optional bool
+	secret   bool
+	choices  []string
+	validate func(string) error
+	when     func(answers map[string]string) bool
+}
+
+var iaasQuestion = initQuestion{
+	key:     "iaas",
+	prompt:  "IaaS to deploy the director onto",
+	choices: []string{"aws", "azure", "gcp", "vsphere", "openstack"},
+}
+
+var iaasQuestions = map[string][]initQuestion{
+	"aws": {
+		{key: "aws-access-key-id", prompt: "AWS access key ID"},
+		{key: "aws-secret-access-key", prompt: "AWS secret access key", secret: true},
+		{key: "aws-region", prompt: "AWS region, such as us-east-1"},
+	},
+	"azure": {
+		{key: "azure-subscription-id", prompt: "Azure subscription ID"},
+		{key: "azure-tenant-id", prompt: "Azure tenant ID"},
+		{key: "azure-client-id", prompt: "Azure client ID"},
+		{key: "azure-client-secret", prompt: "Azure client secret", secret: true},
+		{key: "azure-region", prompt: "Azure region, such as eastus"},
+	},
+	"gcp": {
+		{key: "gcp-service-account-key", prompt: "Path to the GCP service account key"},
+		{key: "gcp-region", prompt: "GCP region, such as us-east1"},
+	},
+	"vsphere": {
+		{key: "vsphere-vcenter-ip", prompt: "vSphere vCenter IP", validate: validateIP},
+		{key: "vsphere-vcenter-user", prompt: "vSphere vCenter user"},
+		{key: "vsphere-vcenter-password", prompt: "vSphere vCenter password", secret: true},
+		{key: "vsphere-vcenter-dc", prompt: "vSphere vCenter datacenter"},
+		{key: "vsphere-vcenter-cluster", prompt: "vSphere vCenter cluster"},
+		{key: "vsphere-vcenter-rp", prompt: "vSphere vCenter resource pool"},
+		{key: "vsphere-vcenter-ds", prompt: "vSphere vCenter datastore"},
+		{key: "vsphere-network", prompt: "vSphere network"},
+		{key: "vsphere-subnet", prompt: "vSphere subnet CIDR, such as 10.0.0.0/24", validate: validateCIDR},
+	},
+	"openstack": {
+		{key: "openstack-auth-url", prompt: "OpenStack auth URL", validate: validateURL},
+		{key: "openstack-username", prompt: "OpenStack username"},
+		{key: "openstack-password", prompt: "OpenStack password", secret: true},
+		{key: "openstack-project", prompt: "OpenStack project"},
+		{key: "openstack-domain", prompt: "OpenStack domain"},
+		{key: "openstack-region", prompt: "OpenStack region"},
+		{key: "openstack-az", prompt: "OpenStack availability zone"},
+		{key: "openstack-network-id", prompt: "OpenStack network ID"},
+		{key: "openstack-internal-cidr", prompt: "OpenStack internal CIDR, such as 10.0.0.0/24", validate: validateCIDR},
+		{key: "openstack-external-ip", prompt: "OpenStack external IP for the director", validate: validateIP},
+		{key: "openstack-default-key-name", prompt: "OpenStack default key name"},
+		{key: "openstack-default-security-group", prompt: "OpenStack default security group"},
+		{key: "openstack-private-key", prompt: "OpenStack private key"},
+	},
+}
+
+var envQuestions = []initQuestion{
+	{key: "name", prompt: "Name of the environment", optional: true},
+	{key: "lb-type", prompt: "Load balancer type", optional: true, choices: []string{"cf", "concourse"}, when: hasLBs},
+	{key: "lb-cert", prompt: "Path to the load balancer certificate", when: isCFLB},
+	{key: "lb-key", prompt: "Path to the load balancer certificate key", when: isCFLB},
+	{key: "lb-chain", prompt: "Path to the load balancer certificate chain", optional: true, when: func(answers map[string]string) bool {
+		return isCFLB(answers) && answers["iaas"] == "aws"
+	}},
+	{key: "lb-domain", prompt: "Domain to create DNS records for", optional: true, when: isCFLB},
+}
+
+func hasLBs(answers map[string]string) bool {
+	return len(lbTypes(answers["iaas"])) > 0
+}
+
+func isCFLB(answers map[string]string) bool {
+	return hasLBs(answers) && answers["lb-type"] == "cf"
+}
+
+func validateIP(value string) error {
+	if net.ParseIP(value) == nil {
+		return fmt.Errorf("%q is not an IP address.", value)
+	}
+	return nil
+}
+
+func validateCIDR(value string) error {
+	if _, _, err := net.ParseCIDR(value); err != nil {
+		return fmt.Errorf("%q is not a CIDR block.", value)
+	}
+	return nil
+}
+
+func validateURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL.", value)
+	}
+	return nil
+}
+
+func NewInit(logger logger, input io.Reader, checker credentialsChecker, lbArgsHandler lbArgsHandler, fs initFs, cipher storage.Cipher, stateDir, configFile string) Init {
+	terminal, _ := input.(*os.File)
+
+	return Init{
+		logger:        logger,
+		input:         bufio.NewReader(input),
+		terminal:      terminal,
+		checker:       checker,
+		lbArgsHandler: lbArgsHandler,
+		fs:            fs,
+		cipher:        cipher,
+		stateDir:      stateDir,
+		configFile:    configFile,
+	}
+}
+
+func initFlags(args *initArgs) flags.Flags {
+	f := flags.New("init")
+	f.String(&args.answers, "answers", "")
+	f.Bool(&args.skipChecks, "skip-checks")
+	return f
+}
+
+func (i Init) Flags() []flags.Flags {
+	return []flags.Flags{initFlags(&initArgs{})}
+}
+
+func (i Init) parseArgs(subcommandFlags []string) (initArgs, error) {
+	var args initArgs
+	err := initFlags(&args).Parse(subcommandFlags)
+	if err != nil {
+		return initArgs{}, err
+	}
+	return args, nil
+}
+
+func (i Init) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := i.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if _, err := i.fs.Stat(i.configFile); err == nil {
+		return fmt.Errorf("%s already exists. Edit it, or remove it to start over.", i.configFile)
+	}
+
+	if _, err := i.fs.Stat(filepath.Join(i.stateDir, storage.STATE_FILE)); err == nil {
+		return fmt.Errorf("%s already has an environment. bbl init only sets up new ones.", i.stateDir)
+	}
+
+	return nil
+}
+
+func (i Init) Execute(subcommandFlags []string, state storage.State) error {
+	args, err := i.parseArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	var given map[string]string
+	if args.answers != "" {
+		given, err = i.readAnswers(args.answers)
+		if err != nil {
+			return err
+		}
+	}
+
+	answers := map[string]string{}
+	asked := []initQuestion{}
+	questions := []initQuestion{iaasQuestion}
+	for n := 0; n < len(questions); n++ {
+		question := questions[n]
+		if question.when != nil && !question.when(answers) {
+			continue
+		}
+		asked = append(asked, question)
+
+		answer, err := i.answer(question, answers, given, args.answers)
+		if err != nil {
+			return err
+		}
+		if answer != "" {
+			answers[question.key] = answer
+		}
+
+		if question.key == "iaas" {
+			questions = append(questions, iaasQuestions[answer]...)
+			questions = append(questions, envQuestions...)
+		}
+	}
+
+	for key := range given {
+		if !wasAsked(asked, key) {
+			return fmt.Errorf("%s does not apply to this environment. Remove it from %s.", key, args.answers)
+		}
+	}
+
+	if !args.skipChecks {
+		err = i.check(answers)
+		if err != nil {
+			return err
+		}
+	}
+
+	// bbl.yml is read before the state can be decrypted, so with encryption
+	// enabled the secret answers are left out of it rather than written in
+	// plain text next to the encrypted state.
+	written := answers
+	withheld := []initQuestion{}
+	if i.cipher.Enabled() {
+		written = map[string]string{}
+		for key, value := range answers {
+			written[key] = value
+		}
+		for _, question := range asked {
+			if question.secret && answers[question.key] != "" {
+				delete(written, question.key)
+				withheld = append(withheld, question)
+			}
+		}
+	}
+
+	contents, err := yaml.Marshal(orderAnswers(asked, written))
+	if err != nil {
+		return err // not tested
+	}
+
+	err = i.fs.MkdirAll(i.stateDir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("Create state directory: %s", err)
+	}
+
+	err = i.fs.WriteFile(i.configFile, contents, storage.ConfigMode)
+	if err != nil {
+		return fmt.Errorf("Write %s: %s", i.configFile, err)
+	}
+
+	i.logger.Println(fmt.Sprintf("Wrote %s. Run bbl up to create the environment.", i.configFile))
+	if len(withheld) > 0 {
+		i.logger.Println(fmt.Sprintf("The state is encrypted, so %s does not hold your secret %s credentials. Set them in the environment before running bbl up:", i.configFile, answers["iaas"]))
+		for _, question := range withheld {
+			i.logger.Println(fmt.Sprintf("  %s", envVar(question.key)))
+		}
+		return nil
+	}
+	for _, question := range asked {
+		if question.secret && answers[question.key] != "" {
+			i.logger.Println(fmt.Sprintf("%s holds your %s credentials and only you can read it. Do not commit it or share it.", i.configFile, answers["iaas"]))
+			break
+		}
+	}
+	return nil
+}
+
+// envVar returns the environment variable that sets the global flag key.
+func envVar(key string) string {
+	return "BBL_" + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+}
+
+// answer returns the answer to question from the answers file, or asks
+// for it until the answer is valid when there is no answers file.
+func (i Init) answer(question initQuestion, answers, given map[string]string, answersFile string) (string, error) {
+	if given != nil {
+		value := given[question.key]
+		if value == "" && !question.optional {
+			return "", fmt.Errorf("No answer for %s in %s.", question.key, answersFile)
+		}
+
+		err := i.validate(question, value, answers)
+		if err != nil {
+			return "", fmt.Errorf("Invalid answer for %s: %s", question.key, err)
+		}
+		return value, nil
+	}
+
+	for {
+		i.logger.Printf("%s: ", promptFor(question))
+
+		line, readErr := i.readLine(question.secret)
+		value := strings.TrimSpace(line)
+		if readErr != nil && value == "" {
+			if question.optional {
+				i.logger.Printf("\n")
+				return "", nil
+			}
+			return "", fmt.Errorf("No answer for %s. Pass the answers with --answers when there is no terminal.", question.key)
+		}
+
+		err := i.validate(question, value, answers)
+		if err == nil {
+			return value, nil
+		}
+		i.logger.Println(err.Error())
+	}
+}
+
+// readLine reads an answer. The answer to a secret question is not shown
+// as it is typed on a terminal.
+func (i Init) readLine(secret bool) (string, error) {
+	if secret && i.terminal != nil {
+		show, err := ssh.HideInput(i.terminal.Fd())
+		if err == nil {
+			defer func() {
+				show()
+				i.logger.Printf("\n")
+			}()
+		}
+	}
+
+	return i.input.ReadString('\n')
+}
+
+func (i Init) validate(question initQuestion, value string, answers map[string]string) error {
+	if value == "" {
+		if question.optional {
+			return nil
+		}
+		return fmt.Errorf("%s is required.", question.key)
+	}
+
+	if len(question.choices) > 0 {
+		known := false
+		for _, choice := range question.choices {
+			known = known || choice == value
+		}
+		if !known {
+			return fmt.Errorf("%q is not one of %s.", value, strings.Join(question.choices, ", "))
+		}
+	}
+
+	if question.key == "gcp-service-account-key" && !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		if _, err := i.fs.Stat(value); err != nil {
+			return fmt.Errorf("%s does not exist. Give the path to the key file or its JSON contents.", value)
+		}
+	}
+
+	if question.validate != nil {
+		return question.validate(value)
+	}
+
+	return nil
+}
+
+// check asks the IaaS whether the credentials work, and reads the load
+// balancer certificate the way bbl up will.
+func (i Init) check(answers map[string]string) error {
+	i.logger.Step("checking the %s credentials", answers["iaas"])
+	err := i.checker.CheckCredentials(answers)
+	if err != nil {
+		return fmt.Errorf("%s\nFix the answer and run bbl init again, or pass --skip-checks to write bbl.yml without checking it.", err)
+	}
+
+	if answers["lb-type"] != "" {
+		_, err = i.lbArgsHandler.GetLBState(answers["iaas"], LBArgs{
+			LBType:    answers["lb-type"],
+			CertPath:  answers["lb-cert"],
+			KeyPath:   answers["lb-key"],
+			ChainPath: answers["lb-chain"],
+			Domain:    answers["lb-domain"],
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i Init) readAnswers(path string) (map[string]string, error) {
+	contents, err := i.fs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Read answers file: %s", err)
+	}
+
+	var answers map[string]string
+	err = yaml.Unmarshal(contents, &answers)
+	if err != nil {
+		return nil, fmt.Errorf("Parse answers file: %s", err)
+	}
+
+	known := map[string]bool{iaasQuestion.key: true}
+	for _, questions := range iaasQuestions {
+		for _, question := range questions {
+			known[question.key] = true
+		}
+	}
+	for _, question := range envQuestions {
+		known[question.key] = true
+	}
+
+	for key := range answers {
+		if !known[key] {
+			return nil, fmt.Errorf("Unknown key %q in the answers file. The keys are the ones bbl init writes to bbl.yml.", key)
+		}
+	}
+
+	if answers == nil {
+		answers = map[string]string{}
+	}
+
+	return answers, nil
+}
+
+func promptFor(question initQuestion) string {
+	details := []string{"--" + question.key}
+	if len(question.choices) > 0 {
+		details = append(details, strings.Join(question.choices, ", "))
+	}
+	if question.optional {
+		details = append(details, "optional")
+	}
+	return fmt.Sprintf("%s (%s)", question.prompt, strings.Join(details, ", "))
+}
+
+func wasAsked(questions []initQuestion, key string) bool {
+	for _, question := range questions {
+		if question.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func orderAnswers(questions []initQuestion, answers map[string]string) yaml.MapSlice {
+	ordered := yaml.MapSlice{}
+	for _, question := range questions {
+		if value, ok := answers[question.key]; ok {
+			ordered = append(ordered, yaml.MapItem{Key: question.key, Value: value})
+		}
+	}
+	return ordered
+}
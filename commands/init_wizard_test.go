@@ -0,0 +1,267 @@
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Init", func() {
+	var (
+		logger        *fakes.Logger
+		checker       *fakes.CredentialsChecker
+		lbArgsHandler *fakes.LBArgsHandler
+		fileIO        *fakes.FileIO
+		cipher        storage.Cipher
+		input         string
+		command       commands.Init
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		checker = &fakes.CredentialsChecker{}
+		lbArgsHandler = &fakes.LBArgsHandler{}
+		fileIO = &fakes.FileIO{}
+		fileIO.StatCall.Returns.Error = os.ErrNotExist
+		cipher = storage.Cipher{}
+		input = ""
+	})
+
+	JustBeforeEach(func() {
+		command = commands.NewInit(logger, strings.NewReader(input), checker, lbArgsHandler, fileIO, cipher, "/state", "/state/bbl.yml")
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when bbl.yml already exists", func() {
+			fileIO.StatCall.Returns.Error = nil
+
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("/state/bbl.yml already exists. Edit it, or remove it to start over."))
+		})
+
+		It("returns an error when the state directory already has an environment", func() {
+			fileIO.StatCall.Fake = func(name string) (os.FileInfo, error) {
+				if name == "/state/bbl-state.json" {
+					return nil, nil
+				}
+				return nil, os.ErrNotExist
+			}
+
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("/state already has an environment. bbl init only sets up new ones."))
+		})
+	})
+
+	Describe("Execute", func() {
+		Context("when answering the prompts", func() {
+			BeforeEach(func() {
+				input = "aws\nsome-key-id\nsome-secret\nus-east-1\n\ncf\n/certs/lb.crt\n/certs/lb.key\n\nexample.com\n"
+			})
+
+			It("asks for the IaaS and its settings and writes bbl.yml", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{
+					"IaaS to deploy the director onto (--iaas, aws, azure, gcp, vsphere, openstack): ",
+					"AWS access key ID (--aws-access-key-id): ",
+					"AWS secret access key (--aws-secret-access-key): ",
+					"AWS region, such as us-east-1 (--aws-region): ",
+					"Name of the environment (--name, optional): ",
+					"Load balancer type (--lb-type, cf, concourse, optional): ",
+					"Path to the load balancer certificate (--lb-cert): ",
+					"Path to the load balancer certificate key (--lb-key): ",
+					"Path to the load balancer certificate chain (--lb-chain, optional): ",
+					"Domain to create DNS records for (--lb-domain, optional): ",
+				}))
+
+				Expect(fileIO.MkdirAllCall.Receives.Dir).To(Equal("/state"))
+				Expect(fileIO.WriteFileCall.Receives).To(HaveLen(1))
+				Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal("/state/bbl.yml"))
+				Expect(fileIO.WriteFileCall.Receives[0].Mode).To(Equal(os.FileMode(0600)))
+				Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(Equal(`iaas: aws
+aws-access-key-id: some-key-id
+aws-secret-access-key: some-secret
+aws-region: us-east-1
+lb-type: cf
+lb-cert: /certs/lb.crt
+lb-key: /certs/lb.key
+lb-domain: example.com
+`))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					"Wrote /state/bbl.yml. Run bbl up to create the environment.",
+					"/state/bbl.yml holds your aws credentials and only you can read it. Do not commit it or share it.",
+				}))
+			})
+
+			Context("when the state is encrypted", func() {
+				BeforeEach(func() {
+					cipher = storage.NewCipher([]byte("some-passphrase"))
+				})
+
+				It("leaves the secret answers out of bbl.yml and names their environment variables", func() {
+					err := command.Execute([]string{}, storage.State{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(checker.CheckCredentialsCall.Receives.Answers).To(HaveKeyWithValue("aws-secret-access-key", "some-secret"))
+					Expect(fileIO.WriteFileCall.Receives).To(HaveLen(1))
+					Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(Equal(`iaas: aws
+aws-access-key-id: some-key-id
+aws-region: us-east-1
+lb-type: cf
+lb-cert: /certs/lb.crt
+lb-key: /certs/lb.key
+lb-domain: example.com
+`))
+					Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+						"Wrote /state/bbl.yml. Run bbl up to create the environment.",
+						"The state is encrypted, so /state/bbl.yml does not hold your secret aws credentials. Set them in the environment before running bbl up:",
+						"  BBL_AWS_SECRET_ACCESS_KEY",
+					}))
+				})
+			})
+
+			It("checks the credentials and the certificate", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.StepCall.Messages).To(Equal([]string{"checking the aws credentials"}))
+				Expect(checker.CheckCredentialsCall.Receives.Answers).To(HaveKeyWithValue("aws-region", "us-east-1"))
+				Expect(lbArgsHandler.GetLBStateCall.Receives.IAAS).To(Equal("aws"))
+				Expect(lbArgsHandler.GetLBStateCall.Receives.Args).To(Equal(commands.LBArgs{
+					LBType:   "cf",
+					CertPath: "/certs/lb.crt",
+					KeyPath:  "/certs/lb.key",
+					Domain:   "example.com",
+				}))
+			})
+
+			Context("when --skip-checks is given", func() {
+				It("does not check the credentials", func() {
+					err := command.Execute([]string{"--skip-checks"}, storage.State{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(checker.CheckCredentialsCall.CallCount).To(Equal(0))
+					Expect(lbArgsHandler.GetLBStateCall.CallCount).To(Equal(0))
+					Expect(fileIO.WriteFileCall.CallCount).To(Equal(1))
+				})
+			})
+
+			Context("when the credentials do not work", func() {
+				It("returns an error without writing bbl.yml", func() {
+					checker.CheckCredentialsCall.Returns.Error = errors.New("AWS rejected the credentials: AuthFailure")
+
+					err := command.Execute([]string{}, storage.State{})
+					Expect(err).To(MatchError("AWS rejected the credentials: AuthFailure\nFix the answer and run bbl init again, or pass --skip-checks to write bbl.yml without checking it."))
+					Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when an answer is invalid", func() {
+			BeforeEach(func() {
+				input = "openstak\nvsphere\n10.0.0.300\n10.0.0.3\n"
+			})
+
+			It("says why and asks again", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("No answer for vsphere-vcenter-user. Pass the answers with --answers when there is no terminal."))
+
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					`"openstak" is not one of aws, azure, gcp, vsphere, openstack.`,
+					`"10.0.0.300" is not an IP address.`,
+				}))
+				Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the answers come from a file", func() {
+			BeforeEach(func() {
+				fileIO.ReadFileCall.Returns.Contents = []byte(`
+iaas: gcp
+gcp-service-account-key: '{"project_id": "some-project"}'
+gcp-region: us-east1
+name: no
+lb-type: concourse
+`)
+			})
+
+			It("writes bbl.yml without asking", func() {
+				err := command.Execute([]string{"--answers", "answers.yml"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fileIO.ReadFileCall.Receives.Filename).To(Equal("answers.yml"))
+				Expect(logger.PrintfCall.CallCount).To(Equal(0))
+				Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(Equal(`iaas: gcp
+gcp-service-account-key: '{"project_id": "some-project"}'
+gcp-region: us-east1
+name: "no"
+lb-type: concourse
+`))
+			})
+
+			Context("when an answer is invalid", func() {
+				It("returns an error", func() {
+					fileIO.ReadFileCall.Returns.Contents = []byte("iaas: vsphere\nvsphere-vcenter-ip: vcenter\n")
+
+					err := command.Execute([]string{"--answers", "answers.yml"}, storage.State{})
+					Expect(err).To(MatchError(`Invalid answer for vsphere-vcenter-ip: "vcenter" is not an IP address.`))
+				})
+			})
+
+			Context("when a required answer is missing", func() {
+				It("returns an error", func() {
+					fileIO.ReadFileCall.Returns.Contents = []byte("iaas: aws\naws-access-key-id: a\n")
+
+					err := command.Execute([]string{"--answers", "answers.yml"}, storage.State{})
+					Expect(err).To(MatchError("No answer for aws-secret-access-key in answers.yml."))
+				})
+			})
+
+			Context("when an answer does not apply to the IaaS", func() {
+				It("returns an error", func() {
+					fileIO.ReadFileCall.Returns.Contents = []byte("iaas: aws\naws-access-key-id: a\naws-secret-access-key: b\naws-region: c\ngcp-region: us-east1\n")
+
+					err := command.Execute([]string{"--answers", "answers.yml"}, storage.State{})
+					Expect(err).To(MatchError("gcp-region does not apply to this environment. Remove it from answers.yml."))
+				})
+			})
+
+			Context("when a key is unknown", func() {
+				It("returns an error", func() {
+					fileIO.ReadFileCall.Returns.Contents = []byte("iaas: aws\nzone: us-east-1a\n")
+
+					err := command.Execute([]string{"--answers", "answers.yml"}, storage.State{})
+					Expect(err).To(MatchError(`Unknown key "zone" in the answers file. The keys are the ones bbl init writes to bbl.yml.`))
+				})
+			})
+
+			Context("when the gcp service account key file does not exist", func() {
+				It("returns an error", func() {
+					fileIO.ReadFileCall.Returns.Contents = []byte("iaas: gcp\ngcp-service-account-key: /no/key.json\n")
+
+					err := command.Execute([]string{"--answers", "answers.yml"}, storage.State{})
+					Expect(err).To(MatchError("Invalid answer for gcp-service-account-key: /no/key.json does not exist. Give the path to the key file or its JSON contents."))
+				})
+			})
+		})
+
+		Context("when bbl.yml cannot be written", func() {
+			It("returns an error", func() {
+				input = "vsphere\n10.0.0.3\nuser\npassword\ndc\ncluster\nrp\nds\nnetwork\n10.0.0.0/24\n\n"
+				fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("disk full")}}
+				command = commands.NewInit(logger, strings.NewReader(input), checker, lbArgsHandler, fileIO, cipher, "/state", "/state/bbl.yml")
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).To(MatchError("Write /state/bbl.yml: disk full"))
+			})
+		})
+	})
+})
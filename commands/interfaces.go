@@ -23,8 +23,11 @@ type up interface {
 type terraformManager interface {
 	ValidateVersion() error
 	GetOutputs() (terraform.Outputs, error)
+	CachedOutputs(storage.State) (terraform.Outputs, error)
 	Init(storage.State) error
 	Apply(storage.State) (storage.State, error)
+	Plan(storage.State) (string, error)
+	Drift(storage.State) ([]terraform.ResourceDrift, error)
 	Destroy(storage.State) (storage.State, error)
 	IsPaved() (bool, error)
 }
@@ -34,6 +37,8 @@ type boshManager interface {
 	CreateDirector(bblState storage.State, terraformOutputs terraform.Outputs) (storage.State, error)
 	InitializeJumpbox(bblState storage.State) error
 	CreateJumpbox(bblState storage.State, terraformOutputs terraform.Outputs) (storage.State, error)
+	InterpolateJumpbox(bblState storage.State, terraformOutputs terraform.Outputs) (string, error)
+	InterpolateDirector(bblState storage.State, terraformOutputs terraform.Outputs) (string, error)
 	DeleteDirector(bblState storage.State, terraformOutputs terraform.Outputs) error
 	DeleteJumpbox(bblState storage.State, terraformOutputs terraform.Outputs) error
 	GetDirectorDeploymentVars(bblState storage.State, terraformOutputs terraform.Outputs) string
@@ -102,6 +107,8 @@ type cloudConfigManager interface {
 	Initialize(state storage.State) error
 	GenerateVars(state storage.State) error
 	Interpolate() (string, error)
+	Current(state storage.State) (string, error)
+	Applied() (string, error)
 	IsPresentCloudConfig() bool
 	IsPresentCloudConfigVars() bool
 }
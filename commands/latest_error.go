@@ -27,3 +27,7 @@ func (l LatestError) Execute(subcommandFlags []string, bblState storage.State) e
 	l.logger.Println(bblState.LatestTFOutput)
 	return nil
 }
+
+func (l LatestError) ExecuteJSON(subcommandFlags []string, bblState storage.State) error {
+	return printJSON(l.logger, map[string]string{"latest_terraform_output": bblState.LatestTFOutput})
+}
@@ -51,4 +51,13 @@ var _ = Describe("latest-error", func() {
 			Expect(logger.PrintlnCall.Messages).To(ContainElement("some tf output"))
 		})
 	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the latest terraform output as json", func() {
+			err := command.ExecuteJSON([]string{}, storage.State{LatestTFOutput: "some tf output"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"latest_terraform_output": "some tf output"}`))
+		})
+	})
 })
@@ -21,6 +21,15 @@ type LBArgs struct {
 	Domain    string
 }
 
+// lbTypes returns the load balancer types that bbl can create on iaas.
+func lbTypes(iaas string) []string {
+	switch iaas {
+	case "aws", "azure", "gcp":
+		return []string{"cf", "concourse"}
+	}
+	return []string{}
+}
+
 func NewLBArgsHandler(certificateValidator certificateValidator) LBArgsHandler {
 	return LBArgsHandler{
 		certificateValidator: certificateValidator,
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/certs"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// LBDescriptionVersion changes when a field of LBDescription is removed or
+// changes meaning. New fields do not change it.
+const LBDescriptionVersion = 1
+
+// LBDescription is what bbl lbs prints with --json and --yaml. It has the
+// same shape on every IaaS; see docs/advanced-configuration.md#lbs.
+type LBDescription struct {
+	Version                int          `json:"version" yaml:"version"`
+	IAAS                   string       `json:"iaas" yaml:"iaas"`
+	Type                   string       `json:"type" yaml:"type"`
+	Domain                 string       `json:"domain,omitempty" yaml:"domain,omitempty"`
+	Endpoints              []LBEndpoint `json:"endpoints" yaml:"endpoints"`
+	DNSNameServers         []string     `json:"dnsNameServers" yaml:"dnsNameServers"`
+	CertificateFingerprint string       `json:"certificateFingerprint,omitempty" yaml:"certificateFingerprint,omitempty"`
+}
+
+// LBEndpoint is one load balancer. Purpose is router, ssh-proxy,
+// tcp-router, websocket or concourse. Address is an IP or a DNS name.
+type LBEndpoint struct {
+	Purpose string `json:"purpose" yaml:"purpose"`
+	Name    string `json:"name,omitempty" yaml:"name,omitempty"`
+	Address string `json:"address,omitempty" yaml:"address,omitempty"`
+}
+
+func lbsFlags(asJSON, asYAML *bool) flags.Flags {
+	f := flags.New("lbs")
+	f.Bool(asJSON, "json")
+	f.Bool(asYAML, "yaml")
+	return f
+}
+
+// parseLBFormat returns json or yaml when subcommandFlags ask for a
+// machine readable description, and "" for text.
+func parseLBFormat(subcommandFlags []string) (string, error) {
+	var asJSON, asYAML bool
+	err := lbsFlags(&asJSON, &asYAML).Parse(subcommandFlags)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case asJSON && asYAML:
+		return "", errors.New("--json and --yaml cannot be used together.")
+	case asJSON:
+		return "json", nil
+	case asYAML:
+		return "yaml", nil
+	}
+	return "", nil
+}
+
+func newLBDescription(state storage.State, endpoints []LBEndpoint, dnsNameServers []string) (LBDescription, error) {
+	if dnsNameServers == nil {
+		dnsNameServers = []string{}
+	}
+
+	description := LBDescription{
+		Version:        LBDescriptionVersion,
+		IAAS:           state.IAAS,
+		Type:           state.LB.Type,
+		Domain:         state.LB.Domain,
+		Endpoints:      endpoints,
+		DNSNameServers: dnsNameServers,
+	}
+
+	if state.LB.Cert != "" {
+		cert, err := lbCertificate(state)
+		if err != nil {
+			return LBDescription{}, fmt.Errorf("LB certificate fingerprint: %s", err)
+		}
+
+		fingerprint, err := certs.Fingerprint(cert)
+		if err != nil {
+			return LBDescription{}, fmt.Errorf("LB certificate fingerprint: %s", err)
+		}
+		description.CertificateFingerprint = fingerprint
+	}
+
+	return description, nil
+}
+
+// lbCertificate returns the load balancer certificate as PEM. On Azure it
+// is stored as a base64 encoded PFX with its password in the lb key.
+func lbCertificate(state storage.State) ([]byte, error) {
+	if state.IAAS == "azure" {
+		return certs.PFXToPEM([]byte(state.LB.Cert), state.LB.Key)
+	}
+	return []byte(state.LB.Cert), nil
+}
+
+func printLBDescription(logger logger, format string, description LBDescription) error {
+	if format == "yaml" {
+		contents, err := yaml.Marshal(description)
+		if err != nil {
+			return err // not tested
+		}
+		logger.Printf("%s", contents)
+		return nil
+	}
+
+	return printJSON(logger, description)
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
@@ -20,6 +21,11 @@ func NewLBs(lbs LBsCmd, stateValidator stateValidator) LBs {
 	}
 }
 
+func (l LBs) Flags() []flags.Flags {
+	var asJSON, asYAML bool
+	return []flags.Flags{lbsFlags(&asJSON, &asYAML)}
+}
+
 func (l LBs) CheckFastFails(subcommandFlags []string, state storage.State) error {
 	err := l.stateValidator.Validate()
 	if err != nil {
@@ -32,3 +38,7 @@ func (l LBs) CheckFastFails(subcommandFlags []string, state storage.State) error
 func (l LBs) Execute(subcommandFlags []string, state storage.State) error {
 	return l.lbs.Execute(subcommandFlags, state)
 }
+
+func (l LBs) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return l.lbs.Execute(append([]string{"--json"}, subcommandFlags...), state)
+}
@@ -66,4 +66,16 @@ var _ = Describe("LBs", func() {
 			})
 		})
 	})
+
+	Describe("ExecuteJSON", func() {
+		It("asks the iaas specific command for json", func() {
+			incomingState := storage.State{IAAS: "gcp"}
+
+			err := lbsCommand.ExecuteJSON([]string{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(lbs.ExecuteCall.Receives.SubcommandFlags).To(Equal([]string{"--json"}))
+			Expect(lbs.ExecuteCall.Receives.State).To(Equal(incomingState))
+		})
+	})
 })
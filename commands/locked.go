@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateLocker interface {
+	GetLock() (storage.Lock, error)
+	CheckLock() error
+	Lock(command string) error
+	Unlock() error
+	ForceUnlock() error
+	RecordOperation(command string, succeeded bool) error
+}
+
+type stateLoader interface {
+	LoadState() (storage.State, error)
+}
+
+// Locked runs a command while holding the state lock. The state the
+// command was given was loaded before the lock was taken, so it is loaded
+// again from the state directory once the lock is held.
+type Locked struct {
+	command Command
+	name    string
+	locker  stateLocker
+	loader  stateLoader
+}
+
+func NewLocked(command Command, name string, locker stateLocker, loader stateLoader) Locked {
+	return Locked{
+		command: command,
+		name:    name,
+		locker:  locker,
+		loader:  loader,
+	}
+}
+
+func (l Locked) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := l.locker.CheckLock()
+	if err != nil {
+		return err
+	}
+
+	return l.command.CheckFastFails(subcommandFlags, state)
+}
+
+func (l Locked) Execute(subcommandFlags []string, state storage.State) error {
+	return l.run(func(state storage.State) error {
+		return l.command.Execute(subcommandFlags, state)
+	})
+}
+
+func (l Locked) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	command, ok := jsonCommand(l.command)
+	if !ok {
+		return fmt.Errorf("bbl %s does not support --json", l.name)
+	}
+
+	return l.run(func(state storage.State) error {
+		return command.ExecuteJSON(subcommandFlags, state)
+	})
+}
+
+func (l Locked) run(execute func(storage.State) error) (err error) {
+	err = l.locker.Lock(l.name)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		unlockErr := l.locker.Unlock()
+		if unlockErr != nil && err == nil {
+			err = fmt.Errorf("Release lock: %s", unlockErr)
+		}
+	}()
+
+	state, err := l.loader.LoadState()
+	if err != nil {
+		return err
+	}
+
+	err = execute(state)
+
+	recordErr := l.locker.RecordOperation(l.name, err == nil)
+	if recordErr != nil && err == nil {
+		err = fmt.Errorf("Record operation: %s", recordErr)
+	}
+
+	return err
+}
+
+func (l Locked) Usage() string {
+	return l.command.Usage()
+}
@@ -0,0 +1,199 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locked", func() {
+	var (
+		command *fakes.Command
+		locker  *fakes.StateLocker
+		loader  *fakes.StateLoader
+		locked  commands.Locked
+		state   storage.State
+	)
+
+	BeforeEach(func() {
+		command = &fakes.Command{}
+		locker = &fakes.StateLocker{}
+		loader = &fakes.StateLoader{}
+		state = storage.State{EnvID: "some-env-id"}
+		loader.LoadStateCall.Returns.State = storage.State{EnvID: "reloaded-env-id"}
+
+		locked = commands.NewLocked(command, "up", locker, loader)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("checks the lock and then the wrapped command", func() {
+			err := locked.CheckFastFails([]string{"--some-flag"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(locker.CheckLockCall.CallCount).To(Equal(1))
+			Expect(command.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(command.CheckFastFailsCall.Receives.State).To(Equal(state))
+		})
+
+		Context("when someone else holds the lock", func() {
+			BeforeEach(func() {
+				locker.CheckLockCall.Returns.Error = errors.New("locked by someone")
+			})
+
+			It("fails fast without checking the wrapped command", func() {
+				err := locked.CheckFastFails([]string{}, state)
+				Expect(err).To(MatchError("locked by someone"))
+				Expect(command.CheckFastFailsCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Execute", func() {
+		It("holds the lock while the wrapped command runs", func() {
+			err := locked.Execute([]string{"--some-flag"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(locker.LockCall.Receives.Command).To(Equal("up"))
+			Expect(command.ExecuteCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(locker.UnlockCall.CallCount).To(Equal(1))
+		})
+
+		It("runs the wrapped command with the state loaded once the lock is held", func() {
+			err := locked.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(loader.LoadStateCall.CallCount).To(Equal(1))
+			Expect(command.ExecuteCall.Receives.State).To(Equal(storage.State{EnvID: "reloaded-env-id"}))
+		})
+
+		Context("when the state cannot be loaded again", func() {
+			BeforeEach(func() {
+				loader.LoadStateCall.Returns.Error = errors.New("fig")
+			})
+
+			It("releases the lock without running the wrapped command", func() {
+				err := locked.Execute([]string{}, state)
+				Expect(err).To(MatchError("fig"))
+				Expect(command.ExecuteCall.CallCount).To(Equal(0))
+				Expect(locker.UnlockCall.CallCount).To(Equal(1))
+			})
+		})
+
+		It("records that the command succeeded", func() {
+			err := locked.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(locker.RecordOperationCall.Receives.Command).To(Equal("up"))
+			Expect(locker.RecordOperationCall.Receives.Succeeded).To(BeTrue())
+		})
+
+		Context("when the lock cannot be taken", func() {
+			BeforeEach(func() {
+				locker.LockCall.Returns.Error = errors.New("locked by someone")
+			})
+
+			It("does not run the wrapped command", func() {
+				err := locked.Execute([]string{}, state)
+				Expect(err).To(MatchError("locked by someone"))
+				Expect(command.ExecuteCall.CallCount).To(Equal(0))
+				Expect(locker.UnlockCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the wrapped command fails", func() {
+			BeforeEach(func() {
+				command.ExecuteCall.Returns.Error = errors.New("apple")
+				locker.UnlockCall.Returns.Error = errors.New("banana")
+			})
+
+			It("releases the lock and returns the command error", func() {
+				err := locked.Execute([]string{}, state)
+				Expect(err).To(MatchError("apple"))
+				Expect(locker.UnlockCall.CallCount).To(Equal(1))
+			})
+
+			It("records that the command failed", func() {
+				locker.RecordOperationCall.Returns.Error = errors.New("cherry")
+
+				err := locked.Execute([]string{}, state)
+				Expect(err).To(MatchError("apple"))
+				Expect(locker.RecordOperationCall.Receives.Succeeded).To(BeFalse())
+			})
+		})
+
+		Context("when the operation cannot be recorded", func() {
+			BeforeEach(func() {
+				locker.RecordOperationCall.Returns.Error = errors.New("cherry")
+			})
+
+			It("returns an error and releases the lock", func() {
+				err := locked.Execute([]string{}, state)
+				Expect(err).To(MatchError("Record operation: cherry"))
+				Expect(locker.UnlockCall.CallCount).To(Equal(1))
+			})
+		})
+
+		Context("when the lock cannot be released", func() {
+			BeforeEach(func() {
+				locker.UnlockCall.Returns.Error = errors.New("banana")
+			})
+
+			It("returns an error", func() {
+				err := locked.Execute([]string{}, state)
+				Expect(err).To(MatchError("Release lock: banana"))
+			})
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("holds the lock while the wrapped command prints json", func() {
+			jsonCommand := &fakes.JSONCommand{}
+			locked = commands.NewLocked(jsonCommand, "drift", locker, loader)
+
+			err := locked.ExecuteJSON([]string{"--some-flag"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(locker.LockCall.Receives.Command).To(Equal("drift"))
+			Expect(jsonCommand.ExecuteJSONCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(jsonCommand.ExecuteJSONCall.Receives.State).To(Equal(storage.State{EnvID: "reloaded-env-id"}))
+			Expect(locker.UnlockCall.CallCount).To(Equal(1))
+		})
+
+		It("looks through a sealed command", func() {
+			jsonCommand := &fakes.JSONCommand{}
+			locked = commands.NewLocked(commands.NewSealed(jsonCommand, "drift", &fakes.Sealer{}), "drift", locker, loader)
+
+			err := locked.ExecuteJSON([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jsonCommand.ExecuteJSONCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error when the wrapped command cannot print json", func() {
+			locked = commands.NewLocked(commands.NewSealed(command, "up", &fakes.Sealer{}), "up", locker, loader)
+
+			err := locked.ExecuteJSON([]string{}, state)
+			Expect(err).To(MatchError("bbl up does not support --json"))
+			Expect(locker.LockCall.CallCount).To(Equal(0))
+		})
+
+		It("looks through a logged command", func() {
+			locked = commands.NewLocked(commands.NewLogged(commands.NewSealed(command, "up", &fakes.Sealer{}), "up", &fakes.RunLog{}), "up", locker, loader)
+
+			err := locked.ExecuteJSON([]string{}, state)
+			Expect(err).To(MatchError("bbl up does not support --json"))
+			Expect(locker.LockCall.CallCount).To(Equal(0))
+		})
+	})
+
+	Describe("Usage", func() {
+		It("returns the wrapped command's usage", func() {
+			command.UsageCall.Returns.Usage = "some-usage"
+			Expect(locked.Usage()).To(Equal("some-usage"))
+		})
+	})
+})
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type runRecorder interface {
+	Start(command string, args []string, state storage.State) error
+	Finish(exitCode int, err error) error
+}
+
+// Logged writes a run log in the state directory while the command runs.
+type Logged struct {
+	command Command
+	name    string
+	runLog  runRecorder
+}
+
+func NewLogged(command Command, name string, runLog runRecorder) Logged {
+	return Logged{
+		command: command,
+		name:    name,
+		runLog:  runLog,
+	}
+}
+
+func (l Logged) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return l.command.CheckFastFails(subcommandFlags, state)
+}
+
+func (l Logged) Execute(subcommandFlags []string, state storage.State) error {
+	return l.run(subcommandFlags, state, func() error {
+		return l.command.Execute(subcommandFlags, state)
+	})
+}
+
+func (l Logged) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	command, ok := jsonCommand(l.command)
+	if !ok {
+		return fmt.Errorf("bbl %s does not support --json", l.name)
+	}
+
+	return l.run(subcommandFlags, state, func() error {
+		return command.ExecuteJSON(subcommandFlags, state)
+	})
+}
+
+func (l Logged) run(subcommandFlags []string, state storage.State, execute func() error) (err error) {
+	err = l.runLog.Start(l.name, redactArgs(l.command, subcommandFlags), state)
+	if err != nil {
+		return fmt.Errorf("Start run log: %s", err)
+	}
+
+	err = execute()
+
+	finishErr := l.runLog.Finish(exitCode(err), err)
+	if finishErr != nil && err == nil {
+		err = finishErr
+	}
+
+	return err
+}
+
+func (l Logged) Usage() string {
+	return l.command.Usage()
+}
+
+// exitCode returns the code bbl exits with when a command returns err.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(ExitError); ok {
+		return exitErr.Code
+	}
+	return 1
+}
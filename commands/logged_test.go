@@ -0,0 +1,131 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logged", func() {
+	var (
+		command *fakes.Command
+		runLog  *fakes.RunLog
+		logged  commands.Logged
+		state   storage.State
+	)
+
+	BeforeEach(func() {
+		command = &fakes.Command{}
+		runLog = &fakes.RunLog{}
+		state = storage.State{EnvID: "some-env-id"}
+
+		logged = commands.NewLogged(command, "up", runLog)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("checks the wrapped command without logging", func() {
+			command.CheckFastFailsCall.Returns.Error = errors.New("apple")
+
+			err := logged.CheckFastFails([]string{"--some-flag"}, state)
+			Expect(err).To(MatchError("apple"))
+			Expect(command.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(runLog.StartCall.CallCount).To(Equal(0))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("logs the run of the wrapped command", func() {
+			err := logged.Execute([]string{"--some-flag"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runLog.StartCall.Receives.Command).To(Equal("up"))
+			Expect(runLog.StartCall.Receives.Args).To(Equal([]string{"--some-flag"}))
+			Expect(runLog.StartCall.Receives.State).To(Equal(state))
+			Expect(command.ExecuteCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(runLog.FinishCall.Receives.ExitCode).To(Equal(0))
+			Expect(runLog.FinishCall.Receives.Error).To(BeNil())
+		})
+
+		It("leaves the secrets the wrapped command takes out of the logged args", func() {
+			varsSet := commands.NewVarsSet(&fakes.Logger{}, &fakes.StateValidator{}, &fakes.VarsEditor{}, &fakes.StateStore{})
+			logged = commands.NewLogged(commands.NewSealed(varsSet, "vars set", &fakes.Sealer{}), "vars set", runLog)
+
+			err := logged.Execute([]string{"--deployment=jumpbox", "some-name", "some-password"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(runLog.StartCall.Receives.Args).To(Equal([]string{"--deployment=jumpbox", "some-name", "REDACTED"}))
+		})
+
+		Context("when the wrapped command fails", func() {
+			It("logs exit code 1", func() {
+				command.ExecuteCall.Returns.Error = errors.New("apple")
+
+				err := logged.Execute([]string{}, state)
+				Expect(err).To(MatchError("apple"))
+				Expect(runLog.FinishCall.Receives.ExitCode).To(Equal(1))
+				Expect(runLog.FinishCall.Receives.Error).To(MatchError("apple"))
+			})
+
+			It("logs the exit code of an exit error", func() {
+				command.ExecuteCall.Returns.Error = commands.ExitError{Code: 2, Message: "drift found"}
+
+				err := logged.Execute([]string{}, state)
+				Expect(err).To(MatchError("drift found"))
+				Expect(runLog.FinishCall.Receives.ExitCode).To(Equal(2))
+			})
+
+			It("returns the command error over a run log error", func() {
+				command.ExecuteCall.Returns.Error = errors.New("apple")
+				runLog.FinishCall.Returns.Error = errors.New("banana")
+
+				err := logged.Execute([]string{}, state)
+				Expect(err).To(MatchError("apple"))
+			})
+		})
+
+		Context("when the run log cannot be started", func() {
+			It("does not run the wrapped command", func() {
+				runLog.StartCall.Returns.Error = errors.New("disk full")
+
+				err := logged.Execute([]string{}, state)
+				Expect(err).To(MatchError("Start run log: disk full"))
+				Expect(command.ExecuteCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when the run log cannot be finished", func() {
+			It("returns an error", func() {
+				runLog.FinishCall.Returns.Error = errors.New("Write run log: disk full")
+
+				err := logged.Execute([]string{}, state)
+				Expect(err).To(MatchError("Write run log: disk full"))
+			})
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("logs the run of the wrapped command", func() {
+			jsonCommand := &fakes.JSONCommand{}
+			logged = commands.NewLogged(jsonCommand, "drift", runLog)
+
+			err := logged.ExecuteJSON([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jsonCommand.ExecuteJSONCall.CallCount).To(Equal(1))
+			Expect(runLog.StartCall.Receives.Command).To(Equal("drift"))
+			Expect(runLog.FinishCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error without logging when the wrapped command cannot print json", func() {
+			logged = commands.NewLogged(commands.NewSealed(command, "up", &fakes.Sealer{}), "up", runLog)
+
+			err := logged.ExecuteJSON([]string{}, state)
+			Expect(err).To(MatchError("bbl up does not support --json"))
+			Expect(runLog.StartCall.CallCount).To(Equal(0))
+		})
+	})
+})
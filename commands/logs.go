@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type runLogs interface {
+	Runs() ([]storage.Run, error)
+	Run(id string) (storage.Run, []storage.LogEntry, error)
+}
+
+type Logs struct {
+	logger  logger
+	runLogs runLogs
+}
+
+type logsArgs struct {
+	last bool
+	id   string
+}
+
+type runDocument struct {
+	storage.Run
+	Entries []storage.LogEntry `json:"entries"`
+}
+
+func NewLogs(logger logger, runLogs runLogs) Logs {
+	return Logs{
+		logger:  logger,
+		runLogs: runLogs,
+	}
+}
+
+func logsFlags(args *logsArgs) flags.Flags {
+	f := flags.New("logs")
+	f.Bool(&args.last, "last")
+	f.String(&args.id, "id", "")
+	return f
+}
+
+func (l Logs) Flags() []flags.Flags {
+	return []flags.Flags{logsFlags(&logsArgs{})}
+}
+
+func parseLogsArgs(subcommandFlags []string) (logsArgs, error) {
+	var args logsArgs
+	err := logsFlags(&args).Parse(subcommandFlags)
+	if err != nil {
+		return logsArgs{}, err
+	}
+
+	if args.last && args.id != "" {
+		return logsArgs{}, errors.New("--last and --id cannot be used together.")
+	}
+
+	return args, nil
+}
+
+func (l Logs) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := parseLogsArgs(subcommandFlags)
+	return err
+}
+
+func (l Logs) Execute(subcommandFlags []string, state storage.State) error {
+	args, err := parseLogsArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if !args.last && args.id == "" {
+		return l.list()
+	}
+
+	run, entries, err := l.run(args)
+	if err != nil {
+		return err
+	}
+
+	l.logger.Printf("Run %s: bbl %s\n", run.ID, strings.TrimSpace(run.Command+" "+strings.Join(run.Args, " ")))
+	l.logger.Printf("Started %s with bbl %s, %s\n", run.StartedAt.Format(time.RFC3339), orNone(run.BBLVersion), outcome(run))
+	if run.Error != "" {
+		l.logger.Printf("Error: %s\n", run.Error)
+	}
+
+	l.logger.Printf("\n")
+	for _, entry := range entries {
+		switch entry.Type {
+		case storage.LogStep:
+			l.logger.Printf("%s %-9s step: %s\n", entry.Time.Format("15:04:05"), entry.Source, entry.Message)
+		case storage.LogOutput:
+			l.logger.Printf("%s %-9s %s\n", entry.Time.Format("15:04:05"), entry.Source, entry.Message)
+		}
+	}
+
+	if len(run.Steps) > 0 {
+		l.logger.Printf("\n%-50s %s\n", "STEP", "DURATION")
+		for _, step := range run.Steps {
+			l.logger.Printf("%-50s %s\n", step.Name, seconds(step.Duration))
+		}
+	}
+
+	return nil
+}
+
+func (l Logs) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	args, err := parseLogsArgs(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if !args.last && args.id == "" {
+		runs, err := l.runLogs.Runs()
+		if err != nil {
+			return err
+		}
+		return printJSON(l.logger, runs)
+	}
+
+	run, entries, err := l.run(args)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(l.logger, runDocument{Run: run, Entries: entries})
+}
+
+func (l Logs) list() error {
+	runs, err := l.runLogs.Runs()
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		l.logger.Println("No runs have been logged yet.")
+		return nil
+	}
+
+	l.logger.Printf("%-36s %-16s %-22s %-9s %s\n", "ID", "COMMAND", "STARTED", "DURATION", "RESULT")
+	for _, run := range runs {
+		duration := "-"
+		if run.Finished {
+			duration = run.Duration().Round(time.Second).String()
+		}
+		l.logger.Printf("%-36s %-16s %-22s %-9s %s\n", run.ID, run.Command, run.StartedAt.Format(time.RFC3339), duration, outcome(run))
+	}
+
+	return nil
+}
+
+func (l Logs) run(args logsArgs) (storage.Run, []storage.LogEntry, error) {
+	id := args.id
+	if args.last {
+		runs, err := l.runLogs.Runs()
+		if err != nil {
+			return storage.Run{}, nil, err
+		}
+		if len(runs) == 0 {
+			return storage.Run{}, nil, errors.New("No runs have been logged yet.")
+		}
+		id = runs[0].ID
+	}
+
+	return l.runLogs.Run(id)
+}
+
+func outcome(run storage.Run) string {
+	if !run.Finished {
+		return "did not finish"
+	}
+	if run.ExitCode == 0 {
+		return "succeeded"
+	}
+	return fmt.Sprintf("failed with exit code %d", run.ExitCode)
+}
+
+func seconds(s float64) string {
+	return (time.Duration(s * float64(time.Second))).Round(time.Second).String()
+}
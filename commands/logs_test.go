@@ -0,0 +1,165 @@
+package commands_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Logs", func() {
+	var (
+		logger  *fakes.Logger
+		runLog  *fakes.RunLog
+		command commands.Logs
+		started time.Time
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		runLog = &fakes.RunLog{}
+		started = time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+		runLog.RunsCall.Returns.Runs = []storage.Run{
+			{
+				ID:         "20180301T120500Z-destroy",
+				Command:    "destroy",
+				StartedAt:  started.Add(5 * time.Minute),
+				FinishedAt: started.Add(7 * time.Minute),
+				Finished:   true,
+				ExitCode:   1,
+			},
+			{
+				ID:        "20180301T120000Z-up",
+				Command:   "up",
+				StartedAt: started,
+			},
+		}
+
+		command = commands.NewLogs(logger, runLog)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when both --last and --id are given", func() {
+			err := command.CheckFastFails([]string{"--last", "--id", "some-id"}, storage.State{})
+			Expect(err).To(MatchError("--last and --id cannot be used together."))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("lists the runs", func() {
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"ID                                   COMMAND          STARTED                DURATION  RESULT\n",
+				"20180301T120500Z-destroy             destroy          2018-03-01T12:05:00Z   2m0s      failed with exit code 1\n",
+				"20180301T120000Z-up                  up               2018-03-01T12:00:00Z   -         did not finish\n",
+			}))
+		})
+
+		It("says so when no runs have been logged", func() {
+			runLog.RunsCall.Returns.Runs = []storage.Run{}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("No runs have been logged yet."))
+		})
+
+		Context("when a run is given", func() {
+			BeforeEach(func() {
+				runLog.RunCall.Returns.Run = storage.Run{
+					ID:         "20180301T120000Z-up",
+					Command:    "up",
+					Args:       []string{"--lb-type", "cf"},
+					BBLVersion: "7.0.0",
+					StartedAt:  started,
+					FinishedAt: started.Add(90 * time.Second),
+					Finished:   true,
+					Steps: []storage.RunStep{
+						{Name: "terraform apply", StartedAt: started, Duration: 61.7},
+					},
+				}
+				runLog.RunCall.Returns.Entries = []storage.LogEntry{
+					{Time: started, Type: storage.LogStart, Command: "up"},
+					{Time: started, Type: storage.LogStep, Source: "bbl", Message: "terraform apply"},
+					{Time: started.Add(time.Second), Type: storage.LogOutput, Source: "terraform", Message: "Apply complete!"},
+					{Time: started.Add(90 * time.Second), Type: storage.LogEnd},
+				}
+			})
+
+			It("prints the run with --id", func() {
+				err := command.Execute([]string{"--id", "20180301T120000Z-up"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(runLog.RunCall.Receives.ID).To(Equal("20180301T120000Z-up"))
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{
+					"Run 20180301T120000Z-up: bbl up --lb-type cf\n",
+					"Started 2018-03-01T12:00:00Z with bbl 7.0.0, succeeded\n",
+					"\n",
+					"12:00:00 bbl       step: terraform apply\n",
+					"12:00:01 terraform Apply complete!\n",
+					"\nSTEP                                               DURATION\n",
+					"terraform apply                                    1m2s\n",
+				}))
+			})
+
+			It("prints the latest run with --last", func() {
+				err := command.Execute([]string{"--last"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runLog.RunCall.Receives.ID).To(Equal("20180301T120500Z-destroy"))
+			})
+
+			It("prints the error of a failed run", func() {
+				runLog.RunCall.Returns.Run.ExitCode = 1
+				runLog.RunCall.Returns.Run.Error = "terraform apply failed"
+
+				err := command.Execute([]string{"--last"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger.PrintfCall.Messages[1:3]).To(Equal([]string{
+					"Started 2018-03-01T12:00:00Z with bbl 7.0.0, failed with exit code 1\n",
+					"Error: terraform apply failed\n",
+				}))
+			})
+		})
+
+		It("returns an error with --last when no runs have been logged", func() {
+			runLog.RunsCall.Returns.Runs = []storage.Run{}
+
+			err := command.Execute([]string{"--last"}, storage.State{})
+			Expect(err).To(MatchError("No runs have been logged yet."))
+		})
+
+		It("returns an error when the run does not exist", func() {
+			runLog.RunCall.Returns.Error = errors.New(`Run log "nope" does not exist.`)
+
+			err := command.Execute([]string{"--id", "nope"}, storage.State{})
+			Expect(err).To(MatchError(`Run log "nope" does not exist.`))
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the runs", func() {
+			err := command.ExecuteJSON([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring(`"id": "20180301T120500Z-destroy"`))
+		})
+
+		It("prints a run with its entries", func() {
+			runLog.RunCall.Returns.Run = storage.Run{ID: "20180301T120000Z-up", Command: "up"}
+			runLog.RunCall.Returns.Entries = []storage.LogEntry{
+				{Time: started, Type: storage.LogOutput, Source: "bbl", Message: "hello"},
+			}
+
+			err := command.ExecuteJSON([]string{"--id", "20180301T120000Z-up"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring(`"id": "20180301T120000Z-up"`))
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring(`"message": "hello"`))
+		})
+	})
+})
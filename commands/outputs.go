@@ -24,7 +24,7 @@ func (o Outputs) CheckFastFails(subcommandFlags []string, state storage.State) e
 }
 
 func (o Outputs) Execute(subcommandFlags []string, state storage.State) error {
-	outputs, err := o.terraformManager.GetOutputs()
+	outputs, err := o.terraformManager.CachedOutputs(state)
 	if err != nil {
 		return err
 	}
@@ -35,3 +35,12 @@ func (o Outputs) Execute(subcommandFlags []string, state storage.State) error {
 	o.logger.Printf(string(marshalled))
 	return nil
 }
+
+func (o Outputs) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	outputs, err := o.terraformManager.CachedOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(o.logger, outputs.Map)
+}
@@ -51,7 +51,7 @@ make sure we quote multiline strings`,
 					"external": "address",
 				},
 			}
-			terraformManager.GetOutputsCall.Returns.Outputs = terraformOutputs
+			terraformManager.CachedOutputsCall.Returns.Outputs = terraformOutputs
 			err := outputsCommand.Execute([]string{}, storage.State{})
 			Expect(err).NotTo(HaveOccurred())
 			Expect(logger.PrintfCall.Receives.Message).To(ContainSubstring("external: address\nfirewall: |-\n  cidr\n  make sure we quote multiline strings"))
@@ -60,7 +60,7 @@ make sure we quote multiline strings`,
 		Context("failure cases", func() {
 			Context("when getOutputs failes", func() {
 				It("returns an error", func() {
-					terraformManager.GetOutputsCall.Returns.Error = errors.New("tangelo")
+					terraformManager.CachedOutputsCall.Returns.Error = errors.New("tangelo")
 
 					err := outputsCommand.Execute([]string{}, storage.State{})
 
@@ -69,4 +69,26 @@ make sure we quote multiline strings`,
 			})
 		})
 	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the terraform outputs as json", func() {
+			terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{
+				Map: map[string]interface{}{
+					"external": "address",
+					"zones":    []interface{}{"z1", "z2"},
+				},
+			}
+
+			err := outputsCommand.ExecuteJSON([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"external": "address", "zones": ["z1", "z2"]}`))
+		})
+
+		It("returns an error when the outputs cannot be read", func() {
+			terraformManager.CachedOutputsCall.Returns.Error = errors.New("tangelo")
+
+			err := outputsCommand.ExecuteJSON([]string{}, storage.State{})
+			Expect(err).To(MatchError("tangelo"))
+		})
+	})
 })
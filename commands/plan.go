@@ -46,6 +46,11 @@ func NewPlan(boshManager boshManager,
 }
 
 func (p Plan) CheckFastFails(args []string, state storage.State) error {
+	_, args, err := parseDiff(args)
+	if err != nil {
+		return err
+	}
+
 	config, err := p.ParseArgs(args, state)
 	if err != nil {
 		return err
@@ -66,22 +71,32 @@ func (p Plan) CheckFastFails(args []string, state storage.State) error {
 	return nil
 }
 
+// planFlags are the flags of bbl plan and bbl up. --lb-chain is only
+// defined with lbChain, as only AWS takes a certificate chain.
+func planFlags(config *PlanConfig, lbArgs *LBArgs, lbChain bool) flags.Flags {
+	f := flags.New("up")
+	f.String(&config.Name, "name", os.Getenv("BBL_ENV_NAME"))
+	f.String(&lbArgs.LBType, "lb-type", "")
+	f.String(&lbArgs.CertPath, "lb-cert", "")
+	f.String(&lbArgs.KeyPath, "lb-key", "")
+	f.String(&lbArgs.Domain, "lb-domain", "")
+	if lbChain {
+		f.String(&lbArgs.ChainPath, "lb-chain", "")
+	}
+	return f
+}
+
+func (p Plan) Flags() []flags.Flags {
+	var diff bool
+	return []flags.Flags{diffFlags(&diff), planFlags(&PlanConfig{}, &LBArgs{}, true)}
+}
+
 func (p Plan) ParseArgs(args []string, state storage.State) (PlanConfig, error) {
 	var (
 		config PlanConfig
 		lbArgs LBArgs
 	)
-	planFlags := flags.New("up")
-	planFlags.String(&config.Name, "name", os.Getenv("BBL_ENV_NAME"))
-	planFlags.String(&lbArgs.LBType, "lb-type", "")
-	planFlags.String(&lbArgs.CertPath, "lb-cert", "")
-	planFlags.String(&lbArgs.KeyPath, "lb-key", "")
-	planFlags.String(&lbArgs.Domain, "lb-domain", "")
-	if state.IAAS == "aws" {
-		planFlags.String(&lbArgs.ChainPath, "lb-chain", "")
-	}
-
-	err := planFlags.Parse(args)
+	err := planFlags(&config, &lbArgs, state.IAAS == "aws").Parse(args)
 	if err != nil {
 		return PlanConfig{}, err
 	}
@@ -98,13 +113,26 @@ func (p Plan) ParseArgs(args []string, state storage.State) (PlanConfig, error)
 }
 
 func (p Plan) Execute(args []string, state storage.State) error {
+	diff, args, err := parseDiff(args)
+	if err != nil {
+		return err
+	}
+
 	config, err := p.ParseArgs(args, state)
 	if err != nil {
 		return err
 	}
 
-	_, err = p.InitializePlan(config, state)
-	return err
+	state, err = p.InitializePlan(config, state)
+	if err != nil {
+		return err
+	}
+
+	if diff {
+		return p.diff(state)
+	}
+
+	return nil
 }
 
 func (p Plan) InitializePlan(config PlanConfig, state storage.State) (storage.State, error) {
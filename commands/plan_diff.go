@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// PlanDiffExitCode is the exit code of bbl plan --diff when the next bbl up
+// would change something, so that CI can tell it apart from a failed plan.
+const PlanDiffExitCode = 2
+
+// diff shows what the next bbl up will change: the terraform plan, the
+// jumpbox and director manifests against the ones the last bbl up
+// deployed, and the cloud config against the one on the director. It
+// returns an ExitError when anything would change so that CI can gate on it.
+func (p Plan) diff(state storage.State) error {
+	var changed []string
+
+	plan, err := p.terraformManager.Plan(state)
+	if err != nil {
+		return fmt.Errorf("Terraform plan: %s", err)
+	}
+	if plan != "" {
+		changed = append(changed, "terraform")
+		p.logger.Printf("terraform:\n%s\n", plan)
+	} else {
+		p.logger.Println("terraform: no changes")
+	}
+
+	if state.Jumpbox.URL == "" {
+		p.logger.Println("jumpbox: will be created")
+		p.logger.Println("director: will be created")
+		p.logger.Println("cloud-config: will be uploaded")
+		return diffSummary(append(changed, "jumpbox", "director", "cloud-config"))
+	}
+
+	outputs, err := p.terraformManager.CachedOutputs(state)
+	if err != nil {
+		return fmt.Errorf("Parse terraform outputs: %s", err)
+	}
+
+	jumpboxManifest, err := p.boshManager.InterpolateJumpbox(state, outputs)
+	if err != nil {
+		return fmt.Errorf("Interpolate jumpbox manifest: %s", err)
+	}
+	if p.printDiff("jumpbox", state.Jumpbox.Manifest, jumpboxManifest) {
+		changed = append(changed, "jumpbox")
+	}
+
+	if state.BOSH.DirectorAddress == "" {
+		p.logger.Println("director: will be created")
+		p.logger.Println("cloud-config: will be uploaded")
+		return diffSummary(append(changed, "director", "cloud-config"))
+	}
+
+	directorManifest, err := p.boshManager.InterpolateDirector(state, outputs)
+	if err != nil {
+		return fmt.Errorf("Interpolate director manifest: %s", err)
+	}
+	if p.printDiff("director", state.BOSH.Manifest, directorManifest) {
+		changed = append(changed, "director")
+	}
+
+	err = p.cloudConfigManager.GenerateVars(state)
+	if err != nil {
+		return err
+	}
+
+	cloudConfig, err := p.cloudConfigManager.Interpolate()
+	if err != nil {
+		return fmt.Errorf("Interpolate cloud config: %s", err)
+	}
+
+	currentCloudConfig, err := p.cloudConfigManager.Current(state)
+	if err != nil {
+		return err
+	}
+	if p.printDiff("cloud-config", currentCloudConfig, cloudConfig) {
+		changed = append(changed, "cloud-config")
+	}
+
+	return diffSummary(changed)
+}
+
+// printDiff prints a unified diff from the deployed to the planned
+// contents and reports whether they differ.
+func (p Plan) printDiff(name, deployed, planned string) bool {
+	if deployed == planned {
+		p.logger.Printf("%s: no changes\n", name)
+		return false
+	}
+
+	diff := unifiedDiff(deployed, planned, fmt.Sprintf("%s (deployed)", name), fmt.Sprintf("%s (bbl up)", name))
+	p.logger.Printf("%s:\n%s", name, diff)
+	return true
+}
+
+func unifiedDiff(from, to, fromLabel, toLabel string) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(from),
+		B:        diffLines(to),
+		FromFile: fromLabel,
+		ToFile:   toLabel,
+		Context:  3,
+	})
+	return diff
+}
+
+func diffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(strings.TrimSuffix(s, "\n"), "\n")
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+func diffSummary(changed []string) error {
+	if len(changed) == 0 {
+		return nil
+	}
+
+	return ExitError{
+		Code:    PlanDiffExitCode,
+		Message: fmt.Sprintf("bbl up will change: %s.", strings.Join(changed, ", ")),
+	}
+}
+
+func diffFlags(diff *bool) flags.Flags {
+	f := flags.New("plan")
+	f.Bool(diff, "diff")
+	return f
+}
+
+// parseDiff parses --diff out of args and returns the remaining args.
+func parseDiff(args []string) (bool, []string, error) {
+	var diff bool
+	rest, err := diffFlags(&diff).ParseKnown(args)
+	return diff, rest, err
+}
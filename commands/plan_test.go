@@ -8,6 +8,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -127,6 +128,108 @@ var _ = Describe("Plan", func() {
 			})
 		})
 
+		Context("with --diff", func() {
+			BeforeEach(func() {
+				syncedState = storage.State{
+					ID:      "synced-state-id",
+					Jumpbox: storage.Jumpbox{URL: "10.0.0.5:22", Manifest: "name: jumpbox\n"},
+					BOSH: storage.BOSH{
+						DirectorAddress: "https://10.0.0.6:25555",
+						Manifest:        "name: bosh\nversion: 1\n",
+					},
+				}
+				envIDManager.SyncCall.Returns.State = syncedState
+
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{Map: map[string]interface{}{"jumpbox_url": "10.0.0.5:22"}}
+				boshManager.InterpolateJumpboxCall.Returns.Manifest = "name: jumpbox\n"
+				boshManager.InterpolateDirectorCall.Returns.Manifest = "name: bosh\nversion: 1\n"
+				cloudConfigManager.InterpolateCall.Returns.CloudConfig = "vm_types: []\n"
+				cloudConfigManager.CurrentCall.Returns.CloudConfig = "vm_types: []\n"
+			})
+
+			It("reports no changes when the environment matches the plan", func() {
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.PlanCall.Receives.BBLState).To(Equal(syncedState))
+				Expect(boshManager.InterpolateJumpboxCall.Receives.State).To(Equal(syncedState))
+				Expect(boshManager.InterpolateDirectorCall.Receives.TerraformOutputs).To(Equal(terraformManager.CachedOutputsCall.Returns.Outputs))
+				Expect(cloudConfigManager.GenerateVarsCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.CurrentCall.Receives.State).To(Equal(syncedState))
+
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("terraform: no changes"))
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{
+					"jumpbox: no changes\n",
+					"director: no changes\n",
+					"cloud-config: no changes\n",
+				}))
+			})
+
+			It("prints the changes and returns an error for CI", func() {
+				terraformManager.PlanCall.Returns.Plan = "some-terraform-plan"
+				boshManager.InterpolateDirectorCall.Returns.Manifest = "name: bosh\nversion: 2\n"
+				cloudConfigManager.CurrentCall.Returns.CloudConfig = ""
+
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).To(Equal(commands.ExitError{
+					Code:    2,
+					Message: "bbl up will change: terraform, director, cloud-config.",
+				}))
+
+				Expect(logger.PrintfCall.Messages).To(ContainElement("terraform:\nsome-terraform-plan\n"))
+				Expect(logger.PrintfCall.Messages).To(ContainElement(`director:
+--- director (deployed)
++++ director (bbl up)
+@@ -1,2 +1,2 @@
+ name: bosh
+-version: 1
++version: 2
+`))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("jumpbox: no changes\n"))
+			})
+
+			It("says what will be created in a new environment", func() {
+				envIDManager.SyncCall.Returns.State = storage.State{ID: "synced-state-id"}
+
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).To(Equal(commands.ExitError{
+					Code:    2,
+					Message: "bbl up will change: jumpbox, director, cloud-config.",
+				}))
+
+				Expect(boshManager.InterpolateJumpboxCall.CallCount).To(Equal(0))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("jumpbox: will be created"))
+			})
+
+			It("returns an error when terraform plan fails", func() {
+				terraformManager.PlanCall.Returns.Error = errors.New("mango")
+
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).To(MatchError("Terraform plan: mango"))
+			})
+
+			It("returns an error when a manifest cannot be interpolated", func() {
+				boshManager.InterpolateJumpboxCall.Returns.Error = errors.New("plum")
+
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).To(MatchError("Interpolate jumpbox manifest: plum"))
+			})
+
+			It("returns an error when the cloud config cannot be read from the director", func() {
+				cloudConfigManager.CurrentCall.Returns.Error = errors.New("peach")
+
+				err := command.Execute([]string{"--diff"}, state)
+				Expect(err).To(MatchError("peach"))
+			})
+
+			It("is ignored by CheckFastFails", func() {
+				terraformManager.ValidateVersionCall.Returns.Error = nil
+
+				err := command.CheckFastFails([]string{"--diff"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
 		Describe("failure cases", func() {
 			It("returns an error if state store set fails", func() {
 				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("peach")}}
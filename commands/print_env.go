@@ -2,8 +2,10 @@ package commands
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
@@ -29,6 +31,7 @@ type credhubGetter interface {
 
 type allProxyGetter interface {
 	GeneratePrivateKey() (string, error)
+	WritePrivateKey(path string) (string, error)
 	BoshAllProxy(string, string) string
 }
 
@@ -66,52 +69,159 @@ func (p PrintEnv) CheckFastFails(subcommandFlags []string, state storage.State)
 }
 
 func (p PrintEnv) Execute(args []string, state storage.State) error {
+	shell, keyPath, err := p.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	variables, err := p.environment(state, keyPath)
+	if err != nil {
+		return err
+	}
+
+	if shell == "json" {
+		return printJSON(p.logger, envDocument(variables))
+	}
+
+	for _, variable := range variables {
+		p.logger.Println(formatEnvVariable(shell, variable))
+	}
+
+	return nil
+}
+
+func (p PrintEnv) ExecuteJSON(args []string, state storage.State) error {
+	_, keyPath, err := p.parseArgs(args)
+	if err != nil {
+		return err
+	}
+
+	variables, err := p.environment(state, keyPath)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(p.logger, envDocument(variables))
+}
+
+func printEnvFlags(shell, keyPath *string) flags.Flags {
+	f := flags.New("print-env")
+	f.String(shell, "shell", "bash")
+	f.String(keyPath, "jumpbox-key-path", "")
+	return f
+}
+
+func (p PrintEnv) Flags() []flags.Flags {
+	var shell, keyPath string
+	return []flags.Flags{printEnvFlags(&shell, &keyPath)}
+}
+
+func (p PrintEnv) parseArgs(args []string) (string, string, error) {
+	var shell, keyPath string
+	err := printEnvFlags(&shell, &keyPath).Parse(args)
+	if err != nil {
+		return "", "", err
+	}
+
+	switch shell {
+	case "bash", "fish", "powershell", "dotenv", "json":
+		return shell, keyPath, nil
+	}
+
+	return "", "", fmt.Errorf("Unsupported shell %q: use bash, fish, powershell, dotenv or json.", shell)
+}
+
+type envVariable struct {
+	name   string
+	value  string
+	quoted bool
+}
+
+func envDocument(variables []envVariable) map[string]string {
+	document := map[string]string{}
+	for _, variable := range variables {
+		document[variable.name] = variable.value
+	}
+	return document
+}
+
+// formatEnvVariable quotes a variable for the target shell. Values marked
+// as quoted may span several lines.
+func formatEnvVariable(shell string, variable envVariable) string {
+	switch shell {
+	case "fish":
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(variable.value)
+		return fmt.Sprintf("set -gx %s '%s'", variable.name, value)
+	case "powershell":
+		value := strings.Replace(variable.value, "'", "''", -1)
+		return fmt.Sprintf("$env:%s = '%s'", variable.name, value)
+	case "dotenv":
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(variable.value)
+		return fmt.Sprintf(`%s="%s"`, variable.name, value)
+	}
+
+	if variable.quoted {
+		value := strings.Replace(variable.value, "'", `'\''`, -1)
+		return fmt.Sprintf("export %s='%s'", variable.name, value)
+	}
+	return fmt.Sprintf("export %s=%s", variable.name, variable.value)
+}
+
+func (p PrintEnv) environment(state storage.State, keyPath string) ([]envVariable, error) {
 	if state.NoDirector {
-		terraformOutputs, err := p.terraformManager.GetOutputs()
+		terraformOutputs, err := p.terraformManager.CachedOutputs(state)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		p.logger.Println(fmt.Sprintf("export BOSH_ENVIRONMENT=https://%s:25555", terraformOutputs.GetString("external_ip")))
-		return nil
+		return []envVariable{
+			{name: "BOSH_ENVIRONMENT", value: fmt.Sprintf("https://%s:25555", terraformOutputs.GetString("external_ip"))},
+		}, nil
 	}
 
-	p.logger.Println(fmt.Sprintf("export BOSH_CLIENT=%s", state.BOSH.DirectorUsername))
-	p.logger.Println(fmt.Sprintf("export BOSH_CLIENT_SECRET=%s", state.BOSH.DirectorPassword))
-	p.logger.Println(fmt.Sprintf("export BOSH_ENVIRONMENT=%s", state.BOSH.DirectorAddress))
-	p.logger.Println(fmt.Sprintf("export BOSH_CA_CERT='%s'", state.BOSH.DirectorSSLCA))
-
-	p.logger.Println("export CREDHUB_CLIENT=credhub-admin")
+	variables := []envVariable{
+		{name: "BOSH_CLIENT", value: state.BOSH.DirectorUsername},
+		{name: "BOSH_CLIENT_SECRET", value: state.BOSH.DirectorPassword},
+		{name: "BOSH_ENVIRONMENT", value: state.BOSH.DirectorAddress},
+		{name: "BOSH_CA_CERT", value: state.BOSH.DirectorSSLCA, quoted: true},
+		{name: "CREDHUB_CLIENT", value: "credhub-admin"},
+	}
 
 	credhubPassword, err := p.credhubGetter.GetPassword()
 	if err == nil {
-		p.logger.Println(fmt.Sprintf("export CREDHUB_SECRET=%s", credhubPassword))
+		variables = append(variables, envVariable{name: "CREDHUB_SECRET", value: credhubPassword})
 	} else {
 		p.stderrLogger.Println("No credhub password found.")
 	}
 
 	credhubServer, err := p.credhubGetter.GetServer()
 	if err == nil {
-		p.logger.Println(fmt.Sprintf("export CREDHUB_SERVER=%s", credhubServer))
+		variables = append(variables, envVariable{name: "CREDHUB_SERVER", value: credhubServer})
 	} else {
 		p.stderrLogger.Println("No credhub server found.")
 	}
 
 	credhubCerts, err := p.credhubGetter.GetCerts()
 	if err == nil {
-		p.logger.Println(fmt.Sprintf("export CREDHUB_CA_CERT='%s'", credhubCerts))
+		variables = append(variables, envVariable{name: "CREDHUB_CA_CERT", value: credhubCerts, quoted: true})
 	} else {
 		p.stderrLogger.Println("No credhub certs found.")
 	}
 
-	privateKeyPath, err := p.allProxyGetter.GeneratePrivateKey()
+	var privateKeyPath string
+	if keyPath != "" {
+		privateKeyPath, err = p.allProxyGetter.WritePrivateKey(keyPath)
+	} else {
+		privateKeyPath, err = p.allProxyGetter.GeneratePrivateKey()
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	p.logger.Println(fmt.Sprintf("export JUMPBOX_PRIVATE_KEY=%s", privateKeyPath))
-	p.logger.Println(fmt.Sprintf("export BOSH_ALL_PROXY=%s", p.allProxyGetter.BoshAllProxy(state.Jumpbox.URL, privateKeyPath)))
-	p.logger.Println(fmt.Sprintf("export CREDHUB_PROXY=%s", p.allProxyGetter.BoshAllProxy(state.Jumpbox.URL, privateKeyPath)))
-
-	return nil
+	allProxy := p.allProxyGetter.BoshAllProxy(state.Jumpbox.URL, privateKeyPath)
+	return append(variables,
+		envVariable{name: "JUMPBOX_PRIVATE_KEY", value: privateKeyPath},
+		envVariable{name: "BOSH_ALL_PROXY", value: allProxy},
+		envVariable{name: "CREDHUB_PROXY", value: allProxy},
+	), nil
 }
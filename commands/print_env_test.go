@@ -8,6 +8,7 @@ import (
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 	"github.com/cloudfoundry/bosh-bootloader/terraform"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 )
 
@@ -92,7 +93,7 @@ var _ = Describe("PrintEnv", func() {
 
 		Context("when there is no director", func() {
 			BeforeEach(func() {
-				terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{
 					Map: map[string]interface{}{"external_ip": "some-external-ip"},
 				}
 			})
@@ -103,17 +104,82 @@ var _ = Describe("PrintEnv", func() {
 				})
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
 
 				Expect(logger.PrintlnCall.Messages).To(ContainElement("export BOSH_ENVIRONMENT=https://some-external-ip:25555"))
 				Expect(logger.PrintlnCall.Messages).NotTo(ContainElement("export BOSH_CLIENT=some-director-username"))
 			})
 		})
 
+		Context("when --shell is given", func() {
+			BeforeEach(func() {
+				state.BOSH.DirectorSSLCA = "-----BEGIN CERTIFICATE-----\nit's\n-----END CERTIFICATE-----"
+			})
+
+			DescribeTable("quotes the variables for the shell",
+				func(shell, clientLine, caCertLine string) {
+					err := printEnv.Execute([]string{"--shell", shell}, state)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(logger.PrintlnCall.Messages).To(ContainElement(clientLine))
+					Expect(logger.PrintlnCall.Messages).To(ContainElement(caCertLine))
+				},
+				Entry("bash", "bash",
+					"export BOSH_CLIENT=some-director-username",
+					"export BOSH_CA_CERT='-----BEGIN CERTIFICATE-----\nit'\\''s\n-----END CERTIFICATE-----'"),
+				Entry("fish", "fish",
+					"set -gx BOSH_CLIENT 'some-director-username'",
+					"set -gx BOSH_CA_CERT '-----BEGIN CERTIFICATE-----\nit\\'s\n-----END CERTIFICATE-----'"),
+				Entry("powershell", "powershell",
+					"$env:BOSH_CLIENT = 'some-director-username'",
+					"$env:BOSH_CA_CERT = '-----BEGIN CERTIFICATE-----\nit''s\n-----END CERTIFICATE-----'"),
+				Entry("dotenv", "dotenv",
+					`BOSH_CLIENT="some-director-username"`,
+					`BOSH_CA_CERT="-----BEGIN CERTIFICATE-----\nit's\n-----END CERTIFICATE-----"`),
+			)
+
+			It("prints json", func() {
+				err := printEnv.Execute([]string{"--shell=json"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Messages).To(HaveLen(1))
+				Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring(`"BOSH_CLIENT": "some-director-username"`))
+			})
+		})
+
+		Context("when --jumpbox-key-path is given", func() {
+			BeforeEach(func() {
+				allProxyGetter.WritePrivateKeyCall.Returns.PrivateKey = "/home/me/jumpbox.key"
+			})
+
+			It("writes the jumpbox key to that path", func() {
+				err := printEnv.Execute([]string{"--jumpbox-key-path", "jumpbox.key"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(allProxyGetter.GeneratePrivateKeyCall.CallCount).To(Equal(0))
+				Expect(allProxyGetter.WritePrivateKeyCall.Receives.Path).To(Equal("jumpbox.key"))
+				Expect(allProxyGetter.BoshAllProxyCall.Receives.PrivateKey).To(Equal("/home/me/jumpbox.key"))
+				Expect(logger.PrintlnCall.Messages).To(ContainElement("export JUMPBOX_PRIVATE_KEY=/home/me/jumpbox.key"))
+			})
+
+			It("returns an error when the key cannot be written", func() {
+				allProxyGetter.WritePrivateKeyCall.Returns.Error = errors.New("guava")
+
+				err := printEnv.Execute([]string{"--jumpbox-key-path", "jumpbox.key"}, state)
+				Expect(err).To(MatchError("guava"))
+			})
+		})
+
 		Context("failure cases", func() {
+			It("returns an error for an unsupported shell", func() {
+				err := printEnv.Execute([]string{"--shell", "tcsh"}, state)
+				Expect(err).To(MatchError(`Unsupported shell "tcsh": use bash, fish, powershell, dotenv or json.`))
+				Expect(allProxyGetter.GeneratePrivateKeyCall.CallCount).To(Equal(0))
+			})
+
 			Context("when terraform manager get outputs fails", func() {
 				BeforeEach(func() {
-					terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get terraform output")
+					terraformManager.CachedOutputsCall.Returns.Error = errors.New("failed to get terraform output")
 				})
 
 				It("returns an error", func() {
@@ -173,4 +239,32 @@ var _ = Describe("PrintEnv", func() {
 			})
 		})
 	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the environment variables as json", func() {
+			err := printEnv.ExecuteJSON([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{
+				"BOSH_CLIENT": "some-director-username",
+				"BOSH_CLIENT_SECRET": "some-director-password",
+				"BOSH_ENVIRONMENT": "some-director-address",
+				"BOSH_CA_CERT": "some-director-ca-cert",
+				"CREDHUB_CLIENT": "credhub-admin",
+				"CREDHUB_SECRET": "some-credhub-password",
+				"CREDHUB_SERVER": "some-credhub-server",
+				"CREDHUB_CA_CERT": "some-credhub-certs",
+				"JUMPBOX_PRIVATE_KEY": "the-key-path",
+				"BOSH_ALL_PROXY": "ipfs://some-domain-with?private_key=the-key-path",
+				"CREDHUB_PROXY": "ipfs://some-domain-with?private_key=the-key-path"
+			}`))
+		})
+
+		It("returns an error when the private key cannot be written", func() {
+			allProxyGetter.GeneratePrivateKeyCall.Returns.Error = errors.New("papaya")
+
+			err := printEnv.ExecuteJSON([]string{}, state)
+			Expect(err).To(MatchError("papaya"))
+		})
+	})
 })
@@ -1,36 +1,63 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
-type sshKeyDeleter interface {
-	Delete() error
+type credentialRotator interface {
+	Rotate(names []string) ([]storage.Rotation, error)
 }
 
 type Rotate struct {
-	stateValidator stateValidator
-	sshKeyDeleter  sshKeyDeleter
-	up             up
+	stateValidator    stateValidator
+	credentialRotator credentialRotator
+	boshManager       boshManager
+	stateStore        stateStore
+	up                up
+	stateLoader       stateLoader
+	logger            logger
 }
 
-func NewRotate(stateValidator stateValidator, sshKeyDeleter sshKeyDeleter, up up) Rotate {
+func NewRotate(stateValidator stateValidator, credentialRotator credentialRotator, boshManager boshManager, stateStore stateStore, up up, stateLoader stateLoader, logger logger) Rotate {
 	return Rotate{
-		stateValidator: stateValidator,
-		sshKeyDeleter:  sshKeyDeleter,
-		up:             up,
+		stateValidator:    stateValidator,
+		credentialRotator: credentialRotator,
+		boshManager:       boshManager,
+		stateStore:        stateStore,
+		up:                up,
+		stateLoader:       stateLoader,
+		logger:            logger,
 	}
 }
 
+// Flags are the flags of bbl rotate and of the bbl up that it runs.
+func (r Rotate) Flags() []flags.Flags {
+	var (
+		credentials string
+		all         bool
+	)
+	return append([]flags.Flags{rotateFlags(&credentials, &all)}, Up{}.Flags()...)
+}
+
 func (r Rotate) CheckFastFails(subcommandFlags []string, state storage.State) error {
 	err := r.stateValidator.Validate()
 	if err != nil {
 		return fmt.Errorf("validate state: %s", err)
 	}
 
-	err = r.up.CheckFastFails(subcommandFlags, state)
+	_, upArgs, err := parseCredentials(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	err = r.up.CheckFastFails(upArgs, state)
 	if err != nil {
 		return fmt.Errorf("up: %s", err)
 	}
@@ -38,15 +65,110 @@ func (r Rotate) CheckFastFails(subcommandFlags []string, state storage.State) er
 }
 
 func (r Rotate) Execute(args []string, state storage.State) error {
-	err := r.sshKeyDeleter.Delete()
+	names, upArgs, err := parseCredentials(args)
 	if err != nil {
-		return fmt.Errorf("delete ssh key: %s", err)
+		return err
 	}
 
-	err = r.up.Execute(args, state)
+	r.logger.Step("rotating %s", strings.Join(names, ", "))
+	rotations, err := r.credentialRotator.Rotate(names)
+	if err != nil {
+		return fmt.Errorf("rotate credentials: %s", err)
+	}
+
+	twoPhase := false
+	for _, rotation := range rotations {
+		twoPhase = twoPhase || rotation.Phase > 0
+	}
+
+	// The ops that carry a two-phase rotation between its phases are only
+	// picked up by a fresh create-director.sh.
+	if twoPhase && !state.NoDirector {
+		err = r.boshManager.InitializeDirector(state)
+		if err != nil {
+			return fmt.Errorf("plan director: %s", err)
+		}
+	}
+
+	err = r.up.Execute(upArgs, state)
 	if err != nil {
 		return fmt.Errorf("up: %s", err)
 	}
 
+	// up saves the state it deployed, so the rotations are recorded on a
+	// fresh copy of it.
+	state, err = r.stateLoader.LoadState()
+	if err != nil {
+		return fmt.Errorf("load state: %s", err)
+	}
+
+	rotatedAt := time.Now().UTC()
+	for _, rotation := range rotations {
+		rotation.RotatedAt = rotatedAt
+		state.Rotations = append(state.Rotations, rotation)
+	}
+
+	err = r.stateStore.Set(state)
+	if err != nil {
+		return fmt.Errorf("save state: %s", err)
+	}
+
+	for _, rotation := range rotations {
+		switch rotation.Phase {
+		case 1:
+			r.logger.Println(fmt.Sprintf("Rotating %s is half done. Recreate every deployment on the director, then run `bbl rotate --credentials=%s` again to finish.", rotation.Credential, rotation.Credential))
+		case 2:
+			r.logger.Println(fmt.Sprintf("Rotating %s is done.", rotation.Credential))
+		}
+	}
+
 	return nil
 }
+
+func rotateFlags(credentials *string, all *bool) flags.Flags {
+	f := flags.New("rotate")
+	f.String(credentials, "credentials", "")
+	f.Bool(all, "all")
+	return f
+}
+
+// parseCredentials returns the credentials named by --credentials or
+// --all, the jumpbox SSH key when neither is given, and the rest of args.
+func parseCredentials(args []string) ([]string, []string, error) {
+	var (
+		credentials string
+		all         bool
+	)
+	rest, err := rotateFlags(&credentials, &all).ParseKnown(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if all && credentials != "" {
+		return nil, nil, errors.New("--credentials and --all cannot be used together.")
+	}
+
+	if all {
+		return bosh.Credentials(), rest, nil
+	}
+	if credentials == "" {
+		return []string{"jumpbox-ssh"}, rest, nil
+	}
+
+	names := strings.Split(credentials, ",")
+	for _, credential := range names {
+		if !validCredential(credential) {
+			return nil, nil, fmt.Errorf("Unknown credential %q: use %s.", credential, strings.Join(bosh.Credentials(), ", "))
+		}
+	}
+	return names, rest, nil
+}
+
+func validCredential(name string) bool {
+	for _, credential := range bosh.Credentials() {
+		if credential == name {
+			return true
+		}
+	}
+	return false
+}
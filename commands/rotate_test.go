@@ -12,17 +12,25 @@ import (
 
 var _ = Describe("Rotate", func() {
 	var (
-		stateValidator *fakes.StateValidator
-		sshKeyDeleter  *fakes.SSHKeyDeleter
-		up             *fakes.Up
-		rotate         commands.Rotate
+		stateValidator    *fakes.StateValidator
+		credentialRotator *fakes.CredentialRotator
+		boshManager       *fakes.BOSHManager
+		stateStore        *fakes.StateStore
+		up                *fakes.Up
+		stateLoader       *fakes.StateLoader
+		logger            *fakes.Logger
+		rotate            commands.Rotate
 	)
 
 	BeforeEach(func() {
 		stateValidator = &fakes.StateValidator{}
-		sshKeyDeleter = &fakes.SSHKeyDeleter{}
+		credentialRotator = &fakes.CredentialRotator{}
+		boshManager = &fakes.BOSHManager{}
+		stateStore = &fakes.StateStore{}
 		up = &fakes.Up{}
-		rotate = commands.NewRotate(stateValidator, sshKeyDeleter, up)
+		stateLoader = &fakes.StateLoader{}
+		logger = &fakes.Logger{}
+		rotate = commands.NewRotate(stateValidator, credentialRotator, boshManager, stateStore, up, stateLoader, logger)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -33,17 +41,33 @@ var _ = Describe("Rotate", func() {
 			Expect(stateValidator.ValidateCall.CallCount).To(Equal(1))
 		})
 
-		It("calls up.CheckFastFails", func() {
-			subcommandFlags := []string{"some", "subcommand", "flags"}
+		It("calls up.CheckFastFails without the rotate flags", func() {
+			subcommandFlags := []string{"some", "--credentials", "ca", "subcommand", "flags"}
 			state := storage.State{EnvID: "some-env-id"}
 			err := rotate.CheckFastFails(subcommandFlags, state)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(up.CheckFastFailsCall.CallCount).To(Equal(1))
-			Expect(up.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal(subcommandFlags))
+			Expect(up.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal([]string{"some", "subcommand", "flags"}))
 			Expect(up.CheckFastFailsCall.Receives.State).To(Equal(state))
 		})
 
+		It("returns an error for an unknown credential", func() {
+			err := rotate.CheckFastFails([]string{"--credentials=ca,kiwi"}, storage.State{})
+			Expect(err).To(MatchError(`Unknown credential "kiwi": use blobstore-certs, ca, credhub-admin, credhub-encryption, director-admin, director-ssh, jumpbox-ssh, nats-certs, uaa-clients.`))
+			Expect(up.CheckFastFailsCall.CallCount).To(Equal(0))
+		})
+
+		It("returns an error when --credentials and --all are both given", func() {
+			err := rotate.CheckFastFails([]string{"--credentials=ca", "--all"}, storage.State{})
+			Expect(err).To(MatchError("--credentials and --all cannot be used together."))
+		})
+
+		It("returns an error when --credentials has no value", func() {
+			err := rotate.CheckFastFails([]string{"--credentials"}, storage.State{})
+			Expect(err).To(MatchError("flag needs an argument: -credentials"))
+		})
+
 		Context("when the state validator returns an error", func() {
 			BeforeEach(func() {
 				stateValidator.ValidateCall.Returns.Error = errors.New("coconut")
@@ -65,7 +89,6 @@ var _ = Describe("Rotate", func() {
 				Expect(err).To(MatchError("up: passionfruit"))
 			})
 		})
-
 	})
 
 	Describe("Execute", func() {
@@ -78,47 +101,139 @@ var _ = Describe("Rotate", func() {
 			args = []string{"some", "args"}
 			state = storage.State{
 				EnvID: "some-env-id",
-				Jumpbox: storage.Jumpbox{
-					Variables: "ssh_key: foo\nsome_other_key: bar\n",
-				},
 			}
+			credentialRotator.RotateCall.Returns.Rotations = []storage.Rotation{{Credential: "jumpbox-ssh"}}
 		})
 
-		It("deletes the ssh key", func() {
+		It("rotates the jumpbox ssh key by default", func() {
 			err := rotate.Execute(args, state)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(sshKeyDeleter.DeleteCall.CallCount).To(Equal(1))
+			Expect(credentialRotator.RotateCall.CallCount).To(Equal(1))
+			Expect(credentialRotator.RotateCall.Receives.Names).To(Equal([]string{"jumpbox-ssh"}))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"rotating jumpbox-ssh"}))
 		})
 
-		It("calls up with args and new state", func() {
+		It("rotates the named credentials", func() {
+			err := rotate.Execute([]string{"--credentials=director-admin,uaa-clients"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(credentialRotator.RotateCall.Receives.Names).To(Equal([]string{"director-admin", "uaa-clients"}))
+			Expect(up.ExecuteCall.Receives.Args).To(Equal([]string{}))
+		})
+
+		It("rotates every credential with --all", func() {
+			err := rotate.Execute([]string{"--all"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(credentialRotator.RotateCall.Receives.Names).To(Equal([]string{
+				"blobstore-certs", "ca", "credhub-admin", "credhub-encryption", "director-admin",
+				"director-ssh", "jumpbox-ssh", "nats-certs", "uaa-clients",
+			}))
+		})
+
+		It("records the rotations in the state that up saved", func() {
+			stateLoader.LoadStateCall.Returns.State = storage.State{
+				EnvID:     "some-env-id",
+				Rotations: []storage.Rotation{{Credential: "director-admin"}},
+			}
+
+			err := rotate.Execute(args, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateLoader.LoadStateCall.CallCount).To(Equal(1))
+			Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			rotations := stateStore.SetCall.Receives[0].State.Rotations
+			Expect(rotations).To(HaveLen(2))
+			Expect(rotations[0]).To(Equal(storage.Rotation{Credential: "director-admin"}))
+			Expect(rotations[1].Credential).To(Equal("jumpbox-ssh"))
+			Expect(rotations[1].RotatedAt).NotTo(BeZero())
+		})
+
+		It("calls up with args and the state", func() {
 			err := rotate.Execute(args, state)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(up.ExecuteCall.CallCount).To(Equal(1))
 			Expect(up.ExecuteCall.Receives.Args).To(Equal(args))
 			Expect(up.ExecuteCall.Receives.State).To(Equal(state))
+			Expect(boshManager.InitializeDirectorCall.CallCount).To(Equal(0))
 		})
 
-		Context("when the ssh key deleter returns an error", func() {
-			BeforeEach(func() {
-				sshKeyDeleter.DeleteCall.Returns.Error = errors.New("guava")
+		Context("when a credential is rotated in two phases", func() {
+			It("plans the director again and explains how to finish after the first phase", func() {
+				credentialRotator.RotateCall.Returns.Rotations = []storage.Rotation{{Credential: "ca", Phase: 1}}
+
+				err := rotate.Execute([]string{"--credentials=ca"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.InitializeDirectorCall.CallCount).To(Equal(1))
+				Expect(boshManager.InitializeDirectorCall.Receives.State).To(Equal(state))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{
+					"Rotating ca is half done. Recreate every deployment on the director, then run `bbl rotate --credentials=ca` again to finish.",
+				}))
 			})
 
-			It("wraps and returns the error from the sshKeyDeleter", func() {
-				err := rotate.Execute(args, state)
-				Expect(err).To(MatchError("delete ssh key: guava"))
+			It("reports the rotation as done after the second phase", func() {
+				credentialRotator.RotateCall.Returns.Rotations = []storage.Rotation{{Credential: "ca", Phase: 2}}
+
+				err := rotate.Execute([]string{"--credentials=ca"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.InitializeDirectorCall.CallCount).To(Equal(1))
+				Expect(logger.PrintlnCall.Messages).To(Equal([]string{"Rotating ca is done."}))
+			})
+
+			It("does not plan a director when there is none", func() {
+				credentialRotator.RotateCall.Returns.Rotations = []storage.Rotation{{Credential: "ca", Phase: 1}}
+				state.NoDirector = true
+
+				err := rotate.Execute([]string{"--credentials=ca"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.InitializeDirectorCall.CallCount).To(Equal(0))
 			})
 		})
 
-		Context("when up returns an error", func() {
-			BeforeEach(func() {
-				up.ExecuteCall.Returns.Error = errors.New("fig")
+		Context("failure cases", func() {
+			It("returns an error when the credential rotator fails", func() {
+				credentialRotator.RotateCall.Returns.Error = errors.New("guava")
+
+				err := rotate.Execute(args, state)
+				Expect(err).To(MatchError("rotate credentials: guava"))
+				Expect(up.ExecuteCall.CallCount).To(Equal(0))
 			})
 
-			It("returns the error from up", func() {
+			It("returns an error when the state cannot be saved", func() {
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{Error: errors.New("kumquat")}}
+
+				err := rotate.Execute(args, state)
+				Expect(err).To(MatchError("save state: kumquat"))
+			})
+
+			It("returns an error when the director cannot be planned", func() {
+				credentialRotator.RotateCall.Returns.Rotations = []storage.Rotation{{Credential: "ca", Phase: 1}}
+				boshManager.InitializeDirectorCall.Returns.Error = errors.New("lime")
+
+				err := rotate.Execute(args, state)
+				Expect(err).To(MatchError("plan director: lime"))
+			})
+
+			It("returns the error from up without recording the rotations", func() {
+				up.ExecuteCall.Returns.Error = errors.New("fig")
+
 				err := rotate.Execute(args, state)
 				Expect(err).To(MatchError("up: fig"))
+				Expect(logger.PrintlnCall.Messages).To(BeEmpty())
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the state cannot be loaded after up", func() {
+				stateLoader.LoadStateCall.Returns.Error = errors.New("pear")
+
+				err := rotate.Execute(args, state)
+				Expect(err).To(MatchError("load state: pear"))
+				Expect(stateStore.SetCall.CallCount).To(Equal(0))
 			})
 		})
 	})
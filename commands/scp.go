@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type SCP struct {
+	client    sshClient
+	keyGetter sshKeyGetter
+}
+
+func NewSCP(sshClient sshClient, sshKeyGetter sshKeyGetter) SCP {
+	return SCP{
+		client:    sshClient,
+		keyGetter: sshKeyGetter,
+	}
+}
+
+func (s SCP) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(state.Jumpbox.URL) == 0 {
+		return errors.New("Invalid bbl state for bbl scp.")
+	}
+
+	return nil
+}
+
+func (s SCP) Execute(args []string, state storage.State) error {
+	if len(args) != 2 {
+		return errors.New("This command requires a source and a destination, one of which starts with jumpbox: or director:.")
+	}
+
+	sourceHost, sourcePath := remotePath(args[0])
+	destinationHost, destinationPath := remotePath(args[1])
+
+	if (sourceHost == "") == (destinationHost == "") {
+		return errors.New("Exactly one of the source and the destination must start with jumpbox: or director:.")
+	}
+
+	if sourceHost != "" {
+		target, err := sshTarget(s.keyGetter, state, sourceHost)
+		if err != nil {
+			return err
+		}
+
+		return s.client.Download(target, sourcePath, destinationPath)
+	}
+
+	target, err := sshTarget(s.keyGetter, state, destinationHost)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Upload(target, sourcePath, destinationPath)
+}
+
+func remotePath(arg string) (string, string) {
+	for _, deployment := range []string{"jumpbox", "director"} {
+		if strings.HasPrefix(arg, deployment+":") {
+			path := strings.TrimPrefix(arg, deployment+":")
+			if path == "" {
+				path = "."
+			}
+			return deployment, path
+		}
+	}
+
+	return "", arg
+}
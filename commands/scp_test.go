@@ -0,0 +1,111 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/ssh"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SCP", func() {
+	var (
+		scp          commands.SCP
+		sshClient    *fakes.SSHClient
+		sshKeyGetter *fakes.FancySSHKeyGetter
+		state        storage.State
+	)
+
+	BeforeEach(func() {
+		sshClient = &fakes.SSHClient{}
+		sshKeyGetter = &fakes.FancySSHKeyGetter{}
+		sshKeyGetter.JumpboxGetCall.Returns.PrivateKey = "jumpbox-private-key"
+		sshKeyGetter.DirectorGetCall.Returns.PrivateKey = "director-private-key"
+
+		state = storage.State{
+			Jumpbox: storage.Jumpbox{URL: "jumpboxURL:22"},
+			BOSH:    storage.BOSH{DirectorAddress: "https://directorURL:25555"},
+		}
+
+		scp = commands.NewSCP(sshClient, sshKeyGetter)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when there is no jumpbox url", func() {
+			err := scp.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("Invalid bbl state for bbl scp."))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("uploads a file to the jumpbox", func() {
+			err := scp.Execute([]string{"manifest.yml", "jumpbox:/tmp/"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sshClient.UploadCall.CallCount).To(Equal(1))
+			Expect(sshClient.UploadCall.Receives.Target).To(Equal(ssh.Target{
+				JumpboxAddress: "jumpboxURL:22",
+				JumpboxKey:     "jumpbox-private-key",
+			}))
+			Expect(sshClient.UploadCall.Receives.LocalPath).To(Equal("manifest.yml"))
+			Expect(sshClient.UploadCall.Receives.RemotePath).To(Equal("/tmp/"))
+		})
+
+		It("uploads to the home directory when the remote path is empty", func() {
+			err := scp.Execute([]string{"manifest.yml", "jumpbox:"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sshClient.UploadCall.Receives.RemotePath).To(Equal("."))
+		})
+
+		It("downloads a file from the director", func() {
+			err := scp.Execute([]string{"director:/var/vcap/sys/log/director/current", "director.log"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sshClient.DownloadCall.CallCount).To(Equal(1))
+			Expect(sshClient.DownloadCall.Receives.Target).To(Equal(ssh.Target{
+				JumpboxAddress:  "jumpboxURL:22",
+				JumpboxKey:      "jumpbox-private-key",
+				DirectorAddress: "directorURL:22",
+				DirectorKey:     "director-private-key",
+			}))
+			Expect(sshClient.DownloadCall.Receives.RemotePath).To(Equal("/var/vcap/sys/log/director/current"))
+			Expect(sshClient.DownloadCall.Receives.LocalPath).To(Equal("director.log"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error without a source and destination", func() {
+				err := scp.Execute([]string{"manifest.yml"}, state)
+				Expect(err).To(MatchError("This command requires a source and a destination, one of which starts with jumpbox: or director:."))
+			})
+
+			It("returns an error when neither path is remote", func() {
+				err := scp.Execute([]string{"a", "b"}, state)
+				Expect(err).To(MatchError("Exactly one of the source and the destination must start with jumpbox: or director:."))
+			})
+
+			It("returns an error when both paths are remote", func() {
+				err := scp.Execute([]string{"jumpbox:a", "director:b"}, state)
+				Expect(err).To(MatchError("Exactly one of the source and the destination must start with jumpbox: or director:."))
+			})
+
+			It("returns an error when the private key cannot be read", func() {
+				sshKeyGetter.DirectorGetCall.Returns.Error = errors.New("fig")
+
+				err := scp.Execute([]string{"a", "director:b"}, state)
+				Expect(err).To(MatchError("Get director private key: fig"))
+			})
+
+			It("returns an error when the copy fails", func() {
+				sshClient.DownloadCall.Returns.Error = errors.New("scp: no such file")
+
+				err := scp.Execute([]string{"jumpbox:a", "b"}, state)
+				Expect(err).To(MatchError("scp: no such file"))
+			})
+		})
+	})
+})
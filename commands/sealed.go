@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+var (
+	notifyInterrupts = defaultNotifyInterrupts
+	stopInterrupts   = defaultStopInterrupts
+	exit             = os.Exit
+	sealGracePeriod  = time.Minute
+)
+
+func defaultNotifyInterrupts(interrupts chan<- os.Signal) {
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+}
+
+func defaultStopInterrupts(interrupts chan<- os.Signal) {
+	signal.Stop(interrupts)
+}
+
+type sealer interface {
+	Enabled() bool
+	Seal() error
+	Unseal() error
+	UnsealVars() error
+}
+
+// Sealed decrypts the vars directory while a command runs, so that the
+// bosh cli and terraform can read the vars stores and terraform state, and
+// encrypts it again when the command returns or bbl is interrupted.
+type Sealed struct {
+	command Command
+	name    string
+	sealer  sealer
+}
+
+func NewSealed(command Command, name string, sealer sealer) Sealed {
+	return Sealed{
+		command: command,
+		name:    name,
+		sealer:  sealer,
+	}
+}
+
+func (s Sealed) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return s.command.CheckFastFails(subcommandFlags, state)
+}
+
+func (s Sealed) Execute(subcommandFlags []string, state storage.State) error {
+	return s.run(func() error {
+		return s.command.Execute(subcommandFlags, state)
+	})
+}
+
+func (s Sealed) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	command, ok := s.command.(JSONCommand)
+	if !ok {
+		return fmt.Errorf("bbl %s does not support --json", s.name)
+	}
+
+	return s.run(func() error {
+		return command.ExecuteJSON(subcommandFlags, state)
+	})
+}
+
+func (s Sealed) run(execute func() error) (err error) {
+	err = s.sealer.UnsealVars()
+	if err != nil {
+		return fmt.Errorf("Decrypt state: %s", err)
+	}
+
+	defer func() {
+		sealErr := s.sealer.Seal()
+		if sealErr != nil && err == nil {
+			err = fmt.Errorf("Encrypt state: %s", sealErr)
+		}
+	}()
+
+	if s.sealer.Enabled() {
+		interrupts := make(chan os.Signal, 2)
+		done := make(chan struct{})
+		notifyInterrupts(interrupts)
+		defer func() {
+			stopInterrupts(interrupts)
+			close(done)
+		}()
+
+		go s.sealOnInterrupt(interrupts, done, sealGracePeriod, exit)
+	}
+
+	return execute()
+}
+
+// sealOnInterrupt keeps bbl from exiting with the state decrypted. The
+// bosh cli and terraform are interrupted along with bbl, and the state is
+// encrypted when the command returns. If it has not returned after the
+// grace period, or bbl is interrupted again, the state is encrypted and
+// bbl exits.
+func (s Sealed) sealOnInterrupt(interrupts <-chan os.Signal, done <-chan struct{}, gracePeriod time.Duration, exit func(int)) {
+	select {
+	case <-done:
+		return
+	case <-interrupts:
+	}
+
+	select {
+	case <-done:
+		return
+	case <-interrupts:
+	case <-time.After(gracePeriod):
+	}
+
+	err := s.sealer.Seal()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Encrypt state: %s\n", err)
+	}
+	exit(130)
+}
+
+func (s Sealed) Usage() string {
+	return s.command.Usage()
+}
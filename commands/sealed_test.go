@@ -0,0 +1,185 @@
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type blockingCommand struct {
+	fakes.Command
+	execute func() error
+}
+
+func (b *blockingCommand) Execute(subcommandFlags []string, state storage.State) error {
+	return b.execute()
+}
+
+var _ = Describe("Sealed", func() {
+	var (
+		command *fakes.Command
+		sealer  *fakes.Sealer
+		sealed  commands.Sealed
+		state   storage.State
+	)
+
+	BeforeEach(func() {
+		command = &fakes.Command{}
+		sealer = &fakes.Sealer{}
+		state = storage.State{EnvID: "some-env-id"}
+
+		sealed = commands.NewSealed(command, "up", sealer)
+	})
+
+	Describe("Execute", func() {
+		It("decrypts the state while the wrapped command runs", func() {
+			err := sealed.Execute([]string{"--some-flag"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sealer.UnsealVarsCall.CallCount).To(Equal(1))
+			Expect(command.ExecuteCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(command.ExecuteCall.Receives.State).To(Equal(state))
+			Expect(sealer.SealCall.CallCount).To(Equal(1))
+		})
+
+		It("encrypts the state again when the wrapped command panics", func() {
+			panicking := &blockingCommand{execute: func() error { panic("apple") }}
+			sealed = commands.NewSealed(panicking, "up", sealer)
+
+			Expect(func() { sealed.Execute([]string{}, state) }).To(Panic())
+			Expect(sealer.SealCall.CallCount).To(Equal(1))
+		})
+
+		Context("when bbl is interrupted", func() {
+			var (
+				interrupts chan<- os.Signal
+				exited     chan int
+				interrupt  chan struct{}
+				release    chan struct{}
+			)
+
+			BeforeEach(func() {
+				exited = make(chan int, 1)
+				interrupt = make(chan struct{})
+				release = make(chan struct{})
+				sealer.EnabledCall.Returns.Enabled = true
+
+				commands.SetNotifyInterrupts(func(c chan<- os.Signal) { interrupts = c })
+				commands.SetStopInterrupts(func(chan<- os.Signal) {})
+				commands.SetExit(func(code int) { exited <- code })
+				commands.SetSealGracePeriod(time.Hour)
+
+				sealed = commands.NewSealed(&blockingCommand{execute: func() error {
+					close(interrupt)
+					<-release
+					return errors.New("interrupted")
+				}}, "up", sealer)
+			})
+
+			AfterEach(func() {
+				commands.ResetInterrupts()
+			})
+
+			It("encrypts the state when the wrapped command stops", func() {
+				go func() {
+					<-interrupt
+					interrupts <- os.Interrupt
+					close(release)
+				}()
+
+				err := sealed.Execute([]string{}, state)
+				Expect(err).To(MatchError("interrupted"))
+				Expect(sealer.SealCall.CallCount).To(Equal(1))
+				Expect(exited).NotTo(Receive())
+			})
+
+			It("encrypts the state and exits when interrupted again", func() {
+				go func() {
+					<-interrupt
+					interrupts <- os.Interrupt
+					interrupts <- os.Interrupt
+				}()
+				returned := make(chan struct{})
+				go func() {
+					sealed.Execute([]string{}, state)
+					close(returned)
+				}()
+
+				Eventually(exited).Should(Receive(Equal(130)))
+				Expect(sealer.SealCall.CallCount).To(Equal(1))
+				close(release)
+				Eventually(returned).Should(BeClosed())
+			})
+
+			It("encrypts the state and exits when the wrapped command does not stop", func() {
+				commands.SetSealGracePeriod(time.Millisecond)
+				go func() {
+					<-interrupt
+					interrupts <- os.Interrupt
+				}()
+				returned := make(chan struct{})
+				go func() {
+					sealed.Execute([]string{}, state)
+					close(returned)
+				}()
+
+				Eventually(exited).Should(Receive(Equal(130)))
+				Expect(sealer.SealCall.CallCount).To(Equal(1))
+				close(release)
+				Eventually(returned).Should(BeClosed())
+			})
+		})
+
+		It("encrypts the state again when the wrapped command fails", func() {
+			command.ExecuteCall.Returns.Error = errors.New("apple")
+
+			err := sealed.Execute([]string{}, state)
+			Expect(err).To(MatchError("apple"))
+			Expect(sealer.SealCall.CallCount).To(Equal(1))
+		})
+
+		Context("failure cases", func() {
+			It("does not run the wrapped command when the state cannot be decrypted", func() {
+				sealer.UnsealVarsCall.Returns.Error = errors.New("banana")
+
+				err := sealed.Execute([]string{}, state)
+				Expect(err).To(MatchError("Decrypt state: banana"))
+				Expect(command.ExecuteCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the state cannot be encrypted", func() {
+				sealer.SealCall.Returns.Error = errors.New("cherry")
+
+				err := sealed.Execute([]string{}, state)
+				Expect(err).To(MatchError("Encrypt state: cherry"))
+			})
+		})
+	})
+
+	Describe("ExecuteJSON", func() {
+		It("decrypts the state while the wrapped command prints json", func() {
+			jsonCommand := &fakes.JSONCommand{}
+			sealed = commands.NewSealed(jsonCommand, "drift", sealer)
+
+			err := sealed.ExecuteJSON([]string{"--some-flag"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sealer.UnsealVarsCall.CallCount).To(Equal(1))
+			Expect(jsonCommand.ExecuteJSONCall.Receives.SubcommandFlags).To(Equal([]string{"--some-flag"}))
+			Expect(sealer.SealCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error when the wrapped command cannot print json", func() {
+			err := sealed.ExecuteJSON([]string{}, state)
+			Expect(err).To(MatchError("bbl up does not support --json"))
+			Expect(sealer.UnsealVarsCall.CallCount).To(Equal(0))
+		})
+	})
+})
@@ -3,40 +3,37 @@ package commands
 import (
 	"errors"
 	"fmt"
-	"path/filepath"
+	"net"
 	"strings"
 
-	"github.com/cloudfoundry/bosh-bootloader/fileio"
 	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/ssh"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
 
 type SSH struct {
-	cmd           sshCmd
-	keyGetter     sshKeyGetter
-	tempDirWriter tempDirWriter
-	randomPort    randomPort
+	client    sshClient
+	keyGetter sshKeyGetter
 }
 
-type sshCmd interface {
-	Run([]string) error
+type sshClient interface {
+	Shell(target ssh.Target) error
+	Run(target ssh.Target, command string) error
+	Upload(target ssh.Target, localPath, remotePath string) error
+	Download(target ssh.Target, remotePath, localPath string) error
 }
 
-type randomPort interface {
-	GetPort() (string, error)
+// exitStatusError is returned by the ssh client when the remote command
+// exits non-zero.
+type exitStatusError interface {
+	error
+	ExitStatus() int
 }
 
-type tempDirWriter interface {
-	fileio.FileWriter
-	fileio.TempDirer
-}
-
-func NewSSH(sshCmd sshCmd, sshKeyGetter sshKeyGetter, tempDirWriter tempDirWriter, randomPort randomPort) SSH {
+func NewSSH(sshClient sshClient, sshKeyGetter sshKeyGetter) SSH {
 	return SSH{
-		cmd:           sshCmd,
-		keyGetter:     sshKeyGetter,
-		tempDirWriter: tempDirWriter,
-		randomPort:    randomPort,
+		client:    sshClient,
+		keyGetter: sshKeyGetter,
 	}
 }
 
@@ -48,69 +45,84 @@ func (s SSH) CheckFastFails(subcommandFlags []string, state storage.State) error
 	return nil
 }
 
-func (s SSH) Execute(args []string, state storage.State) error {
-	var (
-		jumpbox  bool
-		director bool
-	)
-	sshFlags := flags.New("ssh")
-	sshFlags.Bool(&jumpbox, "jumpbox")
-	sshFlags.Bool(&director, "director")
-	err := sshFlags.Parse(args)
+type sshArgs struct {
+	jumpbox  bool
+	director bool
+	cmd      string
+}
+
+func sshFlags(args *sshArgs) flags.Flags {
+	f := flags.New("ssh")
+	f.Bool(&args.jumpbox, "jumpbox")
+	f.Bool(&args.director, "director")
+	f.String(&args.cmd, "cmd", "")
+	return f
+}
+
+func (s SSH) Flags() []flags.Flags {
+	return []flags.Flags{sshFlags(&sshArgs{})}
+}
+
+func (s SSH) Execute(subcommandFlags []string, state storage.State) error {
+	var args sshArgs
+	err := sshFlags(&args).Parse(subcommandFlags)
 	if err != nil {
 		return err
 	}
 
-	if !jumpbox && !director {
+	if !args.jumpbox && !args.director {
 		return fmt.Errorf("This command requires the --jumpbox or --director flag.")
 	}
 
-	tempDir, err := s.tempDirWriter.TempDir("", "")
-	if err != nil {
-		return fmt.Errorf("Create temp directory: %s", err)
+	deployment := "jumpbox"
+	if args.director {
+		deployment = "director"
 	}
 
-	jumpboxKey, err := s.keyGetter.Get("jumpbox")
+	target, err := sshTarget(s.keyGetter, state, deployment)
 	if err != nil {
-		return fmt.Errorf("Get jumpbox private key: %s", err)
+		return err
 	}
 
-	jumpboxKeyPath := filepath.Join(tempDir, "jumpbox-private-key")
-
-	err = s.tempDirWriter.WriteFile(jumpboxKeyPath, []byte(jumpboxKey), 0600)
-	if err != nil {
-		return fmt.Errorf("Write private key file: %s", err)
+	if args.cmd != "" {
+		err = s.client.Run(target, args.cmd)
+		if exitErr, ok := err.(exitStatusError); ok {
+			return ExitError{
+				Code:    exitErr.ExitStatus(),
+				Message: exitErr.Error(),
+			}
+		}
+		return err
 	}
 
-	jumpboxURL := strings.Split(state.Jumpbox.URL, ":")[0]
-
-	if jumpbox {
-		return s.cmd.Run([]string{"-o StrictHostKeyChecking=no -o ServerAliveInterval=300", fmt.Sprintf("jumpbox@%s", jumpboxURL), "-i", jumpboxKeyPath})
-	}
+	return s.client.Shell(target)
+}
 
-	directorPrivateKey, err := s.keyGetter.Get("director")
+// sshTarget builds the connection details for the jumpbox, or for the
+// director reached through the jumpbox.
+func sshTarget(keyGetter sshKeyGetter, state storage.State, deployment string) (ssh.Target, error) {
+	jumpboxKey, err := keyGetter.Get("jumpbox")
 	if err != nil {
-		return fmt.Errorf("Get director private key: %s", err)
+		return ssh.Target{}, fmt.Errorf("Get jumpbox private key: %s", err)
 	}
 
-	directorKeyPath := filepath.Join(tempDir, "director-private-key")
-
-	err = s.tempDirWriter.WriteFile(directorKeyPath, []byte(directorPrivateKey), 0600)
-	if err != nil {
-		return fmt.Errorf("Write private key file: %s", err)
+	target := ssh.Target{
+		JumpboxAddress: net.JoinHostPort(strings.Split(state.Jumpbox.URL, ":")[0], "22"),
+		JumpboxKey:     jumpboxKey,
 	}
 
-	port, err := s.randomPort.GetPort()
-	if err != nil {
-		return fmt.Errorf("Open proxy port: %s", err)
+	if deployment == "jumpbox" {
+		return target, nil
 	}
 
-	err = s.cmd.Run([]string{"-4 -D", port, "-fNC", fmt.Sprintf("jumpbox@%s", jumpboxURL), "-i", jumpboxKeyPath})
+	directorKey, err := keyGetter.Get("director")
 	if err != nil {
-		return fmt.Errorf("Open tunnel to jumpbox: %s", err)
+		return ssh.Target{}, fmt.Errorf("Get director private key: %s", err)
 	}
 
 	ip := strings.Split(strings.TrimPrefix(state.BOSH.DirectorAddress, "https://"), ":")[0]
+	target.DirectorAddress = net.JoinHostPort(ip, "22")
+	target.DirectorKey = directorKey
 
-	return s.cmd.Run([]string{fmt.Sprintf("-o ProxyCommand=nc -x localhost:%s %s", port, "%h %p"), "-i", directorKeyPath, fmt.Sprintf("jumpbox@%s", ip)})
+	return target, nil
 }
@@ -42,6 +42,26 @@ func (s SSHKey) CheckFastFails(subcommandFlags []string, state storage.State) er
 }
 
 func (s SSHKey) Execute(subcommandFlags []string, state storage.State) error {
+	privateKey, err := s.get()
+	if err != nil {
+		return err
+	}
+
+	s.logger.Println(privateKey)
+
+	return nil
+}
+
+func (s SSHKey) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	privateKey, err := s.get()
+	if err != nil {
+		return err
+	}
+
+	return printJSON(s.logger, map[string]string{"private_key": privateKey})
+}
+
+func (s SSHKey) get() (string, error) {
 	deployment := "jumpbox"
 	if s.Director {
 		deployment = "director"
@@ -49,14 +69,12 @@ func (s SSHKey) Execute(subcommandFlags []string, state storage.State) error {
 
 	privateKey, err := s.sshKeyGetter.Get(deployment)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	if privateKey == "" {
-		return errors.New("Could not retrieve the ssh key, please make sure you are targeting the proper state dir.")
+		return "", errors.New("Could not retrieve the ssh key, please make sure you are targeting the proper state dir.")
 	}
 
-	s.logger.Println(privateKey)
-
-	return nil
+	return privateKey, nil
 }
@@ -93,4 +93,20 @@ var _ = Describe("SSHKey", func() {
 			})
 		})
 	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the private ssh key as json", func() {
+			err := sshKeyCommand.ExecuteJSON([]string{}, incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"private_key": "some-private-ssh-key"}`))
+		})
+
+		It("returns an error when the ssh key is empty", func() {
+			sshKeyGetter.GetCall.Returns.PrivateKey = ""
+
+			err := sshKeyCommand.ExecuteJSON([]string{}, incomingState)
+			Expect(err).To(MatchError("Could not retrieve the ssh key, please make sure you are targeting the proper state dir."))
+		})
+	})
 })
@@ -2,11 +2,10 @@ package commands_test
 
 import (
 	"errors"
-	"os"
-	"path/filepath"
 
 	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	sshclient "github.com/cloudfoundry/bosh-bootloader/ssh"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 
 	. "github.com/onsi/ginkgo"
@@ -16,19 +15,15 @@ import (
 var _ = Describe("SSH", func() {
 	var (
 		ssh          commands.SSH
-		sshCmd       *fakes.SSHCmd
+		sshClient    *fakes.SSHClient
 		sshKeyGetter *fakes.FancySSHKeyGetter
-		fileIO       *fakes.FileIO
-		randomPort   *fakes.RandomPort
 	)
 
 	BeforeEach(func() {
-		sshCmd = &fakes.SSHCmd{}
+		sshClient = &fakes.SSHClient{}
 		sshKeyGetter = &fakes.FancySSHKeyGetter{}
-		fileIO = &fakes.FileIO{}
-		randomPort = &fakes.RandomPort{}
 
-		ssh = commands.NewSSH(sshCmd, sshKeyGetter, fileIO, randomPort)
+		ssh = commands.NewSSH(sshClient, sshKeyGetter)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -46,14 +41,10 @@ var _ = Describe("SSH", func() {
 	})
 
 	Describe("Execute", func() {
-		var (
-			jumpboxPrivateKeyPath string
-			state                 storage.State
-		)
+		var state storage.State
+
 		BeforeEach(func() {
-			fileIO.TempDirCall.Returns.Name = "some-temp-dir"
 			sshKeyGetter.JumpboxGetCall.Returns.PrivateKey = "jumpbox-private-key"
-			jumpboxPrivateKeyPath = filepath.Join("some-temp-dir", "jumpbox-private-key")
 
 			state = storage.State{
 				Jumpbox: storage.Jumpbox{
@@ -66,42 +57,24 @@ var _ = Describe("SSH", func() {
 		})
 
 		Context("--director", func() {
-			var directorPrivateKeyPath string
-
 			BeforeEach(func() {
 				sshKeyGetter.DirectorGetCall.Returns.PrivateKey = "director-private-key"
-				directorPrivateKeyPath = filepath.Join("some-temp-dir", "director-private-key")
-				randomPort.GetPortCall.Returns.Port = "60000"
 			})
 
-			It("calls ssh with appropriate arguments", func() {
+			It("opens a shell on the director through the jumpbox", func() {
 				err := ssh.Execute([]string{"--director"}, state)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(sshKeyGetter.JumpboxGetCall.CallCount).To(Equal(1))
 				Expect(sshKeyGetter.DirectorGetCall.CallCount).To(Equal(1))
 
-				Expect(fileIO.WriteFileCall.CallCount).To(Equal(2))
-				Expect(fileIO.WriteFileCall.Receives).To(ConsistOf(
-					fakes.WriteFileReceive{
-						Filename: jumpboxPrivateKeyPath,
-						Contents: []byte("jumpbox-private-key"),
-						Mode:     os.FileMode(0600),
-					},
-					fakes.WriteFileReceive{
-						Filename: directorPrivateKeyPath,
-						Contents: []byte("director-private-key"),
-						Mode:     os.FileMode(0600),
-					},
-				))
-
-				Expect(sshCmd.RunCall.Receives[0].Args).To(ConsistOf(
-					"-4 -D", "60000", "-fNC", "jumpbox@jumpboxURL", "-i", jumpboxPrivateKeyPath,
-				))
-
-				Expect(sshCmd.RunCall.Receives[1].Args).To(ConsistOf(
-					"-o ProxyCommand=nc -x localhost:60000 %h %p", "-i", directorPrivateKeyPath, "jumpbox@directorURL",
-				))
+				Expect(sshClient.ShellCall.CallCount).To(Equal(1))
+				Expect(sshClient.ShellCall.Receives.Target).To(Equal(sshclient.Target{
+					JumpboxAddress:  "jumpboxURL:22",
+					JumpboxKey:      "jumpbox-private-key",
+					DirectorAddress: "directorURL:22",
+					DirectorKey:     "director-private-key",
+				}))
 			})
 
 			Context("when ssh key getter fails to get director key", func() {
@@ -113,83 +86,71 @@ var _ = Describe("SSH", func() {
 					Expect(err).To(MatchError("Get director private key: fig"))
 				})
 			})
+		})
 
-			Context("when fileio fails to create a temp dir", func() {
-				It("returns the error", func() {
-					fileIO.TempDirCall.Returns.Error = errors.New("date")
-
-					err := ssh.Execute([]string{"--director"}, state)
-
-					Expect(err).To(MatchError("Create temp directory: date"))
-				})
-			})
-
-			Context("when fileio fails to create a temp dir", func() {
-				It("contextualizes a failure to write the private key", func() {
-					fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("boisenberry")}}
+		Context("--jumpbox", func() {
+			It("opens a shell on the jumpbox", func() {
+				err := ssh.Execute([]string{"--jumpbox"}, state)
+				Expect(err).NotTo(HaveOccurred())
 
-					err := ssh.Execute([]string{"--director"}, state)
+				Expect(sshKeyGetter.JumpboxGetCall.CallCount).To(Equal(1))
+				Expect(sshKeyGetter.DirectorGetCall.CallCount).To(Equal(0))
 
-					Expect(err).To(MatchError("Write private key file: boisenberry"))
-				})
+				Expect(sshClient.ShellCall.Receives.Target).To(Equal(sshclient.Target{
+					JumpboxAddress: "jumpboxURL:22",
+					JumpboxKey:     "jumpbox-private-key",
+				}))
 			})
 
-			Context("when random port fails to return a port", func() {
+			Context("when ssh key getter fails to get the jumpbox ssh private key", func() {
 				It("returns the error", func() {
-					randomPort.GetPortCall.Returns.Error = errors.New("prune")
+					sshKeyGetter.JumpboxGetCall.Returns.Error = errors.New("fig")
 
-					err := ssh.Execute([]string{"--director"}, state)
+					err := ssh.Execute([]string{"--jumpbox"}, state)
 
-					Expect(err).To(MatchError("Open proxy port: prune"))
+					Expect(err).To(MatchError("Get jumpbox private key: fig"))
 				})
 			})
 
-			Context("when the ssh command fails to open a tunnel to the jumpbox", func() {
+			Context("when the connection fails", func() {
 				It("returns the error", func() {
-					sshCmd.RunCall.Returns = []fakes.SSHRunReturn{fakes.SSHRunReturn{Error: errors.New("lignonberry")}}
+					sshClient.ShellCall.Returns.Error = errors.New("lignonberry")
 
-					err := ssh.Execute([]string{"--director"}, state)
+					err := ssh.Execute([]string{"--jumpbox"}, state)
 
-					Expect(err).To(MatchError("Open tunnel to jumpbox: lignonberry"))
+					Expect(err).To(MatchError("lignonberry"))
 				})
 			})
 		})
 
-		Context("--jumpbox", func() {
-			It("calls ssh with appropriate arguments", func() {
-				err := ssh.Execute([]string{"--jumpbox"}, state)
+		Context("--cmd", func() {
+			It("runs the command instead of opening a shell", func() {
+				err := ssh.Execute([]string{"--jumpbox", "--cmd", "uptime"}, state)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(sshKeyGetter.JumpboxGetCall.CallCount).To(Equal(1))
-
-				Expect(fileIO.WriteFileCall.CallCount).To(Equal(1))
-				Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal(jumpboxPrivateKeyPath))
-				Expect(fileIO.WriteFileCall.Receives[0].Contents).To(Equal([]byte("jumpbox-private-key")))
-				Expect(fileIO.WriteFileCall.Receives[0].Mode).To(Equal(os.FileMode(0600)))
-
-				Expect(sshCmd.RunCall.Receives[0].Args).To(ConsistOf(
-					"-o StrictHostKeyChecking=no -o ServerAliveInterval=300", "jumpbox@jumpboxURL", "-i", jumpboxPrivateKeyPath,
-				))
+				Expect(sshClient.ShellCall.CallCount).To(Equal(0))
+				Expect(sshClient.RunCall.CallCount).To(Equal(1))
+				Expect(sshClient.RunCall.Receives.Command).To(Equal("uptime"))
+				Expect(sshClient.RunCall.Receives.Target.JumpboxAddress).To(Equal("jumpboxURL:22"))
 			})
 
-			Context("when ssh key getter fails to get the jumpbox ssh private key", func() {
-				It("returns the error", func() {
-					sshKeyGetter.JumpboxGetCall.Returns.Error = errors.New("fig")
+			It("exits with the status of the remote command", func() {
+				sshClient.RunCall.Returns.Error = fakes.ExitStatusError{Status: 3, Message: "Process exited with status 3"}
 
-					err := ssh.Execute([]string{"--jumpbox"}, state)
+				err := ssh.Execute([]string{"--director", "--cmd", "exit 3"}, state)
 
-					Expect(err).To(MatchError("Get jumpbox private key: fig"))
-				})
+				Expect(err).To(Equal(commands.ExitError{
+					Code:    3,
+					Message: "Process exited with status 3",
+				}))
 			})
 
-			Context("when fileio fails to write the jumpbox private key", func() {
-				It("returns the error", func() {
-					fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("boisenberry")}}
+			It("returns the error when the command cannot be run", func() {
+				sshClient.RunCall.Returns.Error = errors.New("Open session: some-error")
 
-					err := ssh.Execute([]string{"--jumpbox"}, state)
+				err := ssh.Execute([]string{"--director", "--cmd", "false"}, state)
 
-					Expect(err).To(MatchError("Write private key file: boisenberry"))
-				})
+				Expect(err).To(MatchError("Open session: some-error"))
 			})
 		})
 
@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateBundler interface {
+	Export(dir string, stripSecrets bool) ([]byte, error)
+	Import(dir string, data []byte) (storage.BundleManifest, storage.State, error)
+}
+
+type stateMigrator interface {
+	Migrate(state storage.State) (storage.State, error)
+}
+
+type scriptGenerator interface {
+	InitializeJumpbox(bblState storage.State) error
+	InitializeDirector(bblState storage.State) error
+}
+
+type bundleFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+}
+
+type StateExport struct {
+	logger         logger
+	stateValidator stateValidator
+	bundler        stateBundler
+	fs             bundleFs
+	stateDir       string
+}
+
+func NewStateExport(logger logger, stateValidator stateValidator, bundler stateBundler, fs bundleFs, stateDir string) StateExport {
+	return StateExport{
+		logger:         logger,
+		stateValidator: stateValidator,
+		bundler:        bundler,
+		fs:             fs,
+		stateDir:       stateDir,
+	}
+}
+
+func (s StateExport) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return s.stateValidator.Validate()
+}
+
+func stateExportFlags(output *string, stripSecrets *bool, envID string) flags.Flags {
+	f := flags.New("state export")
+	f.String(output, "output", fmt.Sprintf("%s.bbl.tgz", envID))
+	f.Bool(stripSecrets, "strip-secrets")
+	return f
+}
+
+func (s StateExport) Flags() []flags.Flags {
+	var (
+		output       string
+		stripSecrets bool
+	)
+	return []flags.Flags{stateExportFlags(&output, &stripSecrets, "")}
+}
+
+func (s StateExport) Execute(args []string, state storage.State) error {
+	var (
+		output       string
+		stripSecrets bool
+	)
+	err := stateExportFlags(&output, &stripSecrets, state.EnvID).Parse(args)
+	if err != nil {
+		return err
+	}
+
+	bundle, err := s.bundler.Export(s.stateDir, stripSecrets)
+	if err != nil {
+		return err
+	}
+
+	err = s.fs.WriteFile(output, bundle, storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Write bundle: %s", err)
+	}
+
+	s.logger.Printf("Exported %s to %s.\n", state.EnvID, output)
+	return nil
+}
+
+type StateImport struct {
+	logger          logger
+	bundler         stateBundler
+	migrator        stateMigrator
+	scriptGenerator scriptGenerator
+	fs              bundleFs
+	stateDir        string
+}
+
+func NewStateImport(logger logger, bundler stateBundler, migrator stateMigrator, scriptGenerator scriptGenerator, fs bundleFs, stateDir string) StateImport {
+	return StateImport{
+		logger:          logger,
+		bundler:         bundler,
+		migrator:        migrator,
+		scriptGenerator: scriptGenerator,
+		fs:              fs,
+		stateDir:        stateDir,
+	}
+}
+
+func (s StateImport) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) != 1 {
+		return errors.New("bbl state import requires the path to a bundle created by bbl state export.")
+	}
+
+	if state.EnvID != "" {
+		return fmt.Errorf("%s already contains the bbl environment %s. Import into an empty state directory.", s.stateDir, state.EnvID)
+	}
+
+	return nil
+}
+
+func (s StateImport) Execute(args []string, state storage.State) error {
+	bundle, err := s.fs.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("Read bundle: %s", err)
+	}
+
+	s.logger.Step("importing state bundle")
+	manifest, state, err := s.bundler.Import(s.stateDir, bundle)
+	if err != nil {
+		return err
+	}
+
+	state, err = s.migrator.Migrate(state)
+	if err != nil {
+		return fmt.Errorf("Migrate state: %s", err)
+	}
+
+	s.logger.Step("generating create-env scripts")
+	err = s.scriptGenerator.InitializeJumpbox(state)
+	if err != nil {
+		return fmt.Errorf("Bosh manager initialize jumpbox: %s", err)
+	}
+
+	if !state.NoDirector {
+		err = s.scriptGenerator.InitializeDirector(state)
+		if err != nil {
+			return fmt.Errorf("Bosh manager initialize director: %s", err)
+		}
+	}
+
+	s.logger.Printf("Imported %s (%s), exported by bbl v%s on %s.\n", manifest.EnvID, manifest.IAAS, manifest.BBLVersion, manifest.CreatedAt.Format(time.RFC3339))
+	if manifest.SecretsStripped {
+		s.logger.Println("The bundle was exported with --strip-secrets: the vars stores, create-env state and terraform state were not included.")
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package commands_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateExport", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		bundler        *fakes.StateBundler
+		fileIO         *fakes.FileIO
+		command        commands.StateExport
+		state          storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		bundler = &fakes.StateBundler{}
+		fileIO = &fakes.FileIO{}
+		state = storage.State{EnvID: "some-env"}
+
+		bundler.ExportCall.Returns.Bundle = []byte("some-bundle")
+
+		command = commands.NewStateExport(logger, stateValidator, bundler, fileIO, "/some/state-dir")
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+			Expect(command.CheckFastFails([]string{}, state)).To(MatchError("no state"))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("writes the bundle named after the environment", func() {
+			err := command.Execute([]string{}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bundler.ExportCall.Receives.Dir).To(Equal("/some/state-dir"))
+			Expect(bundler.ExportCall.Receives.StripSecrets).To(BeFalse())
+			Expect(fileIO.WriteFileCall.Receives).To(ConsistOf(fakes.WriteFileReceive{
+				Filename: "some-env.bbl.tgz",
+				Contents: []byte("some-bundle"),
+				Mode:     storage.StateMode,
+			}))
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{"Exported some-env to some-env.bbl.tgz.\n"}))
+		})
+
+		It("accepts an output path and strips secrets", func() {
+			err := command.Execute([]string{"--output", "/tmp/handoff.tgz", "--strip-secrets"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bundler.ExportCall.Receives.StripSecrets).To(BeTrue())
+			Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal("/tmp/handoff.tgz"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the bundle cannot be created", func() {
+				bundler.ExportCall.Returns.Error = errors.New("apple")
+				Expect(command.Execute([]string{}, state)).To(MatchError("apple"))
+			})
+
+			It("returns an error when the bundle cannot be written", func() {
+				fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("banana")}}
+				Expect(command.Execute([]string{}, state)).To(MatchError("Write bundle: banana"))
+			})
+		})
+	})
+})
+
+var _ = Describe("StateImport", func() {
+	var (
+		logger      *fakes.Logger
+		bundler     *fakes.StateBundler
+		migrator    *fakes.StateMigrator
+		boshManager *fakes.BOSHManager
+		fileIO      *fakes.FileIO
+		command     commands.StateImport
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		bundler = &fakes.StateBundler{}
+		migrator = &fakes.StateMigrator{}
+		boshManager = &fakes.BOSHManager{}
+		fileIO = &fakes.FileIO{}
+
+		fileIO.ReadFileCall.Returns.Contents = []byte("some-bundle")
+		bundler.ImportCall.Returns.Manifest = storage.BundleManifest{
+			EnvID:      "some-env",
+			IAAS:       "gcp",
+			BBLVersion: "6.6.0",
+			CreatedAt:  time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC),
+		}
+		bundler.ImportCall.Returns.State = storage.State{EnvID: "some-env", Version: 13}
+		migrator.MigrateCall.Returns.State = storage.State{EnvID: "some-env", Version: 14}
+
+		command = commands.NewStateImport(logger, bundler, migrator, boshManager, fileIO, "/some/state-dir")
+	})
+
+	Describe("CheckFastFails", func() {
+		It("requires the path to a bundle", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("bbl state import requires the path to a bundle created by bbl state export."))
+		})
+
+		It("refuses to import over an existing environment", func() {
+			err := command.CheckFastFails([]string{"some-env.bbl.tgz"}, storage.State{EnvID: "other-env"})
+			Expect(err).To(MatchError("/some/state-dir already contains the bbl environment other-env. Import into an empty state directory."))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("unpacks, migrates and regenerates the create-env scripts", func() {
+			err := command.Execute([]string{"some-env.bbl.tgz"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.ReadFileCall.Receives.Filename).To(Equal("some-env.bbl.tgz"))
+			Expect(bundler.ImportCall.Receives.Dir).To(Equal("/some/state-dir"))
+			Expect(bundler.ImportCall.Receives.Bundle).To(Equal([]byte("some-bundle")))
+			Expect(migrator.MigrateCall.Receives.State).To(Equal(storage.State{EnvID: "some-env", Version: 13}))
+			Expect(boshManager.InitializeJumpboxCall.Receives.State).To(Equal(storage.State{EnvID: "some-env", Version: 14}))
+			Expect(boshManager.InitializeDirectorCall.Receives.State).To(Equal(storage.State{EnvID: "some-env", Version: 14}))
+			Expect(logger.PrintfCall.Messages).To(ContainElement("Imported some-env (gcp), exported by bbl v6.6.0 on 2018-03-01T12:00:00Z.\n"))
+		})
+
+		It("does not generate director scripts for jumpbox-only environments", func() {
+			migrator.MigrateCall.Returns.State = storage.State{EnvID: "some-env", NoDirector: true}
+
+			Expect(command.Execute([]string{"some-env.bbl.tgz"}, storage.State{})).To(Succeed())
+			Expect(boshManager.InitializeDirectorCall.CallCount).To(Equal(0))
+		})
+
+		It("warns when secrets were stripped", func() {
+			bundler.ImportCall.Returns.Manifest.SecretsStripped = true
+
+			Expect(command.Execute([]string{"some-env.bbl.tgz"}, storage.State{})).To(Succeed())
+			Expect(logger.PrintlnCall.Receives.Message).To(ContainSubstring("--strip-secrets"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the bundle cannot be read", func() {
+				fileIO.ReadFileCall.Returns.Error = errors.New("apple")
+				Expect(command.Execute([]string{"some-env.bbl.tgz"}, storage.State{})).To(MatchError("Read bundle: apple"))
+			})
+
+			It("returns an error when the bundle is invalid", func() {
+				bundler.ImportCall.Returns.Error = errors.New("banana")
+				Expect(command.Execute([]string{"some-env.bbl.tgz"}, storage.State{})).To(MatchError("banana"))
+				Expect(migrator.MigrateCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the state cannot be migrated", func() {
+				migrator.MigrateCall.Returns.Error = errors.New("cherry")
+				Expect(command.Execute([]string{"some-env.bbl.tgz"}, storage.State{})).To(MatchError("Migrate state: cherry"))
+			})
+
+			It("returns an error when the scripts cannot be generated", func() {
+				boshManager.InitializeDirectorCall.Returns.Error = errors.New("durian")
+				Expect(command.Execute([]string{"some-env.bbl.tgz"}, storage.State{})).To(MatchError("Bosh manager initialize director: durian"))
+			})
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type StateEncrypt struct {
+	logger         logger
+	stateValidator stateValidator
+	sealer         sealer
+}
+
+func NewStateEncrypt(logger logger, stateValidator stateValidator, sealer sealer) StateEncrypt {
+	return StateEncrypt{
+		logger:         logger,
+		stateValidator: stateValidator,
+		sealer:         sealer,
+	}
+}
+
+func (s StateEncrypt) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	err := s.stateValidator.Validate()
+	if err != nil {
+		return err
+	}
+
+	if !s.sealer.Enabled() {
+		return errors.New("bbl state encrypt requires --state-passphrase or --state-key-file.")
+	}
+
+	return nil
+}
+
+func (s StateEncrypt) Execute(subcommandFlags []string, state storage.State) error {
+	s.logger.Step("encrypting state directory")
+
+	err := s.sealer.Seal()
+	if err != nil {
+		return err
+	}
+
+	s.logger.Println("State directory encrypted. Pass the same passphrase or key file to every bbl command from now on.")
+	return nil
+}
+
+type StateDecrypt struct {
+	logger         logger
+	stateValidator stateValidator
+	sealer         sealer
+}
+
+func NewStateDecrypt(logger logger, stateValidator stateValidator, sealer sealer) StateDecrypt {
+	return StateDecrypt{
+		logger:         logger,
+		stateValidator: stateValidator,
+		sealer:         sealer,
+	}
+}
+
+func (s StateDecrypt) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return s.stateValidator.Validate()
+}
+
+func (s StateDecrypt) Execute(subcommandFlags []string, state storage.State) error {
+	s.logger.Step("decrypting state directory")
+
+	err := s.sealer.Unseal()
+	if err != nil {
+		return err
+	}
+
+	s.logger.Println("State directory decrypted. Stop passing the passphrase or key file to keep it that way.")
+	return nil
+}
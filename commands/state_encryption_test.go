@@ -0,0 +1,86 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateEncrypt", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		sealer         *fakes.Sealer
+		command        commands.StateEncrypt
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		sealer = &fakes.Sealer{}
+		sealer.EnabledCall.Returns.Enabled = true
+
+		command = commands.NewStateEncrypt(logger, stateValidator, sealer)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+			Expect(command.CheckFastFails([]string{}, storage.State{})).To(MatchError("no state"))
+		})
+
+		It("requires a passphrase or key file", func() {
+			sealer.EnabledCall.Returns.Enabled = false
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("bbl state encrypt requires --state-passphrase or --state-key-file."))
+		})
+	})
+
+	Describe("Execute", func() {
+		It("encrypts the state directory", func() {
+			Expect(command.Execute([]string{}, storage.State{})).To(Succeed())
+			Expect(sealer.SealCall.CallCount).To(Equal(1))
+			Expect(logger.StepCall.Receives.Message).To(Equal("encrypting state directory"))
+		})
+
+		It("returns an error when encryption fails", func() {
+			sealer.SealCall.Returns.Error = errors.New("apple")
+			Expect(command.Execute([]string{}, storage.State{})).To(MatchError("apple"))
+		})
+	})
+})
+
+var _ = Describe("StateDecrypt", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		sealer         *fakes.Sealer
+		command        commands.StateDecrypt
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		sealer = &fakes.Sealer{}
+
+		command = commands.NewStateDecrypt(logger, stateValidator, sealer)
+	})
+
+	Describe("Execute", func() {
+		It("decrypts the state directory", func() {
+			Expect(command.Execute([]string{}, storage.State{})).To(Succeed())
+			Expect(sealer.UnsealCall.CallCount).To(Equal(1))
+			Expect(logger.StepCall.Receives.Message).To(Equal("decrypting state directory"))
+		})
+
+		It("returns an error when decryption fails", func() {
+			sealer.UnsealCall.Returns.Error = errors.New("apple")
+			Expect(command.Execute([]string{}, storage.State{})).To(MatchError("apple"))
+		})
+	})
+})
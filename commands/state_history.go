@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateSnapshots interface {
+	Snapshots() ([]storage.Snapshot, error)
+	Rollback(id string) error
+}
+
+type StateHistory struct {
+	logger         logger
+	stateValidator stateValidator
+	snapshots      stateSnapshots
+}
+
+func NewStateHistory(logger logger, stateValidator stateValidator, snapshots stateSnapshots) StateHistory {
+	return StateHistory{
+		logger:         logger,
+		stateValidator: stateValidator,
+		snapshots:      snapshots,
+	}
+}
+
+func (s StateHistory) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return validateStateOrSnapshots(s.stateValidator, s.snapshots)
+}
+
+func (s StateHistory) Execute(subcommandFlags []string, state storage.State) error {
+	snapshots, err := s.snapshots.Snapshots()
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) == 0 {
+		s.logger.Println("No snapshots have been taken yet.")
+		return nil
+	}
+
+	s.logger.Printf("%-6s %-22s %s\n", "ID", "TAKEN", "FILES")
+	for _, snapshot := range snapshots {
+		s.logger.Printf("%-6s %-22s %s\n", snapshot.ID, snapshot.Timestamp.Format(time.RFC3339), strings.Join(snapshot.Files, ", "))
+	}
+
+	return nil
+}
+
+type StateRollback struct {
+	logger         logger
+	stateValidator stateValidator
+	snapshots      stateSnapshots
+}
+
+func NewStateRollback(logger logger, stateValidator stateValidator, snapshots stateSnapshots) StateRollback {
+	return StateRollback{
+		logger:         logger,
+		stateValidator: stateValidator,
+		snapshots:      snapshots,
+	}
+}
+
+func (s StateRollback) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) != 1 {
+		return errors.New("bbl state rollback requires a snapshot ID. Run `bbl state history` to list them.")
+	}
+
+	return validateStateOrSnapshots(s.stateValidator, s.snapshots)
+}
+
+func (s StateRollback) Execute(subcommandFlags []string, state storage.State) error {
+	id := subcommandFlags[0]
+	s.logger.Step("rolling back to snapshot %s", id)
+
+	err := s.snapshots.Rollback(id)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Println("State directory restored. The state before the rollback was saved as a new snapshot.")
+	return nil
+}
+
+// validateStateOrSnapshots also accepts a state directory without
+// bbl-state.json that has snapshots, which bbl destroy leaves behind.
+func validateStateOrSnapshots(stateValidator stateValidator, snapshots stateSnapshots) error {
+	err := stateValidator.Validate()
+	if err == nil {
+		return nil
+	}
+
+	taken, snapshotsErr := snapshots.Snapshots()
+	if snapshotsErr == nil && len(taken) > 0 {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,130 @@
+package commands_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StateHistory", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		snapshots      *fakes.StateSnapshots
+		command        commands.StateHistory
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		snapshots = &fakes.StateSnapshots{}
+
+		command = commands.NewStateHistory(logger, stateValidator, snapshots)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("validates the state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+			Expect(command.CheckFastFails([]string{}, storage.State{})).To(MatchError("no state"))
+		})
+
+		It("accepts a destroyed environment that left snapshots behind", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+			snapshots.SnapshotsCall.Returns.Snapshots = []storage.Snapshot{{ID: "1"}}
+			Expect(command.CheckFastFails([]string{}, storage.State{})).To(Succeed())
+		})
+	})
+
+	Describe("Execute", func() {
+		It("lists the snapshots", func() {
+			snapshots.SnapshotsCall.Returns.Snapshots = []storage.Snapshot{
+				{
+					ID:        "2",
+					Timestamp: time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC),
+					Files:     []string{"bbl-state.json", "vars/bosh-state.json"},
+				},
+				{
+					ID:        "1",
+					Timestamp: time.Date(2018, time.March, 1, 11, 0, 0, 0, time.UTC),
+					Files:     []string{"bbl-state.json"},
+				},
+			}
+
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintfCall.Messages).To(Equal([]string{
+				"ID     TAKEN                  FILES\n",
+				"2      2018-03-01T12:00:00Z   bbl-state.json, vars/bosh-state.json\n",
+				"1      2018-03-01T11:00:00Z   bbl-state.json\n",
+			}))
+		})
+
+		It("says so when there are no snapshots", func() {
+			err := command.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("No snapshots have been taken yet."))
+		})
+
+		It("returns an error when the snapshots cannot be listed", func() {
+			snapshots.SnapshotsCall.Returns.Error = errors.New("apple")
+			Expect(command.Execute([]string{}, storage.State{})).To(MatchError("apple"))
+		})
+	})
+})
+
+var _ = Describe("StateRollback", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		snapshots      *fakes.StateSnapshots
+		command        commands.StateRollback
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		snapshots = &fakes.StateSnapshots{}
+
+		command = commands.NewStateRollback(logger, stateValidator, snapshots)
+	})
+
+	Describe("CheckFastFails", func() {
+		It("requires a snapshot ID", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("bbl state rollback requires a snapshot ID. Run `bbl state history` to list them."))
+		})
+
+		It("validates the state", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+			Expect(command.CheckFastFails([]string{"3"}, storage.State{})).To(MatchError("no state"))
+		})
+
+		It("accepts a destroyed environment that left snapshots behind", func() {
+			stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+			snapshots.SnapshotsCall.Returns.Snapshots = []storage.Snapshot{{ID: "1"}}
+			Expect(command.CheckFastFails([]string{"3"}, storage.State{})).To(Succeed())
+		})
+	})
+
+	Describe("Execute", func() {
+		It("rolls back to the snapshot", func() {
+			err := command.Execute([]string{"3"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(snapshots.RollbackCall.Receives.ID).To(Equal("3"))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"rolling back to snapshot 3"}))
+		})
+
+		It("returns an error when the rollback fails", func() {
+			snapshots.RollbackCall.Returns.Error = errors.New("apple")
+			Expect(command.Execute([]string{"3"}, storage.State{})).To(MatchError("apple"))
+		})
+	})
+})
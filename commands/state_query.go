@@ -3,6 +3,7 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/storage"
 )
@@ -55,6 +56,27 @@ func (s StateQuery) CheckFastFails(subcommandFlags []string, state storage.State
 }
 
 func (s StateQuery) Execute(subcommandFlags []string, state storage.State) error {
+	propertyValue, err := s.get(state)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Println(propertyValue)
+	return nil
+}
+
+func (s StateQuery) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	propertyValue, err := s.get(state)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(s.logger, map[string]string{
+		strings.Replace(s.propertyName, " ", "_", -1): propertyValue,
+	})
+}
+
+func (s StateQuery) get(state storage.State) (string, error) {
 	var (
 		propertyValue string
 		err           error
@@ -63,12 +85,12 @@ func (s StateQuery) Execute(subcommandFlags []string, state storage.State) error
 	case JumpboxAddressPropertyName:
 		propertyValue, err = s.getEIP(state)
 		if err != nil {
-			return err
+			return "", err
 		}
 	case DirectorAddressPropertyName:
 		propertyValue, err = s.getDirectorAddress(state)
 		if err != nil {
-			return err
+			return "", err
 		}
 	case DirectorUsernamePropertyName:
 		propertyValue = state.BOSH.DirectorUsername
@@ -81,11 +103,10 @@ func (s StateQuery) Execute(subcommandFlags []string, state storage.State) error
 	}
 
 	if propertyValue == "" {
-		return fmt.Errorf("Could not retrieve %s, please make sure you are targeting the proper state dir.", s.propertyName)
+		return "", fmt.Errorf("Could not retrieve %s, please make sure you are targeting the proper state dir.", s.propertyName)
 	}
 
-	s.logger.Println(propertyValue)
-	return nil
+	return propertyValue, nil
 }
 
 func (s StateQuery) getDirectorAddress(state storage.State) (string, error) {
@@ -103,7 +124,7 @@ func (s StateQuery) getDirectorAddress(state storage.State) (string, error) {
 }
 
 func (s StateQuery) getEIP(state storage.State) (string, error) {
-	terraformOutputs, err := s.terraformManager.GetOutputs()
+	terraformOutputs, err := s.terraformManager.CachedOutputs(state)
 	if err != nil {
 		return "", err
 	}
@@ -69,7 +69,7 @@ var _ = Describe("StateQuery", func() {
 	Describe("Execute", func() {
 		Context("bbl manages the jumpbox", func() {
 			BeforeEach(func() {
-				terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{
 					Map: map[string]interface{}{"external_ip": "some-external-ip"},
 				}
 			})
@@ -77,10 +77,12 @@ var _ = Describe("StateQuery", func() {
 			It("prints out the jumpbox information", func() {
 				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, "jumpbox address")
 
-				err := command.Execute([]string{}, storage.State{})
+				state := storage.State{TFOutputsChecksum: "some-checksum"}
+				err := command.Execute([]string{}, state)
 				Expect(err).NotTo(HaveOccurred())
 
-				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
+				Expect(terraformManager.CachedOutputsCall.Receives.BBLState).To(Equal(state))
 				Expect(fakeLogger.PrintlnCall.Receives.Message).To(Equal("some-external-ip"))
 			})
 		})
@@ -135,7 +137,7 @@ var _ = Describe("StateQuery", func() {
 			})
 
 			It("prints the eip as the director-address", func() {
-				terraformManager.GetOutputsCall.Returns.Outputs = terraform.Outputs{
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{
 					Map: map[string]interface{}{"external_ip": "some-external-ip"},
 				}
 
@@ -149,7 +151,7 @@ var _ = Describe("StateQuery", func() {
 		Context("failure cases", func() {
 			Context("when the terraform output provider fails", func() {
 				BeforeEach(func() {
-					terraformManager.GetOutputsCall.Returns.Error = errors.New("failed to get terraform output")
+					terraformManager.CachedOutputsCall.Returns.Error = errors.New("failed to get terraform output")
 				})
 
 				It("director-address returns an error for no-director environment", func() {
@@ -184,4 +186,37 @@ var _ = Describe("StateQuery", func() {
 			})
 		})
 	})
+
+	Describe("ExecuteJSON", func() {
+		DescribeTable("prints the property as json",
+			func(propertyName, expectedOutput string) {
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraform.Outputs{
+					Map: map[string]interface{}{"external_ip": "some-external-ip"},
+				}
+				command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, propertyName)
+
+				err := command.ExecuteJSON([]string{}, storage.State{
+					EnvID: "some-env-id",
+					BOSH: storage.BOSH{
+						DirectorAddress:  "some-director-address",
+						DirectorUsername: "some-director-username",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeLogger.PrintlnCall.Receives.Message).To(MatchJSON(expectedOutput))
+			},
+			Entry("env-id", "environment id", `{"environment_id": "some-env-id"}`),
+			Entry("jumpbox-address", "jumpbox address", `{"jumpbox_address": "some-external-ip"}`),
+			Entry("director-address", "director address", `{"director_address": "some-director-address"}`),
+			Entry("director-username", "director username", `{"director_username": "some-director-username"}`),
+		)
+
+		It("returns an error when the property is missing", func() {
+			command := commands.NewStateQuery(fakeLogger, fakeStateValidator, terraformManager, "director password")
+
+			err := command.ExecuteJSON([]string{}, storage.State{})
+			Expect(err).To(MatchError("Could not retrieve director password, please make sure you are targeting the proper state dir."))
+		})
+	})
 })
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const redacted = "REDACTED"
+
+var secretOutputName = regexp.MustCompile(`(?i)private_key|password|secret|token|credentials`)
+
+type StateShow struct {
+	logger           logger
+	stateValidator   stateValidator
+	terraformManager terraformManager
+}
+
+type stateDocument struct {
+	EnvID            string                 `json:"env_id"`
+	IAAS             string                 `json:"iaas"`
+	BBLVersion       string                 `json:"bbl_version"`
+	Jumpbox          jumpboxDocument        `json:"jumpbox"`
+	Director         *directorDocument      `json:"director,omitempty"`
+	LB               *lbDocument            `json:"lb,omitempty"`
+	TerraformOutputs map[string]interface{} `json:"terraform_outputs"`
+}
+
+type jumpboxDocument struct {
+	Address string `json:"address"`
+	URL     string `json:"url"`
+}
+
+type directorDocument struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	CACert   string `json:"ca_cert"`
+}
+
+type lbDocument struct {
+	Type   string `json:"type"`
+	Domain string `json:"domain,omitempty"`
+	Cert   string `json:"cert,omitempty"`
+	Chain  string `json:"chain,omitempty"`
+	Key    string `json:"key,omitempty"`
+}
+
+func NewStateShow(logger logger, stateValidator stateValidator, terraformManager terraformManager) StateShow {
+	return StateShow{
+		logger:           logger,
+		stateValidator:   stateValidator,
+		terraformManager: terraformManager,
+	}
+}
+
+func (s StateShow) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return s.stateValidator.Validate()
+}
+
+func stateShowFlags(showSecrets *bool) flags.Flags {
+	f := flags.New("state show")
+	f.Bool(showSecrets, "show-secrets")
+	return f
+}
+
+func (s StateShow) Flags() []flags.Flags {
+	var showSecrets bool
+	return []flags.Flags{stateShowFlags(&showSecrets)}
+}
+
+func (s StateShow) Execute(args []string, state storage.State) error {
+	var showSecrets bool
+	err := stateShowFlags(&showSecrets).Parse(args)
+	if err != nil {
+		return err
+	}
+
+	outputs, err := s.terraformManager.CachedOutputs(state)
+	if err != nil {
+		return err
+	}
+
+	document := stateDocument{
+		EnvID:      state.EnvID,
+		IAAS:       state.IAAS,
+		BBLVersion: state.BBLVersion,
+		Jumpbox: jumpboxDocument{
+			Address: outputs.GetString("external_ip"),
+			URL:     state.Jumpbox.URL,
+		},
+		TerraformOutputs: secretOutputs(outputs.Map, showSecrets),
+	}
+
+	if state.NoDirector {
+		document.Director = &directorDocument{
+			Address: fmt.Sprintf("https://%s:25555", outputs.GetString("external_ip")),
+		}
+	} else {
+		document.Director = &directorDocument{
+			Name:     state.BOSH.DirectorName,
+			Address:  state.BOSH.DirectorAddress,
+			Username: state.BOSH.DirectorUsername,
+			Password: secret(state.BOSH.DirectorPassword, showSecrets),
+			CACert:   state.BOSH.DirectorSSLCA,
+		}
+	}
+
+	if state.LB.Type != "" {
+		document.LB = &lbDocument{
+			Type:   state.LB.Type,
+			Domain: state.LB.Domain,
+			Cert:   state.LB.Cert,
+			Chain:  state.LB.Chain,
+			Key:    secret(state.LB.Key, showSecrets),
+		}
+	}
+
+	return printJSON(s.logger, document)
+}
+
+func (s StateShow) ExecuteJSON(args []string, state storage.State) error {
+	return s.Execute(args, state)
+}
+
+// secretOutputs redacts the terraform outputs that hold credentials, such
+// as the private key that AWS and Azure environments generate for the
+// bosh VMs.
+func secretOutputs(outputs map[string]interface{}, show bool) map[string]interface{} {
+	redactedOutputs := map[string]interface{}{}
+	for name, value := range outputs {
+		if !show && (secretOutputName.MatchString(name) || strings.Contains(fmt.Sprint(value), "PRIVATE KEY-----")) {
+			value = redacted
+		}
+		redactedOutputs[name] = value
+	}
+	return redactedOutputs
+}
+
+func secret(value string, show bool) string {
+	if value == "" || show {
+		return value
+	}
+	return redacted
+}
@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	TunnelPIDFile     = "tunnel.pid"
+	TunnelAddressFile = "tunnel-address"
+	TunnelLogFile     = "tunnel.log"
+
+	tunnelStartTimeout = 30 * time.Second
+)
+
+type Tunnel struct {
+	logger    logger
+	keyGetter sshKeyGetter
+	server    tunnelServer
+	process   tunnelProcess
+	fileIO    tunnelFileIO
+	stateDir  string
+	env       []string
+}
+
+type tunnelServer interface {
+	Listen(privateKey, jumpboxURL string, port int) (string, error)
+}
+
+type tunnelProcess interface {
+	Daemonize(args, env []string, logPath string) (int, error)
+	Running(pid int) bool
+	Stop(pid int) error
+	WaitForInterrupt()
+}
+
+type tunnelFileIO interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.Remover
+}
+
+type tunnelStatus struct {
+	Running bool   `json:"running"`
+	Address string `json:"address,omitempty"`
+	PID     int    `json:"pid,omitempty"`
+}
+
+// NewTunnel takes the environment to start the tunnel daemon with, which
+// carries the global flags such as the state passphrase.
+func NewTunnel(logger logger, keyGetter sshKeyGetter, server tunnelServer, process tunnelProcess, fileIO tunnelFileIO, stateDir string, env []string) Tunnel {
+	return Tunnel{
+		logger:    logger,
+		keyGetter: keyGetter,
+		server:    server,
+		process:   process,
+		fileIO:    fileIO,
+		stateDir:  stateDir,
+		env:       env,
+	}
+}
+
+func (t Tunnel) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	if len(subcommandFlags) > 0 && (subcommandFlags[0] == "stop" || subcommandFlags[0] == "status") {
+		return nil
+	}
+
+	if len(state.Jumpbox.URL) == 0 {
+		return errors.New("Invalid bbl state for bbl tunnel.")
+	}
+
+	return nil
+}
+
+func (t Tunnel) Execute(args []string, state storage.State) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "stop":
+			return t.stop()
+		case "status":
+			status := t.status()
+			if !status.Running {
+				t.logger.Println("No tunnel is running.")
+				return nil
+			}
+			t.logger.Printf("The tunnel is running on %s (pid %d).\n", status.Address, status.PID)
+			return nil
+		}
+	}
+
+	return t.start(args, state)
+}
+
+func (t Tunnel) ExecuteJSON(args []string, state storage.State) error {
+	if len(args) == 0 || args[0] != "status" {
+		return errors.New("Only bbl tunnel status supports --json.")
+	}
+
+	return printJSON(t.logger, t.status())
+}
+
+func tunnelFlags(port *int, daemon *bool) flags.Flags {
+	f := flags.New("tunnel")
+	f.Int(port, "port", 0)
+	f.Bool(daemon, "daemon")
+	return f
+}
+
+func (t Tunnel) Flags() []flags.Flags {
+	var (
+		port   int
+		daemon bool
+	)
+	return []flags.Flags{tunnelFlags(&port, &daemon)}
+}
+
+func (t Tunnel) start(args []string, state storage.State) error {
+	var (
+		port   int
+		daemon bool
+	)
+	err := tunnelFlags(&port, &daemon).Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if status := t.status(); status.Running {
+		return fmt.Errorf("A tunnel is already running on %s (pid %d). Run `bbl tunnel stop` first.", status.Address, status.PID)
+	}
+	t.removeFiles()
+
+	if daemon {
+		return t.daemonize(port)
+	}
+
+	privateKey, err := t.keyGetter.Get("jumpbox")
+	if err != nil {
+		return fmt.Errorf("Get jumpbox private key: %s", err)
+	}
+
+	address, err := t.server.Listen(privateKey, state.Jumpbox.URL, port)
+	if err != nil {
+		return err
+	}
+
+	err = t.fileIO.WriteFile(t.path(TunnelPIDFile), []byte(strconv.Itoa(os.Getpid())), storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Write pid file: %s", err)
+	}
+	defer t.removeFiles()
+
+	err = t.fileIO.WriteFile(t.path(TunnelAddressFile), []byte(address), storage.StateMode)
+	if err != nil {
+		return fmt.Errorf("Write address file: %s", err)
+	}
+
+	t.logger.Printf("SOCKS5 proxy listening on %s. Press Ctrl-C to stop it.\n", address)
+	t.logger.Printf("Point the bosh cli at it with: export BOSH_ALL_PROXY=socks5://%s\n", address)
+
+	t.process.WaitForInterrupt()
+
+	t.logger.Println("Stopped the tunnel.")
+	return nil
+}
+
+func (t Tunnel) daemonize(port int) error {
+	args := []string{"--state-dir", t.stateDir, "tunnel", "--port", strconv.Itoa(port)}
+	pid, err := t.process.Daemonize(args, t.env, t.path(TunnelLogFile))
+	if err != nil {
+		return err
+	}
+
+	for deadline := time.Now().Add(tunnelStartTimeout); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if status := t.status(); status.Running && status.PID == pid {
+			t.logger.Printf("SOCKS5 proxy listening on %s (pid %d).\n", status.Address, pid)
+			t.logger.Printf("Point the bosh cli at it with: export BOSH_ALL_PROXY=socks5://%s\n", status.Address)
+			return nil
+		}
+
+		if !t.process.Running(pid) {
+			return fmt.Errorf("The tunnel exited before it started. See %s for details.", t.path(TunnelLogFile))
+		}
+	}
+
+	t.process.Stop(pid)
+	return fmt.Errorf("The tunnel did not start within %s. See %s for details.", tunnelStartTimeout, t.path(TunnelLogFile))
+}
+
+func (t Tunnel) stop() error {
+	status := t.status()
+	if !status.Running {
+		t.removeFiles()
+		t.logger.Println("No tunnel is running.")
+		return nil
+	}
+
+	err := t.process.Stop(status.PID)
+	if err != nil {
+		return fmt.Errorf("Stop tunnel: %s", err)
+	}
+	t.removeFiles()
+
+	t.logger.Printf("Stopped the tunnel on %s (pid %d).\n", status.Address, status.PID)
+	return nil
+}
+
+// status reads the pid and address files. A pid file left behind by a
+// tunnel that was killed does not count as running.
+func (t Tunnel) status() tunnelStatus {
+	contents, err := t.fileIO.ReadFile(t.path(TunnelPIDFile))
+	if err != nil {
+		return tunnelStatus{}
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil || !t.process.Running(pid) {
+		return tunnelStatus{}
+	}
+
+	address, err := t.fileIO.ReadFile(t.path(TunnelAddressFile))
+	if err != nil {
+		return tunnelStatus{}
+	}
+
+	return tunnelStatus{
+		Running: true,
+		Address: strings.TrimSpace(string(address)),
+		PID:     pid,
+	}
+}
+
+func (t Tunnel) removeFiles() {
+	t.fileIO.Remove(t.path(TunnelPIDFile))
+	t.fileIO.Remove(t.path(TunnelAddressFile))
+}
+
+func (t Tunnel) path(name string) string {
+	return filepath.Join(t.stateDir, name)
+}
@@ -0,0 +1,229 @@
+package commands_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tunnel", func() {
+	var (
+		logger       *fakes.Logger
+		sshKeyGetter *fakes.FancySSHKeyGetter
+		server       *fakes.TunnelServer
+		process      *fakes.TunnelProcess
+		fileIO       *fakes.FileIO
+		files        map[string]string
+		command      commands.Tunnel
+		state        storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		sshKeyGetter = &fakes.FancySSHKeyGetter{}
+		sshKeyGetter.JumpboxGetCall.Returns.PrivateKey = "jumpbox-private-key"
+		server = &fakes.TunnelServer{}
+		server.ListenCall.Returns.Address = "127.0.0.1:1080"
+		process = &fakes.TunnelProcess{}
+
+		files = map[string]string{}
+		fileIO = &fakes.FileIO{}
+		fileIO.ReadFileCall.Fake = func(name string) ([]byte, error) {
+			contents, ok := files[name]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(contents), nil
+		}
+
+		state = storage.State{Jumpbox: storage.Jumpbox{URL: "10.0.0.5:22"}}
+
+		command = commands.NewTunnel(logger, sshKeyGetter, server, process, fileIO, "/some/state-dir", []string{"BBL_STATE_PASSPHRASE=some-passphrase"})
+	})
+
+	Describe("CheckFastFails", func() {
+		It("returns an error when there is no jumpbox", func() {
+			err := command.CheckFastFails([]string{}, storage.State{})
+			Expect(err).To(MatchError("Invalid bbl state for bbl tunnel."))
+		})
+
+		It("does not need a jumpbox to stop or check the tunnel", func() {
+			Expect(command.CheckFastFails([]string{"stop"}, storage.State{})).To(Succeed())
+			Expect(command.CheckFastFails([]string{"status"}, storage.State{})).To(Succeed())
+		})
+	})
+
+	Describe("Execute", func() {
+		It("serves the proxy in the foreground until interrupted", func() {
+			err := command.Execute([]string{"--port", "1080"}, state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(server.ListenCall.Receives.PrivateKey).To(Equal("jumpbox-private-key"))
+			Expect(server.ListenCall.Receives.JumpboxURL).To(Equal("10.0.0.5:22"))
+			Expect(server.ListenCall.Receives.Port).To(Equal(1080))
+
+			Expect(fileIO.WriteFileCall.Receives).To(Equal([]fakes.WriteFileReceive{
+				{Filename: "/some/state-dir/tunnel.pid", Contents: []byte(fmt.Sprint(os.Getpid())), Mode: storage.StateMode},
+				{Filename: "/some/state-dir/tunnel-address", Contents: []byte("127.0.0.1:1080"), Mode: storage.StateMode},
+			}))
+			Expect(logger.PrintfCall.Messages).To(ContainElement("Point the bosh cli at it with: export BOSH_ALL_PROXY=socks5://127.0.0.1:1080\n"))
+
+			Expect(process.WaitForInterruptCall.CallCount).To(Equal(1))
+			Expect(fileIO.RemoveCall.Receives).To(ContainElement(fakes.RemoveReceive{Name: "/some/state-dir/tunnel.pid"}))
+			Expect(fileIO.RemoveCall.Receives).To(ContainElement(fakes.RemoveReceive{Name: "/some/state-dir/tunnel-address"}))
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("Stopped the tunnel."))
+		})
+
+		It("refuses to start a second tunnel", func() {
+			process.DaemonizeCall.Returns.PID = 4242
+			process.RunningCall.Returns.Running = true
+			files["/some/state-dir/tunnel.pid"] = "4242"
+			files["/some/state-dir/tunnel-address"] = "127.0.0.1:40000"
+
+			err := command.Execute([]string{"--daemon"}, state)
+			Expect(err).To(MatchError("A tunnel is already running on 127.0.0.1:40000 (pid 4242). Run `bbl tunnel stop` first."))
+		})
+
+		Context("with --daemon", func() {
+			BeforeEach(func() {
+				process.DaemonizeCall.Returns.PID = 4242
+			})
+
+			It("starts bbl tunnel in a new process and waits for it to listen", func() {
+				process.RunningCall.Returns.Running = true
+				fileIO.ReadFileCall.Fake = func(name string) ([]byte, error) {
+					if process.DaemonizeCall.CallCount == 0 {
+						return nil, os.ErrNotExist
+					}
+					return []byte(map[string]string{
+						"/some/state-dir/tunnel.pid":     "4242",
+						"/some/state-dir/tunnel-address": "127.0.0.1:40000",
+					}[name]), nil
+				}
+
+				err := command.Execute([]string{"--daemon"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(server.ListenCall.CallCount).To(Equal(0))
+				Expect(process.DaemonizeCall.Receives.Args).To(Equal([]string{"--state-dir", "/some/state-dir", "tunnel", "--port", "0"}))
+				Expect(process.DaemonizeCall.Receives.Env).To(Equal([]string{"BBL_STATE_PASSPHRASE=some-passphrase"}))
+				Expect(process.DaemonizeCall.Receives.LogPath).To(Equal("/some/state-dir/tunnel.log"))
+				Expect(logger.PrintfCall.Messages).To(ContainElement("SOCKS5 proxy listening on 127.0.0.1:40000 (pid 4242).\n"))
+			})
+
+			It("returns an error when the tunnel exits before it starts", func() {
+				err := command.Execute([]string{"--daemon"}, state)
+				Expect(err).To(MatchError("The tunnel exited before it started. See /some/state-dir/tunnel.log for details."))
+			})
+
+			It("returns an error when the process cannot be started", func() {
+				process.DaemonizeCall.Returns.Error = errors.New("apple")
+
+				err := command.Execute([]string{"--daemon"}, state)
+				Expect(err).To(MatchError("apple"))
+			})
+		})
+
+		Describe("stop", func() {
+			It("stops the running tunnel", func() {
+				process.RunningCall.Returns.Running = true
+				files["/some/state-dir/tunnel.pid"] = "4242"
+				files["/some/state-dir/tunnel-address"] = "127.0.0.1:40000"
+
+				err := command.Execute([]string{"stop"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(process.StopCall.Receives.PID).To(Equal(4242))
+				Expect(fileIO.RemoveCall.Receives).To(ContainElement(fakes.RemoveReceive{Name: "/some/state-dir/tunnel.pid"}))
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{"Stopped the tunnel on 127.0.0.1:40000 (pid 4242).\n"}))
+			})
+
+			It("cleans up after a tunnel that is no longer running", func() {
+				files["/some/state-dir/tunnel.pid"] = "4242"
+
+				err := command.Execute([]string{"stop"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(process.StopCall.CallCount).To(Equal(0))
+				Expect(fileIO.RemoveCall.Receives).To(ContainElement(fakes.RemoveReceive{Name: "/some/state-dir/tunnel.pid"}))
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("No tunnel is running."))
+			})
+
+			It("returns an error when the tunnel cannot be stopped", func() {
+				process.RunningCall.Returns.Running = true
+				process.StopCall.Returns.Error = errors.New("banana")
+				files["/some/state-dir/tunnel.pid"] = "4242"
+				files["/some/state-dir/tunnel-address"] = "127.0.0.1:40000"
+
+				err := command.Execute([]string{"stop"}, storage.State{})
+				Expect(err).To(MatchError("Stop tunnel: banana"))
+			})
+		})
+
+		Describe("status", func() {
+			It("prints the address of the running tunnel", func() {
+				process.RunningCall.Returns.Running = true
+				files["/some/state-dir/tunnel.pid"] = "4242"
+				files["/some/state-dir/tunnel-address"] = "127.0.0.1:40000"
+
+				err := command.Execute([]string{"status"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(process.RunningCall.Receives.PID).To(Equal(4242))
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{"The tunnel is running on 127.0.0.1:40000 (pid 4242).\n"}))
+			})
+
+			It("prints the status as json", func() {
+				process.RunningCall.Returns.Running = true
+				files["/some/state-dir/tunnel.pid"] = "4242"
+				files["/some/state-dir/tunnel-address"] = "127.0.0.1:40000"
+
+				err := command.ExecuteJSON([]string{"status"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`{"running": true, "address": "127.0.0.1:40000", "pid": 4242}`))
+			})
+
+			It("reports when no tunnel is running", func() {
+				err := command.Execute([]string{"status"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("No tunnel is running."))
+			})
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the flags cannot be parsed", func() {
+				err := command.Execute([]string{"--port", "abc"}, state)
+				Expect(err).To(MatchError(`invalid value "abc" for flag -port: parse error`))
+			})
+
+			It("returns an error when the jumpbox key cannot be read", func() {
+				sshKeyGetter.JumpboxGetCall.Returns.Error = errors.New("apple")
+
+				err := command.Execute([]string{}, state)
+				Expect(err).To(MatchError("Get jumpbox private key: apple"))
+			})
+
+			It("returns an error when the proxy cannot listen", func() {
+				server.ListenCall.Returns.Error = errors.New("Listen on port 1080: address already in use")
+
+				err := command.Execute([]string{"--port", "1080"}, state)
+				Expect(err).To(MatchError("Listen on port 1080: address already in use"))
+				Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+			})
+
+			It("only supports --json for status", func() {
+				err := command.ExecuteJSON([]string{}, state)
+				Expect(err).To(MatchError("Only bbl tunnel status supports --json."))
+			})
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type Unlock struct {
+	logger logger
+	locker stateLocker
+}
+
+func NewUnlock(logger logger, locker stateLocker) Unlock {
+	return Unlock{
+		logger: logger,
+		locker: locker,
+	}
+}
+
+func (u Unlock) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	return nil
+}
+
+func unlockFlags(force *bool) flags.Flags {
+	f := flags.New("unlock")
+	f.Bool(force, "force")
+	return f
+}
+
+func (u Unlock) Flags() []flags.Flags {
+	var force bool
+	return []flags.Flags{unlockFlags(&force)}
+}
+
+func (u Unlock) Execute(args []string, state storage.State) error {
+	var force bool
+	err := unlockFlags(&force).Parse(args)
+	if err != nil {
+		return err
+	}
+
+	lock, err := u.locker.GetLock()
+	if err != nil {
+		return err
+	}
+
+	if lock.Empty() {
+		u.logger.Println("The state directory is not locked.")
+		return nil
+	}
+
+	if !lock.Stale() && !force {
+		return fmt.Errorf("%s.\nRe-run with --force if you are sure that run is no longer active.", lock)
+	}
+
+	err = u.locker.ForceUnlock()
+	if err != nil {
+		return err
+	}
+
+	u.logger.Printf("Removed lock: %s.\n", lock)
+	return nil
+}
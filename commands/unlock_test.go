@@ -0,0 +1,102 @@
+package commands_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Unlock", func() {
+	var (
+		logger *fakes.Logger
+		locker *fakes.StateLocker
+		unlock commands.Unlock
+		lock   storage.Lock
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		locker = &fakes.StateLocker{}
+
+		hostname, err := os.Hostname()
+		Expect(err).NotTo(HaveOccurred())
+
+		lock = storage.Lock{
+			Holder:    "some-user",
+			PID:       os.Getpid(),
+			Host:      hostname,
+			Command:   "up",
+			Timestamp: time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC),
+		}
+		locker.GetLockCall.Returns.Lock = lock
+
+		unlock = commands.NewUnlock(logger, locker)
+	})
+
+	Context("when the state directory is not locked", func() {
+		BeforeEach(func() {
+			locker.GetLockCall.Returns.Lock = storage.Lock{}
+		})
+
+		It("says so", func() {
+			err := unlock.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.PrintlnCall.Receives.Message).To(Equal("The state directory is not locked."))
+			Expect(locker.ForceUnlockCall.CallCount).To(Equal(0))
+		})
+	})
+
+	Context("when the lock may still be active", func() {
+		BeforeEach(func() {
+			lock.Timestamp = time.Now()
+			locker.GetLockCall.Returns.Lock = lock
+		})
+
+		It("refuses to remove it without --force", func() {
+			err := unlock.Execute([]string{}, storage.State{})
+			Expect(err).To(MatchError(ContainSubstring("Re-run with --force")))
+			Expect(locker.ForceUnlockCall.CallCount).To(Equal(0))
+		})
+
+		It("removes it with --force", func() {
+			err := unlock.Execute([]string{"--force"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locker.ForceUnlockCall.CallCount).To(Equal(1))
+			Expect(logger.PrintfCall.Messages).To(ContainElement(ContainSubstring("Removed lock: some-user")))
+		})
+	})
+
+	Context("when the lock is stale", func() {
+		It("removes it without --force", func() {
+			err := unlock.Execute([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(locker.ForceUnlockCall.CallCount).To(Equal(1))
+		})
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the lock cannot be read", func() {
+			locker.GetLockCall.Returns.Error = errors.New("fig")
+			err := unlock.Execute([]string{}, storage.State{})
+			Expect(err).To(MatchError("fig"))
+		})
+
+		It("returns an error when the lock cannot be removed", func() {
+			locker.ForceUnlockCall.Returns.Error = errors.New("plum")
+			err := unlock.Execute([]string{"--force"}, storage.State{})
+			Expect(err).To(MatchError("plum"))
+		})
+
+		It("returns an error when the flags cannot be parsed", func() {
+			err := unlock.Execute([]string{"--unknown"}, storage.State{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -1,10 +1,13 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/terraform"
 )
 
 type Up struct {
@@ -13,26 +16,50 @@ type Up struct {
 	cloudConfigManager cloudConfigManager
 	stateStore         stateStore
 	terraformManager   terraformManager
+	fingerprinter      fingerprinter
+	logger             logger
+}
+
+type fingerprinter interface {
+	Fingerprint(paths ...string) (string, error)
 }
 
 func NewUp(plan plan, boshManager boshManager,
 	cloudConfigManager cloudConfigManager,
-	stateStore stateStore, terraformManager terraformManager) Up {
+	stateStore stateStore, terraformManager terraformManager,
+	fingerprinter fingerprinter, logger logger) Up {
 	return Up{
 		plan:               plan,
 		boshManager:        boshManager,
 		cloudConfigManager: cloudConfigManager,
 		stateStore:         stateStore,
 		terraformManager:   terraformManager,
+		fingerprinter:      fingerprinter,
+		logger:             logger,
 	}
 }
 
+func (u Up) Flags() []flags.Flags {
+	var only, skip string
+	return []flags.Flags{phaseFlags(&only, &skip), planFlags(&PlanConfig{}, &LBArgs{}, true)}
+}
+
 func (u Up) CheckFastFails(args []string, state storage.State) error {
-	return u.plan.CheckFastFails(args, state)
+	_, planArgs, err := parsePhases(args)
+	if err != nil {
+		return err
+	}
+
+	return u.plan.CheckFastFails(planArgs, state)
 }
 
 func (u Up) Execute(args []string, state storage.State) error {
-	config, err := u.ParseArgs(args, state)
+	phases, planArgs, err := parsePhases(args)
+	if err != nil {
+		return err
+	}
+
+	config, err := u.ParseArgs(planArgs, state)
 	if err != nil {
 		return err
 	}
@@ -45,65 +72,188 @@ func (u Up) Execute(args []string, state storage.State) error {
 		state = planState
 	}
 
-	state, err = u.terraformManager.Apply(state)
+	err = phases.validate(state)
 	if err != nil {
-		return handleTerraformError(err, state, u.stateStore)
+		return err
 	}
 
-	state.NoDirector = false
+	// checkpoint holds the phases finished so far. A phase that runs again
+	// invalidates whatever an earlier bbl up recorded for the later phases.
+	checkpoint := map[string]string{}
+	resuming := phases.resumable() && len(state.UpCheckpoint) > 0
+	skip := func(phase string) (bool, error) {
+		if !phases.run(phase) {
+			return true, nil
+		}
+		if !resuming {
+			return false, nil
+		}
 
-	err = u.stateStore.Set(state)
-	if err != nil {
-		return fmt.Errorf("Save state after terraform apply: %s", err)
+		finished, err := u.finished(phase, state)
+		if err != nil {
+			return false, err
+		}
+		if finished {
+			checkpoint[phase] = state.UpCheckpoint[phase]
+			u.logger.Step("skipping the %s phase, which finished in an earlier bbl up and has not changed since", phase)
+			return true, nil
+		}
+
+		resuming = false
+		u.logger.Step("resuming bbl up from the %s phase", phase)
+		return false, nil
+	}
+
+	record := func(phase string) error {
+		if !phases.resumable() {
+			return nil
+		}
+
+		fingerprint, err := u.fingerprinter.Fingerprint(upPhaseInputs[phase]...)
+		if err != nil {
+			return fmt.Errorf("Fingerprint %s inputs: %s", phase, err)
+		}
+		checkpoint[phase] = fingerprint
+
+		state.UpCheckpoint = map[string]string{}
+		for p, f := range checkpoint {
+			state.UpCheckpoint[p] = f
+		}
+		return nil
 	}
 
-	terraformOutputs, err := u.terraformManager.GetOutputs()
+	var terraformOutputs terraform.Outputs
+	skipTerraform, err := skip(PhaseTerraform)
 	if err != nil {
-		return fmt.Errorf("Parse terraform outputs: %s", err)
+		return err
 	}
+	if !skipTerraform {
+		state, err = u.terraformManager.Apply(state)
+		if err != nil {
+			return handleTerraformError(err, state, u.stateStore)
+		}
+
+		state.NoDirector = false
+
+		err = record(PhaseTerraform)
+		if err != nil {
+			return err
+		}
+
+		err = u.stateStore.Set(state)
+		if err != nil {
+			return fmt.Errorf("Save state after terraform apply: %s", err)
+		}
+
+		terraformOutputs, err = u.terraformManager.GetOutputs()
+		if err != nil {
+			return fmt.Errorf("Parse terraform outputs: %s", err)
+		}
+	} else if phases.run(PhaseJumpbox) || phases.run(PhaseDirector) {
+		terraformOutputs, err = u.terraformManager.CachedOutputs(state)
+		if err != nil {
+			return fmt.Errorf("Parse terraform outputs: %s", err)
+		}
 
-	state, err = u.boshManager.CreateJumpbox(state, terraformOutputs)
-	switch err.(type) {
-	case bosh.ManagerCreateError:
-		bcErr := err.(bosh.ManagerCreateError)
-		if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
-			return fmt.Errorf("Save state after jumpbox create error: %s, %s", err, setErr)
+		if len(terraformOutputs.Map) == 0 {
+			return errors.New("Skipping the terraform phase needs the outputs of an earlier terraform apply. Run `bbl up --only terraform` first.")
 		}
-		return fmt.Errorf("Create jumpbox: %s", err)
-	case error:
-		return fmt.Errorf("Create jumpbox: %s", err)
 	}
 
-	err = u.stateStore.Set(state)
+	skipJumpbox, err := skip(PhaseJumpbox)
 	if err != nil {
-		return fmt.Errorf("Save state after create jumpbox: %s", err)
+		return err
 	}
+	if !skipJumpbox {
+		state, err = u.boshManager.CreateJumpbox(state, terraformOutputs)
+		switch err.(type) {
+		case bosh.ManagerCreateError:
+			bcErr := err.(bosh.ManagerCreateError)
+			if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
+				return fmt.Errorf("Save state after jumpbox create error: %s, %s", err, setErr)
+			}
+			return fmt.Errorf("Create jumpbox: %s", err)
+		case error:
+			return fmt.Errorf("Create jumpbox: %s", err)
+		}
 
-	state, err = u.boshManager.CreateDirector(state, terraformOutputs)
-	switch err.(type) {
-	case bosh.ManagerCreateError:
-		bcErr := err.(bosh.ManagerCreateError)
-		if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
-			return fmt.Errorf("Save state after bosh director create error: %s, %s", err, setErr)
+		err = record(PhaseJumpbox)
+		if err != nil {
+			return err
+		}
+
+		err = u.stateStore.Set(state)
+		if err != nil {
+			return fmt.Errorf("Save state after create jumpbox: %s", err)
 		}
-		return fmt.Errorf("Create bosh director: %s", err)
-	case error:
-		return fmt.Errorf("Create bosh director: %s", err)
 	}
 
-	err = u.stateStore.Set(state)
+	skipDirector, err := skip(PhaseDirector)
 	if err != nil {
-		return fmt.Errorf("Save state after create director: %s", err)
+		return err
+	}
+	if !skipDirector {
+		state, err = u.boshManager.CreateDirector(state, terraformOutputs)
+		switch err.(type) {
+		case bosh.ManagerCreateError:
+			bcErr := err.(bosh.ManagerCreateError)
+			if setErr := u.stateStore.Set(bcErr.State()); setErr != nil {
+				return fmt.Errorf("Save state after bosh director create error: %s, %s", err, setErr)
+			}
+			return fmt.Errorf("Create bosh director: %s", err)
+		case error:
+			return fmt.Errorf("Create bosh director: %s", err)
+		}
+
+		err = record(PhaseDirector)
+		if err != nil {
+			return err
+		}
+
+		err = u.stateStore.Set(state)
+		if err != nil {
+			return fmt.Errorf("Save state after create director: %s", err)
+		}
 	}
 
-	err = u.cloudConfigManager.Update(state)
+	skipCloudConfig, err := skip(PhaseCloudConfig)
 	if err != nil {
-		return fmt.Errorf("Update cloud config: %s", err)
+		return err
+	}
+	if !skipCloudConfig {
+		err = u.cloudConfigManager.Update(state)
+		if err != nil {
+			return fmt.Errorf("Update cloud config: %s", err)
+		}
+	}
+
+	if phases.resumable() && state.UpCheckpoint != nil {
+		state.UpCheckpoint = nil
+		err = u.stateStore.Set(state)
+		if err != nil {
+			return fmt.Errorf("Save state after bbl up: %s", err)
+		}
 	}
 
 	return nil
 }
 
+// finished reports whether the phase completed in an earlier bbl up and
+// its inputs still match the fingerprint recorded then.
+func (u Up) finished(phase string, state storage.State) (bool, error) {
+	recorded, ok := state.UpCheckpoint[phase]
+	if !ok {
+		return false, nil
+	}
+
+	fingerprint, err := u.fingerprinter.Fingerprint(upPhaseInputs[phase]...)
+	if err != nil {
+		return false, fmt.Errorf("Fingerprint %s inputs: %s", phase, err)
+	}
+
+	return fingerprint == recorded, nil
+}
+
 func (u Up) ParseArgs(args []string, state storage.State) (PlanConfig, error) {
 	return u.plan.ParseArgs(args, state)
 }
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+const (
+	PhaseTerraform   = "terraform"
+	PhaseJumpbox     = "jumpbox"
+	PhaseDirector    = "director"
+	PhaseCloudConfig = "cloud-config"
+)
+
+// UpPhases lists the phases of bbl up in the order they run.
+var UpPhases = []string{PhaseTerraform, PhaseJumpbox, PhaseDirector, PhaseCloudConfig}
+
+// upPhaseInputs lists the files in the state directory that each phase
+// reads. A phase that finished in an interrupted bbl up is only skipped on
+// the rerun if these have not changed.
+var upPhaseInputs = map[string][]string{
+	PhaseTerraform:   {"terraform", "vars/*.tfvars"},
+	PhaseJumpbox:     {"jumpbox-deployment", "create-jumpbox.sh", "create-jumpbox-override.sh"},
+	PhaseDirector:    {"bosh-deployment", "create-director.sh", "create-director-override.sh"},
+	PhaseCloudConfig: {"cloud-config"},
+}
+
+type phases struct {
+	selected map[string]bool
+	only     bool
+}
+
+func (p phases) run(phase string) bool {
+	return p.selected[phase]
+}
+
+// resumable is false for --only, which always runs the named phases.
+func (p phases) resumable() bool {
+	return !p.only
+}
+
+// validate checks that every phase that is skipped has already done the
+// work that the phases after it depend on.
+func (p phases) validate(state storage.State) error {
+	if p.run(PhaseDirector) && !p.run(PhaseJumpbox) && state.Jumpbox.URL == "" {
+		return errors.New("The director phase needs a jumpbox. Run `bbl up --only terraform,jumpbox` first.")
+	}
+
+	if p.run(PhaseCloudConfig) && !p.run(PhaseDirector) && state.BOSH.DirectorAddress == "" {
+		return errors.New("The cloud-config phase needs a director. Run `bbl up --skip cloud-config` first.")
+	}
+
+	return nil
+}
+
+func phaseFlags(only, skip *string) flags.Flags {
+	f := flags.New("up")
+	f.String(only, "only", "")
+	f.String(skip, "skip", "")
+	return f
+}
+
+// parsePhases parses --only and --skip out of args and returns the phases
+// to run with the remaining args.
+func parsePhases(args []string) (phases, []string, error) {
+	var only, skip string
+	rest, err := phaseFlags(&only, &skip).ParseKnown(args)
+	if err != nil {
+		return phases{}, nil, err
+	}
+
+	if only != "" && skip != "" {
+		return phases{}, nil, errors.New("--only and --skip cannot be used together.")
+	}
+
+	selected := phases{selected: map[string]bool{}, only: only != ""}
+	for _, phase := range UpPhases {
+		selected.selected[phase] = only == ""
+	}
+
+	onlyPhases, err := phaseList(only)
+	if err != nil {
+		return phases{}, nil, err
+	}
+	for _, phase := range onlyPhases {
+		selected.selected[phase] = true
+	}
+
+	skipPhases, err := phaseList(skip)
+	if err != nil {
+		return phases{}, nil, err
+	}
+	for _, phase := range skipPhases {
+		selected.selected[phase] = false
+	}
+
+	return selected, rest, nil
+}
+
+// phaseList splits a comma separated list of phases.
+func phaseList(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	phases := strings.Split(list, ",")
+	for _, phase := range phases {
+		if !validPhase(phase) {
+			return nil, fmt.Errorf("Unknown phase %q: use %s.", phase, strings.Join(UpPhases, ", "))
+		}
+	}
+	return phases, nil
+}
+
+func validPhase(phase string) bool {
+	for _, p := range UpPhases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
@@ -22,6 +22,8 @@ var _ = Describe("Up", func() {
 		terraformManager   *fakes.TerraformManager
 		cloudConfigManager *fakes.CloudConfigManager
 		stateStore         *fakes.StateStore
+		fingerprinter      *fakes.Fingerprinter
+		logger             *fakes.Logger
 	)
 
 	BeforeEach(func() {
@@ -30,8 +32,10 @@ var _ = Describe("Up", func() {
 		terraformManager = &fakes.TerraformManager{}
 		cloudConfigManager = &fakes.CloudConfigManager{}
 		stateStore = &fakes.StateStore{}
+		fingerprinter = &fakes.Fingerprinter{}
+		logger = &fakes.Logger{}
 
-		command = commands.NewUp(plan, boshManager, cloudConfigManager, stateStore, terraformManager)
+		command = commands.NewUp(plan, boshManager, cloudConfigManager, stateStore, terraformManager, fingerprinter, logger)
 	})
 
 	Describe("CheckFastFails", func() {
@@ -43,6 +47,13 @@ var _ = Describe("Up", func() {
 			Expect(plan.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal([]string{}))
 			Expect(plan.CheckFastFailsCall.Receives.State).To(Equal(storage.State{Version: 999}))
 		})
+
+		It("passes the args without the phases to Plan", func() {
+			err := command.CheckFastFails([]string{"--skip", "cloud-config", "--name", "some-name"}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plan.CheckFastFailsCall.Receives.SubcommandFlags).To(Equal([]string{"--name", "some-name"}))
+		})
 	})
 
 	Describe("Execute", func() {
@@ -81,6 +92,10 @@ var _ = Describe("Up", func() {
 			terraformManager.GetOutputsCall.Returns.Outputs = terraformOutputs
 
 			plan.IsInitializedCall.Returns.IsInitialized = true
+
+			fingerprinter.FingerprintCall.Fake = func(paths ...string) (string, error) {
+				return paths[0] + "-fingerprint", nil
+			}
 		})
 
 		Context("when bbl plan has been run", func() {
@@ -101,6 +116,7 @@ var _ = Describe("Up", func() {
 
 				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
 				Expect(terraformManager.ApplyCall.Receives.BBLState).To(Equal(incomingState))
+				terraformApplyState.UpCheckpoint = map[string]string{"terraform": "terraform-fingerprint"}
 				Expect(stateStore.SetCall.Receives[0].State).To(Equal(terraformApplyState))
 
 				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(1))
@@ -109,18 +125,229 @@ var _ = Describe("Up", func() {
 				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(1))
 				Expect(boshManager.CreateJumpboxCall.Receives.State).To(Equal(terraformApplyState))
 				Expect(boshManager.CreateJumpboxCall.Receives.TerraformOutputs).To(Equal(terraformOutputs))
+				createJumpboxState.UpCheckpoint = map[string]string{
+					"terraform": "terraform-fingerprint",
+					"jumpbox":   "jumpbox-deployment-fingerprint",
+				}
 				Expect(stateStore.SetCall.Receives[1].State).To(Equal(createJumpboxState))
 
 				Expect(boshManager.InitializeDirectorCall.CallCount).To(Equal(0))
 				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
 				Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(createJumpboxState))
 				Expect(boshManager.CreateDirectorCall.Receives.TerraformOutputs).To(Equal(terraformOutputs))
+				createDirectorState.UpCheckpoint = map[string]string{
+					"terraform": "terraform-fingerprint",
+					"jumpbox":   "jumpbox-deployment-fingerprint",
+					"director":  "bosh-deployment-fingerprint",
+				}
 				Expect(stateStore.SetCall.Receives[2].State).To(Equal(createDirectorState))
 
 				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
 				Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(createDirectorState))
 
-				Expect(stateStore.SetCall.CallCount).To(Equal(3))
+				createDirectorState.UpCheckpoint = nil
+				Expect(stateStore.SetCall.Receives[3].State).To(Equal(createDirectorState))
+				Expect(stateStore.SetCall.CallCount).To(Equal(4))
+			})
+		})
+
+		Context("when an earlier bbl up was interrupted", func() {
+			BeforeEach(func() {
+				incomingState.UpCheckpoint = map[string]string{
+					"terraform": "terraform-fingerprint",
+					"jumpbox":   "jumpbox-deployment-fingerprint",
+				}
+				incomingState.Jumpbox.URL = "10.0.0.5:22"
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraformOutputs
+			})
+
+			It("resumes from the first phase that did not finish", func() {
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(0))
+
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(incomingState))
+				Expect(boshManager.CreateDirectorCall.Receives.TerraformOutputs).To(Equal(terraformOutputs))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+
+				Expect(logger.StepCall.Messages).To(Equal([]string{
+					"skipping the terraform phase, which finished in an earlier bbl up and has not changed since",
+					"skipping the jumpbox phase, which finished in an earlier bbl up and has not changed since",
+					"resuming bbl up from the director phase",
+				}))
+
+				Expect(stateStore.SetCall.CallCount).To(Equal(2))
+				Expect(stateStore.SetCall.Receives[0].State.UpCheckpoint).To(Equal(map[string]string{
+					"terraform": "terraform-fingerprint",
+					"jumpbox":   "jumpbox-deployment-fingerprint",
+					"director":  "bosh-deployment-fingerprint",
+				}))
+				Expect(stateStore.SetCall.Receives[1].State.UpCheckpoint).To(BeNil())
+			})
+
+			It("reruns a phase whose inputs have changed and every phase after it", func() {
+				fingerprinter.FingerprintCall.Fake = func(paths ...string) (string, error) {
+					if paths[0] == "jumpbox-deployment" {
+						return "changed-fingerprint", nil
+					}
+					return paths[0] + "-fingerprint", nil
+				}
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(1))
+
+				Expect(logger.StepCall.Messages).To(ContainElement("resuming bbl up from the jumpbox phase"))
+				Expect(stateStore.SetCall.Receives[0].State.UpCheckpoint).To(Equal(map[string]string{
+					"terraform": "terraform-fingerprint",
+					"jumpbox":   "changed-fingerprint",
+				}))
+			})
+
+			It("forgets the later phases when an earlier phase runs again", func() {
+				incomingState.UpCheckpoint["terraform"] = "old-fingerprint"
+				stateStore.SetCall.Returns = []fakes.SetCallReturn{{}, {Error: errors.New("lime")}}
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("Save state after create jumpbox: lime"))
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(stateStore.SetCall.Receives[0].State.UpCheckpoint).To(Equal(map[string]string{
+					"terraform": "terraform-fingerprint",
+				}))
+			})
+
+			It("ignores the checkpoint with --only", func() {
+				err := command.Execute([]string{"--only", "jumpbox"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(1))
+				Expect(fingerprinter.FingerprintCall.CallCount).To(Equal(0))
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+			})
+
+			It("returns an error when the inputs cannot be fingerprinted", func() {
+				fingerprinter.FingerprintCall.Fake = func(paths ...string) (string, error) {
+					return "", errors.New("kiwi")
+				}
+
+				err := command.Execute([]string{}, incomingState)
+				Expect(err).To(MatchError("Fingerprint terraform inputs: kiwi"))
+			})
+		})
+
+		Context("with --only", func() {
+			BeforeEach(func() {
+				incomingState.Jumpbox.URL = "10.0.0.5:22"
+				incomingState.BOSH.DirectorAddress = "https://10.0.0.6:25555"
+				terraformManager.CachedOutputsCall.Returns.Outputs = terraformOutputs
+			})
+
+			It("runs only the named phases with the cached terraform outputs", func() {
+				err := command.Execute([]string{"--only", "director", "--name", "some-name"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(plan.ParseArgsCall.Receives.Args).To(Equal([]string{"--name", "some-name"}))
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(terraformManager.GetOutputsCall.CallCount).To(Equal(0))
+				Expect(terraformManager.CachedOutputsCall.Receives.BBLState).To(Equal(incomingState))
+
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateDirectorCall.Receives.State).To(Equal(incomingState))
+				Expect(boshManager.CreateDirectorCall.Receives.TerraformOutputs).To(Equal(terraformOutputs))
+				Expect(stateStore.SetCall.CallCount).To(Equal(1))
+
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+			})
+
+			It("updates only the cloud-config without reading terraform outputs", func() {
+				err := command.Execute([]string{"--only=cloud-config"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.CachedOutputsCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(0))
+				Expect(cloudConfigManager.UpdateCall.Receives.State).To(Equal(incomingState))
+			})
+
+			It("accepts a comma separated list of phases", func() {
+				err := command.Execute([]string{"--only", "jumpbox,director"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(0))
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("with --skip", func() {
+			It("runs every other phase", func() {
+				err := command.Execute([]string{"--skip", "cloud-config"}, incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(terraformManager.ApplyCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(1))
+				Expect(boshManager.CreateDirectorCall.CallCount).To(Equal(1))
+				Expect(cloudConfigManager.UpdateCall.CallCount).To(Equal(0))
+			})
+		})
+
+		Context("when a skipped phase has not run before", func() {
+			It("returns an error when there are no terraform outputs", func() {
+				err := command.Execute([]string{"--skip", "terraform"}, incomingState)
+				Expect(err).To(MatchError("Skipping the terraform phase needs the outputs of an earlier terraform apply. Run `bbl up --only terraform` first."))
+
+				Expect(boshManager.CreateJumpboxCall.CallCount).To(Equal(0))
+			})
+
+			It("returns an error when the cached terraform outputs cannot be read", func() {
+				terraformManager.CachedOutputsCall.Returns.Error = errors.New("apple")
+
+				err := command.Execute([]string{"--only", "jumpbox"}, incomingState)
+				Expect(err).To(MatchError("Parse terraform outputs: apple"))
+			})
+
+			It("returns an error when there is no jumpbox", func() {
+				err := command.Execute([]string{"--only", "director"}, incomingState)
+				Expect(err).To(MatchError("The director phase needs a jumpbox. Run `bbl up --only terraform,jumpbox` first."))
+			})
+
+			It("returns an error when there is no director", func() {
+				err := command.Execute([]string{"--only", "cloud-config"}, incomingState)
+				Expect(err).To(MatchError("The cloud-config phase needs a director. Run `bbl up --skip cloud-config` first."))
+			})
+		})
+
+		Context("when the phases are invalid", func() {
+			It("returns an error for an unknown phase", func() {
+				err := command.Execute([]string{"--only", "dns"}, incomingState)
+				Expect(err).To(MatchError(`Unknown phase "dns": use terraform, jumpbox, director, cloud-config.`))
+			})
+
+			It("returns an error when --only and --skip are combined", func() {
+				err := command.Execute([]string{"--only", "director", "--skip", "terraform"}, incomingState)
+				Expect(err).To(MatchError("--only and --skip cannot be used together."))
+			})
+
+			It("returns an error when the phase is missing", func() {
+				err := command.Execute([]string{"--skip"}, incomingState)
+				Expect(err).To(MatchError("flag needs an argument: -skip"))
+			})
+
+			It("fails fast", func() {
+				err := command.CheckFastFails([]string{"--only", "dns"}, incomingState)
+				Expect(err).To(MatchError(`Unknown phase "dns": use terraform, jumpbox, director, cloud-config.`))
+				Expect(plan.CheckFastFailsCall.CallCount).To(Equal(0))
 			})
 		})
 
@@ -41,19 +41,37 @@ Usage:
 Global Options:
   --help       [-h]        Prints usage. Use "bbl [command] --help" for more information about a command
   --state-dir  [-s]        Directory containing the bbl state                                            env:"BBL_STATE_DIRECTORY"
+  --env                    Name of the environment in the workspace to use as the state directory        env:"BBL_ENV"
+  --workspace              Directory containing one state directory per environment (Defaults to .)      env:"BBL_WORKSPACE"
+  --config                 YAML file of flag values (Defaults to bbl.yml in the state directory)         env:"BBL_CONFIG"
   --debug      [-d]        Prints debugging output                                                       env:"BBL_DEBUG"
   --version    [-v]        Prints version
   --no-confirm [-n]        No confirm
+  --json                   Prints the result of query commands as a JSON document                        env:"BBL_JSON"
+  --state-backend          Remote state location: s3://bucket/path or gcs://bucket/path                  env:"BBL_STATE_BACKEND"
+  --state-passphrase       Passphrase used to encrypt the state directory                                env:"BBL_STATE_PASSPHRASE"
+  --state-key-file         File containing the key used to encrypt the state directory                   env:"BBL_STATE_KEY_FILE"
 
 Basic Commands: A good place to start
+  init                    Asks for the settings of a new environment and writes bbl.yml
   up                      Deploys BOSH director on an IAAS, creates CF/Concourse load balancers. Updates existing director.
   print-env               All environment variables needed for targeting BOSH. Use with: eval "$(bbl print-env)"
 
 Maintenance Lifecycle Commands:
   destroy                 Tears down BOSH director infrastructure. Cleans up state directory
-  rotate                  Rotates SSH key for the jumpbox user
+  rotate                  Rotates the jumpbox SSH key or other director credentials
   plan                    Populates a state directory with the latest config without applying it
   cleanup-leftovers       Cleans up orphaned IAAS resources
+  unlock                  Removes a stale lock left behind by an interrupted run
+  state                   Manages the state directory: show, export, import, history, rollback, encrypt, decrypt
+  doctor                  Checks the state directory for missing or inconsistent files
+  drift                   Reports infrastructure and cloud config changed outside of bbl
+  certs                   Lists certificates and reports the ones about to expire
+  versions                Compares deployed stemcell and release versions with the ones this bbl ships
+  vars                    Lists, gets, sets and deletes vars in the director, jumpbox and cloud-config vars stores
+  env                     Lists the environments of a workspace and runs a command in each of them
+  config                  Shows the effective configuration and where each setting came from
+  completion              Prints a shell completion script for bash, zsh or fish
 
 Environmental Detail Commands: Useful for automation and gaining access
   jumpbox-address         Prints BOSH jumpbox address
@@ -67,11 +85,14 @@ Environmental Detail Commands: Useful for automation and gaining access
   lbs                     Prints load balancer(s) and DNS records
   outputs                 Prints the outputs from terraform
   ssh                     Opens an SSH connection to the director or jumpbox
+  scp                     Copies a file to or from the director or jumpbox
+  tunnel                  Starts a SOCKS5 proxy to the environment through the jumpbox
 
 Troubleshooting Commands:
   help                    Prints usage
   version                 Prints version
   latest-error            Prints the output from the latest call to terraform
+  logs                    Prints the logs of the runs that changed the environment
 `, "\n")))
 		})
 	})
@@ -86,9 +107,16 @@ Troubleshooting Commands:
 Global Options:
   --help       [-h]        Prints usage. Use "bbl [command] --help" for more information about a command
   --state-dir  [-s]        Directory containing the bbl state                                            env:"BBL_STATE_DIRECTORY"
+  --env                    Name of the environment in the workspace to use as the state directory        env:"BBL_ENV"
+  --workspace              Directory containing one state directory per environment (Defaults to .)      env:"BBL_WORKSPACE"
+  --config                 YAML file of flag values (Defaults to bbl.yml in the state directory)         env:"BBL_CONFIG"
   --debug      [-d]        Prints debugging output                                                       env:"BBL_DEBUG"
   --version    [-v]        Prints version
   --no-confirm [-n]        No confirm
+  --json                   Prints the result of query commands as a JSON document                        env:"BBL_JSON"
+  --state-backend          Remote state location: s3://bucket/path or gcs://bucket/path                  env:"BBL_STATE_BACKEND"
+  --state-passphrase       Passphrase used to encrypt the state directory                                env:"BBL_STATE_PASSPHRASE"
+  --state-key-file         File containing the key used to encrypt the state directory                   env:"BBL_STATE_KEY_FILE"
 
 [my-command command options]
   some message
@@ -0,0 +1,316 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/flags"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type varsEditor interface {
+	List(deployment string) ([]bosh.Var, error)
+	Get(deployment, name string) (string, error)
+	Set(deployment, name, value string) error
+	Delete(deployment, name string) error
+}
+
+type varsArgs struct {
+	deployment string
+	positional []string
+}
+
+func varsFlags(subcommand string, deployment *string) flags.Flags {
+	f := flags.New("vars " + subcommand)
+	f.String(deployment, "deployment", *deployment)
+	return f
+}
+
+// parseVarsArgs reads --deployment wherever it appears among the
+// positional arguments, and checks that there are as many of them as
+// the subcommand takes.
+func parseVarsArgs(subcommand string, subcommandFlags []string, names ...string) (varsArgs, error) {
+	args := varsArgs{positional: []string{}}
+
+	remaining := subcommandFlags
+	for {
+		f := varsFlags(subcommand, &args.deployment)
+		err := f.Parse(remaining)
+		if err != nil {
+			return varsArgs{}, err
+		}
+
+		remaining = f.Args()
+		if len(remaining) == 0 {
+			break
+		}
+		args.positional = append(args.positional, remaining[0])
+		remaining = remaining[1:]
+	}
+
+	if args.deployment == "" {
+		args.deployment = "director"
+	}
+
+	known := false
+	for _, deployment := range bosh.VarsDeployments() {
+		known = known || deployment == args.deployment
+	}
+	if !known {
+		return varsArgs{}, fmt.Errorf("Unknown deployment %q: use %s.", args.deployment, strings.Join(bosh.VarsDeployments(), ", "))
+	}
+
+	if len(args.positional) != len(names) {
+		usage := "bbl vars " + subcommand
+		for _, name := range names {
+			usage += " <" + name + ">"
+		}
+		return varsArgs{}, fmt.Errorf("Usage: %s [--deployment=%s]", usage, strings.Join(bosh.VarsDeployments(), "|"))
+	}
+
+	return args, nil
+}
+
+type VarsList struct {
+	logger         logger
+	stateValidator stateValidator
+	varsEditor     varsEditor
+}
+
+func NewVarsList(logger logger, stateValidator stateValidator, varsEditor varsEditor) VarsList {
+	return VarsList{
+		logger:         logger,
+		stateValidator: stateValidator,
+		varsEditor:     varsEditor,
+	}
+}
+
+func (v VarsList) Flags() []flags.Flags {
+	var deployment string
+	return []flags.Flags{varsFlags("list", &deployment)}
+}
+
+func (v VarsList) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := parseVarsArgs("list", subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	return v.stateValidator.Validate()
+}
+
+func (v VarsList) Execute(subcommandFlags []string, state storage.State) error {
+	vars, err := v.list(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	if len(vars) == 0 {
+		v.logger.Println("No vars found. Run bbl up to generate them.")
+		return nil
+	}
+
+	v.logger.Printf("%-40s %-26s %s\n", "NAME", "FILE", "EDITABLE")
+	for _, variable := range vars {
+		editable := "yes"
+		if variable.Regenerated {
+			editable = "no"
+		}
+		v.logger.Printf("%-40s %-26s %s\n", variable.Name, variable.File, editable)
+	}
+
+	return nil
+}
+
+func (v VarsList) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	vars, err := v.list(subcommandFlags)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(v.logger, vars)
+}
+
+func (v VarsList) list(subcommandFlags []string) ([]bosh.Var, error) {
+	args, err := parseVarsArgs("list", subcommandFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.varsEditor.List(args.deployment)
+}
+
+type VarsGet struct {
+	logger         logger
+	stateValidator stateValidator
+	varsEditor     varsEditor
+}
+
+func NewVarsGet(logger logger, stateValidator stateValidator, varsEditor varsEditor) VarsGet {
+	return VarsGet{
+		logger:         logger,
+		stateValidator: stateValidator,
+		varsEditor:     varsEditor,
+	}
+}
+
+func (v VarsGet) Flags() []flags.Flags {
+	var deployment string
+	return []flags.Flags{varsFlags("get", &deployment)}
+}
+
+func (v VarsGet) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := parseVarsArgs("get", subcommandFlags, "name")
+	if err != nil {
+		return err
+	}
+
+	return v.stateValidator.Validate()
+}
+
+func (v VarsGet) Execute(subcommandFlags []string, state storage.State) error {
+	args, err := parseVarsArgs("get", subcommandFlags, "name")
+	if err != nil {
+		return err
+	}
+
+	value, err := v.varsEditor.Get(args.deployment, args.positional[0])
+	if err != nil {
+		return err
+	}
+
+	v.logger.Println(strings.TrimSuffix(value, "\n"))
+	return nil
+}
+
+type VarsSet struct {
+	logger         logger
+	stateValidator stateValidator
+	varsEditor     varsEditor
+	stateStore     stateStore
+}
+
+func NewVarsSet(logger logger, stateValidator stateValidator, varsEditor varsEditor, stateStore stateStore) VarsSet {
+	return VarsSet{
+		logger:         logger,
+		stateValidator: stateValidator,
+		varsEditor:     varsEditor,
+		stateStore:     stateStore,
+	}
+}
+
+func (v VarsSet) Flags() []flags.Flags {
+	var deployment string
+	return []flags.Flags{varsFlags("set", &deployment)}
+}
+
+func (v VarsSet) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := parseVarsArgs("set", subcommandFlags, "name", "value")
+	if err != nil {
+		return err
+	}
+
+	return v.stateValidator.Validate()
+}
+
+func (v VarsSet) Execute(subcommandFlags []string, state storage.State) error {
+	args, err := parseVarsArgs("set", subcommandFlags, "name", "value")
+	if err != nil {
+		return err
+	}
+
+	name := args.positional[0]
+	v.logger.Step("setting %s for the %s", name, args.deployment)
+
+	err = v.varsEditor.Set(args.deployment, name, args.positional[1])
+	if err != nil {
+		return err
+	}
+
+	err = saveVars(v.stateStore, state)
+	if err != nil {
+		return err
+	}
+
+	v.logger.Println(fmt.Sprintf("Set %s. Run bbl up to deploy it.", name))
+	return nil
+}
+
+// RedactArgs leaves the value out of the run log. All of the arguments
+// are redacted when they cannot be parsed.
+func (v VarsSet) RedactArgs(subcommandFlags []string) []string {
+	redactedArgs := []string{}
+
+	args, err := parseVarsArgs("set", subcommandFlags, "name", "value")
+	for _, arg := range subcommandFlags {
+		if err != nil || arg == args.positional[1] {
+			arg = redacted
+		}
+		redactedArgs = append(redactedArgs, arg)
+	}
+	return redactedArgs
+}
+
+type VarsDelete struct {
+	logger         logger
+	stateValidator stateValidator
+	varsEditor     varsEditor
+	stateStore     stateStore
+}
+
+func NewVarsDelete(logger logger, stateValidator stateValidator, varsEditor varsEditor, stateStore stateStore) VarsDelete {
+	return VarsDelete{
+		logger:         logger,
+		stateValidator: stateValidator,
+		varsEditor:     varsEditor,
+		stateStore:     stateStore,
+	}
+}
+
+func (v VarsDelete) Flags() []flags.Flags {
+	var deployment string
+	return []flags.Flags{varsFlags("delete", &deployment)}
+}
+
+func (v VarsDelete) CheckFastFails(subcommandFlags []string, state storage.State) error {
+	_, err := parseVarsArgs("delete", subcommandFlags, "name")
+	if err != nil {
+		return err
+	}
+
+	return v.stateValidator.Validate()
+}
+
+func (v VarsDelete) Execute(subcommandFlags []string, state storage.State) error {
+	args, err := parseVarsArgs("delete", subcommandFlags, "name")
+	if err != nil {
+		return err
+	}
+
+	name := args.positional[0]
+	v.logger.Step("deleting %s for the %s", name, args.deployment)
+
+	err = v.varsEditor.Delete(args.deployment, name)
+	if err != nil {
+		return err
+	}
+
+	err = saveVars(v.stateStore, state)
+	if err != nil {
+		return err
+	}
+
+	v.logger.Println(fmt.Sprintf("Deleted %s. Run bbl up to generate a new value.", name))
+	return nil
+}
+
+// saveVars saves the state after a vars file was edited, so that the edit
+// is snapshotted and uploaded to the state backend with the state.
+func saveVars(stateStore stateStore, state storage.State) error {
+	err := stateStore.Set(state)
+	if err != nil {
+		return fmt.Errorf("Save state: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package commands_test
+
+import (
+	"errors"
+	"os"
+
+	"github.com/cloudfoundry/bosh-bootloader/bosh"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Vars", func() {
+	var (
+		logger         *fakes.Logger
+		stateValidator *fakes.StateValidator
+		varsEditor     *fakes.VarsEditor
+		stateBackend   *fakes.StateBackend
+		stateStore     storage.Store
+		state          storage.State
+	)
+
+	BeforeEach(func() {
+		logger = &fakes.Logger{}
+		stateValidator = &fakes.StateValidator{}
+		varsEditor = &fakes.VarsEditor{}
+
+		fs := &afero.Afero{Fs: afero.NewMemMapFs()}
+		Expect(fs.MkdirAll("/state", os.ModePerm)).To(Succeed())
+		stateBackend = &fakes.StateBackend{}
+		stateStore = storage.NewStore("/state", fs, &fakes.GarbageCollector{}, stateBackend, &fakes.Snapshots{})
+		state = storage.State{EnvID: "some-env"}
+	})
+
+	Describe("VarsList", func() {
+		var command commands.VarsList
+
+		BeforeEach(func() {
+			command = commands.NewVarsList(logger, stateValidator, varsEditor)
+			varsEditor.ListCall.Returns.Vars = []bosh.Var{
+				{Name: "admin_password", File: "director-vars-store.yml"},
+				{Name: "internal_ip", File: "director-vars-file.yml", Regenerated: true},
+			}
+		})
+
+		Describe("CheckFastFails", func() {
+			It("validates the state", func() {
+				stateValidator.ValidateCall.Returns.Error = errors.New("no state")
+				Expect(command.CheckFastFails([]string{}, storage.State{})).To(MatchError("no state"))
+			})
+
+			It("returns an error for an unknown deployment", func() {
+				err := command.CheckFastFails([]string{"--deployment", "concourse"}, storage.State{})
+				Expect(err).To(MatchError(`Unknown deployment "concourse": use director, jumpbox, cloud-config.`))
+			})
+
+			It("returns an error for extra arguments", func() {
+				err := command.CheckFastFails([]string{"admin_password"}, storage.State{})
+				Expect(err).To(MatchError("Usage: bbl vars list [--deployment=director|jumpbox|cloud-config]"))
+			})
+		})
+
+		Describe("Execute", func() {
+			It("lists the director vars by default", func() {
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(varsEditor.ListCall.Receives.Deployment).To(Equal("director"))
+				Expect(logger.PrintfCall.Messages).To(Equal([]string{
+					"NAME                                     FILE                       EDITABLE\n",
+					"admin_password                           director-vars-store.yml    yes\n",
+					"internal_ip                              director-vars-file.yml     no\n",
+				}))
+			})
+
+			It("lists the vars of another deployment", func() {
+				err := command.Execute([]string{"--deployment=jumpbox"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(varsEditor.ListCall.Receives.Deployment).To(Equal("jumpbox"))
+			})
+
+			It("says so when there are no vars", func() {
+				varsEditor.ListCall.Returns.Vars = []bosh.Var{}
+
+				err := command.Execute([]string{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("No vars found. Run bbl up to generate them."))
+			})
+
+			It("returns an error when the vars cannot be listed", func() {
+				varsEditor.ListCall.Returns.Error = errors.New("pear")
+				Expect(command.Execute([]string{}, storage.State{})).To(MatchError("pear"))
+			})
+		})
+
+		Describe("ExecuteJSON", func() {
+			It("prints the vars as JSON", func() {
+				err := command.ExecuteJSON([]string{}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(`[
+					{"name": "admin_password", "file": "director-vars-store.yml", "regenerated": false},
+					{"name": "internal_ip", "file": "director-vars-file.yml", "regenerated": true}
+				]`))
+			})
+		})
+	})
+
+	Describe("VarsGet", func() {
+		var command commands.VarsGet
+
+		BeforeEach(func() {
+			command = commands.NewVarsGet(logger, stateValidator, varsEditor)
+			varsEditor.GetCall.Returns.Value = "ca: some-ca\n"
+		})
+
+		Describe("CheckFastFails", func() {
+			It("requires a name", func() {
+				err := command.CheckFastFails([]string{}, storage.State{})
+				Expect(err).To(MatchError("Usage: bbl vars get <name> [--deployment=director|jumpbox|cloud-config]"))
+			})
+		})
+
+		Describe("Execute", func() {
+			It("prints the value", func() {
+				err := command.Execute([]string{"director_ssl", "--deployment", "jumpbox"}, storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(varsEditor.GetCall.Receives.Deployment).To(Equal("jumpbox"))
+				Expect(varsEditor.GetCall.Receives.Name).To(Equal("director_ssl"))
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("ca: some-ca"))
+			})
+
+			It("returns an error when the var cannot be read", func() {
+				varsEditor.GetCall.Returns.Error = errors.New("plum")
+				Expect(command.Execute([]string{"director_ssl"}, storage.State{})).To(MatchError("plum"))
+			})
+		})
+	})
+
+	Describe("VarsSet", func() {
+		var command commands.VarsSet
+
+		BeforeEach(func() {
+			command = commands.NewVarsSet(logger, stateValidator, varsEditor, stateStore)
+		})
+
+		Describe("CheckFastFails", func() {
+			It("requires a name and a value", func() {
+				err := command.CheckFastFails([]string{"admin_password"}, storage.State{})
+				Expect(err).To(MatchError("Usage: bbl vars set <name> <value> [--deployment=director|jumpbox|cloud-config]"))
+			})
+		})
+
+		Describe("Execute", func() {
+			It("sets the var", func() {
+				err := command.Execute([]string{"--deployment=director", "admin_password", "some-password"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(varsEditor.SetCall.Receives.Deployment).To(Equal("director"))
+				Expect(varsEditor.SetCall.Receives.Name).To(Equal("admin_password"))
+				Expect(varsEditor.SetCall.Receives.Value).To(Equal("some-password"))
+				Expect(logger.StepCall.Messages).To(Equal([]string{"setting admin_password for the director"}))
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("Set admin_password. Run bbl up to deploy it."))
+			})
+
+			It("uploads the edited vars store to the state backend", func() {
+				err := command.Execute([]string{"admin_password", "some-password"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateBackend.UploadCall.CallCount).To(Equal(1))
+				Expect(stateBackend.UploadCall.Receives.Dir).To(Equal("/state"))
+			})
+
+			It("returns an error when the state cannot be uploaded", func() {
+				stateBackend.UploadCall.Returns.Error = errors.New("lemon")
+
+				err := command.Execute([]string{"admin_password", "some-password"}, state)
+				Expect(err).To(MatchError("Save state: Upload remote state: lemon"))
+			})
+
+			It("returns an error when the var cannot be set", func() {
+				varsEditor.SetCall.Returns.Error = errors.New("fig")
+				Expect(command.Execute([]string{"admin_password", "some-password"}, storage.State{})).To(MatchError("fig"))
+			})
+		})
+
+		Describe("RedactArgs", func() {
+			It("redacts the value", func() {
+				Expect(command.RedactArgs([]string{"admin_password", "some-password", "--deployment", "director"})).To(Equal([]string{
+					"admin_password", "REDACTED", "--deployment", "director",
+				}))
+			})
+
+			It("redacts every argument when they cannot be parsed", func() {
+				Expect(command.RedactArgs([]string{"admin_password", "some-password", "extra"})).To(Equal([]string{
+					"REDACTED", "REDACTED", "REDACTED",
+				}))
+			})
+		})
+	})
+
+	Describe("VarsDelete", func() {
+		var command commands.VarsDelete
+
+		BeforeEach(func() {
+			command = commands.NewVarsDelete(logger, stateValidator, varsEditor, stateStore)
+		})
+
+		Describe("Execute", func() {
+			It("deletes the var", func() {
+				err := command.Execute([]string{"admin_password"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(varsEditor.DeleteCall.Receives.Deployment).To(Equal("director"))
+				Expect(varsEditor.DeleteCall.Receives.Name).To(Equal("admin_password"))
+				Expect(logger.PrintlnCall.Receives.Message).To(Equal("Deleted admin_password. Run bbl up to generate a new value."))
+			})
+
+			It("uploads the edited vars store to the state backend", func() {
+				err := command.Execute([]string{"admin_password"}, state)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateBackend.UploadCall.CallCount).To(Equal(1))
+			})
+
+			It("returns an error when the var cannot be deleted", func() {
+				varsEditor.DeleteCall.Returns.Error = errors.New("kiwi")
+				Expect(command.Execute([]string{"admin_password"}, storage.State{})).To(MatchError("kiwi"))
+			})
+		})
+	})
+})
@@ -10,12 +10,14 @@ import (
 type Version struct {
 	logger  logger
 	version string
+	release string
 }
 
 func NewVersion(version string, logger logger) Version {
 	return Version{
 		logger:  logger,
 		version: fmt.Sprintf("%s (%s/%s)", version, runtime.GOOS, runtime.GOARCH),
+		release: version,
 	}
 }
 
@@ -24,6 +26,14 @@ func (v Version) Execute(subcommandFlags []string, state storage.State) error {
 	return nil
 }
 
+func (v Version) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	return printJSON(v.logger, map[string]string{
+		"version": v.release,
+		"os":      runtime.GOOS,
+		"arch":    runtime.GOARCH,
+	})
+}
+
 func (v Version) CheckFastFails(subcommandFlags []string, state storage.State) error {
 	return nil
 }
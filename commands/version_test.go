@@ -68,4 +68,15 @@ var _ = Describe("Version", func() {
 			})
 		})
 	})
+
+	Describe("ExecuteJSON", func() {
+		It("prints the version, os and architecture as json", func() {
+			version = commands.NewVersion("1.2.3", logger)
+
+			err := version.ExecuteJSON([]string{}, storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger.PrintlnCall.Receives.Message).To(MatchJSON(fmt.Sprintf(`{"version": "1.2.3", "os": %q, "arch": %q}`, runtime.GOOS, runtime.GOARCH)))
+		})
+	})
 })
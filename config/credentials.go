@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	awsclient "github.com/cloudfoundry/bosh-bootloader/aws"
+	azureclient "github.com/cloudfoundry/bosh-bootloader/azure"
+	gcpclient "github.com/cloudfoundry/bosh-bootloader/gcp"
+)
+
+// CheckCredentials builds the state that a new environment would get from
+// the bbl.yml keys in answers and asks the IaaS whether the credentials
+// and region work. vSphere and OpenStack are not checked.
+func (c Config) CheckCredentials(answers map[string]string) error {
+	globals, err := globalsFrom(answers)
+	if err != nil {
+		return err
+	}
+
+	state, err := c.updateIAASState(globals, storage.State{})
+	if err != nil {
+		return err
+	}
+
+	switch state.IAAS {
+	case "aws":
+		zones, err := awsclient.NewClient(state.AWS, c.logger).RetrieveAvailabilityZones(state.AWS.Region)
+		if err != nil {
+			return fmt.Errorf("AWS rejected the credentials: %s", err)
+		}
+		if len(zones) == 0 {
+			return fmt.Errorf("AWS region %q has no availability zones. Check the region name.", state.AWS.Region)
+		}
+	case "gcp":
+		_, err := gcpclient.NewClient(state.GCP, "")
+		if err != nil {
+			return fmt.Errorf("GCP rejected the service account key or region: %s", err)
+		}
+	case "azure":
+		client, err := azureclient.NewClient(state.Azure)
+		if err != nil {
+			return fmt.Errorf("Azure rejected the credentials: %s", err)
+		}
+		err = client.ValidateRegion(state.Azure.Region)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,389 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cloudfoundry/bosh-bootloader/commands"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const CONFIG_FILE = "bbl.yml"
+
+// notInFile are the global flags that bbl.yml cannot set, because they
+// decide where bbl.yml is or are only useful on the command line.
+var notInFile = map[string]bool{
+	"help":      true,
+	"version":   true,
+	"config":    true,
+	"state-dir": true,
+	"env":       true,
+	"workspace": true,
+}
+
+type upSetting struct {
+	key    string
+	env    string
+	secret bool
+}
+
+// upSettings are the bbl up and bbl plan flags that bbl.yml can set.
+var upSettings = []upSetting{
+	{key: "name", env: "BBL_ENV_NAME"},
+	{key: "lb-type"},
+	{key: "lb-cert"},
+	{key: "lb-key", secret: true},
+	{key: "lb-chain"},
+	{key: "lb-domain"},
+}
+
+type globalOption struct {
+	field  int
+	long   string
+	short  string
+	env    string
+	secret bool
+}
+
+func globalOptions() []globalOption {
+	options := []globalOption{}
+
+	t := reflect.TypeOf(globalFlags{})
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag
+		options = append(options, globalOption{
+			field:  i,
+			long:   tag.Get("long"),
+			short:  tag.Get("short"),
+			env:    tag.Get("env"),
+			secret: tag.Get("secret") == "true",
+		})
+	}
+
+	return options
+}
+
+// configFile returns the bbl.yml that bbl reads its settings from.
+func configFile(globals globalFlags) string {
+	if globals.Config != "" {
+		return globals.Config
+	}
+	return filepath.Join(globals.StateDir, CONFIG_FILE)
+}
+
+// childNotInEnv are the global flags that a bbl started by this one does
+// not inherit: it is given its state directory with --state-dir.
+var childNotInEnv = map[string]bool{
+	"state-dir": true,
+	"env":       true,
+	"json":      true,
+}
+
+// ChildEnv returns environ with the global flags in globals set as their
+// BBL_* environment variables, for a bbl that this one starts in the same
+// state directory, such as the tunnel daemon. Secrets such as the state
+// passphrase are passed this way rather than as arguments, which other
+// users on the machine can see.
+func ChildEnv(globals globalFlags, environ []string) []string {
+	options := globalOptions()
+
+	isOption := map[string]bool{}
+	for _, option := range options {
+		isOption[option.env] = option.env != ""
+	}
+
+	env := []string{}
+	for _, variable := range environ {
+		if !isOption[strings.SplitN(variable, "=", 2)[0]] {
+			env = append(env, variable)
+		}
+	}
+
+	value := reflect.ValueOf(globals)
+	for _, option := range options {
+		if option.env == "" || childNotInEnv[option.long] {
+			continue
+		}
+
+		field := value.Field(option.field)
+		if field.IsZero() {
+			continue
+		}
+		env = append(env, fmt.Sprintf("%s=%v", option.env, field.Interface()))
+	}
+
+	return env
+}
+
+// CompletionFlags returns the global flags for the completion scripts.
+func CompletionFlags() []commands.CompletionFlag {
+	t := reflect.TypeOf(globalFlags{})
+
+	flags := []commands.CompletionFlag{}
+	for _, option := range globalOptions() {
+		flags = append(flags, commands.CompletionFlag{
+			Long:       option.long,
+			Short:      option.short,
+			TakesValue: t.Field(option.field).Type.Kind() != reflect.Bool,
+		})
+	}
+	return flags
+}
+
+// readConfigFile reads the bbl.yml at path, or in the state directory when
+// path is empty. Only a bbl.yml named with --config has to exist, and not
+// even that one when bbl init is about to write it.
+func readConfigFile(path, stateDir string, mustExist bool) (map[string]string, error) {
+	if path == "" {
+		path = filepath.Join(stateDir, CONFIG_FILE)
+		mustExist = false
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !mustExist {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Read config file: %s", err)
+	}
+
+	// Reading into strings keeps values such as "no" or "0755" as they are
+	// written, where YAML would make them booleans or numbers.
+	var file map[string]string
+	err = yaml.Unmarshal(contents, &file)
+	if err != nil {
+		return nil, fmt.Errorf("Parse %s: %s", filepath.Base(path), err)
+	}
+
+	known := map[string]bool{}
+	for _, option := range globalOptions() {
+		known[option.long] = !notInFile[option.long]
+	}
+	for _, setting := range upSettings {
+		known[setting.key] = true
+	}
+
+	for key := range file {
+		if !known[key] {
+			return nil, fmt.Errorf("Unknown key %q in %s. The keys are the long names of the global flags and of the bbl up flags.", key, filepath.Base(path))
+		}
+	}
+
+	if file == nil {
+		file = map[string]string{}
+	}
+	return file, nil
+}
+
+// applyConfigFile sets the global flags that were given neither as a flag
+// nor through the environment from bbl.yml, and returns where each global
+// flag came from.
+func applyConfigFile(globals *globalFlags, file map[string]string, args []string) (map[string]string, error) {
+	sources := map[string]string{}
+	value := reflect.ValueOf(globals).Elem()
+
+	for _, option := range globalOptions() {
+		field := value.Field(option.field)
+
+		switch {
+		case flagGiven(args, option.long, option.short):
+			sources[option.long] = "flag"
+			continue
+		case envGiven(option.env):
+			sources[option.long] = "env"
+			continue
+		}
+
+		fileValue, ok := file[option.long]
+		if !ok {
+			continue
+		}
+
+		err := setOption(field, option, fileValue)
+		if err != nil {
+			return nil, err
+		}
+		sources[option.long] = "file"
+	}
+
+	return sources, nil
+}
+
+// globalsFrom returns the global flags that the keys of a bbl.yml would set,
+// ignoring the command line and the environment.
+func globalsFrom(file map[string]string) (globalFlags, error) {
+	var globals globalFlags
+	value := reflect.ValueOf(&globals).Elem()
+
+	for _, option := range globalOptions() {
+		fileValue, ok := file[option.long]
+		if !ok || notInFile[option.long] {
+			continue
+		}
+
+		err := setOption(value.Field(option.field), option, fileValue)
+		if err != nil {
+			return globalFlags{}, err
+		}
+	}
+
+	return globals, nil
+}
+
+func setOption(field reflect.Value, option globalOption, value string) error {
+	switch field.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("The value of %s in %s must be true or false.", option.long, CONFIG_FILE)
+		}
+		field.SetBool(b)
+	default:
+		field.SetString(value)
+	}
+	return nil
+}
+
+// fileUpArgs returns bbl.yml's bbl up flags that the command line and the
+// environment do not set. They go before args so that args win.
+func fileUpArgs(file map[string]string, args []string, iaas string) []string {
+	fileArgs := []string{}
+	for _, setting := range upSettings {
+		value, ok := file[setting.key]
+		if !ok || flagGiven(args, setting.key, "") || envGiven(setting.env) {
+			continue
+		}
+		if setting.key == "lb-chain" && iaas != "aws" {
+			continue
+		}
+		fileArgs = append(fileArgs, fmt.Sprintf("--%s=%s", setting.key, value))
+	}
+
+	return append(fileArgs, args...)
+}
+
+// settings returns the effective value of every setting that has one.
+// Values that came from no flag, variable or file are read from the state.
+func settings(globals globalFlags, sources map[string]string, file map[string]string, state storage.State) []commands.Setting {
+	result := []commands.Setting{}
+	value := reflect.ValueOf(globals)
+	fromState := stateSettings(state)
+
+	for _, option := range globalOptions() {
+		if notInFile[option.long] {
+			continue
+		}
+		if iaas := iaasOf(option.long); iaas != "" && iaas != state.IAAS {
+			continue
+		}
+
+		setting := commands.Setting{Key: option.long, Secret: option.secret, Source: sources[option.long]}
+		if setting.Source != "" {
+			setting.Value = fmt.Sprint(value.Field(option.field).Interface())
+		} else if v := fromState[option.long]; v != "" {
+			setting.Value, setting.Source = v, "state"
+		} else {
+			continue
+		}
+
+		result = append(result, setting)
+	}
+
+	for _, up := range upSettings {
+		setting := commands.Setting{Key: up.key, Secret: up.secret}
+		switch {
+		case envGiven(up.env):
+			setting.Value, setting.Source = os.Getenv(up.env), "env"
+		case file[up.key] != "":
+			setting.Value, setting.Source = file[up.key], "file"
+		case fromState[up.key] != "":
+			setting.Value, setting.Source = fromState[up.key], "state"
+		default:
+			continue
+		}
+		result = append(result, setting)
+	}
+
+	return result
+}
+
+func stateSettings(state storage.State) map[string]string {
+	return map[string]string{
+		"iaas":                             state.IAAS,
+		"name":                             state.EnvID,
+		"lb-type":                          state.LB.Type,
+		"lb-domain":                        state.LB.Domain,
+		"aws-access-key-id":                state.AWS.AccessKeyID,
+		"aws-secret-access-key":            state.AWS.SecretAccessKey,
+		"aws-region":                       state.AWS.Region,
+		"azure-client-id":                  state.Azure.ClientID,
+		"azure-client-secret":              state.Azure.ClientSecret,
+		"azure-region":                     state.Azure.Region,
+		"azure-subscription-id":            state.Azure.SubscriptionID,
+		"azure-tenant-id":                  state.Azure.TenantID,
+		"gcp-service-account-key":          state.GCP.ServiceAccountKeyPath,
+		"gcp-region":                       state.GCP.Region,
+		"vsphere-network":                  state.VSphere.Network,
+		"vsphere-subnet":                   state.VSphere.Subnet,
+		"vsphere-vcenter-cluster":          state.VSphere.VCenterCluster,
+		"vsphere-vcenter-dc":               state.VSphere.VCenterDC,
+		"vsphere-vcenter-ds":               state.VSphere.VCenterDS,
+		"vsphere-vcenter-ip":               state.VSphere.VCenterIP,
+		"vsphere-vcenter-password":         state.VSphere.VCenterPassword,
+		"vsphere-vcenter-rp":               state.VSphere.VCenterRP,
+		"vsphere-vcenter-user":             state.VSphere.VCenterUser,
+		"openstack-internal-cidr":          state.OpenStack.InternalCidr,
+		"openstack-external-ip":            state.OpenStack.ExternalIP,
+		"openstack-auth-url":               state.OpenStack.AuthURL,
+		"openstack-az":                     state.OpenStack.AZ,
+		"openstack-default-key-name":       state.OpenStack.DefaultKeyName,
+		"openstack-default-security-group": state.OpenStack.DefaultSecurityGroup,
+		"openstack-network-id":             state.OpenStack.NetworkID,
+		"openstack-password":               state.OpenStack.Password,
+		"openstack-username":               state.OpenStack.Username,
+		"openstack-project":                state.OpenStack.Project,
+		"openstack-domain":                 state.OpenStack.Domain,
+		"openstack-region":                 state.OpenStack.Region,
+		"openstack-private-key":            state.OpenStack.PrivateKey,
+	}
+}
+
+func iaasOf(key string) string {
+	for _, iaas := range []string{"aws", "azure", "gcp", "vsphere", "openstack"} {
+		if strings.HasPrefix(key, iaas+"-") {
+			return iaas
+		}
+	}
+	return ""
+}
+
+func flagGiven(args []string, long, short string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if arg == "--"+long || strings.HasPrefix(arg, "--"+long+"=") || arg == "-"+long || strings.HasPrefix(arg, "-"+long+"=") {
+			return true
+		}
+		if short != "" && (arg == "-"+short || strings.HasPrefix(arg, "-"+short+"=")) {
+			return true
+		}
+	}
+	return false
+}
+
+func envGiven(name string) bool {
+	if name == "" {
+		return false
+	}
+	value, ok := syscall.Getenv(name)
+	return ok && value != ""
+}
@@ -5,20 +5,33 @@ type globalFlags struct {
 	Debug     bool   `short:"d" long:"debug"     env:"BBL_DEBUG"`
 	Version   bool   `short:"v" long:"version"`
 	NoConfirm bool   `short:"n" long:"no-confirm"`
+	JSON      bool   `          long:"json"      env:"BBL_JSON"`
 	StateDir  string `short:"s" long:"state-dir" env:"BBL_STATE_DIRECTORY"`
 	IAAS      string `          long:"iaas"      env:"BBL_IAAS"`
+	Env       string `          long:"env"       env:"BBL_ENV"`
+	Workspace string `          long:"workspace" env:"BBL_WORKSPACE"`
+	Config    string `          long:"config"    env:"BBL_CONFIG"`
+
+	StateBackend                string `long:"state-backend"                    env:"BBL_STATE_BACKEND"`
+	StateBackendEndpoint        string `long:"state-backend-endpoint"           env:"BBL_STATE_BACKEND_ENDPOINT"`
+	StateBackendRegion          string `long:"state-backend-region"             env:"BBL_STATE_BACKEND_REGION"`
+	StateBackendAccessKeyID     string `long:"state-backend-access-key-id"      env:"BBL_STATE_BACKEND_ACCESS_KEY_ID"`
+	StateBackendSecretAccessKey string `long:"state-backend-secret-access-key"  env:"BBL_STATE_BACKEND_SECRET_ACCESS_KEY" secret:"true"`
+
+	StatePassphrase string `long:"state-passphrase" env:"BBL_STATE_PASSPHRASE" secret:"true"`
+	StateKeyFile    string `long:"state-key-file"   env:"BBL_STATE_KEY_FILE"`
 
 	AWSAccessKeyID     string `long:"aws-access-key-id"       env:"BBL_AWS_ACCESS_KEY_ID"`
-	AWSSecretAccessKey string `long:"aws-secret-access-key"   env:"BBL_AWS_SECRET_ACCESS_KEY"`
+	AWSSecretAccessKey string `long:"aws-secret-access-key"   env:"BBL_AWS_SECRET_ACCESS_KEY" secret:"true"`
 	AWSRegion          string `long:"aws-region"              env:"BBL_AWS_REGION"`
 
 	AzureClientID       string `long:"azure-client-id"        env:"BBL_AZURE_CLIENT_ID"`
-	AzureClientSecret   string `long:"azure-client-secret"    env:"BBL_AZURE_CLIENT_SECRET"`
+	AzureClientSecret   string `long:"azure-client-secret"    env:"BBL_AZURE_CLIENT_SECRET" secret:"true"`
 	AzureRegion         string `long:"azure-region"           env:"BBL_AZURE_REGION"`
 	AzureSubscriptionID string `long:"azure-subscription-id"  env:"BBL_AZURE_SUBSCRIPTION_ID"`
 	AzureTenantID       string `long:"azure-tenant-id"        env:"BBL_AZURE_TENANT_ID"`
 
-	GCPServiceAccountKey string `long:"gcp-service-account-key" env:"BBL_GCP_SERVICE_ACCOUNT_KEY"`
+	GCPServiceAccountKey string `long:"gcp-service-account-key" env:"BBL_GCP_SERVICE_ACCOUNT_KEY" secret:"true"`
 	GCPRegion            string `long:"gcp-region"              env:"BBL_GCP_REGION"`
 
 	VSphereNetwork         string `long:"vsphere-network"          env:"BBL_VSPHERE_NETWORK"`
@@ -27,7 +40,7 @@ type globalFlags struct {
 	VSphereVCenterDC       string `long:"vsphere-vcenter-dc"       env:"BBL_VSPHERE_VCENTER_DC"`
 	VSphereVCenterDS       string `long:"vsphere-vcenter-ds"       env:"BBL_VSPHERE_VCENTER_DS"`
 	VSphereVCenterIP       string `long:"vsphere-vcenter-ip"       env:"BBL_VSPHERE_VCENTER_IP"`
-	VSphereVCenterPassword string `long:"vsphere-vcenter-password" env:"BBL_VSPHERE_VCENTER_PASSWORD"`
+	VSphereVCenterPassword string `long:"vsphere-vcenter-password" env:"BBL_VSPHERE_VCENTER_PASSWORD" secret:"true"`
 	VSphereVCenterRP       string `long:"vsphere-vcenter-rp"       env:"BBL_VSPHERE_VCENTER_RP"`
 	VSphereVCenterUser     string `long:"vsphere-vcenter-user"     env:"BBL_VSPHERE_VCENTER_USER"`
 
@@ -38,10 +51,10 @@ type globalFlags struct {
 	OpenStackDefaultKeyName       string `long:"openstack-default-key-name"       env:"BBL_OPENSTACK_DEFAULT_KEY_NAME"`
 	OpenStackDefaultSecurityGroup string `long:"openstack-default-security-group" env:"BBL_OPENSTACK_DEFAULT_SECURITY_GROUP"`
 	OpenStackNetworkID            string `long:"openstack-network-id"             env:"BBL_OPENSTACK_NETWORK_ID"`
-	OpenStackPassword             string `long:"openstack-password"               env:"BBL_OPENSTACK_PASSWORD"`
+	OpenStackPassword             string `long:"openstack-password"               env:"BBL_OPENSTACK_PASSWORD" secret:"true"`
 	OpenStackUsername             string `long:"openstack-username"               env:"BBL_OPENSTACK_USERNAME"`
 	OpenStackProject              string `long:"openstack-project"                env:"BBL_OPENSTACK_PROJECT"`
 	OpenStackDomain               string `long:"openstack-domain"                 env:"BBL_OPENSTACK_DOMAIN"`
 	OpenStackRegion               string `long:"openstack-region"                 env:"BBL_OPENSTACK_REGION"`
-	OpenStackPrivateKey           string `long:"openstack-private-key"            env:"BBL_OPENSTACK_PRIVATE_KEY"`
+	OpenStackPrivateKey           string `long:"openstack-private-key"            env:"BBL_OPENSTACK_PRIVATE_KEY" secret:"true"`
 }
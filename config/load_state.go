@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
@@ -14,6 +15,7 @@ import (
 )
 
 type logger interface {
+	Step(string, ...interface{})
 	Println(string)
 }
 
@@ -48,24 +50,75 @@ type Config struct {
 	fs             fs
 }
 
+type parsedArgs struct {
+	globals   globalFlags
+	remaining []string
+	file      map[string]string
+	sources   map[string]string
+}
+
 func ParseArgs(args []string) (globalFlags, []string, error) {
+	parsed, err := parseArgs(args)
+	return parsed.globals, parsed.remaining, err
+}
+
+func parseArgs(args []string) (parsedArgs, error) {
 	var globals globalFlags
 	parser := flags.NewParser(&globals, flags.IgnoreUnknown)
 
-	remainingArgs, err := parser.ParseArgs(args[1:])
+	// Arguments after -- belong to the command, such as the bbl command
+	// that bbl env foreach runs, and are not read as global flags.
+	flagArgs, passedArgs := args[1:], []string{}
+	for i, arg := range flagArgs {
+		if arg == "--" {
+			flagArgs, passedArgs = args[1:i+1], args[i+1:]
+			break
+		}
+	}
+
+	remainingArgs, err := parser.ParseArgs(flagArgs)
 	if err != nil {
-		return globalFlags{}, remainingArgs, err
+		return parsedArgs{remaining: remainingArgs}, err
 	}
+	remainingArgs = append(remainingArgs, passedArgs...)
+	parsed := parsedArgs{remaining: remainingArgs}
 
-	if !filepath.IsAbs(globals.StateDir) {
-		workingDir, err := os.Getwd()
-		if err != nil {
-			return globalFlags{}, remainingArgs, err // not tested
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return parsed, err // not tested
+	}
+
+	if !filepath.IsAbs(globals.Workspace) {
+		globals.Workspace = filepath.Join(workingDir, globals.Workspace)
+	}
+
+	if globals.Env != "" {
+		if globals.StateDir != "" {
+			return parsed, errors.New("--env and --state-dir cannot be used together.")
 		}
+		if strings.ContainsAny(globals.Env, `/\`) || globals.Env == "." || globals.Env == ".." {
+			return parsed, fmt.Errorf("Invalid environment name %q: use the name of a directory in the workspace.", globals.Env)
+		}
+		globals.StateDir = filepath.Join(globals.Workspace, globals.Env)
+	}
+
+	if !filepath.IsAbs(globals.StateDir) {
 		globals.StateDir = filepath.Join(workingDir, globals.StateDir)
 	}
 
-	return globals, remainingArgs, nil
+	initializing := len(remainingArgs) > 0 && remainingArgs[0] == "init"
+	parsed.file, err = readConfigFile(globals.Config, globals.StateDir, !initializing)
+	if err != nil {
+		return parsed, err
+	}
+
+	parsed.sources, err = applyConfigFile(&globals, parsed.file, flagArgs)
+	if err != nil {
+		return parsed, err
+	}
+
+	parsed.globals = globals
+	return parsed, nil
 }
 
 func (c Config) Bootstrap(args []string) (application.Configuration, error) {
@@ -75,10 +128,11 @@ func (c Config) Bootstrap(args []string) (application.Configuration, error) {
 		}, nil
 	}
 
-	globalFlags, remainingArgs, err := ParseArgs(args)
+	parsed, err := parseArgs(args)
 	if err != nil {
 		return application.Configuration{}, err
 	}
+	globalFlags, remainingArgs := parsed.globals, parsed.remaining
 
 	var command string
 	if len(remainingArgs) > 0 {
@@ -88,6 +142,9 @@ func (c Config) Bootstrap(args []string) (application.Configuration, error) {
 	if globalFlags.Version || command == "version" {
 		command = "version"
 		return application.Configuration{
+			Global: application.GlobalConfiguration{
+				JSON: globalFlags.JSON,
+			},
 			ShowCommandHelp: globalFlags.Help,
 			Command:         command,
 		}, nil
@@ -119,6 +176,21 @@ func (c Config) Bootstrap(args []string) (application.Configuration, error) {
 		}, nil
 	}
 
+	// bbl init sets up a new state directory, which may not exist yet.
+	if command == "init" {
+		return application.Configuration{
+			Global: application.GlobalConfiguration{
+				Debug:      globalFlags.Debug,
+				StateDir:   globalFlags.StateDir,
+				Workspace:  globalFlags.Workspace,
+				ConfigFile: configFile(globalFlags),
+				JSON:       globalFlags.JSON,
+			},
+			Command:         command,
+			SubcommandFlags: remainingArgs[1:],
+		}, nil
+	}
+
 	state, err := c.stateBootstrap.GetState(globalFlags.StateDir)
 	if err != nil {
 		return application.Configuration{}, err
@@ -134,15 +206,24 @@ func (c Config) Bootstrap(args []string) (application.Configuration, error) {
 		return application.Configuration{}, err
 	}
 
+	subcommandFlags := remainingArgs[1:]
+	if command == "up" || command == "plan" {
+		subcommandFlags = fileUpArgs(parsed.file, subcommandFlags, state.IAAS)
+	}
+
 	return application.Configuration{
 		Global: application.GlobalConfiguration{
-			Debug:    globalFlags.Debug,
-			StateDir: globalFlags.StateDir,
+			Debug:      globalFlags.Debug,
+			StateDir:   globalFlags.StateDir,
+			Workspace:  globalFlags.Workspace,
+			ConfigFile: configFile(globalFlags),
+			JSON:       globalFlags.JSON,
 		},
 		State:           state,
 		Command:         command,
-		SubcommandFlags: remainingArgs[1:],
+		SubcommandFlags: subcommandFlags,
 		ShowCommandHelp: globalFlags.Help,
+		Settings:        settings(globalFlags, parsed.sources, parsed.file, state),
 	}, nil
 }
 
@@ -155,6 +236,8 @@ func NeedsIAASCreds(command string) bool {
 		"leftovers":         {},
 		"cleanup-leftovers": {},
 		"rotate":            {},
+		"doctor":            {},
+		"drift":             {},
 	}[command]
 	return ok
 }
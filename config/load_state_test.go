@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 
 	"github.com/cloudfoundry/bosh-bootloader/application"
+	"github.com/cloudfoundry/bosh-bootloader/commands"
 	"github.com/cloudfoundry/bosh-bootloader/config"
 	"github.com/cloudfoundry/bosh-bootloader/fakes"
 	"github.com/cloudfoundry/bosh-bootloader/storage"
@@ -115,6 +116,15 @@ var _ = Describe("LoadState", func() {
 				Expect(appConfig.Global.StateDir).To(Equal(fullStateDirPath))
 			})
 
+			It("returns the json flag", func() {
+				appConfig, err := c.Bootstrap([]string{"bbl", "director-address", "--json"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.Command).To(Equal("director-address"))
+				Expect(appConfig.SubcommandFlags).To(BeEmpty())
+				Expect(appConfig.Global.JSON).To(BeTrue())
+			})
+
 			Context("when --help is passed in after a command", func() {
 				It("returns command help", func() {
 					args := []string{
@@ -169,6 +179,190 @@ var _ = Describe("LoadState", func() {
 			})
 		})
 
+		Describe("workspaces", func() {
+			It("resolves --env to an environment in the workspace", func() {
+				appConfig, err := c.Bootstrap([]string{"bbl", "up", "--workspace", "/some/workspace", "--env", "some-env"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeStateBootstrap.GetStateCall.Receives.Dir).To(Equal("/some/workspace/some-env"))
+				Expect(appConfig.Global.StateDir).To(Equal("/some/workspace/some-env"))
+				Expect(appConfig.Global.Workspace).To(Equal("/some/workspace"))
+			})
+
+			It("uses the working directory as the workspace", func() {
+				os.Setenv("BBL_ENV", "some-env")
+
+				appConfig, err := c.Bootstrap([]string{"bbl", "up"})
+				Expect(err).NotTo(HaveOccurred())
+
+				workingDir, err := os.Getwd()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(appConfig.Global.StateDir).To(Equal(filepath.Join(workingDir, "some-env")))
+				Expect(appConfig.Global.Workspace).To(Equal(workingDir))
+			})
+
+			It("does not read global flags after --", func() {
+				appConfig, err := c.Bootstrap([]string{"bbl", "env", "foreach", "--", "print-env", "--json", "--state-dir", "other"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.Global.JSON).To(BeFalse())
+				Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{"foreach", "--", "print-env", "--json", "--state-dir", "other"}))
+			})
+
+			Context("when --state-dir is also given", func() {
+				It("returns an error", func() {
+					_, err := c.Bootstrap([]string{"bbl", "up", "--env", "some-env", "--state-dir", "some-state-dir"})
+					Expect(err).To(MatchError("--env and --state-dir cannot be used together."))
+				})
+			})
+
+			Context("when the environment name is a path", func() {
+				It("returns an error", func() {
+					_, err := c.Bootstrap([]string{"bbl", "up", "--env", "../some-env"})
+					Expect(err).To(MatchError(`Invalid environment name "../some-env": use the name of a directory in the workspace.`))
+				})
+			})
+		})
+
+		Describe("bbl.yml", func() {
+			var stateDir string
+
+			BeforeEach(func() {
+				var err error
+				stateDir, err = ioutil.TempDir("", "bbl-config-")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = ioutil.WriteFile(filepath.Join(stateDir, "bbl.yml"), []byte(`
+iaas: aws
+aws-access-key-id: file-access-key-id
+aws-secret-access-key: file-secret-access-key
+aws-region: file-region
+debug: true
+name: file-env
+lb-type: cf
+lb-chain: some-chain
+`), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(stateDir)
+			})
+
+			It("reads the global flags from bbl.yml in the state directory", func() {
+				appConfig, err := c.Bootstrap([]string{"bbl", "plan", "--state-dir", stateDir})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.Global.Debug).To(BeTrue())
+				Expect(appConfig.State.IAAS).To(Equal("aws"))
+				Expect(appConfig.State.AWS).To(Equal(storage.AWS{
+					AccessKeyID:     "file-access-key-id",
+					SecretAccessKey: "file-secret-access-key",
+					Region:          "file-region",
+				}))
+			})
+
+			It("passes the bbl up flags from bbl.yml before the ones on the command line", func() {
+				appConfig, err := c.Bootstrap([]string{"bbl", "up", "--state-dir", stateDir, "--lb-type", "concourse"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{"--name=file-env", "--lb-chain=some-chain", "--lb-type", "concourse"}))
+			})
+
+			It("does not pass the bbl up flags to other commands", func() {
+				appConfig, err := c.Bootstrap([]string{"bbl", "destroy", "--state-dir", stateDir})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{}))
+			})
+
+			It("prefers flags, then environment variables, then bbl.yml, then the state", func() {
+				os.Setenv("BBL_AWS_REGION", "env-region")
+				os.Setenv("BBL_ENV_NAME", "env-env")
+				fakeStateMigrator.MigrateCall.Returns.State = storage.State{
+					IAAS:  "aws",
+					EnvID: "state-env",
+					LB:    storage.LB{Domain: "state.example.com"},
+				}
+
+				appConfig, err := c.Bootstrap([]string{"bbl", "plan", "--state-dir", stateDir, "--aws-access-key-id", "flag-access-key-id"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(appConfig.State.AWS.AccessKeyID).To(Equal("flag-access-key-id"))
+				Expect(appConfig.State.AWS.Region).To(Equal("env-region"))
+				Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{"--lb-type=cf", "--lb-chain=some-chain"}))
+				Expect(appConfig.Settings).To(Equal([]commands.Setting{
+					{Key: "debug", Value: "true", Source: "file"},
+					{Key: "iaas", Value: "aws", Source: "file"},
+					{Key: "aws-access-key-id", Value: "flag-access-key-id", Source: "flag"},
+					{Key: "aws-secret-access-key", Value: "file-secret-access-key", Source: "file", Secret: true},
+					{Key: "aws-region", Value: "env-region", Source: "env"},
+					{Key: "name", Value: "env-env", Source: "env"},
+					{Key: "lb-type", Value: "cf", Source: "file"},
+					{Key: "lb-chain", Value: "some-chain", Source: "file"},
+					{Key: "lb-domain", Value: "state.example.com", Source: "state"},
+				}))
+			})
+
+			It("reads the file named by --config", func() {
+				configFile := filepath.Join(stateDir, "other.yml")
+				err := ioutil.WriteFile(configFile, []byte("iaas: gcp\n"), os.ModePerm)
+				Expect(err).NotTo(HaveOccurred())
+
+				appConfig, err := c.Bootstrap([]string{"bbl", "env-id", "--state-dir", stateDir, "--config", configFile})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(appConfig.State.IAAS).To(Equal("gcp"))
+			})
+
+			Context("when the file named by --config does not exist", func() {
+				It("returns an error", func() {
+					_, err := c.Bootstrap([]string{"bbl", "env-id", "--config", "/no/such/bbl.yml"})
+					Expect(err).To(MatchError(ContainSubstring("Read config file: ")))
+				})
+			})
+
+			It("lets bbl init name a bbl.yml that does not exist yet", func() {
+				appConfig, err := c.Bootstrap([]string{"bbl", "init", "--config", "/no/such/bbl.yml"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(appConfig.Global.ConfigFile).To(Equal("/no/such/bbl.yml"))
+			})
+
+			It("uses bbl.yml in the state directory by default", func() {
+				appConfig, err := c.Bootstrap([]string{"bbl", "env-id", "--state-dir", stateDir})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(appConfig.Global.ConfigFile).To(Equal(filepath.Join(stateDir, "bbl.yml")))
+			})
+
+			It("does not read the state for bbl init", func() {
+				appConfig, err := c.Bootstrap([]string{"bbl", "init", "--state-dir", "/no/such/dir", "--answers", "answers.yml"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeStateBootstrap.GetStateCall.CallCount).To(Equal(0))
+				Expect(appConfig.Command).To(Equal("init"))
+				Expect(appConfig.SubcommandFlags).To(Equal(application.StringSlice{"--answers", "answers.yml"}))
+			})
+
+			Context("when bbl.yml has an unknown key", func() {
+				It("returns an error", func() {
+					err := ioutil.WriteFile(filepath.Join(stateDir, "bbl.yml"), []byte("aws-zone: us-east-1a\n"), os.ModePerm)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = c.Bootstrap([]string{"bbl", "env-id", "--state-dir", stateDir})
+					Expect(err).To(MatchError(`Unknown key "aws-zone" in bbl.yml. The keys are the long names of the global flags and of the bbl up flags.`))
+				})
+			})
+
+			Context("when bbl.yml sets a flag that only the command line can set", func() {
+				It("returns an error", func() {
+					err := ioutil.WriteFile(filepath.Join(stateDir, "bbl.yml"), []byte("state-dir: /elsewhere\n"), os.ModePerm)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = c.Bootstrap([]string{"bbl", "env-id", "--state-dir", stateDir})
+					Expect(err).To(MatchError(ContainSubstring(`Unknown key "state-dir"`)))
+				})
+			})
+		})
+
 		Describe("reading a previous state file", func() {
 			var (
 				gotState      storage.State
@@ -1245,4 +1439,84 @@ var _ = Describe("LoadState", func() {
 				"Missing --openstack-region. To see all required credentials run `bbl plan --help`."),
 		)
 	})
+
+	Describe("StateLoader", func() {
+		It("loads and migrates the state again", func() {
+			loader := config.NewStateLoader(c, []string{"bbl", "--state-dir", "some-state-dir", "up"})
+			fakeStateMigrator.MigrateCall.Returns.State = storage.State{EnvID: "changed-env-id"}
+
+			state, err := loader.LoadState()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.EnvID).To(Equal("changed-env-id"))
+			Expect(fakeStateBootstrap.GetStateCall.Receives.Dir).To(HaveSuffix("some-state-dir"))
+		})
+
+		It("returns an error when the state cannot be loaded", func() {
+			loader := config.NewStateLoader(c, []string{"bbl", "up"})
+			fakeStateBootstrap.GetStateCall.Returns.Error = errors.New("fig")
+
+			_, err := loader.LoadState()
+			Expect(err).To(MatchError("fig"))
+		})
+
+		Context("when the loader prunes", func() {
+			var pruner *fakes.StatePruner
+
+			BeforeEach(func() {
+				pruner = &fakes.StatePruner{}
+			})
+
+			It("prunes the state directory before loading the state", func() {
+				loader := config.NewPruningStateLoader(c, []string{"bbl", "--state-dir", "some-state-dir", "up"}, pruner)
+
+				_, err := loader.LoadState()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(pruner.PruneCall.CallCount).To(Equal(1))
+			})
+
+			It("returns an error without loading the state when pruning fails", func() {
+				loader := config.NewPruningStateLoader(c, []string{"bbl", "up"}, pruner)
+				pruner.PruneCall.Returns.Error = errors.New("kiwi")
+
+				_, err := loader.LoadState()
+				Expect(err).To(MatchError("kiwi"))
+				Expect(fakeStateBootstrap.GetStateCall.CallCount).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("ChildEnv", func() {
+		It("passes the global flags as environment variables, except the state directory", func() {
+			globals, _, err := config.ParseArgs([]string{
+				"bbl", "--env", "staging", "--debug", "--json",
+				"--state-passphrase", "some-passphrase", "--state-backend", "s3://some-bucket", "tunnel",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			env := config.ChildEnv(globals, []string{
+				"HOME=/home/me",
+				"BBL_ENV=staging",
+				"BBL_STATE_PASSPHRASE=some-other-passphrase",
+				"BBL_ENV_NAME=some-name",
+			})
+			Expect(env).To(ConsistOf(
+				"HOME=/home/me",
+				"BBL_ENV_NAME=some-name",
+				"BBL_DEBUG=true",
+				"BBL_STATE_PASSPHRASE=some-passphrase",
+				"BBL_STATE_BACKEND=s3://some-bucket",
+				MatchRegexp("^BBL_WORKSPACE=/"),
+			))
+		})
+	})
+
+	Describe("CompletionFlags", func() {
+		It("returns the global flags and whether they take a value", func() {
+			flags := config.CompletionFlags()
+
+			Expect(flags).To(ContainElement(commands.CompletionFlag{Long: "debug", Short: "d"}))
+			Expect(flags).To(ContainElement(commands.CompletionFlag{Long: "state-dir", Short: "s", TakesValue: true}))
+			Expect(flags).To(ContainElement(commands.CompletionFlag{Long: "env", TakesValue: true}))
+		})
+	})
 })
@@ -0,0 +1,48 @@
+package config
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type statePruner interface {
+	Prune() error
+}
+
+// StateLoader loads the state again the way Bootstrap loaded it for args.
+// Commands that take the state lock use it once they hold the lock. The
+// remote state is not downloaded again; commands that change the state
+// also prune the local files that were deleted from it.
+type StateLoader struct {
+	config Config
+	args   []string
+	pruner statePruner
+}
+
+func NewStateLoader(config Config, args []string) StateLoader {
+	return StateLoader{
+		config: config,
+		args:   args,
+	}
+}
+
+func NewPruningStateLoader(config Config, args []string, pruner statePruner) StateLoader {
+	return StateLoader{
+		config: config,
+		args:   args,
+		pruner: pruner,
+	}
+}
+
+func (s StateLoader) LoadState() (storage.State, error) {
+	if s.pruner != nil {
+		err := s.pruner.Prune()
+		if err != nil {
+			return storage.State{}, err
+		}
+	}
+
+	appConfig, err := s.config.Bootstrap(s.args)
+	if err != nil {
+		return storage.State{}, err
+	}
+
+	return appConfig.State, nil
+}
@@ -9,6 +9,17 @@ type AllProxyGetter struct {
 		}
 	}
 
+	WritePrivateKeyCall struct {
+		CallCount int
+		Receives  struct {
+			Path string
+		}
+		Returns struct {
+			PrivateKey string
+			Error      error
+		}
+	}
+
 	BoshAllProxyCall struct {
 		CallCount int
 		Receives  struct {
@@ -26,6 +37,12 @@ func (a *AllProxyGetter) GeneratePrivateKey() (string, error) {
 	return a.GeneratePrivateKeyCall.Returns.PrivateKey, a.GeneratePrivateKeyCall.Returns.Error
 }
 
+func (a *AllProxyGetter) WritePrivateKey(path string) (string, error) {
+	a.WritePrivateKeyCall.CallCount++
+	a.WritePrivateKeyCall.Receives.Path = path
+	return a.WritePrivateKeyCall.Returns.PrivateKey, a.WritePrivateKeyCall.Returns.Error
+}
+
 func (a *AllProxyGetter) BoshAllProxy(jumpboxURL, privateKey string) string {
 	a.BoshAllProxyCall.CallCount++
 	a.BoshAllProxyCall.Receives.JumpboxURL = jumpboxURL
@@ -0,0 +1,24 @@
+package fakes
+
+import (
+	"github.com/Azure/azure-sdk-for-go/arm/resources/resources"
+)
+
+type AzureProvidersClient struct {
+	GetCall struct {
+		CallCount int
+		Receives  struct {
+			Namespace string
+		}
+		Returns struct {
+			Provider resources.Provider
+			Error    error
+		}
+	}
+}
+
+func (a *AzureProvidersClient) Get(namespace string, expand string) (resources.Provider, error) {
+	a.GetCall.CallCount++
+	a.GetCall.Receives.Namespace = namespace
+	return a.GetCall.Returns.Provider, a.GetCall.Returns.Error
+}
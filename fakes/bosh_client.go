@@ -16,6 +16,14 @@ type BOSHClient struct {
 		}
 	}
 
+	CloudConfigCall struct {
+		CallCount int
+		Returns   struct {
+			CloudConfig string
+			Error       error
+		}
+	}
+
 	ConfigureHTTPClientCall struct {
 		CallCount int
 		Receives  struct {
@@ -38,6 +46,11 @@ func (c *BOSHClient) UpdateCloudConfig(yaml []byte) error {
 	return c.UpdateCloudConfigCall.Returns.Error
 }
 
+func (c *BOSHClient) CloudConfig() (string, error) {
+	c.CloudConfigCall.CallCount++
+	return c.CloudConfigCall.Returns.CloudConfig, c.CloudConfigCall.Returns.Error
+}
+
 func (c *BOSHClient) ConfigureHTTPClient(socks5Client proxy.Dialer) {
 	c.ConfigureHTTPClientCall.CallCount++
 	c.ConfigureHTTPClientCall.Receives.Socks5Client = socks5Client
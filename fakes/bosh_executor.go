@@ -64,6 +64,18 @@ type BOSHExecutor struct {
 		}
 	}
 
+	InterpolateCall struct {
+		CallCount int
+		Receives  struct {
+			DirInput bosh.DirInput
+			State    storage.State
+		}
+		Returns struct {
+			Manifest string
+			Error    error
+		}
+	}
+
 	PathCall struct {
 		CallCount int
 		Returns   struct {
@@ -122,6 +134,14 @@ func (e *BOSHExecutor) PlanDirector(input bosh.DirInput, deploymentDir, iaas str
 	return e.PlanDirectorCall.Returns.Error
 }
 
+func (e *BOSHExecutor) Interpolate(input bosh.DirInput, state storage.State) (string, error) {
+	e.InterpolateCall.CallCount++
+	e.InterpolateCall.Receives.DirInput = input
+	e.InterpolateCall.Receives.State = state
+
+	return e.InterpolateCall.Returns.Manifest, e.InterpolateCall.Returns.Error
+}
+
 func (e *BOSHExecutor) Path() string {
 	e.PathCall.CallCount++
 	return e.PathCall.Returns.Path
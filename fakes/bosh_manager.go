@@ -46,6 +46,28 @@ type BOSHManager struct {
 			Error error
 		}
 	}
+	InterpolateJumpboxCall struct {
+		CallCount int
+		Receives  struct {
+			State            storage.State
+			TerraformOutputs terraform.Outputs
+		}
+		Returns struct {
+			Manifest string
+			Error    error
+		}
+	}
+	InterpolateDirectorCall struct {
+		CallCount int
+		Receives  struct {
+			State            storage.State
+			TerraformOutputs terraform.Outputs
+		}
+		Returns struct {
+			Manifest string
+			Error    error
+		}
+	}
 	PathCall struct {
 		CallCount int
 		Returns   struct {
@@ -127,6 +149,20 @@ func (b *BOSHManager) CreateDirector(state storage.State, terraformOutputs terra
 	return b.CreateDirectorCall.Returns.State, b.CreateDirectorCall.Returns.Error
 }
 
+func (b *BOSHManager) InterpolateJumpbox(state storage.State, terraformOutputs terraform.Outputs) (string, error) {
+	b.InterpolateJumpboxCall.CallCount++
+	b.InterpolateJumpboxCall.Receives.State = state
+	b.InterpolateJumpboxCall.Receives.TerraformOutputs = terraformOutputs
+	return b.InterpolateJumpboxCall.Returns.Manifest, b.InterpolateJumpboxCall.Returns.Error
+}
+
+func (b *BOSHManager) InterpolateDirector(state storage.State, terraformOutputs terraform.Outputs) (string, error) {
+	b.InterpolateDirectorCall.CallCount++
+	b.InterpolateDirectorCall.Receives.State = state
+	b.InterpolateDirectorCall.Receives.TerraformOutputs = terraformOutputs
+	return b.InterpolateDirectorCall.Returns.Manifest, b.InterpolateDirectorCall.Returns.Error
+}
+
 func (b *BOSHManager) DeleteDirector(state storage.State, terraformOutputs terraform.Outputs) error {
 	b.DeleteDirectorCall.CallCount++
 	b.DeleteDirectorCall.Receives.State = state
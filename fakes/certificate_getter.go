@@ -0,0 +1,26 @@
+package fakes
+
+type CertificateGetter struct {
+	GetCall struct {
+		CallCount int
+		Fake      func(string) (map[string]string, error)
+		Receives  struct {
+			Deployment string
+		}
+		Returns struct {
+			Certificates map[string]string
+			Error        error
+		}
+	}
+}
+
+func (c *CertificateGetter) Get(deployment string) (map[string]string, error) {
+	c.GetCall.CallCount++
+	c.GetCall.Receives.Deployment = deployment
+
+	if c.GetCall.Fake != nil {
+		return c.GetCall.Fake(deployment)
+	}
+
+	return c.GetCall.Returns.Certificates, c.GetCall.Returns.Error
+}
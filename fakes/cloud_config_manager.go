@@ -39,6 +39,23 @@ type CloudConfigManager struct {
 			Error       error
 		}
 	}
+	CurrentCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			CloudConfig string
+			Error       error
+		}
+	}
+	AppliedCall struct {
+		CallCount int
+		Returns   struct {
+			CloudConfig string
+			Error       error
+		}
+	}
 	IsPresentCloudConfigCall struct {
 		CallCount int
 		Returns   struct {
@@ -51,6 +68,16 @@ type CloudConfigManager struct {
 			IsPresent bool
 		}
 	}
+	ModifiedCall struct {
+		CallCount int
+		Receives  struct {
+			State storage.State
+		}
+		Returns struct {
+			Files []string
+			Error error
+		}
+	}
 }
 
 func (c *CloudConfigManager) Update(state storage.State) error {
@@ -76,6 +103,17 @@ func (c *CloudConfigManager) Interpolate() (string, error) {
 	return c.InterpolateCall.Returns.CloudConfig, c.InterpolateCall.Returns.Error
 }
 
+func (c *CloudConfigManager) Current(state storage.State) (string, error) {
+	c.CurrentCall.CallCount++
+	c.CurrentCall.Receives.State = state
+	return c.CurrentCall.Returns.CloudConfig, c.CurrentCall.Returns.Error
+}
+
+func (c *CloudConfigManager) Applied() (string, error) {
+	c.AppliedCall.CallCount++
+	return c.AppliedCall.Returns.CloudConfig, c.AppliedCall.Returns.Error
+}
+
 func (c *CloudConfigManager) IsPresentCloudConfig() bool {
 	c.IsPresentCloudConfigCall.CallCount++
 	return c.IsPresentCloudConfigCall.Returns.IsPresent
@@ -85,3 +123,9 @@ func (c *CloudConfigManager) IsPresentCloudConfigVars() bool {
 	c.IsPresentCloudConfigVarsCall.CallCount++
 	return c.IsPresentCloudConfigVarsCall.Returns.IsPresent
 }
+
+func (c *CloudConfigManager) Modified(state storage.State) ([]string, error) {
+	c.ModifiedCall.CallCount++
+	c.ModifiedCall.Receives.State = state
+	return c.ModifiedCall.Returns.Files, c.ModifiedCall.Returns.Error
+}
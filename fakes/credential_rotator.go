@@ -0,0 +1,23 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type CredentialRotator struct {
+	RotateCall struct {
+		CallCount int
+		Receives  struct {
+			Names []string
+		}
+		Returns struct {
+			Rotations []storage.Rotation
+			Error     error
+		}
+	}
+}
+
+func (c *CredentialRotator) Rotate(names []string) ([]storage.Rotation, error) {
+	c.RotateCall.CallCount++
+	c.RotateCall.Receives.Names = names
+
+	return c.RotateCall.Returns.Rotations, c.RotateCall.Returns.Error
+}
@@ -0,0 +1,19 @@
+package fakes
+
+type CredentialsChecker struct {
+	CheckCredentialsCall struct {
+		CallCount int
+		Receives  struct {
+			Answers map[string]string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (c *CredentialsChecker) CheckCredentials(answers map[string]string) error {
+	c.CheckCredentialsCall.CallCount++
+	c.CheckCredentialsCall.Receives.Answers = answers
+	return c.CheckCredentialsCall.Returns.Error
+}
@@ -0,0 +1,34 @@
+package fakes
+
+import (
+	"io"
+	"sync"
+)
+
+type EnvRunnerRunReceive struct {
+	StateDir string
+	Args     []string
+}
+
+type EnvRunner struct {
+	mutex sync.Mutex
+
+	RunCall struct {
+		CallCount int
+		Fake      func(string, []string, io.Writer) (int, error)
+		Receives  []EnvRunnerRunReceive
+	}
+}
+
+func (e *EnvRunner) Run(stateDir string, args []string, out io.Writer) (int, error) {
+	e.mutex.Lock()
+	e.RunCall.CallCount++
+	e.RunCall.Receives = append(e.RunCall.Receives, EnvRunnerRunReceive{StateDir: stateDir, Args: args})
+	e.mutex.Unlock()
+
+	if e.RunCall.Fake != nil {
+		return e.RunCall.Fake(stateDir, args, out)
+	}
+
+	return 0, nil
+}
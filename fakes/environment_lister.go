@@ -0,0 +1,19 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/workspace"
+
+type EnvironmentLister struct {
+	EnvironmentsCall struct {
+		CallCount int
+		Returns   struct {
+			Environments []workspace.Environment
+			Error        error
+		}
+	}
+}
+
+func (e *EnvironmentLister) Environments() ([]workspace.Environment, error) {
+	e.EnvironmentsCall.CallCount++
+
+	return e.EnvironmentsCall.Returns.Environments, e.EnvironmentsCall.Returns.Error
+}
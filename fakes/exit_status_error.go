@@ -0,0 +1,14 @@
+package fakes
+
+type ExitStatusError struct {
+	Status  int
+	Message string
+}
+
+func (e ExitStatusError) Error() string {
+	return e.Message
+}
+
+func (e ExitStatusError) ExitStatus() int {
+	return e.Status
+}
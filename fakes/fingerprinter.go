@@ -0,0 +1,26 @@
+package fakes
+
+type Fingerprinter struct {
+	FingerprintCall struct {
+		CallCount int
+		Fake      func(paths ...string) (string, error)
+		Receives  struct {
+			Paths []string
+		}
+		Returns struct {
+			Fingerprint string
+			Error       error
+		}
+	}
+}
+
+func (f *Fingerprinter) Fingerprint(paths ...string) (string, error) {
+	f.FingerprintCall.CallCount++
+	f.FingerprintCall.Receives.Paths = paths
+
+	if f.FingerprintCall.Fake != nil {
+		return f.FingerprintCall.Fake(paths...)
+	}
+
+	return f.FingerprintCall.Returns.Fingerprint, f.FingerprintCall.Returns.Error
+}
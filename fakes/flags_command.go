@@ -0,0 +1,19 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/flags"
+
+type FlagsCommand struct {
+	Command
+	FlagsCall struct {
+		CallCount int
+		Returns   struct {
+			Flags []flags.Flags
+		}
+	}
+}
+
+func (c *FlagsCommand) Flags() []flags.Flags {
+	c.FlagsCall.CallCount++
+
+	return c.FlagsCall.Returns.Flags
+}
@@ -0,0 +1,25 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type JSONCommand struct {
+	Command
+	ExecuteJSONCall struct {
+		CallCount int
+		Receives  struct {
+			State           storage.State
+			SubcommandFlags []string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (c *JSONCommand) ExecuteJSON(subcommandFlags []string, state storage.State) error {
+	c.ExecuteJSONCall.CallCount++
+	c.ExecuteJSONCall.Receives.State = state
+	c.ExecuteJSONCall.Receives.SubcommandFlags = subcommandFlags
+
+	return c.ExecuteJSONCall.Returns.Error
+}
@@ -0,0 +1,74 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type RunLog struct {
+	StartCall struct {
+		CallCount int
+		Receives  struct {
+			Command string
+			Args    []string
+			State   storage.State
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	FinishCall struct {
+		CallCount int
+		Receives  struct {
+			ExitCode int
+			Error    error
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	RunsCall struct {
+		CallCount int
+		Returns   struct {
+			Runs  []storage.Run
+			Error error
+		}
+	}
+
+	RunCall struct {
+		CallCount int
+		Receives  struct {
+			ID string
+		}
+		Returns struct {
+			Run     storage.Run
+			Entries []storage.LogEntry
+			Error   error
+		}
+	}
+}
+
+func (r *RunLog) Start(command string, args []string, state storage.State) error {
+	r.StartCall.CallCount++
+	r.StartCall.Receives.Command = command
+	r.StartCall.Receives.Args = args
+	r.StartCall.Receives.State = state
+	return r.StartCall.Returns.Error
+}
+
+func (r *RunLog) Finish(exitCode int, err error) error {
+	r.FinishCall.CallCount++
+	r.FinishCall.Receives.ExitCode = exitCode
+	r.FinishCall.Receives.Error = err
+	return r.FinishCall.Returns.Error
+}
+
+func (r *RunLog) Runs() ([]storage.Run, error) {
+	r.RunsCall.CallCount++
+	return r.RunsCall.Returns.Runs, r.RunsCall.Returns.Error
+}
+
+func (r *RunLog) Run(id string) (storage.Run, []storage.LogEntry, error) {
+	r.RunCall.CallCount++
+	r.RunCall.Receives.ID = id
+	return r.RunCall.Returns.Run, r.RunCall.Returns.Entries, r.RunCall.Returns.Error
+}
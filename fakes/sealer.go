@@ -0,0 +1,51 @@
+package fakes
+
+type Sealer struct {
+	EnabledCall struct {
+		CallCount int
+		Returns   struct {
+			Enabled bool
+		}
+	}
+
+	SealCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	UnsealCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	UnsealVarsCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+func (s *Sealer) Enabled() bool {
+	s.EnabledCall.CallCount++
+	return s.EnabledCall.Returns.Enabled
+}
+
+func (s *Sealer) Seal() error {
+	s.SealCall.CallCount++
+	return s.SealCall.Returns.Error
+}
+
+func (s *Sealer) Unseal() error {
+	s.UnsealCall.CallCount++
+	return s.UnsealCall.Returns.Error
+}
+
+func (s *Sealer) UnsealVars() error {
+	s.UnsealVarsCall.CallCount++
+	return s.UnsealVarsCall.Returns.Error
+}
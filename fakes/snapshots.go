@@ -0,0 +1,56 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type Snapshots struct {
+	TakeCall struct {
+		CallCount int
+		Receives  struct {
+			Dir string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	ListCall struct {
+		CallCount int
+		Receives  struct {
+			Dir string
+		}
+		Returns struct {
+			Snapshots []storage.Snapshot
+			Error     error
+		}
+	}
+
+	RestoreCall struct {
+		CallCount int
+		Receives  struct {
+			Dir string
+			ID  string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *Snapshots) Take(dir string) error {
+	s.TakeCall.CallCount++
+	s.TakeCall.Receives.Dir = dir
+	return s.TakeCall.Returns.Error
+}
+
+func (s *Snapshots) List(dir string) ([]storage.Snapshot, error) {
+	s.ListCall.CallCount++
+	s.ListCall.Receives.Dir = dir
+	return s.ListCall.Returns.Snapshots, s.ListCall.Returns.Error
+}
+
+func (s *Snapshots) Restore(dir, id string) error {
+	s.RestoreCall.CallCount++
+	s.RestoreCall.Receives.Dir = dir
+	s.RestoreCall.Receives.ID = id
+	return s.RestoreCall.Returns.Error
+}
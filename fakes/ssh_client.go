@@ -0,0 +1,80 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/ssh"
+
+type SSHClient struct {
+	ShellCall struct {
+		CallCount int
+		Receives  struct {
+			Target ssh.Target
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	RunCall struct {
+		CallCount int
+		Receives  struct {
+			Target  ssh.Target
+			Command string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	UploadCall struct {
+		CallCount int
+		Receives  struct {
+			Target     ssh.Target
+			LocalPath  string
+			RemotePath string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	DownloadCall struct {
+		CallCount int
+		Receives  struct {
+			Target     ssh.Target
+			RemotePath string
+			LocalPath  string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *SSHClient) Shell(target ssh.Target) error {
+	s.ShellCall.CallCount++
+	s.ShellCall.Receives.Target = target
+
+	return s.ShellCall.Returns.Error
+}
+
+func (s *SSHClient) Run(target ssh.Target, command string) error {
+	s.RunCall.CallCount++
+	s.RunCall.Receives.Target = target
+	s.RunCall.Receives.Command = command
+
+	return s.RunCall.Returns.Error
+}
+
+func (s *SSHClient) Upload(target ssh.Target, localPath, remotePath string) error {
+	s.UploadCall.CallCount++
+	s.UploadCall.Receives.Target = target
+	s.UploadCall.Receives.LocalPath = localPath
+	s.UploadCall.Receives.RemotePath = remotePath
+
+	return s.UploadCall.Returns.Error
+}
+
+func (s *SSHClient) Download(target ssh.Target, remotePath, localPath string) error {
+	s.DownloadCall.CallCount++
+	s.DownloadCall.Receives.Target = target
+	s.DownloadCall.Receives.RemotePath = remotePath
+	s.DownloadCall.Receives.LocalPath = localPath
+
+	return s.DownloadCall.Returns.Error
+}
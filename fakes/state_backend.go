@@ -0,0 +1,130 @@
+package fakes
+
+type StateBackend struct {
+	DownloadCall struct {
+		CallCount int
+		Receives  struct {
+			Dir string
+		}
+		Returns struct {
+			Files map[string]bool
+			Error error
+		}
+	}
+
+	PruneCall struct {
+		CallCount int
+		Receives  struct {
+			Dir        string
+			Downloaded map[string]bool
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	UploadCall struct {
+		CallCount int
+		Receives  struct {
+			Dir string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	DeleteCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	ReadLockCall struct {
+		CallCount int
+		Receives  struct {
+			Dir  string
+			Name string
+		}
+		Returns struct {
+			Contents []byte
+			Error    error
+		}
+	}
+
+	WriteLockCall struct {
+		CallCount int
+		Receives  struct {
+			Dir      string
+			Name     string
+			Contents []byte
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	DeleteLockCall struct {
+		CallCount int
+		Receives  struct {
+			Dir  string
+			Name string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *StateBackend) Download(dir string) (map[string]bool, error) {
+	s.DownloadCall.CallCount++
+	s.DownloadCall.Receives.Dir = dir
+
+	return s.DownloadCall.Returns.Files, s.DownloadCall.Returns.Error
+}
+
+func (s *StateBackend) Prune(dir string, downloaded map[string]bool) error {
+	s.PruneCall.CallCount++
+	s.PruneCall.Receives.Dir = dir
+	s.PruneCall.Receives.Downloaded = downloaded
+
+	return s.PruneCall.Returns.Error
+}
+
+func (s *StateBackend) Upload(dir string) error {
+	s.UploadCall.CallCount++
+	s.UploadCall.Receives.Dir = dir
+
+	return s.UploadCall.Returns.Error
+}
+
+func (s *StateBackend) Delete() error {
+	s.DeleteCall.CallCount++
+
+	return s.DeleteCall.Returns.Error
+}
+
+func (s *StateBackend) ReadLock(dir, name string) ([]byte, error) {
+	s.ReadLockCall.CallCount++
+	s.ReadLockCall.Receives.Dir = dir
+	s.ReadLockCall.Receives.Name = name
+
+	return s.ReadLockCall.Returns.Contents, s.ReadLockCall.Returns.Error
+}
+
+func (s *StateBackend) WriteLock(dir, name string, contents []byte) error {
+	s.WriteLockCall.CallCount++
+	s.WriteLockCall.Receives.Dir = dir
+	s.WriteLockCall.Receives.Name = name
+	s.WriteLockCall.Receives.Contents = contents
+
+	return s.WriteLockCall.Returns.Error
+}
+
+func (s *StateBackend) DeleteLock(dir, name string) error {
+	s.DeleteLockCall.CallCount++
+	s.DeleteLockCall.Receives.Dir = dir
+	s.DeleteLockCall.Receives.Name = name
+
+	return s.DeleteLockCall.Returns.Error
+}
@@ -5,6 +5,7 @@ import "github.com/cloudfoundry/bosh-bootloader/storage"
 type StateBootstrap struct {
 	GetStateCall struct {
 		CallCount int
+		Fake      func(string) (storage.State, error)
 		Returns   struct {
 			State storage.State
 			Error error
@@ -19,5 +20,9 @@ func (s *StateBootstrap) GetState(dir string) (storage.State, error) {
 	s.GetStateCall.CallCount++
 	s.GetStateCall.Receives.Dir = dir
 
+	if s.GetStateCall.Fake != nil {
+		return s.GetStateCall.Fake(dir)
+	}
+
 	return s.GetStateCall.Returns.State, s.GetStateCall.Returns.Error
 }
@@ -0,0 +1,44 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateBundler struct {
+	ExportCall struct {
+		CallCount int
+		Receives  struct {
+			Dir          string
+			StripSecrets bool
+		}
+		Returns struct {
+			Bundle []byte
+			Error  error
+		}
+	}
+
+	ImportCall struct {
+		CallCount int
+		Receives  struct {
+			Dir    string
+			Bundle []byte
+		}
+		Returns struct {
+			Manifest storage.BundleManifest
+			State    storage.State
+			Error    error
+		}
+	}
+}
+
+func (s *StateBundler) Export(dir string, stripSecrets bool) ([]byte, error) {
+	s.ExportCall.CallCount++
+	s.ExportCall.Receives.Dir = dir
+	s.ExportCall.Receives.StripSecrets = stripSecrets
+	return s.ExportCall.Returns.Bundle, s.ExportCall.Returns.Error
+}
+
+func (s *StateBundler) Import(dir string, bundle []byte) (storage.BundleManifest, storage.State, error) {
+	s.ImportCall.CallCount++
+	s.ImportCall.Receives.Dir = dir
+	s.ImportCall.Receives.Bundle = bundle
+	return s.ImportCall.Returns.Manifest, s.ImportCall.Returns.State, s.ImportCall.Returns.Error
+}
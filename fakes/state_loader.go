@@ -0,0 +1,19 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateLoader struct {
+	LoadStateCall struct {
+		CallCount int
+		Returns   struct {
+			State storage.State
+			Error error
+		}
+	}
+}
+
+func (s *StateLoader) LoadState() (storage.State, error) {
+	s.LoadStateCall.CallCount++
+
+	return s.LoadStateCall.Returns.State, s.LoadStateCall.Returns.Error
+}
@@ -0,0 +1,94 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateLocker struct {
+	GetLockCall struct {
+		CallCount int
+		Returns   struct {
+			Lock  storage.Lock
+			Error error
+		}
+	}
+
+	CheckLockCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	LockCall struct {
+		CallCount int
+		Receives  struct {
+			Command string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+
+	UnlockCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	ForceUnlockCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+
+	RecordOperationCall struct {
+		CallCount int
+		Receives  struct {
+			Command   string
+			Succeeded bool
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *StateLocker) GetLock() (storage.Lock, error) {
+	s.GetLockCall.CallCount++
+
+	return s.GetLockCall.Returns.Lock, s.GetLockCall.Returns.Error
+}
+
+func (s *StateLocker) CheckLock() error {
+	s.CheckLockCall.CallCount++
+
+	return s.CheckLockCall.Returns.Error
+}
+
+func (s *StateLocker) Lock(command string) error {
+	s.LockCall.CallCount++
+	s.LockCall.Receives.Command = command
+
+	return s.LockCall.Returns.Error
+}
+
+func (s *StateLocker) Unlock() error {
+	s.UnlockCall.CallCount++
+
+	return s.UnlockCall.Returns.Error
+}
+
+func (s *StateLocker) ForceUnlock() error {
+	s.ForceUnlockCall.CallCount++
+
+	return s.ForceUnlockCall.Returns.Error
+}
+
+func (s *StateLocker) RecordOperation(command string, succeeded bool) error {
+	s.RecordOperationCall.CallCount++
+	s.RecordOperationCall.Receives.Command = command
+	s.RecordOperationCall.Receives.Succeeded = succeeded
+
+	return s.RecordOperationCall.Returns.Error
+}
@@ -0,0 +1,16 @@
+package fakes
+
+type StatePruner struct {
+	PruneCall struct {
+		CallCount int
+		Returns   struct {
+			Error error
+		}
+	}
+}
+
+func (s *StatePruner) Prune() error {
+	s.PruneCall.CallCount++
+
+	return s.PruneCall.Returns.Error
+}
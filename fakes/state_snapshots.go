@@ -0,0 +1,34 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/storage"
+
+type StateSnapshots struct {
+	SnapshotsCall struct {
+		CallCount int
+		Returns   struct {
+			Snapshots []storage.Snapshot
+			Error     error
+		}
+	}
+
+	RollbackCall struct {
+		CallCount int
+		Receives  struct {
+			ID string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (s *StateSnapshots) Snapshots() ([]storage.Snapshot, error) {
+	s.SnapshotsCall.CallCount++
+	return s.SnapshotsCall.Returns.Snapshots, s.SnapshotsCall.Returns.Error
+}
+
+func (s *StateSnapshots) Rollback(id string) error {
+	s.RollbackCall.CallCount++
+	s.RollbackCall.Receives.ID = id
+	return s.RollbackCall.Returns.Error
+}
@@ -11,8 +11,9 @@ type TerraformCmd struct {
 		Returns   struct {
 			Errors []error
 		}
-		Initialized bool
-		Receives    struct {
+		Initialized  bool
+		ReceivedArgs [][]string
+		Receives     struct {
 			Stdout           io.Writer
 			WorkingDirectory string
 			Args             []string
@@ -26,6 +27,7 @@ func (t *TerraformCmd) RunWithEnv(stdout io.Writer, workingDirectory string, arg
 	t.RunCall.Receives.Stdout = stdout
 	t.RunCall.Receives.WorkingDirectory = workingDirectory
 	t.RunCall.Receives.Args = args
+	t.RunCall.ReceivedArgs = append(t.RunCall.ReceivedArgs, args)
 	t.RunCall.Receives.Env = env
 
 	switch args[0] {
@@ -1,5 +1,7 @@
 package fakes
 
+import "github.com/cloudfoundry/bosh-bootloader/terraform"
+
 type Import struct {
 	Addr string
 	ID   string
@@ -38,6 +40,26 @@ type TerraformExecutor struct {
 			Error error
 		}
 	}
+	PlanCall struct {
+		CallCount int
+		Receives  struct {
+			Credentials map[string]string
+		}
+		Returns struct {
+			Plan  string
+			Error error
+		}
+	}
+	DriftCall struct {
+		CallCount int
+		Receives  struct {
+			Credentials map[string]string
+		}
+		Returns struct {
+			Drift []terraform.ResourceDrift
+			Error error
+		}
+	}
 	DestroyCall struct {
 		CallCount int
 		Receives  struct {
@@ -73,6 +95,13 @@ type TerraformExecutor struct {
 			Error   error
 		}
 	}
+	StateChecksumCall struct {
+		CallCount int
+		Returns   struct {
+			Checksum string
+			Error    error
+		}
+	}
 	IsPavedCall struct {
 		CallCount int
 		Returns   struct {
@@ -105,6 +134,18 @@ func (t *TerraformExecutor) Apply(credentials map[string]string) error {
 	return t.ApplyCall.Returns.Error
 }
 
+func (t *TerraformExecutor) Plan(credentials map[string]string) (string, error) {
+	t.PlanCall.CallCount++
+	t.PlanCall.Receives.Credentials = credentials
+	return t.PlanCall.Returns.Plan, t.PlanCall.Returns.Error
+}
+
+func (t *TerraformExecutor) Drift(credentials map[string]string) ([]terraform.ResourceDrift, error) {
+	t.DriftCall.CallCount++
+	t.DriftCall.Receives.Credentials = credentials
+	return t.DriftCall.Returns.Drift, t.DriftCall.Returns.Error
+}
+
 func (t *TerraformExecutor) Destroy(credentials map[string]string) error {
 	t.DestroyCall.CallCount++
 	t.DestroyCall.Receives.Credentials = credentials
@@ -137,6 +178,11 @@ func (t *TerraformExecutor) Outputs() (map[string]interface{}, error) {
 	return t.OutputsCall.Returns.Outputs, t.OutputsCall.Returns.Error
 }
 
+func (t *TerraformExecutor) StateChecksum() (string, error) {
+	t.StateChecksumCall.CallCount++
+	return t.StateChecksumCall.Returns.Checksum, t.StateChecksumCall.Returns.Error
+}
+
 func (t *TerraformExecutor) IsPaved() (bool, error) {
 	t.IsPavedCall.CallCount++
 	return t.IsPavedCall.Returns.IsPaved, t.IsPavedCall.Returns.Error
@@ -25,6 +25,26 @@ type TerraformManager struct {
 			Error    error
 		}
 	}
+	PlanCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Plan  string
+			Error error
+		}
+	}
+	DriftCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Drift []terraform.ResourceDrift
+			Error error
+		}
+	}
 	DestroyCall struct {
 		CallCount int
 		Receives  struct {
@@ -53,6 +73,16 @@ type TerraformManager struct {
 			Error   error
 		}
 	}
+	CachedOutputsCall struct {
+		CallCount int
+		Receives  struct {
+			BBLState storage.State
+		}
+		Returns struct {
+			Outputs terraform.Outputs
+			Error   error
+		}
+	}
 	VersionCall struct {
 		CallCount int
 		Returns   struct {
@@ -89,6 +119,20 @@ func (t *TerraformManager) Apply(bblState storage.State) (storage.State, error)
 	return t.ApplyCall.Returns.BBLState, t.ApplyCall.Returns.Error
 }
 
+func (t *TerraformManager) Plan(bblState storage.State) (string, error) {
+	t.PlanCall.CallCount++
+	t.PlanCall.Receives.BBLState = bblState
+
+	return t.PlanCall.Returns.Plan, t.PlanCall.Returns.Error
+}
+
+func (t *TerraformManager) Drift(bblState storage.State) ([]terraform.ResourceDrift, error) {
+	t.DriftCall.CallCount++
+	t.DriftCall.Receives.BBLState = bblState
+
+	return t.DriftCall.Returns.Drift, t.DriftCall.Returns.Error
+}
+
 func (t *TerraformManager) Destroy(bblState storage.State) (storage.State, error) {
 	t.DestroyCall.CallCount++
 	t.DestroyCall.Receives.BBLState = bblState
@@ -109,6 +153,12 @@ func (t *TerraformManager) GetOutputs() (terraform.Outputs, error) {
 	return t.GetOutputsCall.Returns.Outputs, t.GetOutputsCall.Returns.Error
 }
 
+func (t *TerraformManager) CachedOutputs(bblState storage.State) (terraform.Outputs, error) {
+	t.CachedOutputsCall.CallCount++
+	t.CachedOutputsCall.Receives.BBLState = bblState
+	return t.CachedOutputsCall.Returns.Outputs, t.CachedOutputsCall.Returns.Error
+}
+
 func (t *TerraformManager) Version() (string, error) {
 	t.VersionCall.CallCount++
 	return t.VersionCall.Returns.Version, t.VersionCall.Returns.Error
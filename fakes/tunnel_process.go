@@ -0,0 +1,64 @@
+package fakes
+
+type TunnelProcess struct {
+	DaemonizeCall struct {
+		CallCount int
+		Receives  struct {
+			Args    []string
+			Env     []string
+			LogPath string
+		}
+		Returns struct {
+			PID   int
+			Error error
+		}
+	}
+	RunningCall struct {
+		CallCount int
+		Receives  struct {
+			PID int
+		}
+		Returns struct {
+			Running bool
+		}
+	}
+	StopCall struct {
+		CallCount int
+		Receives  struct {
+			PID int
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	WaitForInterruptCall struct {
+		CallCount int
+	}
+}
+
+func (t *TunnelProcess) Daemonize(args, env []string, logPath string) (int, error) {
+	t.DaemonizeCall.CallCount++
+	t.DaemonizeCall.Receives.Args = args
+	t.DaemonizeCall.Receives.Env = env
+	t.DaemonizeCall.Receives.LogPath = logPath
+
+	return t.DaemonizeCall.Returns.PID, t.DaemonizeCall.Returns.Error
+}
+
+func (t *TunnelProcess) Running(pid int) bool {
+	t.RunningCall.CallCount++
+	t.RunningCall.Receives.PID = pid
+
+	return t.RunningCall.Returns.Running
+}
+
+func (t *TunnelProcess) Stop(pid int) error {
+	t.StopCall.CallCount++
+	t.StopCall.Receives.PID = pid
+
+	return t.StopCall.Returns.Error
+}
+
+func (t *TunnelProcess) WaitForInterrupt() {
+	t.WaitForInterruptCall.CallCount++
+}
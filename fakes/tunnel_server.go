@@ -0,0 +1,25 @@
+package fakes
+
+type TunnelServer struct {
+	ListenCall struct {
+		CallCount int
+		Receives  struct {
+			PrivateKey string
+			JumpboxURL string
+			Port       int
+		}
+		Returns struct {
+			Address string
+			Error   error
+		}
+	}
+}
+
+func (t *TunnelServer) Listen(privateKey, jumpboxURL string, port int) (string, error) {
+	t.ListenCall.CallCount++
+	t.ListenCall.Receives.PrivateKey = privateKey
+	t.ListenCall.Receives.JumpboxURL = jumpboxURL
+	t.ListenCall.Receives.Port = port
+
+	return t.ListenCall.Returns.Address, t.ListenCall.Returns.Error
+}
@@ -0,0 +1,80 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/bosh"
+
+type VarsEditor struct {
+	ListCall struct {
+		CallCount int
+		Receives  struct {
+			Deployment string
+		}
+		Returns struct {
+			Vars  []bosh.Var
+			Error error
+		}
+	}
+	GetCall struct {
+		CallCount int
+		Receives  struct {
+			Deployment string
+			Name       string
+		}
+		Returns struct {
+			Value string
+			Error error
+		}
+	}
+	SetCall struct {
+		CallCount int
+		Receives  struct {
+			Deployment string
+			Name       string
+			Value      string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+	DeleteCall struct {
+		CallCount int
+		Receives  struct {
+			Deployment string
+			Name       string
+		}
+		Returns struct {
+			Error error
+		}
+	}
+}
+
+func (v *VarsEditor) List(deployment string) ([]bosh.Var, error) {
+	v.ListCall.CallCount++
+	v.ListCall.Receives.Deployment = deployment
+
+	return v.ListCall.Returns.Vars, v.ListCall.Returns.Error
+}
+
+func (v *VarsEditor) Get(deployment, name string) (string, error) {
+	v.GetCall.CallCount++
+	v.GetCall.Receives.Deployment = deployment
+	v.GetCall.Receives.Name = name
+
+	return v.GetCall.Returns.Value, v.GetCall.Returns.Error
+}
+
+func (v *VarsEditor) Set(deployment, name, value string) error {
+	v.SetCall.CallCount++
+	v.SetCall.Receives.Deployment = deployment
+	v.SetCall.Receives.Name = name
+	v.SetCall.Receives.Value = value
+
+	return v.SetCall.Returns.Error
+}
+
+func (v *VarsEditor) Delete(deployment, name string) error {
+	v.DeleteCall.CallCount++
+	v.DeleteCall.Receives.Deployment = deployment
+	v.DeleteCall.Receives.Name = name
+
+	return v.DeleteCall.Returns.Error
+}
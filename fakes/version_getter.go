@@ -0,0 +1,52 @@
+package fakes
+
+import "github.com/cloudfoundry/bosh-bootloader/bosh"
+
+type VersionGetter struct {
+	ShippedCall struct {
+		CallCount int
+		Fake      func(string, string) (bosh.Versions, error)
+		Receives  struct {
+			Deployment string
+			IAAS       string
+		}
+		Returns struct {
+			Versions bosh.Versions
+			Error    error
+		}
+	}
+	DeployedCall struct {
+		CallCount int
+		Fake      func(string) (bosh.Versions, error)
+		Receives  struct {
+			Manifest string
+		}
+		Returns struct {
+			Versions bosh.Versions
+			Error    error
+		}
+	}
+}
+
+func (v *VersionGetter) Shipped(deployment, iaas string) (bosh.Versions, error) {
+	v.ShippedCall.CallCount++
+	v.ShippedCall.Receives.Deployment = deployment
+	v.ShippedCall.Receives.IAAS = iaas
+
+	if v.ShippedCall.Fake != nil {
+		return v.ShippedCall.Fake(deployment, iaas)
+	}
+
+	return v.ShippedCall.Returns.Versions, v.ShippedCall.Returns.Error
+}
+
+func (v *VersionGetter) Deployed(manifest string) (bosh.Versions, error) {
+	v.DeployedCall.CallCount++
+	v.DeployedCall.Receives.Manifest = manifest
+
+	if v.DeployedCall.Fake != nil {
+		return v.DeployedCall.Fake(manifest)
+	}
+
+	return v.DeployedCall.Returns.Versions, v.DeployedCall.Returns.Error
+}
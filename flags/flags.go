@@ -3,6 +3,7 @@ package flags
 import (
 	"flag"
 	"io/ioutil"
+	"strings"
 )
 
 type Flags struct {
@@ -23,6 +24,10 @@ func (f Flags) String(v *string, name string, value string) {
 	f.set.StringVar(v, name, value, "")
 }
 
+func (f Flags) Int(v *int, name string, value int) {
+	f.set.IntVar(v, name, value, "")
+}
+
 func (f Flags) Bool(v *bool, name string) {
 	f.set.BoolVar(v, name, false, "")
 }
@@ -34,3 +39,58 @@ func (f Flags) Parse(args []string) error {
 func (f Flags) Args() []string {
 	return f.set.Args()
 }
+
+// ParseKnown parses the flags defined in f out of args and returns the
+// other args in order, so that a command can pass them on to the command
+// it wraps.
+func (f Flags) ParseKnown(args []string) ([]string, error) {
+	known := []string{}
+	rest := []string{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		if len(name) == len(arg) || name == "" {
+			rest = append(rest, arg)
+			continue
+		}
+
+		value := strings.Contains(name, "=")
+		name = strings.SplitN(name, "=", 2)[0]
+
+		defined := f.set.Lookup(name)
+		if defined == nil {
+			rest = append(rest, arg)
+			continue
+		}
+
+		known = append(known, arg)
+		if !value && !isBoolFlag(defined) && i+1 < len(args) {
+			i++
+			known = append(known, args[i])
+		}
+	}
+
+	return rest, f.set.Parse(known)
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface {
+		IsBoolFlag() bool
+	})
+	return ok && b.IsBoolFlag()
+}
+
+// Names returns the names of the flags defined in f in lexical order.
+func (f Flags) Names() []string {
+	names := []string{}
+	f.set.VisitAll(func(defined *flag.Flag) {
+		names = append(names, defined.Name)
+	})
+	return names
+}
@@ -12,12 +12,14 @@ var _ = Describe("Flags", func() {
 		f         flags.Flags
 		stringVal string
 		boolVal   bool
+		intVal    int
 	)
 
 	BeforeEach(func() {
 		f = flags.New("test")
 		f.String(&stringVal, "string", "")
 		f.Bool(&boolVal, "bool")
+		f.Int(&intVal, "int", 0)
 	})
 
 	Describe("Parse", func() {
@@ -32,6 +34,12 @@ var _ = Describe("Flags", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(boolVal).To(BeTrue())
 		})
+
+		It("can parse int flags", func() {
+			err := f.Parse([]string{"--int", "1080"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(intVal).To(Equal(1080))
+		})
 	})
 
 	Describe("Args", func() {
@@ -41,4 +49,33 @@ var _ = Describe("Flags", func() {
 			Expect(f.Args()).To(Equal([]string{"some-command", "--some-flag"}))
 		})
 	})
+
+	Describe("ParseKnown", func() {
+		It("parses the defined flags and returns the other args in order", func() {
+			rest, err := f.ParseKnown([]string{"some-command", "--name", "some-name", "--string", "string_value", "--bool", "--other=value", "--int=1080"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stringVal).To(Equal("string_value"))
+			Expect(boolVal).To(BeTrue())
+			Expect(intVal).To(Equal(1080))
+			Expect(rest).To(Equal([]string{"some-command", "--name", "some-name", "--other=value"}))
+		})
+
+		It("passes on everything after --", func() {
+			rest, err := f.ParseKnown([]string{"--", "--string", "string_value"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stringVal).To(BeEmpty())
+			Expect(rest).To(Equal([]string{"--", "--string", "string_value"}))
+		})
+
+		It("returns an error when a defined flag is missing its value", func() {
+			_, err := f.ParseKnown([]string{"--bool", "--string"})
+			Expect(err).To(MatchError("flag needs an argument: -string"))
+		})
+	})
+
+	Describe("Names", func() {
+		It("returns the names of the defined flags in order", func() {
+			Expect(f.Names()).To(Equal([]string{"bool", "int", "string"}))
+		})
+	})
 })
@@ -0,0 +1,182 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	user              = "jumpbox"
+	dialTimeout       = 30 * time.Second
+	keepAliveInterval = 5 * time.Minute
+)
+
+type Target struct {
+	JumpboxAddress  string
+	JumpboxKey      string
+	DirectorAddress string
+	DirectorKey     string
+}
+
+type hostKeyCallbacks interface {
+	Callback(deployment string) ssh.HostKeyCallback
+}
+
+type Client struct {
+	in       io.Reader
+	out      io.Writer
+	err      io.Writer
+	hostKeys hostKeyCallbacks
+}
+
+func NewClient(in io.Reader, out, err io.Writer, hostKeys hostKeyCallbacks) Client {
+	return Client{
+		in:       in,
+		out:      out,
+		err:      err,
+		hostKeys: hostKeys,
+	}
+}
+
+// Shell opens an interactive login shell on the target.
+func (c Client) Shell(target Target) error {
+	client, err := c.connect(target)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("Open session: %s", err)
+	}
+	defer session.Close()
+
+	session.Stdin = c.in
+	session.Stdout = c.out
+	session.Stderr = c.err
+
+	if f, ok := c.in.(*os.File); ok && isTerminal(f.Fd()) {
+		width, height := terminalSize(f.Fd())
+		err = session.RequestPty(terminalType(), height, width, ssh.TerminalModes{ssh.ECHO: 1})
+		if err != nil {
+			return fmt.Errorf("Request pty: %s", err)
+		}
+
+		restore, err := makeRaw(f.Fd())
+		if err != nil {
+			return fmt.Errorf("Set terminal mode: %s", err)
+		}
+		defer restore()
+	}
+
+	err = session.Shell()
+	if err != nil {
+		return fmt.Errorf("Start shell: %s", err)
+	}
+
+	return ignoreExitMissing(session.Wait())
+}
+
+// Run runs command on the target with the client's stdin, stdout and
+// stderr attached.
+func (c Client) Run(target Target, command string) error {
+	client, err := c.connect(target)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("Open session: %s", err)
+	}
+	defer session.Close()
+
+	session.Stdin = c.in
+	session.Stdout = c.out
+	session.Stderr = c.err
+
+	return ignoreExitMissing(session.Run(command))
+}
+
+func (c Client) connect(target Target) (*ssh.Client, error) {
+	jumpboxConfig, err := c.clientConfig("jumpbox", target.JumpboxKey)
+	if err != nil {
+		return nil, err
+	}
+
+	jumpbox, err := ssh.Dial("tcp", target.JumpboxAddress, jumpboxConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Connect to jumpbox: %s", err)
+	}
+	go keepAlive(jumpbox)
+
+	if target.DirectorAddress == "" {
+		return jumpbox, nil
+	}
+
+	directorConfig, err := c.clientConfig("director", target.DirectorKey)
+	if err != nil {
+		jumpbox.Close()
+		return nil, err
+	}
+
+	conn, err := jumpbox.Dial("tcp", target.DirectorAddress)
+	if err != nil {
+		jumpbox.Close()
+		return nil, fmt.Errorf("Connect to director through the jumpbox: %s", err)
+	}
+
+	directorConn, chans, reqs, err := ssh.NewClientConn(conn, target.DirectorAddress, directorConfig)
+	if err != nil {
+		conn.Close()
+		jumpbox.Close()
+		return nil, fmt.Errorf("Connect to director: %s", err)
+	}
+
+	director := ssh.NewClient(directorConn, chans, reqs)
+	go func() {
+		director.Wait()
+		jumpbox.Close()
+	}()
+
+	return director, nil
+}
+
+func (c Client) clientConfig(deployment, privateKey string) (*ssh.ClientConfig, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+	if err != nil {
+		return nil, fmt.Errorf("Parse %s private key: %s", deployment, err)
+	}
+
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: c.hostKeys.Callback(deployment),
+		Timeout:         dialTimeout,
+	}, nil
+}
+
+func keepAlive(client *ssh.Client) {
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func ignoreExitMissing(err error) error {
+	if _, ok := err.(*ssh.ExitMissingError); ok {
+		return nil
+	}
+	return err
+}
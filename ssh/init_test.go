@@ -0,0 +1,13 @@
+package ssh_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSSH(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ssh")
+}
@@ -0,0 +1,173 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"golang.org/x/crypto/ssh"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const KnownHostsFile = "known_hosts"
+
+type stateStore interface {
+	GetVarsDir() (string, error)
+}
+
+type knownHostsFS interface {
+	fileio.FileReader
+	fileio.FileWriter
+}
+
+// KnownHosts verifies the host key of the jumpbox and the director
+// against the <deployment>_host_key that bbl has bosh generate into their
+// vars stores. A VM deployed before bbl generated host keys falls back to
+// trust on first use until its next bbl up: the first key it presents is
+// pinned in vars/known_hosts to the VM CID in its create-env state, and any
+// other key for that CID is rejected.
+type KnownHosts struct {
+	stateStore stateStore
+	fs         knownHostsFS
+}
+
+func NewKnownHosts(stateStore stateStore, fs knownHostsFS) KnownHosts {
+	return KnownHosts{
+		stateStore: stateStore,
+		fs:         fs,
+	}
+}
+
+func (k KnownHosts) Callback(deployment string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return k.check(deployment, key)
+	}
+}
+
+func (k KnownHosts) check(deployment string, key ssh.PublicKey) error {
+	varsDir, err := k.stateStore.GetVarsDir()
+	if err != nil {
+		return fmt.Errorf("Get vars directory: %s", err)
+	}
+
+	hostKey, varsStore, err := k.deployedHostKey(varsDir, deployment)
+	if err != nil {
+		return err
+	}
+	if hostKey != nil {
+		if !bytes.Equal(hostKey.Marshal(), key.Marshal()) {
+			return fmt.Errorf("The %s host key does not match the %s_host_key in %s. The %s may have been replaced by another machine.", deployment, deployment, varsStore, deployment)
+		}
+		return nil
+	}
+
+	vmCID, err := k.vmCID(varsDir, deployment)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(varsDir, KnownHostsFile)
+	contents, err := k.fs.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Read known hosts: %s", err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(contents), "\n") {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 || fields[0] != deployment {
+			if line != "" {
+				lines = append(lines, line)
+			}
+			continue
+		}
+
+		if fields[1] != vmCID {
+			continue
+		}
+
+		pinned, _, _, _, err := ssh.ParseAuthorizedKey([]byte(fields[2]))
+		if err != nil {
+			return fmt.Errorf("Parse pinned %s host key: %s", deployment, err)
+		}
+
+		if !bytes.Equal(pinned.Marshal(), key.Marshal()) {
+			return fmt.Errorf("The %s host key does not match the key pinned in %s. The %s may have been replaced by another machine.", deployment, path, deployment)
+		}
+
+		return nil
+	}
+
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+	lines = append(lines, fmt.Sprintf("%s %s %s", deployment, vmCID, authorizedKey))
+
+	err = k.fs.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+	if err != nil {
+		return fmt.Errorf("Write known hosts: %s", err)
+	}
+
+	return nil
+}
+
+// deployedHostKey returns the host key in the vars store of the
+// deployment, or nil when the deployment does not have one yet.
+func (k KnownHosts) deployedHostKey(varsDir, deployment string) (ssh.PublicKey, string, error) {
+	path := filepath.Join(varsDir, fmt.Sprintf("%s-vars-store.yml", deployment))
+	contents, err := k.fs.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, path, nil
+	}
+	if err != nil {
+		return nil, path, fmt.Errorf("Read %s vars store: %s", deployment, err)
+	}
+
+	var vars map[string]interface{}
+	err = yaml.Unmarshal(contents, &vars)
+	if err != nil {
+		return nil, path, fmt.Errorf("Parse %s vars store: %s", deployment, err)
+	}
+
+	variable, _ := vars[fmt.Sprintf("%s_host_key", deployment)].(map[interface{}]interface{})
+	hostKey, _ := variable["public_key"].(string)
+	if hostKey == "" {
+		return nil, path, nil
+	}
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err != nil {
+		return nil, path, fmt.Errorf("Parse %s host key: %s", deployment, err)
+	}
+
+	return publicKey, path, nil
+}
+
+func (k KnownHosts) vmCID(varsDir, deployment string) (string, error) {
+	stateFile := "bosh-state.json"
+	if deployment == "jumpbox" {
+		stateFile = "jumpbox-state.json"
+	}
+
+	contents, err := k.fs.ReadFile(filepath.Join(varsDir, stateFile))
+	if err != nil {
+		return "", fmt.Errorf("Read %s state: %s", deployment, err)
+	}
+
+	var state struct {
+		CurrentVMCID string `json:"current_vm_cid"`
+	}
+	err = json.Unmarshal(contents, &state)
+	if err != nil {
+		return "", fmt.Errorf("Parse %s state: %s", deployment, err)
+	}
+
+	if state.CurrentVMCID == "" {
+		return "", fmt.Errorf("The %s state does not record a VM.", deployment)
+	}
+
+	return state.CurrentVMCID, nil
+}
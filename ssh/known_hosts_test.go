@@ -0,0 +1,184 @@
+package ssh_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/ssh"
+	gossh "golang.org/x/crypto/ssh"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KnownHosts", func() {
+	var (
+		stateStore *fakes.StateStore
+		fileIO     *fakes.FileIO
+		files      map[string]string
+		hostKey    gossh.PublicKey
+		otherKey   gossh.PublicKey
+		knownHosts ssh.KnownHosts
+	)
+
+	newKey := func() gossh.PublicKey {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		publicKey, err := gossh.NewPublicKey(&privateKey.PublicKey)
+		Expect(err).NotTo(HaveOccurred())
+		return publicKey
+	}
+
+	BeforeEach(func() {
+		stateStore = &fakes.StateStore{}
+		stateStore.GetVarsDirCall.Returns.Directory = "/some/vars"
+
+		files = map[string]string{
+			"/some/vars/jumpbox-state.json": `{"current_vm_cid": "vm-1"}`,
+		}
+		fileIO = &fakes.FileIO{}
+		fileIO.ReadFileCall.Fake = func(name string) ([]byte, error) {
+			contents, ok := files[name]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(contents), nil
+		}
+
+		hostKey = newKey()
+		otherKey = newKey()
+
+		knownHosts = ssh.NewKnownHosts(stateStore, fileIO)
+	})
+
+	pinned := func(cid string, key gossh.PublicKey) string {
+		return "jumpbox " + cid + " " + strings.TrimSpace(string(gossh.MarshalAuthorizedKey(key))) + "\n"
+	}
+
+	Context("when the vars store has the host key of the deployment", func() {
+		BeforeEach(func() {
+			authorizedKey := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(hostKey)))
+			files["/some/vars/jumpbox-vars-store.yml"] = "jumpbox_ssh:\n  public_key: some-user-key\njumpbox_host_key:\n  public_key: " + authorizedKey + "\nmbus_bootstrap_password: some-password\n"
+		})
+
+		It("accepts that host key without pinning it", func() {
+			err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, hostKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+		})
+
+		It("rejects any other host key, even on the first connection", func() {
+			err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, otherKey)
+			Expect(err).To(MatchError("The jumpbox host key does not match the jumpbox_host_key in /some/vars/jumpbox-vars-store.yml. The jumpbox may have been replaced by another machine."))
+
+			Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+		})
+
+		It("reads the director host key from the director vars store", func() {
+			authorizedKey := strings.TrimSpace(string(gossh.MarshalAuthorizedKey(otherKey)))
+			files["/some/vars/director-vars-store.yml"] = "director_host_key:\n  public_key: " + authorizedKey + "\n"
+
+			err := knownHosts.Callback("director")("10.0.0.6:22", nil, otherKey)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = knownHosts.Callback("director")("10.0.0.6:22", nil, hostKey)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error when the host key cannot be parsed", func() {
+			files["/some/vars/jumpbox-vars-store.yml"] = "jumpbox_host_key:\n  public_key: not-a-key\n"
+
+			err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, hostKey)
+			Expect(err).To(MatchError(ContainSubstring("Parse jumpbox host key: ")))
+		})
+
+		It("returns an error when the vars store cannot be parsed", func() {
+			files["/some/vars/jumpbox-vars-store.yml"] = "%%%"
+
+			err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, hostKey)
+			Expect(err).To(MatchError(ContainSubstring("Parse jumpbox vars store: ")))
+		})
+	})
+
+	It("pins the host key on the first connection when the vars store has no host key", func() {
+		files["/some/vars/jumpbox-vars-store.yml"] = "jumpbox_ssh:\n  public_key: some-user-key\n"
+
+		err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, hostKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fileIO.WriteFileCall.CallCount).To(Equal(1))
+		Expect(fileIO.WriteFileCall.Receives[0].Filename).To(Equal("/some/vars/known_hosts"))
+		Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(Equal(pinned("vm-1", hostKey)))
+	})
+
+	It("accepts the pinned host key", func() {
+		files["/some/vars/known_hosts"] = pinned("vm-1", hostKey)
+
+		err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, hostKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fileIO.WriteFileCall.CallCount).To(Equal(0))
+	})
+
+	It("rejects a different host key for the same VM", func() {
+		files["/some/vars/known_hosts"] = pinned("vm-1", hostKey)
+
+		err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, otherKey)
+		Expect(err).To(MatchError("The jumpbox host key does not match the key pinned in /some/vars/known_hosts. The jumpbox may have been replaced by another machine."))
+	})
+
+	It("pins the host key again after the VM was recreated", func() {
+		files["/some/vars/known_hosts"] = pinned("vm-0", hostKey) +
+			"director vm-2 " + strings.TrimSpace(string(gossh.MarshalAuthorizedKey(hostKey))) + "\n"
+
+		err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, otherKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents := string(fileIO.WriteFileCall.Receives[0].Contents)
+		Expect(contents).To(ContainSubstring(pinned("vm-1", otherKey)))
+		Expect(contents).To(ContainSubstring("director vm-2 "))
+		Expect(contents).NotTo(ContainSubstring("vm-0"))
+	})
+
+	It("reads the VM CID of the director from bosh-state.json", func() {
+		files["/some/vars/bosh-state.json"] = `{"current_vm_cid": "vm-2"}`
+
+		err := knownHosts.Callback("director")("10.0.0.6:22", nil, hostKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(string(fileIO.WriteFileCall.Receives[0].Contents)).To(HavePrefix("director vm-2 "))
+	})
+
+	Context("failure cases", func() {
+		It("returns an error when the deployment state cannot be read", func() {
+			err := knownHosts.Callback("director")("10.0.0.6:22", nil, hostKey)
+			Expect(err).To(MatchError("Read director state: file does not exist"))
+		})
+
+		It("returns an error when the deployment state has no VM", func() {
+			files["/some/vars/jumpbox-state.json"] = `{}`
+
+			err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, hostKey)
+			Expect(err).To(MatchError("The jumpbox state does not record a VM."))
+		})
+
+		It("returns an error when the vars directory cannot be found", func() {
+			stateStore.GetVarsDirCall.Returns.Error = errors.New("apple")
+
+			err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, hostKey)
+			Expect(err).To(MatchError("Get vars directory: apple"))
+		})
+
+		It("returns an error when the known hosts cannot be written", func() {
+			fileIO.WriteFileCall.Returns = []fakes.WriteFileReturn{{Error: errors.New("banana")}}
+
+			err := knownHosts.Callback("jumpbox")("10.0.0.5:22", nil, hostKey)
+			Expect(err).To(MatchError("Write known hosts: banana"))
+		})
+	})
+})
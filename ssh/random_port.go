@@ -1,21 +0,0 @@
-package ssh
-
-import "net"
-
-type RandomPort struct{}
-
-func (r RandomPort) GetPort() (string, error) {
-	l, err := net.Listen("tcp4", "127.0.0.1:0")
-	if err != nil {
-		return "", err
-	}
-
-	defer l.Close()
-
-	_, port, err := net.SplitHostPort(l.Addr().String())
-	if err != nil {
-		return "", err
-	}
-
-	return port, nil
-}
@@ -0,0 +1,204 @@
+package ssh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Upload copies a local file to remotePath on the target using the scp
+// protocol. remotePath may name a directory.
+func (c Client) Upload(target Target, localPath, remotePath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("Open %s: %s", localPath, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("Stat %s: %s", localPath, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory.", localPath)
+	}
+
+	client, err := c.connect(target)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("Open session: %s", err)
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("Open session: %s", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("Open session: %s", err)
+	}
+	remote := bufio.NewReader(stdout)
+
+	err = session.Start(fmt.Sprintf("scp -t %s", shellQuote(remotePath)))
+	if err != nil {
+		return fmt.Errorf("Start scp: %s", err)
+	}
+
+	err = readAck(remote)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdin, "C%04o %d %s\n", info.Mode().Perm(), info.Size(), filepath.Base(localPath))
+	err = readAck(remote)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(stdin, file)
+	if err != nil {
+		return fmt.Errorf("Copy %s: %s", localPath, err)
+	}
+	fmt.Fprint(stdin, "\x00")
+
+	err = readAck(remote)
+	if err != nil {
+		return err
+	}
+	stdin.Close()
+
+	return ignoreExitMissing(session.Wait())
+}
+
+// Download copies remotePath on the target to a local file using the scp
+// protocol. localPath may name an existing directory.
+func (c Client) Download(target Target, remotePath, localPath string) error {
+	client, err := c.connect(target)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("Open session: %s", err)
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("Open session: %s", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("Open session: %s", err)
+	}
+	remote := bufio.NewReader(stdout)
+
+	err = session.Start(fmt.Sprintf("scp -f %s", shellQuote(remotePath)))
+	if err != nil {
+		return fmt.Errorf("Start scp: %s", err)
+	}
+
+	fmt.Fprint(stdin, "\x00")
+
+	header, err := readMessage(remote)
+	if err != nil {
+		return err
+	}
+
+	mode, size, name, err := parseFileHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		localPath = filepath.Join(localPath, path.Base(name))
+	}
+
+	file, err := os.OpenFile(localPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("Create %s: %s", localPath, err)
+	}
+	defer file.Close()
+
+	fmt.Fprint(stdin, "\x00")
+
+	_, err = io.CopyN(file, remote, size)
+	if err != nil {
+		return fmt.Errorf("Copy %s: %s", remotePath, err)
+	}
+
+	err = readAck(remote)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(stdin, "\x00")
+	stdin.Close()
+
+	return ignoreExitMissing(session.Wait())
+}
+
+func readAck(r *bufio.Reader) error {
+	code, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("Read scp response: %s", err)
+	}
+	if code == 0 {
+		return nil
+	}
+
+	message, _ := r.ReadString('\n')
+	return errors.New(strings.TrimSpace(message))
+}
+
+func readMessage(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("Read scp response: %s", err)
+	}
+
+	switch line[0] {
+	case 1, 2:
+		return "", errors.New(strings.TrimSpace(line[1:]))
+	case 'D':
+		return "", errors.New("Copying directories is not supported.")
+	}
+
+	return strings.TrimSuffix(line, "\n"), nil
+}
+
+func parseFileHeader(header string) (os.FileMode, int64, string, error) {
+	fields := strings.SplitN(header, " ", 3)
+	if len(fields) != 3 || !strings.HasPrefix(fields[0], "C") {
+		return 0, 0, "", fmt.Errorf("Unexpected scp response %q.", header)
+	}
+
+	mode, err := strconv.ParseUint(fields[0][1:], 8, 32)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("Unexpected scp response %q.", header)
+	}
+
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("Unexpected scp response %q.", header)
+	}
+
+	return os.FileMode(mode), size, fields[2], nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
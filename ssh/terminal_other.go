@@ -0,0 +1,26 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package ssh
+
+import "errors"
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func terminalSize(fd uintptr) (int, int) {
+	return 80, 24
+}
+
+func terminalType() string {
+	return "xterm"
+}
+
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+func HideInput(fd uintptr) (func(), error) {
+	return nil, errors.New("hiding input is not supported on this platform")
+}
@@ -0,0 +1,78 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package ssh
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func isTerminal(fd uintptr) bool {
+	_, err := unix.IoctlGetTermios(int(fd), ioctlReadTermios)
+	return err == nil
+}
+
+func terminalSize(fd uintptr) (int, int) {
+	size, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil {
+		return 80, 24
+	}
+	return int(size.Col), int(size.Row)
+}
+
+func terminalType() string {
+	if term := os.Getenv("TERM"); term != "" {
+		return term
+	}
+	return "xterm"
+}
+
+// makeRaw puts the terminal in raw mode so that keystrokes are sent to the
+// remote shell as they are typed, and returns a function that restores it.
+func makeRaw(fd uintptr) (func(), error) {
+	termios, err := unix.IoctlGetTermios(int(fd), ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+	original := *termios
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+
+	err = unix.IoctlSetTermios(int(fd), ioctlWriteTermios, termios)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		unix.IoctlSetTermios(int(fd), ioctlWriteTermios, &original)
+	}, nil
+}
+
+// HideInput turns off echo on the terminal fd so that a secret typed at a
+// prompt is not shown, and returns a function that turns it back on.
+func HideInput(fd uintptr) (func(), error) {
+	termios, err := unix.IoctlGetTermios(int(fd), ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+	original := *termios
+
+	termios.Lflag &^= unix.ECHO
+	termios.Lflag |= unix.ICANON | unix.ISIG
+	err = unix.IoctlSetTermios(int(fd), ioctlWriteTermios, termios)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		unix.IoctlSetTermios(int(fd), ioctlWriteTermios, &original)
+	}, nil
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	awslib "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+const (
+	BackendObjectName  = "bbl-state.tgz"
+	LOCK_FILE          = "bbl.lock"
+	LOCK_TAKEOVER_FILE = "bbl.lock.takeover"
+
+	gcsEndpoint     = "https://storage.googleapis.com"
+	defaultS3Region = "us-east-1"
+)
+
+var ErrLockExists = errors.New("lock already exists")
+
+// Backend keeps a copy of the state directory. Download returns the names
+// of the files it wrote, nil when there is no remote state, and Prune
+// removes the local files that are not among them.
+type Backend interface {
+	Download(dir string) (map[string]bool, error)
+	Prune(dir string, downloaded map[string]bool) error
+	Upload(dir string) error
+	Delete() error
+
+	ReadLock(dir, name string) ([]byte, error)
+	WriteLock(dir, name string, contents []byte) error
+	DeleteLock(dir, name string) error
+}
+
+type backendFs interface {
+	archiveFs
+	fileio.FileOpener
+	fileio.Remover
+}
+
+type BackendConfig struct {
+	URL             string
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+// NewBackend returns the backend described by config.URL. Supported
+// schemes are s3://bucket/prefix and gcs://bucket/prefix. GCS buckets
+// are reached through their S3-compatible XML API using HMAC keys.
+// An empty URL keeps the state in the local state directory only. When
+// the cipher is enabled, sensitive files are encrypted before upload.
+func NewBackend(config BackendConfig, fs backendFs, cipher Cipher) (Backend, error) {
+	if config.URL == "" {
+		return NewLocalBackend(fs), nil
+	}
+
+	backendURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Parse state backend: %s", err)
+	}
+
+	if backendURL.Host == "" {
+		return nil, errors.New("State backend must include a bucket name, e.g. s3://my-bucket/my-env")
+	}
+
+	region := config.Region
+	endpoint := config.Endpoint
+
+	switch backendURL.Scheme {
+	case "s3":
+		if region == "" {
+			region = defaultS3Region
+		}
+	case "gcs", "gs":
+		if endpoint == "" {
+			endpoint = gcsEndpoint
+		}
+		if region == "" {
+			region = "auto"
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported state backend %q: must be one of [s3, gcs]", backendURL.Scheme)
+	}
+
+	awsConfig := &awslib.Config{
+		Region: awslib.String(region),
+	}
+	if config.AccessKeyID != "" || config.SecretAccessKey != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(config.AccessKeyID, config.SecretAccessKey, "")
+	}
+	if endpoint != "" {
+		awsConfig.Endpoint = awslib.String(endpoint)
+		awsConfig.S3ForcePathStyle = awslib.Bool(true)
+	}
+
+	prefix := strings.TrimPrefix(backendURL.Path, "/")
+
+	return NewS3Backend(s3.New(session.New(awsConfig)), backendURL.Host, prefix, fs, cipher), nil
+}
+
+type LocalBackend struct {
+	fs backendFs
+}
+
+func NewLocalBackend(fs backendFs) LocalBackend {
+	return LocalBackend{fs: fs}
+}
+
+func (LocalBackend) Download(dir string) (map[string]bool, error) {
+	return nil, nil
+}
+
+func (LocalBackend) Prune(dir string, downloaded map[string]bool) error {
+	return nil
+}
+
+func (LocalBackend) Upload(dir string) error {
+	return nil
+}
+
+func (LocalBackend) Delete() error {
+	return nil
+}
+
+func (l LocalBackend) ReadLock(dir, name string) ([]byte, error) {
+	contents, err := l.fs.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return contents, nil
+}
+
+func (l LocalBackend) WriteLock(dir, name string, contents []byte) error {
+	file, err := l.fs.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, StateMode)
+	if err != nil {
+		if os.IsExist(err) {
+			return ErrLockExists
+		}
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(contents)
+	return err
+}
+
+func (l LocalBackend) DeleteLock(dir, name string) error {
+	err := l.fs.Remove(filepath.Join(dir, name))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
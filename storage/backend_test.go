@@ -0,0 +1,232 @@
+package storage_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type s3StandIn struct {
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch r.Method {
+	case "GET":
+		object, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		w.Write(object)
+	case "PUT":
+		body, _ := ioutil.ReadAll(r.Body)
+		s.objects[r.URL.Path] = body
+	case "DELETE":
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func tarball(files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for name, contents := range files {
+		Expect(tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(contents))})).To(Succeed())
+		_, err := tarWriter.Write([]byte(contents))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(tarWriter.Close()).To(Succeed())
+	Expect(gzipWriter.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+var _ = Describe("Backend", func() {
+	var (
+		fs      *afero.Afero
+		standIn *s3StandIn
+		server  *httptest.Server
+		backend storage.Backend
+	)
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		standIn = &s3StandIn{objects: map[string][]byte{}}
+		server = httptest.NewServer(standIn)
+
+		var err error
+		backend, err = storage.NewBackend(storage.BackendConfig{
+			URL:             "s3://some-bucket/some/env",
+			Endpoint:        server.URL,
+			AccessKeyID:     "some-access-key-id",
+			SecretAccessKey: "some-secret-access-key",
+		}, fs, storage.Cipher{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("NewBackend", func() {
+		It("keeps the state local when no backend is configured", func() {
+			backend, err := storage.NewBackend(storage.BackendConfig{}, fs, storage.Cipher{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backend).To(BeAssignableToTypeOf(storage.LocalBackend{}))
+		})
+
+		It("accepts gcs buckets", func() {
+			_, err := storage.NewBackend(storage.BackendConfig{URL: "gcs://some-bucket"}, fs, storage.Cipher{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the scheme is not supported", func() {
+				_, err := storage.NewBackend(storage.BackendConfig{URL: "ftp://some-bucket"}, fs, storage.Cipher{})
+				Expect(err).To(MatchError(`Unsupported state backend "ftp": must be one of [s3, gcs]`))
+			})
+
+			It("returns an error when the bucket is missing", func() {
+				_, err := storage.NewBackend(storage.BackendConfig{URL: "s3://"}, fs, storage.Cipher{})
+				Expect(err).To(MatchError("State backend must include a bucket name, e.g. s3://my-bucket/my-env"))
+			})
+		})
+	})
+
+	Describe("Upload and Download", func() {
+		BeforeEach(func() {
+			Expect(fs.MkdirAll("/state/vars", os.ModePerm)).To(Succeed())
+			Expect(fs.MkdirAll("/state/terraform/.terraform", os.ModePerm)).To(Succeed())
+			Expect(fs.MkdirAll("/state/cloud-config", os.ModePerm)).To(Succeed())
+			Expect(fs.MkdirAll("/state/bosh-deployment", os.ModePerm)).To(Succeed())
+
+			Expect(fs.WriteFile("/state/bbl-state.json", []byte(`{"envID":"some-env"}`), 0644)).To(Succeed())
+			Expect(fs.WriteFile("/state/vars/director-vars-store.yml", []byte("admin_password: secret"), storage.StateMode)).To(Succeed())
+			Expect(fs.WriteFile("/state/vars/terraform.tfstate", []byte("some-tf-state"), storage.StateMode)).To(Succeed())
+			Expect(fs.WriteFile("/state/terraform/bbl-template.tf", []byte("some-template"), storage.StateMode)).To(Succeed())
+			Expect(fs.WriteFile("/state/terraform/.terraform/plugin", []byte("some-plugin"), storage.StateMode)).To(Succeed())
+			Expect(fs.WriteFile("/state/cloud-config/ops.yml", []byte("some-ops"), storage.StateMode)).To(Succeed())
+			Expect(fs.WriteFile("/state/bosh-deployment/bosh.yml", []byte("some-manifest"), storage.StateMode)).To(Succeed())
+		})
+
+		It("round-trips the state, vars, terraform and cloud-config through the bucket", func() {
+			err := backend.Upload("/state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(standIn.objects).To(HaveKey("/some-bucket/some/env/bbl-state.tgz"))
+
+			_, err = backend.Download("/other-state")
+			Expect(err).NotTo(HaveOccurred())
+
+			for path, contents := range map[string]string{
+				"bbl-state.json":               `{"envID":"some-env"}`,
+				"vars/director-vars-store.yml": "admin_password: secret",
+				"vars/terraform.tfstate":       "some-tf-state",
+				"terraform/bbl-template.tf":    "some-template",
+				"cloud-config/ops.yml":         "some-ops",
+			} {
+				actual, err := fs.ReadFile(filepath.Join("/other-state", path))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(actual)).To(Equal(contents))
+			}
+
+			Expect(fs.Exists("/other-state/terraform/.terraform/plugin")).To(BeFalse())
+			Expect(fs.Exists("/other-state/bosh-deployment/bosh.yml")).To(BeFalse())
+		})
+
+		It("removes the local files that are no longer in the bucket when pruned", func() {
+			Expect(backend.Upload("/state")).To(Succeed())
+
+			Expect(fs.MkdirAll("/other-state/vars", os.ModePerm)).To(Succeed())
+			Expect(fs.MkdirAll("/other-state/terraform/.terraform", os.ModePerm)).To(Succeed())
+			Expect(fs.WriteFile("/other-state/vars/jumpbox-vars-store.yml", []byte("stale"), storage.StateMode)).To(Succeed())
+			Expect(fs.WriteFile("/other-state/terraform/.terraform/plugin", []byte("some-plugin"), storage.StateMode)).To(Succeed())
+
+			downloaded, err := backend.Download("/other-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fs.Exists("/other-state/vars/jumpbox-vars-store.yml")).To(BeTrue())
+
+			err = backend.Prune("/other-state", downloaded)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fs.Exists("/other-state/vars/director-vars-store.yml")).To(BeTrue())
+			Expect(fs.Exists("/other-state/vars/jumpbox-vars-store.yml")).To(BeFalse())
+			Expect(fs.Exists("/other-state/terraform/.terraform/plugin")).To(BeTrue())
+		})
+
+		It("does nothing when the bucket has no state yet", func() {
+			downloaded, err := backend.Download("/other-state")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(downloaded).To(BeNil())
+			Expect(fs.Exists("/other-state/bbl-state.json")).To(BeFalse())
+		})
+
+		It("deletes the state from the bucket", func() {
+			Expect(backend.Upload("/state")).To(Succeed())
+
+			err := backend.Delete()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(standIn.objects).To(BeEmpty())
+		})
+
+		It("stores the lock next to the state and refuses a second writer", func() {
+			contents, err := backend.ReadLock("/state", storage.LOCK_FILE)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(BeNil())
+
+			Expect(backend.WriteLock("/state", storage.LOCK_FILE, []byte("some-lock"))).To(Succeed())
+			Expect(standIn.objects).To(HaveKeyWithValue("/some-bucket/some/env/bbl.lock", []byte("some-lock")))
+			Expect(backend.WriteLock("/state", storage.LOCK_FILE, []byte("other-lock"))).To(Equal(storage.ErrLockExists))
+
+			Expect(backend.DeleteLock("/state", storage.LOCK_FILE)).To(Succeed())
+			Expect(standIn.objects).NotTo(HaveKey("/some-bucket/some/env/bbl.lock"))
+		})
+
+		Context("when the archive is not a tarball", func() {
+			BeforeEach(func() {
+				standIn.objects["/some-bucket/some/env/bbl-state.tgz"] = []byte("not-a-tarball")
+			})
+
+			It("returns an error", func() {
+				_, err := backend.Download("/other-state")
+				Expect(err).To(MatchError(ContainSubstring("Read state archive")))
+			})
+		})
+
+		Context("when the archive contains paths outside of the state directory", func() {
+			It("returns an error without writing them", func() {
+				standIn.objects["/some-bucket/some/env/bbl-state.tgz"] = tarball(map[string]string{"vars/../../evil": "some-contents"})
+
+				_, err := backend.Download("/other-state/env")
+				Expect(err).To(MatchError("Unexpected file in state archive: vars/../../evil"))
+				Expect(fs.Exists("/evil")).To(BeFalse())
+				Expect(fs.Exists("/other-state/evil")).To(BeFalse())
+			})
+
+			It("returns an error for absolute paths", func() {
+				standIn.objects["/some-bucket/some/env/bbl-state.tgz"] = tarball(map[string]string{"/etc/evil": "some-contents"})
+
+				_, err := backend.Download("/other-state")
+				Expect(err).To(MatchError("Unexpected file in state archive: /etc/evil"))
+				Expect(fs.Exists("/etc/evil")).To(BeFalse())
+			})
+		})
+	})
+})
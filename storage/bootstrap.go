@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -16,12 +17,14 @@ type logger interface {
 type StateBootstrap struct {
 	logger     logger
 	bblVersion string
+	cipher     Cipher
 }
 
-func NewStateBootstrap(logger logger, bblVersion string) StateBootstrap {
+func NewStateBootstrap(logger logger, bblVersion string, cipher Cipher) StateBootstrap {
 	return StateBootstrap{
 		logger:     logger,
 		bblVersion: bblVersion,
+		cipher:     cipher,
 	}
 }
 
@@ -31,7 +34,7 @@ func (b StateBootstrap) GetState(dir string) (State, error) {
 		return State{}, err
 	}
 
-	file, err := os.Open(filepath.Join(dir, STATE_FILE))
+	contents, err := ioutil.ReadFile(filepath.Join(dir, STATE_FILE))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return State{}, nil
@@ -39,8 +42,13 @@ func (b StateBootstrap) GetState(dir string) (State, error) {
 		return State{}, err
 	}
 
+	contents, err = b.cipher.Decrypt(contents)
+	if err != nil {
+		return State{}, err
+	}
+
 	state := State{}
-	err = json.NewDecoder(file).Decode(&state)
+	err = json.Unmarshal(contents, &state)
 	if err != nil {
 		return state, err
 	}
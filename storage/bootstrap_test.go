@@ -24,7 +24,7 @@ var _ = Describe("StateBootstrap", func() {
 		BeforeEach(func() {
 			logger = &fakes.Logger{}
 			latestVersion = "latest"
-			bootstrap = storage.NewStateBootstrap(logger, latestVersion)
+			bootstrap = storage.NewStateBootstrap(logger, latestVersion, storage.Cipher{})
 
 			var err error
 			tempDir, err = ioutil.TempDir("", "")
@@ -48,6 +48,29 @@ var _ = Describe("StateBootstrap", func() {
 			})
 		})
 
+		Context("when the state file is encrypted", func() {
+			BeforeEach(func() {
+				contents, err := storage.NewCipher([]byte("some-passphrase")).Encrypt([]byte(`{"version": 14, "envID": "some-env-id"}`))
+				Expect(err).NotTo(HaveOccurred())
+
+				err = ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.json"), contents, storage.StateMode)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("decrypts it with the passphrase", func() {
+				bootstrap = storage.NewStateBootstrap(logger, latestVersion, storage.NewCipher([]byte("some-passphrase")))
+
+				state, err := bootstrap.GetState(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.EnvID).To(Equal("some-env-id"))
+			})
+
+			It("returns an error without the passphrase", func() {
+				_, err := bootstrap.GetState(tempDir)
+				Expect(err).To(Equal(storage.ErrNoPassphrase))
+			})
+		})
+
 		Context("when there is a pre v3 state file", func() {
 			BeforeEach(func() {
 				err := ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte(`{
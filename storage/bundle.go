@@ -0,0 +1,390 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+const (
+	BundleFormat        = "bbl-state-bundle"
+	BundleFormatVersion = 1
+
+	bundleManifestFile = "manifest.json"
+)
+
+type bundleFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.DirReader
+	fileio.Stater
+	fileio.AllMkdirer
+}
+
+type BundleManifest struct {
+	Format          string       `json:"format"`
+	FormatVersion   int          `json:"formatVersion"`
+	StateSchema     int          `json:"stateSchema"`
+	BBLVersion      string       `json:"bblVersion"`
+	IAAS            string       `json:"iaas"`
+	EnvID           string       `json:"envID"`
+	CreatedAt       time.Time    `json:"createdAt"`
+	SecretsStripped bool         `json:"secretsStripped"`
+	Encrypted       bool         `json:"encrypted"`
+	Files           []BundleFile `json:"files"`
+}
+
+type BundleFile struct {
+	Path   string `json:"path"`
+	Mode   int64  `json:"mode"`
+	SHA256 string `json:"sha256"`
+}
+
+// Bundler packs a state directory into a portable archive and unpacks it
+// somewhere else. Only bbl-state.json and the files directly inside vars,
+// terraform and cloud-config are bundled; scripts and deployment
+// directories embed paths of the exporting host and are regenerated on
+// import. When the state directory is encrypted, the files that hold
+// secrets stay encrypted in the bundle and can only be imported with the
+// same passphrase or key file.
+type Bundler struct {
+	fs     bundleFs
+	cipher Cipher
+}
+
+func NewBundler(fs bundleFs, cipher Cipher) Bundler {
+	return Bundler{
+		fs:     fs,
+		cipher: cipher,
+	}
+}
+
+func (b Bundler) Export(dir string, stripSecrets bool) ([]byte, error) {
+	stateContents, err := b.fs.ReadFile(filepath.Join(dir, STATE_FILE))
+	if err != nil {
+		return nil, fmt.Errorf("Read %s: %s", STATE_FILE, err)
+	}
+
+	var state State
+	err = json.Unmarshal(stateContents, &state)
+	if err != nil {
+		return nil, fmt.Errorf("Read %s: %s", STATE_FILE, err)
+	}
+
+	if stripSecrets {
+		stateContents, err = marshalIndent(withoutSecrets(state), "", "\t")
+		if err != nil {
+			return nil, err // not tested
+		}
+	}
+
+	stateContents, err = b.seal(STATE_FILE, stateContents)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := map[string][]byte{STATE_FILE: stateContents}
+	manifest := BundleManifest{
+		Format:          BundleFormat,
+		FormatVersion:   BundleFormatVersion,
+		StateSchema:     state.Version,
+		BBLVersion:      state.BBLVersion,
+		IAAS:            state.IAAS,
+		EnvID:           state.EnvID,
+		CreatedAt:       timeNow().UTC(),
+		SecretsStripped: stripSecrets,
+		Encrypted:       b.cipher.Enabled(),
+		Files:           []BundleFile{bundleFile(STATE_FILE, StateMode, stateContents)},
+	}
+
+	for _, subdir := range archivedDirs {
+		files, err := b.fs.ReadDir(filepath.Join(dir, subdir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("Read %s dir: %s", subdir, err)
+		}
+
+		for _, file := range files {
+			name := filepath.Join(subdir, file.Name())
+			if file.IsDir() || (stripSecrets && isSecret(name)) {
+				continue
+			}
+
+			fileContents, err := b.fs.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return nil, fmt.Errorf("Read %s: %s", name, err)
+			}
+
+			fileContents, err = b.seal(name, fileContents)
+			if err != nil {
+				return nil, err
+			}
+
+			contents[name] = fileContents
+			manifest.Files = append(manifest.Files, bundleFile(name, file.Mode().Perm(), fileContents))
+		}
+	}
+
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+	manifestContents, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = writeTarEntry(tarWriter, bundleManifestFile, 0644, manifestContents)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range manifest.Files {
+		err = writeTarEntry(tarWriter, file.Path, file.Mode, contents[filepath.FromSlash(file.Path)])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Import checks the bundle's format, checksums and state schema and then
+// writes its files into dir, which must not already contain a bbl
+// environment.
+func (b Bundler) Import(dir string, data []byte) (BundleManifest, State, error) {
+	manifest, contents, err := readBundle(data)
+	if err != nil {
+		return BundleManifest{}, State{}, err
+	}
+
+	if manifest.Encrypted && !b.cipher.Enabled() {
+		return BundleManifest{}, State{}, errors.New("The bundle is encrypted. Provide the --state-passphrase or --state-key-file of the exported state directory to import it.")
+	}
+
+	stateContents, err := b.cipher.Decrypt(contents[STATE_FILE])
+	if err != nil {
+		return BundleManifest{}, State{}, fmt.Errorf("%s: %s", STATE_FILE, err)
+	}
+
+	var state State
+	err = json.Unmarshal(stateContents, &state)
+	if err != nil {
+		return BundleManifest{}, State{}, fmt.Errorf("Invalid bundle: %s: %s", STATE_FILE, err)
+	}
+
+	if state.Version < 3 {
+		return BundleManifest{}, State{}, fmt.Errorf("Invalid bundle: state schema %d is incompatible with bbl v3 and later.", state.Version)
+	}
+
+	if state.Version > STATE_SCHEMA {
+		return BundleManifest{}, State{}, fmt.Errorf("Bundle was exported with state schema %d, which is newer than this bbl supports (%d). Please upgrade to bbl v%s.", state.Version, STATE_SCHEMA, state.BBLVersion)
+	}
+
+	_, err = b.fs.Stat(filepath.Join(dir, STATE_FILE))
+	if err == nil {
+		return BundleManifest{}, State{}, fmt.Errorf("%s already contains a bbl environment. Import into an empty state directory.", dir)
+	}
+
+	for _, file := range manifest.Files {
+		path := filepath.Join(dir, filepath.FromSlash(file.Path))
+
+		err = b.fs.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			return BundleManifest{}, State{}, fmt.Errorf("Create %s: %s", filepath.Dir(path), err)
+		}
+
+		err = b.fs.WriteFile(path, contents[filepath.FromSlash(file.Path)], os.FileMode(file.Mode).Perm())
+		if err != nil {
+			return BundleManifest{}, State{}, fmt.Errorf("Write %s: %s", file.Path, err)
+		}
+	}
+
+	return manifest, state, nil
+}
+
+func readBundle(data []byte) (BundleManifest, map[string][]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return BundleManifest{}, nil, fmt.Errorf("Invalid bundle: %s", err)
+	}
+
+	contents := map[string][]byte{}
+	var manifestContents []byte
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return BundleManifest{}, nil, fmt.Errorf("Invalid bundle: %s", err)
+		}
+
+		entry, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return BundleManifest{}, nil, fmt.Errorf("Invalid bundle: %s", err)
+		}
+
+		if header.Name == bundleManifestFile {
+			manifestContents = entry
+			continue
+		}
+
+		name := filepath.FromSlash(header.Name)
+		if !isArchivable(name) {
+			return BundleManifest{}, nil, fmt.Errorf("Invalid bundle: unexpected file %s", header.Name)
+		}
+		contents[name] = entry
+	}
+
+	if manifestContents == nil {
+		return BundleManifest{}, nil, errors.New("Invalid bundle: missing manifest.json")
+	}
+
+	var manifest BundleManifest
+	err = json.Unmarshal(manifestContents, &manifest)
+	if err != nil {
+		return BundleManifest{}, nil, fmt.Errorf("Invalid bundle: manifest.json: %s", err)
+	}
+
+	if manifest.Format != BundleFormat {
+		return BundleManifest{}, nil, errors.New("Invalid bundle: not a bbl state bundle")
+	}
+
+	if manifest.FormatVersion > BundleFormatVersion {
+		return BundleManifest{}, nil, fmt.Errorf("Bundle format version %d is newer than this bbl supports. Please upgrade to bbl v%s.", manifest.FormatVersion, manifest.BBLVersion)
+	}
+
+	listed := map[string]bool{}
+	for _, file := range manifest.Files {
+		name := filepath.FromSlash(file.Path)
+		entry, ok := contents[name]
+		if !ok {
+			return BundleManifest{}, nil, fmt.Errorf("Invalid bundle: %s is missing", file.Path)
+		}
+
+		if checksum(entry) != file.SHA256 {
+			return BundleManifest{}, nil, fmt.Errorf("Invalid bundle: checksum mismatch for %s", file.Path)
+		}
+		listed[name] = true
+	}
+
+	for name := range contents {
+		if !listed[name] {
+			return BundleManifest{}, nil, fmt.Errorf("Invalid bundle: %s is not listed in the manifest", filepath.ToSlash(name))
+		}
+	}
+
+	if !listed[STATE_FILE] {
+		return BundleManifest{}, nil, fmt.Errorf("Invalid bundle: %s is missing", STATE_FILE)
+	}
+
+	return manifest, contents, nil
+}
+
+// seal encrypts the files that hold secrets when the state directory is
+// encrypted, so that exporting it does not write them in plain text.
+func (b Bundler) seal(name string, contents []byte) ([]byte, error) {
+	if !b.cipher.Enabled() || !isSensitive(name) {
+		return contents, nil
+	}
+
+	sealed, err := b.cipher.Encrypt(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Encrypt %s: %s", name, err)
+	}
+	return sealed, nil
+}
+
+func writeTarEntry(tarWriter *tar.Writer, name string, mode int64, contents []byte) error {
+	err := tarWriter.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(len(contents)),
+		ModTime: timeNow(),
+	})
+	if err != nil {
+		return fmt.Errorf("Archive %s: %s", name, err) // not tested
+	}
+
+	_, err = tarWriter.Write(contents)
+	if err != nil {
+		return fmt.Errorf("Archive %s: %s", name, err) // not tested
+	}
+
+	return nil
+}
+
+func bundleFile(name string, mode os.FileMode, contents []byte) BundleFile {
+	return BundleFile{
+		Path:   filepath.ToSlash(name),
+		Mode:   int64(mode),
+		SHA256: checksum(contents),
+	}
+}
+
+func checksum(contents []byte) string {
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:])
+}
+
+// isSecret reports whether a bundled file holds credentials for the
+// environment rather than a description of it. The terraform state holds
+// the LB private key and the generated ssh keys in its outputs.
+func isSecret(name string) bool {
+	if name != STATE_FILE && isSensitive(name) {
+		return true
+	}
+
+	switch name {
+	case filepath.Join("vars", "bbl.tfvars"),
+		filepath.Join("vars", "terraform.tfstate"),
+		filepath.Join("vars", "terraform.tfstate.backup"):
+		return true
+	}
+
+	return false
+}
+
+func withoutSecrets(state State) State {
+	state.LB.Key = ""
+	state.BOSH.DirectorPassword = ""
+	state.BOSH.DirectorSSLPrivateKey = ""
+	state.BOSH.Variables = ""
+	state.BOSH.State = nil
+	state.Jumpbox.Variables = ""
+	state.Jumpbox.State = nil
+	state.TFOutputs = nil
+	state.TFOutputsChecksum = ""
+	state.LatestTFOutput = ""
+	return state
+}
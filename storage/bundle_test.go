@@ -0,0 +1,257 @@
+package storage_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bundler", func() {
+	var (
+		fs      *afero.Afero
+		bundler storage.Bundler
+		now     time.Time
+	)
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		bundler = storage.NewBundler(fs, storage.Cipher{})
+
+		now = time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC)
+		storage.SetTimeNow(func() time.Time { return now })
+
+		Expect(fs.MkdirAll("/state/vars", os.ModePerm)).To(Succeed())
+		Expect(fs.MkdirAll("/state/terraform/.terraform", os.ModePerm)).To(Succeed())
+		Expect(fs.MkdirAll("/state/bosh-deployment", os.ModePerm)).To(Succeed())
+
+		for path, contents := range map[string]string{
+			"/state/bbl-state.json":                `{"version": 14, "bblVersion": "6.6.0", "iaas": "gcp", "envID": "some-env", "lb": {"type": "cf", "cert": "some-cert", "key": "some-key"}, "tfOutputs": {"some_output": "some-output-value"}}`,
+			"/state/vars/director-vars-store.yml":  "admin_password: secret",
+			"/state/vars/director-vars-file.yml":   "internal_ip: 10.0.0.6",
+			"/state/vars/bbl.tfvars":               `ssl_certificate_private_key="some-key"`,
+			"/state/vars/terraform.tfstate":        `{"outputs": {"private_key": "some-private-key"}}`,
+			"/state/vars/terraform.tfstate.backup": `{"outputs": {"private_key": "some-private-key"}}`,
+			"/state/terraform/bbl-template.tf":     "some-template",
+			"/state/terraform/.terraform/plugin":   "some-plugin",
+			"/state/create-director.sh":            "/home/someone/bin/bosh create-env",
+			"/state/bosh-deployment/bosh.yml":      "some-manifest",
+		} {
+			Expect(fs.WriteFile(path, []byte(contents), storage.StateMode)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		storage.ResetTimeNow()
+	})
+
+	unpack := func(bundle []byte) map[string]string {
+		gzipReader, err := gzip.NewReader(bytes.NewReader(bundle))
+		Expect(err).NotTo(HaveOccurred())
+
+		entries := map[string]string{}
+		tarReader := tar.NewReader(gzipReader)
+		for {
+			header, err := tarReader.Next()
+			if err != nil {
+				break
+			}
+			contents, err := ioutil.ReadAll(tarReader)
+			Expect(err).NotTo(HaveOccurred())
+			entries[header.Name] = string(contents)
+		}
+		return entries
+	}
+
+	repack := func(entries map[string]string) []byte {
+		buf := &bytes.Buffer{}
+		gzipWriter := gzip.NewWriter(buf)
+		tarWriter := tar.NewWriter(gzipWriter)
+		for name, contents := range entries {
+			Expect(tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))})).To(Succeed())
+			_, err := tarWriter.Write([]byte(contents))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(tarWriter.Close()).To(Succeed())
+		Expect(gzipWriter.Close()).To(Succeed())
+		return buf.Bytes()
+	}
+
+	Describe("Export", func() {
+		It("bundles the state with a checksummed manifest and leaves out host-specific files", func() {
+			bundle, err := bundler.Export("/state", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			entries := unpack(bundle)
+			Expect(entries).To(HaveKey("manifest.json"))
+			Expect(entries).To(HaveKeyWithValue("vars/director-vars-store.yml", "admin_password: secret"))
+			Expect(entries).To(HaveKeyWithValue("terraform/bbl-template.tf", "some-template"))
+			Expect(entries).NotTo(HaveKey("create-director.sh"))
+			Expect(entries).NotTo(HaveKey("terraform/.terraform/plugin"))
+			Expect(entries).NotTo(HaveKey("bosh-deployment/bosh.yml"))
+
+			var manifest storage.BundleManifest
+			Expect(json.Unmarshal([]byte(entries["manifest.json"]), &manifest)).To(Succeed())
+			Expect(manifest.Format).To(Equal("bbl-state-bundle"))
+			Expect(manifest.FormatVersion).To(Equal(1))
+			Expect(manifest.StateSchema).To(Equal(14))
+			Expect(manifest.EnvID).To(Equal("some-env"))
+			Expect(manifest.IAAS).To(Equal("gcp"))
+			Expect(manifest.CreatedAt).To(Equal(now))
+			Expect(manifest.Files).To(HaveLen(7))
+			Expect(manifest.Files[0].Path).To(Equal("bbl-state.json"))
+			Expect(manifest.Files[0].SHA256).To(HaveLen(64))
+		})
+
+		It("strips secrets when asked to", func() {
+			bundle, err := bundler.Export("/state", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			entries := unpack(bundle)
+			Expect(entries).NotTo(HaveKey("vars/director-vars-store.yml"))
+			Expect(entries).NotTo(HaveKey("vars/bbl.tfvars"))
+			Expect(entries).NotTo(HaveKey("vars/terraform.tfstate"))
+			Expect(entries).NotTo(HaveKey("vars/terraform.tfstate.backup"))
+			Expect(entries).To(HaveKey("vars/director-vars-file.yml"))
+			Expect(entries["bbl-state.json"]).NotTo(ContainSubstring("some-key"))
+			Expect(entries["bbl-state.json"]).NotTo(ContainSubstring("some-output-value"))
+			Expect(entries["bbl-state.json"]).To(ContainSubstring("some-cert"))
+			Expect(entries["manifest.json"]).To(ContainSubstring(`"secretsStripped": true`))
+		})
+
+		Context("when the state directory is encrypted", func() {
+			var cipher storage.Cipher
+
+			BeforeEach(func() {
+				cipher = storage.NewCipher([]byte("some-passphrase"))
+				Expect(storage.NewEncryptor("/state", fs, cipher).Seal()).To(Succeed())
+
+				bundler = storage.NewBundler(storage.NewEncryptedFs(fs, cipher), cipher)
+			})
+
+			It("keeps the secrets encrypted in the bundle", func() {
+				bundle, err := bundler.Export("/state", false)
+				Expect(err).NotTo(HaveOccurred())
+
+				for name, contents := range unpack(bundle) {
+					for _, secret := range []string{"admin_password", "some-key", "some-private-key", "some-output-value"} {
+						Expect(contents).NotTo(ContainSubstring(secret), name)
+					}
+				}
+
+				entries := unpack(bundle)
+				Expect(storage.IsEncrypted([]byte(entries["bbl-state.json"]))).To(BeTrue())
+				Expect(storage.IsEncrypted([]byte(entries["vars/director-vars-store.yml"]))).To(BeTrue())
+				Expect(entries).To(HaveKeyWithValue("vars/director-vars-file.yml", "internal_ip: 10.0.0.6"))
+				Expect(entries["manifest.json"]).To(ContainSubstring(`"encrypted": true`))
+			})
+
+			It("imports the bundle with the same passphrase", func() {
+				bundle, err := bundler.Export("/state", false)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, state, err := bundler.Import("/other-state", bundle)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.LB.Key).To(Equal("some-key"))
+
+				contents, err := storage.NewEncryptedFs(fs, cipher).ReadFile("/other-state/vars/director-vars-store.yml")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(contents)).To(Equal("admin_password: secret"))
+			})
+
+			It("refuses to import the bundle without a passphrase", func() {
+				bundle, err := bundler.Export("/state", false)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = storage.NewBundler(fs, storage.Cipher{}).Import("/other-state", bundle)
+				Expect(err).To(MatchError("The bundle is encrypted. Provide the --state-passphrase or --state-key-file of the exported state directory to import it."))
+			})
+		})
+	})
+
+	Describe("Import", func() {
+		var bundle []byte
+
+		BeforeEach(func() {
+			var err error
+			bundle, err = bundler.Export("/state", false)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("writes the bundled files into the new state directory", func() {
+			manifest, state, err := bundler.Import("/other-state", bundle)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.EnvID).To(Equal("some-env"))
+			Expect(state.EnvID).To(Equal("some-env"))
+			Expect(state.LB.Key).To(Equal("some-key"))
+
+			contents, err := fs.ReadFile("/other-state/vars/director-vars-store.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("admin_password: secret"))
+			Expect(fs.Exists("/other-state/terraform/bbl-template.tf")).To(BeTrue())
+		})
+
+		Context("failure cases", func() {
+			It("refuses to overwrite an existing environment", func() {
+				_, _, err := bundler.Import("/state", bundle)
+				Expect(err).To(MatchError("/state already contains a bbl environment. Import into an empty state directory."))
+			})
+
+			It("rejects files that do not match their checksum", func() {
+				entries := unpack(bundle)
+				entries["vars/director-vars-store.yml"] = "admin_password: tampered"
+
+				_, _, err := bundler.Import("/other-state", repack(entries))
+				Expect(err).To(MatchError("Invalid bundle: checksum mismatch for vars/director-vars-store.yml"))
+				Expect(fs.Exists("/other-state/bbl-state.json")).To(BeFalse())
+			})
+
+			It("rejects files that are not in the manifest", func() {
+				entries := unpack(bundle)
+				entries["vars/extra.yml"] = "some-extra"
+
+				_, _, err := bundler.Import("/other-state", repack(entries))
+				Expect(err).To(MatchError("Invalid bundle: vars/extra.yml is not listed in the manifest"))
+			})
+
+			It("rejects paths outside the state directory", func() {
+				entries := unpack(bundle)
+				entries["../escape.sh"] = "some-script"
+
+				_, _, err := bundler.Import("/other-state", repack(entries))
+				Expect(err).To(MatchError("Invalid bundle: unexpected file ../escape.sh"))
+			})
+
+			It("rejects archives without a manifest", func() {
+				entries := unpack(bundle)
+				delete(entries, "manifest.json")
+
+				_, _, err := bundler.Import("/other-state", repack(entries))
+				Expect(err).To(MatchError("Invalid bundle: missing manifest.json"))
+			})
+
+			It("rejects state from a newer bbl", func() {
+				Expect(fs.WriteFile("/state/bbl-state.json", []byte(`{"version": 999, "bblVersion": "99.0.0"}`), storage.StateMode)).To(Succeed())
+				bundle, err := bundler.Export("/state", false)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = bundler.Import("/other-state", bundle)
+				Expect(err).To(MatchError(ContainSubstring("Please upgrade to bbl v99.0.0.")))
+			})
+
+			It("rejects files that are not bundles", func() {
+				_, _, err := bundler.Import("/other-state", []byte("not-a-bundle"))
+				Expect(err).To(MatchError(ContainSubstring("Invalid bundle")))
+			})
+		})
+	})
+})
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	encryptedHeader = "bbl-encrypted:v1:"
+
+	saltSize         = 16
+	keySize          = 32
+	keyDerivationIts = 100000
+)
+
+var (
+	randReader = rand.Reader
+
+	ErrNoPassphrase = errors.New("The state directory is encrypted. Provide --state-passphrase or --state-key-file to read it.")
+)
+
+type Cipher struct {
+	secret []byte
+}
+
+func NewCipher(secret []byte) Cipher {
+	return Cipher{secret: secret}
+}
+
+// LoadCipher builds the cipher for the state directory from either a
+// passphrase or the contents of a key file. Providing neither disables
+// encryption.
+func LoadCipher(passphrase, keyFile string, reader fileio.FileReader) (Cipher, error) {
+	if passphrase != "" && keyFile != "" {
+		return Cipher{}, errors.New("Only one of --state-passphrase and --state-key-file may be provided.")
+	}
+
+	if keyFile != "" {
+		key, err := reader.ReadFile(keyFile)
+		if err != nil {
+			return Cipher{}, fmt.Errorf("Read state key file: %s", err)
+		}
+
+		key = bytes.TrimSpace(key)
+		if len(key) == 0 {
+			return Cipher{}, fmt.Errorf("State key file %s is empty.", keyFile)
+		}
+
+		return NewCipher(key), nil
+	}
+
+	return NewCipher([]byte(passphrase)), nil
+}
+
+func (c Cipher) Enabled() bool {
+	return len(c.secret) > 0
+}
+
+func (c Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	if !c.Enabled() {
+		return nil, errors.New("Encryption requires --state-passphrase or --state-key-file.")
+	}
+
+	salt := make([]byte, saltSize)
+	_, err := io.ReadFull(randReader, salt)
+	if err != nil {
+		return nil, fmt.Errorf("Generate salt: %s", err)
+	}
+
+	gcm, err := c.gcm(salt)
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(randReader, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("Generate nonce: %s", err)
+	}
+
+	payload := append(salt, nonce...)
+	payload = gcm.Seal(payload, nonce, plaintext, []byte(encryptedHeader))
+
+	return []byte(encryptedHeader + base64.StdEncoding.EncodeToString(payload) + "\n"), nil
+}
+
+func (c Cipher) Decrypt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+
+	if !c.Enabled() {
+		return nil, ErrNoPassphrase
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(string(data), encryptedHeader)))
+	if err != nil {
+		return nil, fmt.Errorf("Decode encrypted file: %s", err)
+	}
+
+	if len(payload) < saltSize {
+		return nil, errors.New("Decode encrypted file: file is truncated")
+	}
+
+	gcm, err := c.gcm(payload[:saltSize])
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	payload = payload[saltSize:]
+	if len(payload) < gcm.NonceSize() {
+		return nil, errors.New("Decode encrypted file: file is truncated")
+	}
+
+	plaintext, err := gcm.Open(nil, payload[:gcm.NonceSize()], payload[gcm.NonceSize():], []byte(encryptedHeader))
+	if err != nil {
+		return nil, errors.New("Decrypt file: the passphrase or key file is incorrect")
+	}
+
+	return plaintext, nil
+}
+
+func (c Cipher) gcm(salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key(c.secret, salt, keyDerivationIts, keySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(encryptedHeader))
+}
@@ -0,0 +1,223 @@
+package storage_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cipher", func() {
+	var cipher storage.Cipher
+
+	BeforeEach(func() {
+		cipher = storage.NewCipher([]byte("some-passphrase"))
+	})
+
+	It("round-trips contents", func() {
+		ciphertext, err := cipher.Encrypt([]byte("admin_password: secret"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(ciphertext)).NotTo(ContainSubstring("secret"))
+		Expect(storage.IsEncrypted(ciphertext)).To(BeTrue())
+
+		plaintext, err := cipher.Decrypt(ciphertext)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(plaintext)).To(Equal("admin_password: secret"))
+	})
+
+	It("decrypts contents encrypted by earlier versions of bbl", func() {
+		plaintext, err := cipher.Decrypt([]byte("bbl-encrypted:v1:T7QzbAzJpL+RoIwKqeTYw0uXegpz1fezSfQOI+i7H9Fd31bWAOeEHg+WWQBQZ0v/1i/qXNoXK4fXpoJdd9x1SfSs"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(plaintext)).To(Equal("admin_password: secret"))
+	})
+
+	It("passes plain text through Decrypt", func() {
+		plaintext, err := storage.Cipher{}.Decrypt([]byte("admin_password: secret"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(plaintext)).To(Equal("admin_password: secret"))
+	})
+
+	Context("failure cases", func() {
+		It("refuses to decrypt with the wrong passphrase", func() {
+			ciphertext, err := cipher.Encrypt([]byte("some-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = storage.NewCipher([]byte("wrong")).Decrypt(ciphertext)
+			Expect(err).To(MatchError("Decrypt file: the passphrase or key file is incorrect"))
+		})
+
+		It("asks for a passphrase when none was provided", func() {
+			ciphertext, err := cipher.Encrypt([]byte("some-contents"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = storage.Cipher{}.Decrypt(ciphertext)
+			Expect(err).To(Equal(storage.ErrNoPassphrase))
+		})
+
+		It("refuses to encrypt without a passphrase", func() {
+			_, err := storage.Cipher{}.Encrypt([]byte("some-contents"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("LoadCipher", func() {
+		var fileIO *fakes.FileIO
+
+		BeforeEach(func() {
+			fileIO = &fakes.FileIO{}
+		})
+
+		It("is disabled when nothing is provided", func() {
+			cipher, err := storage.LoadCipher("", "", fileIO)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cipher.Enabled()).To(BeFalse())
+		})
+
+		It("reads the key from the key file", func() {
+			fileIO.ReadFileCall.Returns.Contents = []byte("some-key\n")
+
+			cipher, err := storage.LoadCipher("", "/some/key-file", fileIO)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileIO.ReadFileCall.Receives.Filename).To(Equal("/some/key-file"))
+
+			ciphertext, err := cipher.Encrypt([]byte("some-contents"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = storage.NewCipher([]byte("some-key")).Decrypt(ciphertext)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects both a passphrase and a key file", func() {
+			_, err := storage.LoadCipher("some-passphrase", "/some/key-file", fileIO)
+			Expect(err).To(MatchError("Only one of --state-passphrase and --state-key-file may be provided."))
+		})
+
+		It("returns an error when the key file cannot be read", func() {
+			fileIO.ReadFileCall.Returns.Error = errors.New("cherry")
+			_, err := storage.LoadCipher("", "/some/key-file", fileIO)
+			Expect(err).To(MatchError("Read state key file: cherry"))
+		})
+	})
+})
+
+var _ = Describe("Encryptor", func() {
+	var (
+		tempDir   string
+		osFs      *afero.Afero
+		cipher    storage.Cipher
+		encryptor storage.Encryptor
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(tempDir, "vars"), os.ModePerm)).To(Succeed())
+
+		for name, contents := range map[string]string{
+			"bbl-state.json":               `{"envID": "some-env"}`,
+			"vars/director-vars-store.yml": "admin_password: secret",
+			"vars/bosh-state.json":         "{}",
+			"vars/director-vars-file.yml":  "internal_ip: 10.0.0.6",
+			"vars/terraform.tfstate":       `{"outputs": {}}`,
+			"vars/bbl.tfvars":              `env_id="some-env"`,
+		} {
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, name), []byte(contents), storage.StateMode)).To(Succeed())
+		}
+
+		osFs = &afero.Afero{Fs: afero.NewOsFs()}
+		cipher = storage.NewCipher([]byte("some-passphrase"))
+		encryptor = storage.NewEncryptor(tempDir, osFs, cipher)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	readRaw := func(name string) []byte {
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, name))
+		Expect(err).NotTo(HaveOccurred())
+		return contents
+	}
+
+	It("encrypts the sensitive files and leaves the rest alone", func() {
+		Expect(encryptor.Seal()).To(Succeed())
+
+		Expect(storage.IsEncrypted(readRaw("bbl-state.json"))).To(BeTrue())
+		Expect(storage.IsEncrypted(readRaw("vars/director-vars-store.yml"))).To(BeTrue())
+		Expect(storage.IsEncrypted(readRaw("vars/bosh-state.json"))).To(BeTrue())
+		Expect(storage.IsEncrypted(readRaw("vars/terraform.tfstate"))).To(BeTrue())
+		Expect(storage.IsEncrypted(readRaw("vars/bbl.tfvars"))).To(BeTrue())
+		Expect(string(readRaw("vars/director-vars-file.yml"))).To(Equal("internal_ip: 10.0.0.6"))
+
+		info, err := os.Stat(filepath.Join(tempDir, "vars/director-vars-store.yml"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(storage.StateMode)))
+	})
+
+	It("decrypts what it encrypted", func() {
+		Expect(encryptor.Seal()).To(Succeed())
+		Expect(encryptor.Seal()).To(Succeed())
+		Expect(encryptor.Unseal()).To(Succeed())
+
+		Expect(string(readRaw("vars/director-vars-store.yml"))).To(Equal("admin_password: secret"))
+		Expect(string(readRaw("bbl-state.json"))).To(Equal(`{"envID": "some-env"}`))
+	})
+
+	It("decrypts only the vars directory for the bosh cli and terraform", func() {
+		Expect(encryptor.Seal()).To(Succeed())
+		Expect(encryptor.UnsealVars()).To(Succeed())
+
+		Expect(string(readRaw("vars/director-vars-store.yml"))).To(Equal("admin_password: secret"))
+		Expect(string(readRaw("vars/terraform.tfstate"))).To(Equal(`{"outputs": {}}`))
+		Expect(storage.IsEncrypted(readRaw("bbl-state.json"))).To(BeTrue())
+	})
+
+	It("does nothing when encryption is disabled", func() {
+		encryptor = storage.NewEncryptor(tempDir, osFs, storage.Cipher{})
+		Expect(encryptor.Seal()).To(Succeed())
+		Expect(string(readRaw("vars/director-vars-store.yml"))).To(Equal("admin_password: secret"))
+	})
+
+	It("cannot decrypt without the passphrase", func() {
+		Expect(encryptor.Seal()).To(Succeed())
+
+		encryptor = storage.NewEncryptor(tempDir, osFs, storage.Cipher{})
+		err := encryptor.Unseal()
+		Expect(err).To(MatchError(ContainSubstring(storage.ErrNoPassphrase.Error())))
+	})
+
+	Describe("EncryptedFs", func() {
+		It("reads encrypted files transparently and keeps them encrypted on write", func() {
+			Expect(encryptor.Seal()).To(Succeed())
+
+			fs := storage.NewEncryptedFs(osFs, cipher)
+			path := filepath.Join(tempDir, "vars", "director-vars-store.yml")
+
+			contents, err := fs.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("admin_password: secret"))
+
+			Expect(fs.WriteFile(path, []byte("admin_password: rotated"), storage.StateMode)).To(Succeed())
+			Expect(storage.IsEncrypted(readRaw("vars/director-vars-store.yml"))).To(BeTrue())
+
+			contents, err = fs.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(Equal("admin_password: rotated"))
+		})
+
+		It("writes plain text files as plain text", func() {
+			fs := storage.NewEncryptedFs(osFs, cipher)
+			path := filepath.Join(tempDir, "vars", "director-vars-store.yml")
+
+			Expect(fs.WriteFile(path, []byte("admin_password: rotated"), storage.StateMode)).To(Succeed())
+			Expect(string(readRaw("vars/director-vars-store.yml"))).To(Equal("admin_password: rotated"))
+		})
+	})
+})
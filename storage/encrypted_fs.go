@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// EncryptedFs reads encrypted files as plain text and keeps a file
+// encrypted when it is rewritten, so code that reads the vars store or
+// bbl-state.json does not need to know whether encryption is enabled.
+type EncryptedFs struct {
+	*afero.Afero
+	cipher Cipher
+}
+
+func NewEncryptedFs(fs *afero.Afero, cipher Cipher) EncryptedFs {
+	return EncryptedFs{
+		Afero:  fs,
+		cipher: cipher,
+	}
+}
+
+func (e EncryptedFs) ReadFile(filename string) ([]byte, error) {
+	contents, err := e.Afero.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := e.cipher.Decrypt(contents)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filepath.Base(filename), err)
+	}
+
+	return plaintext, nil
+}
+
+func (e EncryptedFs) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	existing, err := e.Afero.ReadFile(filename)
+	if err == nil && IsEncrypted(existing) {
+		data, err = e.cipher.Encrypt(data)
+		if err != nil {
+			return fmt.Errorf("%s: %s", filepath.Base(filename), err)
+		}
+	}
+
+	return e.Afero.WriteFile(filename, data, perm)
+}
+
+func isSensitive(relativePath string) bool {
+	if relativePath == STATE_FILE {
+		return true
+	}
+
+	dir, name := filepath.Split(relativePath)
+	if filepath.Clean(dir) != "vars" {
+		return false
+	}
+
+	return strings.HasSuffix(name, "-vars-store.yml") ||
+		name == "bosh-state.json" ||
+		name == "jumpbox-state.json" ||
+		name == "terraform.tfstate" ||
+		name == "terraform.tfstate.backup" ||
+		name == "bbl.tfvars"
+}
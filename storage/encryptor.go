@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+type encryptorFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.Stater
+	fileio.DirReader
+}
+
+type Encryptor struct {
+	dir    string
+	fs     encryptorFs
+	cipher Cipher
+}
+
+func NewEncryptor(dir string, fs encryptorFs, cipher Cipher) Encryptor {
+	return Encryptor{
+		dir:    dir,
+		fs:     fs,
+		cipher: cipher,
+	}
+}
+
+func (e Encryptor) Enabled() bool {
+	return e.cipher.Enabled()
+}
+
+// Seal encrypts bbl-state.json, the vars stores, the bosh create-env
+// state files and the terraform state and vars in place. It is a no-op
+// when encryption is not enabled.
+func (e Encryptor) Seal() error {
+	if !e.cipher.Enabled() {
+		return nil
+	}
+
+	return e.each(true, func(path string, contents []byte, mode os.FileMode) error {
+		if IsEncrypted(contents) {
+			return nil
+		}
+
+		ciphertext, err := e.cipher.Encrypt(contents)
+		if err != nil {
+			return err
+		}
+
+		return e.fs.WriteFile(path, ciphertext, mode)
+	})
+}
+
+// Unseal decrypts the sensitive files in place.
+func (e Encryptor) Unseal() error {
+	return e.each(true, e.decrypt)
+}
+
+// UnsealVars decrypts the sensitive files in the vars directory in place
+// so that the bosh cli and terraform can read them while a command is
+// running. bbl-state.json stays encrypted: only bbl reads it, and it
+// decrypts it in memory.
+func (e Encryptor) UnsealVars() error {
+	return e.each(false, e.decrypt)
+}
+
+func (e Encryptor) decrypt(path string, contents []byte, mode os.FileMode) error {
+	if !IsEncrypted(contents) {
+		return nil
+	}
+
+	plaintext, err := e.cipher.Decrypt(contents)
+	if err != nil {
+		return err
+	}
+
+	return e.fs.WriteFile(path, plaintext, mode)
+}
+
+func (e Encryptor) each(includeState bool, f func(path string, contents []byte, mode os.FileMode) error) error {
+	paths := []string{}
+	if includeState {
+		paths = append(paths, STATE_FILE)
+	}
+
+	files, err := e.fs.ReadDir(filepath.Join(e.dir, "vars"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Read vars dir: %s", err)
+	}
+	for _, file := range files {
+		path := filepath.Join("vars", file.Name())
+		if !file.IsDir() && isSensitive(path) {
+			paths = append(paths, path)
+		}
+	}
+
+	for _, path := range paths {
+		fullPath := filepath.Join(e.dir, path)
+
+		info, err := e.fs.Stat(fullPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("Stat %s: %s", path, err)
+		}
+
+		contents, err := e.fs.ReadFile(fullPath)
+		if err != nil {
+			return fmt.Errorf("Read %s: %s", path, err)
+		}
+
+		err = f(fullPath, contents, info.Mode().Perm())
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"encoding/json"
+	"os"
+	"time"
 
 	uuid "github.com/nu7hatch/gouuid"
 )
@@ -21,3 +23,35 @@ func SetUUIDNewV4(f func() (uuid *uuid.UUID, err error)) {
 func ResetUUIDNewV4() {
 	uuidNewV4 = uuid.NewV4
 }
+
+func SetGetpid(f func() int) {
+	getpid = f
+}
+
+func ResetGetpid() {
+	getpid = os.Getpid
+}
+
+func SetHostname(f func() (string, error)) {
+	hostname = f
+}
+
+func ResetHostname() {
+	hostname = os.Hostname
+}
+
+func SetTimeNow(f func() time.Time) {
+	timeNow = f
+}
+
+func ResetTimeNow() {
+	timeNow = time.Now
+}
+
+func SetProcessExists(f func(int) bool) {
+	processExists = f
+}
+
+func ResetProcessExists() {
+	processExists = defaultProcessExists
+}
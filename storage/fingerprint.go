@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+type fingerprintFS interface {
+	fileio.FileReader
+	fileio.DirReader
+	fileio.Stater
+}
+
+// Fingerprinter hashes files in the state directory so that bbl can tell
+// whether the inputs to a step have changed since it last ran.
+type Fingerprinter struct {
+	fs  fingerprintFS
+	dir string
+}
+
+func NewFingerprinter(fs fingerprintFS, dir string) Fingerprinter {
+	return Fingerprinter{
+		fs:  fs,
+		dir: dir,
+	}
+}
+
+// Fingerprint returns a sha256 of the paths, relative to the state
+// directory, and of everything below them. A path may end in a pattern
+// such as vars/*.tfvars. Missing paths are hashed as missing, and hidden
+// directories such as terraform/.terraform are skipped.
+func (f Fingerprinter) Fingerprint(paths ...string) (string, error) {
+	h := sha256.New()
+
+	for _, path := range paths {
+		matches, err := f.glob(path)
+		if err != nil {
+			return "", err
+		}
+
+		if len(matches) == 0 {
+			fmt.Fprintf(h, "%s\x00missing\x00", path)
+			continue
+		}
+
+		for _, match := range matches {
+			err = f.hash(h, match)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (f Fingerprinter) glob(path string) ([]string, error) {
+	dir, pattern := filepath.Split(path)
+	if !strings.Contains(pattern, "*") {
+		if _, err := f.fs.Stat(filepath.Join(f.dir, path)); os.IsNotExist(err) {
+			return nil, nil
+		}
+		return []string{path}, nil
+	}
+
+	files, err := f.fs.ReadDir(filepath.Join(f.dir, dir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Read %s: %s", dir, err)
+	}
+
+	var matches []string
+	for _, file := range files {
+		if ok, _ := filepath.Match(pattern, file.Name()); ok && !file.IsDir() {
+			matches = append(matches, filepath.Join(dir, file.Name()))
+		}
+	}
+	sort.Strings(matches)
+
+	return matches, nil
+}
+
+func (f Fingerprinter) hash(h hash.Hash, path string) error {
+	info, err := f.fs.Stat(filepath.Join(f.dir, path))
+	if err != nil {
+		return fmt.Errorf("Stat %s: %s", path, err)
+	}
+
+	if !info.IsDir() {
+		contents, err := f.fs.ReadFile(filepath.Join(f.dir, path))
+		if err != nil {
+			return fmt.Errorf("Read %s: %s", path, err)
+		}
+
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(path), len(contents))
+		h.Write(contents)
+		return nil
+	}
+
+	files, err := f.fs.ReadDir(filepath.Join(f.dir, path))
+	if err != nil {
+		return fmt.Errorf("Read %s: %s", path, err)
+	}
+
+	names := []string{}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), ".") {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = f.hash(h, filepath.Join(path, name))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package storage_test
+
+import (
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fingerprinter", func() {
+	var (
+		fs            *afero.Afero
+		fingerprinter storage.Fingerprinter
+	)
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		Expect(fs.WriteFile("/state/terraform/bbl-template.tf", []byte("template"), storage.StateMode)).To(Succeed())
+		Expect(fs.WriteFile("/state/terraform/.terraform/plugin", []byte("binary"), storage.StateMode)).To(Succeed())
+		Expect(fs.WriteFile("/state/vars/bbl.tfvars", []byte("env_id=\"some-env\""), storage.StateMode)).To(Succeed())
+		Expect(fs.WriteFile("/state/vars/bosh-state.json", []byte("{}"), storage.StateMode)).To(Succeed())
+
+		fingerprinter = storage.NewFingerprinter(fs, "/state")
+	})
+
+	fingerprint := func(paths ...string) string {
+		f, err := fingerprinter.Fingerprint(paths...)
+		Expect(err).NotTo(HaveOccurred())
+		return f
+	}
+
+	It("is stable while the files are unchanged", func() {
+		Expect(fingerprint("terraform", "vars/*.tfvars")).To(Equal(fingerprint("terraform", "vars/*.tfvars")))
+	})
+
+	It("changes when a file in a directory changes", func() {
+		before := fingerprint("terraform")
+		Expect(fs.WriteFile("/state/terraform/override.tf", []byte("override"), storage.StateMode)).To(Succeed())
+
+		Expect(fingerprint("terraform")).NotTo(Equal(before))
+	})
+
+	It("changes when a file matching a pattern changes", func() {
+		before := fingerprint("vars/*.tfvars")
+		Expect(fs.WriteFile("/state/vars/bbl.tfvars", []byte("env_id=\"other-env\""), storage.StateMode)).To(Succeed())
+
+		Expect(fingerprint("vars/*.tfvars")).NotTo(Equal(before))
+	})
+
+	It("ignores hidden directories and files that do not match the pattern", func() {
+		before := fingerprint("terraform", "vars/*.tfvars")
+		Expect(fs.WriteFile("/state/terraform/.terraform/plugin", []byte("other-binary"), storage.StateMode)).To(Succeed())
+		Expect(fs.WriteFile("/state/vars/bosh-state.json", []byte(`{"current_vm_cid": "vm-1"}`), storage.StateMode)).To(Succeed())
+
+		Expect(fingerprint("terraform", "vars/*.tfvars")).To(Equal(before))
+	})
+
+	It("tells a missing file from an empty one", func() {
+		before := fingerprint("create-director-override.sh")
+		Expect(fs.WriteFile("/state/create-director-override.sh", []byte(""), storage.StateMode)).To(Succeed())
+
+		Expect(fingerprint("create-director-override.sh")).NotTo(Equal(before))
+	})
+})
@@ -9,12 +9,14 @@ import (
 var bblManaged = map[string]struct{}{
 	"bbl.tfvars":               struct{}{},
 	"bosh-state.json":          struct{}{},
+	"cloud-config-applied.yml": struct{}{},
 	"cloud-config-vars.yml":    struct{}{},
 	"director-vars-file.yml":   struct{}{},
 	"director-vars-store.yml":  struct{}{},
 	"jumpbox-state.json":       struct{}{},
 	"jumpbox-vars-file.yml":    struct{}{},
 	"jumpbox-vars-store.yml":   struct{}{},
+	"known_hosts":              struct{}{},
 	"terraform.tfstate":        struct{}{},
 	"terraform.tfstate.backup": struct{}{},
 }
@@ -29,6 +31,9 @@ func NewGarbageCollector(fs fs) GarbageCollector {
 	}
 }
 
+// Remove deletes the files bbl manages in dir once the environment is
+// destroyed. The snapshots are kept so that the destroyed environment can
+// be rolled back to.
 func (g GarbageCollector) Remove(dir string) error {
 	bblStateJson := filepath.Join(dir, STATE_FILE)
 	err := g.fs.Remove(bblStateJson)
@@ -64,6 +69,7 @@ func (g GarbageCollector) Remove(dir string) error {
 	g.fs.Remove(filepath.Join(dir, "create-director.sh"))
 	g.fs.Remove(filepath.Join(dir, "delete-jumpbox.sh"))
 	g.fs.Remove(filepath.Join(dir, "delete-director.sh"))
+	g.fs.Remove(filepath.Join(dir, OPERATION_FILE))
 
 	return nil
 }
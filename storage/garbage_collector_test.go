@@ -46,6 +46,13 @@ var _ = Describe("garbage collector", func() {
 			Expect(fileIO.RemoveCall.Receives).To(ContainElement(fakes.RemoveReceive{Name: createJumpbox}))
 		})
 
+		It("removes the record of the last operation", func() {
+			err := gc.Remove("some-dir")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.RemoveCall.Receives).To(ContainElement(fakes.RemoveReceive{Name: filepath.Join("some-dir", "bbl-operation.json")}))
+		})
+
 		DescribeTable("removing bbl-created directories",
 			func(directory string, expectToBeDeleted bool) {
 				err := gc.Remove("some-dir")
@@ -65,6 +72,7 @@ var _ = Describe("garbage collector", func() {
 			Entry("bosh-deployment", "bosh-deployment", true),
 			Entry("jumpbox-deployment", "jumpbox-deployment", true),
 			Entry("bbl-ops-files", "bbl-ops-files", true),
+			Entry(".bbl-snapshots", ".bbl-snapshots", false),
 			Entry("non-bbl directory", "foo", false),
 		)
 
@@ -96,12 +104,14 @@ var _ = Describe("garbage collector", func() {
 					fileIO.ReadDirCall.Returns.FileInfos = []os.FileInfo{
 						fakes.FileInfo{FileName: "bbl.tfvars"},
 						fakes.FileInfo{FileName: "bosh-state.json"},
+						fakes.FileInfo{FileName: "cloud-config-applied.yml"},
 						fakes.FileInfo{FileName: "cloud-config-vars.yml"},
 						fakes.FileInfo{FileName: "director-vars-file.yml"},
 						fakes.FileInfo{FileName: "director-vars-store.yml"},
 						fakes.FileInfo{FileName: "jumpbox-state.json"},
 						fakes.FileInfo{FileName: "jumpbox-vars-file.yml"},
 						fakes.FileInfo{FileName: "jumpbox-vars-store.yml"},
+						fakes.FileInfo{FileName: "known_hosts"},
 						fakes.FileInfo{FileName: "terraform.tfstate"},
 						fakes.FileInfo{FileName: "terraform.tfstate.backup"},
 					}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+)
+
+const LockStaleAfter = 12 * time.Hour
+
+var (
+	getpid        = os.Getpid
+	hostname      = os.Hostname
+	timeNow       = time.Now
+	processExists = defaultProcessExists
+)
+
+type Lock struct {
+	Holder    string    `json:"holder"`
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Command   string    `json:"command"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (l Lock) Empty() bool {
+	return l == Lock{}
+}
+
+// Stale reports whether the process that took the lock is gone. A lock
+// taken on this host is stale as soon as its process has exited; a lock
+// taken elsewhere can only be judged by its age.
+func (l Lock) Stale() bool {
+	if l.Empty() {
+		return false
+	}
+
+	if host, err := hostname(); err == nil && host == l.Host && !processExists(l.PID) {
+		return true
+	}
+
+	return timeNow().Sub(l.Timestamp) > LockStaleAfter
+}
+
+func (l Lock) String() string {
+	return fmt.Sprintf("%s (pid %d on %s) has been running `bbl %s` since %s", l.Holder, l.PID, l.Host, l.Command, l.Timestamp.Format(time.RFC3339))
+}
+
+type LockedError struct {
+	Lock Lock
+}
+
+func (e LockedError) Error() string {
+	return fmt.Sprintf("The state directory is locked: %s.\nIf that run is no longer active, run `bbl unlock --force`.", e.Lock)
+}
+
+func (s Store) GetLock() (Lock, error) {
+	contents, err := s.backend.ReadLock(s.dir, LOCK_FILE)
+	if err != nil {
+		return Lock{}, fmt.Errorf("Read lock: %s", err)
+	}
+
+	if contents == nil {
+		return Lock{}, nil
+	}
+
+	var lock Lock
+	err = json.Unmarshal(contents, &lock)
+	if err != nil {
+		return Lock{}, fmt.Errorf("Read lock: %s", err)
+	}
+
+	return lock, nil
+}
+
+func (s Store) CheckLock() error {
+	lock, err := s.GetLock()
+	if err != nil {
+		return err
+	}
+
+	if lock.Empty() || lock.Stale() || s.ownsLock(lock) {
+		return nil
+	}
+
+	return LockedError{Lock: lock}
+}
+
+func (s Store) Lock(command string) error {
+	lock, err := s.GetLock()
+	if err != nil {
+		return err
+	}
+
+	if !lock.Empty() {
+		if !lock.Stale() {
+			return LockedError{Lock: lock}
+		}
+	}
+
+	host, err := hostname()
+	if err != nil {
+		return fmt.Errorf("Get hostname: %s", err) // not tested
+	}
+
+	contents, err := json.Marshal(Lock{
+		Holder:    currentUser(),
+		PID:       getpid(),
+		Host:      host,
+		Command:   command,
+		Timestamp: timeNow().UTC(),
+	})
+	if err != nil {
+		return err // not tested
+	}
+
+	if !lock.Empty() {
+		err = s.takeOver(lock, contents)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.backend.WriteLock(s.dir, LOCK_FILE, contents)
+	if err == ErrLockExists {
+		lock, err = s.GetLock()
+		if err != nil {
+			return err
+		}
+		return LockedError{Lock: lock}
+	}
+	if err != nil {
+		return fmt.Errorf("Write lock: %s", err)
+	}
+
+	return nil
+}
+
+// takeOver removes a stale lock. Only the run that creates the takeover
+// file may remove it, and only if the lock is still the stale one, so two
+// runs taking over the same stale lock cannot both remove it and both
+// write their own.
+func (s Store) takeOver(stale Lock, contents []byte) error {
+	err := s.backend.WriteLock(s.dir, LOCK_TAKEOVER_FILE, contents)
+	if err == ErrLockExists {
+		return LockedError{Lock: stale}
+	}
+	if err != nil {
+		return fmt.Errorf("Remove stale lock: %s", err)
+	}
+
+	current, err := s.GetLock()
+	if err == nil && current == stale {
+		err = s.backend.DeleteLock(s.dir, LOCK_FILE)
+	}
+
+	releaseErr := s.backend.DeleteLock(s.dir, LOCK_TAKEOVER_FILE)
+	if err == nil {
+		err = releaseErr
+	}
+	if err != nil {
+		return fmt.Errorf("Remove stale lock: %s", err)
+	}
+
+	return nil
+}
+
+func (s Store) Unlock() error {
+	lock, err := s.GetLock()
+	if err != nil {
+		return err
+	}
+
+	if !s.ownsLock(lock) {
+		return nil
+	}
+
+	return s.ForceUnlock()
+}
+
+func (s Store) ForceUnlock() error {
+	for _, name := range []string{LOCK_FILE, LOCK_TAKEOVER_FILE} {
+		err := s.backend.DeleteLock(s.dir, name)
+		if err != nil {
+			return fmt.Errorf("Remove lock: %s", err)
+		}
+	}
+	return nil
+}
+
+func (s Store) ownsLock(lock Lock) bool {
+	host, err := hostname()
+	if err != nil {
+		return false
+	}
+	return lock.PID == getpid() && lock.Host == host
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,263 @@
+package storage_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lock", func() {
+	var (
+		tempDir string
+		store   storage.Store
+		now     time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		fs := &afero.Afero{Fs: afero.NewOsFs()}
+		store = storage.NewStore(tempDir, fs, &fakes.GarbageCollector{}, storage.NewLocalBackend(fs), &fakes.Snapshots{})
+
+		now = time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC)
+		storage.SetTimeNow(func() time.Time { return now })
+		storage.SetGetpid(func() int { return 1234 })
+		storage.SetHostname(func() (string, error) { return "some-host", nil })
+		storage.SetProcessExists(func(int) bool { return true })
+	})
+
+	AfterEach(func() {
+		storage.ResetTimeNow()
+		storage.ResetGetpid()
+		storage.ResetHostname()
+		storage.ResetProcessExists()
+		os.RemoveAll(tempDir)
+	})
+
+	writeLock := func(lock storage.Lock) {
+		contents, err := json.Marshal(lock)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(tempDir, "bbl.lock"), contents, 0644)).To(Succeed())
+	}
+
+	otherHolder := storage.Lock{
+		Holder:  "someone-else",
+		PID:     5678,
+		Host:    "other-host",
+		Command: "destroy",
+	}
+
+	Describe("Lock", func() {
+		It("writes who is holding the lock", func() {
+			err := store.Lock("up")
+			Expect(err).NotTo(HaveOccurred())
+
+			lock, err := store.GetLock()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lock.PID).To(Equal(1234))
+			Expect(lock.Host).To(Equal("some-host"))
+			Expect(lock.Command).To(Equal("up"))
+			Expect(lock.Timestamp).To(Equal(now))
+			Expect(lock.Holder).NotTo(BeEmpty())
+		})
+
+		Context("when another run holds the lock", func() {
+			BeforeEach(func() {
+				otherHolder.Timestamp = now.Add(-time.Hour)
+				writeLock(otherHolder)
+			})
+
+			It("returns an error naming the holder", func() {
+				err := store.Lock("up")
+				Expect(err).To(BeAssignableToTypeOf(storage.LockedError{}))
+				Expect(err.Error()).To(ContainSubstring("someone-else (pid 5678 on other-host) has been running `bbl destroy`"))
+				Expect(err.Error()).To(ContainSubstring("bbl unlock --force"))
+			})
+		})
+
+		Context("when the lock is older than the stale threshold", func() {
+			BeforeEach(func() {
+				otherHolder.Timestamp = now.Add(-storage.LockStaleAfter - time.Minute)
+				writeLock(otherHolder)
+			})
+
+			It("takes over the lock", func() {
+				err := store.Lock("up")
+				Expect(err).NotTo(HaveOccurred())
+
+				lock, err := store.GetLock()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lock.PID).To(Equal(1234))
+				Expect(filepath.Join(tempDir, "bbl.lock.takeover")).NotTo(BeAnExistingFile())
+			})
+
+			Context("when another run is taking over the lock", func() {
+				BeforeEach(func() {
+					Expect(ioutil.WriteFile(filepath.Join(tempDir, "bbl.lock.takeover"), []byte("{}"), 0644)).To(Succeed())
+				})
+
+				It("leaves the lock alone and returns an error", func() {
+					err := store.Lock("up")
+					Expect(err).To(BeAssignableToTypeOf(storage.LockedError{}))
+
+					lock, err := store.GetLock()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lock.PID).To(Equal(5678))
+				})
+			})
+		})
+
+		Context("when the process holding a lock on this host has exited", func() {
+			BeforeEach(func() {
+				writeLock(storage.Lock{Holder: "me", PID: 42, Host: "some-host", Command: "plan", Timestamp: now})
+				storage.SetProcessExists(func(pid int) bool { return pid != 42 })
+			})
+
+			It("takes over the lock", func() {
+				Expect(store.CheckLock()).To(Succeed())
+				Expect(store.Lock("up")).To(Succeed())
+			})
+		})
+
+		Context("when the lock file is corrupt", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(filepath.Join(tempDir, "bbl.lock"), []byte("%%%"), 0644)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				err := store.Lock("up")
+				Expect(err).To(MatchError(ContainSubstring("Read lock")))
+			})
+		})
+	})
+
+	Describe("CheckLock", func() {
+		It("succeeds when nobody holds the lock", func() {
+			Expect(store.CheckLock()).To(Succeed())
+		})
+
+		It("succeeds when this process holds the lock", func() {
+			Expect(store.Lock("up")).To(Succeed())
+			Expect(store.CheckLock()).To(Succeed())
+		})
+
+		It("fails when another run holds the lock", func() {
+			otherHolder.Timestamp = now
+			writeLock(otherHolder)
+			Expect(store.CheckLock()).To(BeAssignableToTypeOf(storage.LockedError{}))
+		})
+	})
+
+	Describe("Unlock", func() {
+		It("removes a lock held by this process", func() {
+			Expect(store.Lock("up")).To(Succeed())
+			Expect(store.Unlock()).To(Succeed())
+
+			_, err := os.Stat(filepath.Join(tempDir, "bbl.lock"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("leaves a lock held by another run alone", func() {
+			otherHolder.Timestamp = now
+			writeLock(otherHolder)
+
+			Expect(store.Unlock()).To(Succeed())
+			Expect(filepath.Join(tempDir, "bbl.lock")).To(BeAnExistingFile())
+		})
+	})
+
+	Describe("ForceUnlock", func() {
+		It("removes any lock", func() {
+			otherHolder.Timestamp = now
+			writeLock(otherHolder)
+
+			Expect(store.ForceUnlock()).To(Succeed())
+			Expect(filepath.Join(tempDir, "bbl.lock")).NotTo(BeAnExistingFile())
+		})
+
+		It("removes a takeover left behind by a run that was killed", func() {
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "bbl.lock.takeover"), []byte("{}"), 0644)).To(Succeed())
+
+			Expect(store.ForceUnlock()).To(Succeed())
+			Expect(filepath.Join(tempDir, "bbl.lock.takeover")).NotTo(BeAnExistingFile())
+		})
+
+		Context("when the backend fails to remove the lock", func() {
+			It("returns an error", func() {
+				backend := &fakes.StateBackend{}
+				backend.DeleteLockCall.Returns.Error = errors.New("grape")
+				store = storage.NewStore(tempDir, &fakes.FileIO{}, &fakes.GarbageCollector{}, backend, &fakes.Snapshots{})
+
+				Expect(store.ForceUnlock()).To(MatchError("Remove lock: grape"))
+			})
+		})
+	})
+
+	Describe("RecordOperation", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "bbl-state.json"), []byte("{}"), os.ModePerm)).To(Succeed())
+		})
+
+		It("records the command, its outcome and when it ran", func() {
+			Expect(store.Lock("up")).To(Succeed())
+			now = now.Add(time.Minute)
+
+			Expect(store.RecordOperation("up", false)).To(Succeed())
+
+			fs := &afero.Afero{Fs: afero.NewOsFs()}
+			operation, err := storage.ReadOperation(fs, tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(operation).To(Equal(storage.Operation{
+				Command:    "up",
+				Succeeded:  false,
+				StartedAt:  time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC),
+				FinishedAt: time.Date(2018, time.March, 1, 12, 1, 0, 0, time.UTC),
+			}))
+		})
+
+		It("records nothing once the environment is destroyed", func() {
+			Expect(os.Remove(filepath.Join(tempDir, "bbl-state.json"))).To(Succeed())
+
+			Expect(store.RecordOperation("destroy", true)).To(Succeed())
+			Expect(filepath.Join(tempDir, "bbl-operation.json")).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Describe("ReadOperation", func() {
+		It("returns an empty operation when none was recorded", func() {
+			operation, err := storage.ReadOperation(&afero.Afero{Fs: afero.NewOsFs()}, tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(operation.Empty()).To(BeTrue())
+		})
+
+		It("returns an error when the record cannot be parsed", func() {
+			Expect(ioutil.WriteFile(filepath.Join(tempDir, "bbl-operation.json"), []byte("%%%"), os.ModePerm)).To(Succeed())
+
+			_, err := storage.ReadOperation(&afero.Afero{Fs: afero.NewOsFs()}, tempDir)
+			Expect(err).To(MatchError(ContainSubstring("Read bbl-operation.json:")))
+		})
+	})
+
+	Describe("when another run takes the lock first", func() {
+		It("returns an error", func() {
+			backend := &fakes.StateBackend{}
+			backend.WriteLockCall.Returns.Error = storage.ErrLockExists
+			store = storage.NewStore(tempDir, &fakes.FileIO{}, &fakes.GarbageCollector{}, backend, &fakes.Snapshots{})
+
+			err := store.Lock("up")
+			Expect(err).To(BeAssignableToTypeOf(storage.LockedError{}))
+		})
+	})
+})
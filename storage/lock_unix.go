@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package storage
+
+import "syscall"
+
+func defaultProcessExists(pid int) bool {
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,13 @@
+package storage
+
+import "os"
+
+// os.FindProcess fails on windows when the process does not exist.
+func defaultProcessExists(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+	return true
+}
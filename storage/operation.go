@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+const OPERATION_FILE = "bbl-operation.json"
+
+// Operation is the outcome of the last command that held the lock on a
+// state directory.
+type Operation struct {
+	Command    string    `json:"command"`
+	Succeeded  bool      `json:"succeeded"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+func (o Operation) Empty() bool {
+	return o == Operation{}
+}
+
+// RecordOperation saves the outcome of the command holding the lock. It
+// does nothing once the environment is destroyed.
+func (s Store) RecordOperation(command string, succeeded bool) error {
+	_, err := s.fs.Stat(filepath.Join(s.dir, STATE_FILE))
+	if err != nil {
+		return nil
+	}
+
+	lock, err := s.GetLock()
+	if err != nil {
+		return err
+	}
+
+	contents, err := json.Marshal(Operation{
+		Command:    command,
+		Succeeded:  succeeded,
+		StartedAt:  lock.Timestamp,
+		FinishedAt: timeNow().UTC(),
+	})
+	if err != nil {
+		return err // not tested
+	}
+
+	return s.fs.WriteFile(filepath.Join(s.dir, OPERATION_FILE), contents, StateMode)
+}
+
+// ReadOperation returns the last operation recorded in dir, or an empty
+// Operation when there is none.
+func ReadOperation(reader fileio.FileReader, dir string) (Operation, error) {
+	contents, err := reader.ReadFile(filepath.Join(dir, OPERATION_FILE))
+	if os.IsNotExist(err) {
+		return Operation{}, nil
+	}
+	if err != nil {
+		return Operation{}, err
+	}
+
+	var operation Operation
+	err = json.Unmarshal(contents, &operation)
+	if err != nil {
+		return Operation{}, fmt.Errorf("Read %s: %s", OPERATION_FILE, err)
+	}
+
+	return operation, nil
+}
@@ -3,4 +3,5 @@ package storage
 const (
 	StateMode  = 0740
 	ScriptMode = 0750
+	ConfigMode = 0600
 )
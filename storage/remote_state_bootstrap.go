@@ -0,0 +1,54 @@
+package storage
+
+import "fmt"
+
+type stateBootstrap interface {
+	GetState(dir string) (State, error)
+}
+
+// RemoteStateBootstrap downloads the remote state once per run, the first
+// time a state directory is read, and leaves the local files the remote
+// state no longer has in place until Prune is called.
+type RemoteStateBootstrap struct {
+	bootstrap  stateBootstrap
+	backend    Backend
+	downloaded map[string]map[string]bool
+}
+
+func NewRemoteStateBootstrap(bootstrap stateBootstrap, backend Backend) RemoteStateBootstrap {
+	return RemoteStateBootstrap{
+		bootstrap:  bootstrap,
+		backend:    backend,
+		downloaded: map[string]map[string]bool{},
+	}
+}
+
+func (r RemoteStateBootstrap) GetState(dir string) (State, error) {
+	if _, ok := r.downloaded[dir]; !ok {
+		files, err := r.backend.Download(dir)
+		if err != nil {
+			return State{}, fmt.Errorf("Download state: %s", err)
+		}
+		r.downloaded[dir] = files
+	}
+
+	return r.bootstrap.GetState(dir)
+}
+
+// Prune removes the files that were deleted from the remote state since
+// the state directories were last written. Only a command holding the
+// state lock may call it, so that no other run is still using them.
+func (r RemoteStateBootstrap) Prune() error {
+	for dir, files := range r.downloaded {
+		if files == nil {
+			continue
+		}
+
+		err := r.backend.Prune(dir, files)
+		if err != nil {
+			return fmt.Errorf("Prune state: %s", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package storage_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RemoteStateBootstrap", func() {
+	var (
+		stateBootstrap *fakes.StateBootstrap
+		stateBackend   *fakes.StateBackend
+		bootstrap      storage.RemoteStateBootstrap
+	)
+
+	BeforeEach(func() {
+		stateBootstrap = &fakes.StateBootstrap{}
+		stateBackend = &fakes.StateBackend{}
+		bootstrap = storage.NewRemoteStateBootstrap(stateBootstrap, stateBackend)
+
+		stateBootstrap.GetStateCall.Returns.State = storage.State{EnvID: "some-env-id"}
+	})
+
+	It("downloads the remote state before reading it", func() {
+		state, err := bootstrap.GetState("some-state-dir")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.EnvID).To(Equal("some-env-id"))
+
+		Expect(stateBackend.DownloadCall.Receives.Dir).To(Equal("some-state-dir"))
+		Expect(stateBootstrap.GetStateCall.Receives.Dir).To(Equal("some-state-dir"))
+	})
+
+	It("downloads the remote state only once", func() {
+		_, err := bootstrap.GetState("some-state-dir")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = bootstrap.GetState("some-state-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(stateBackend.DownloadCall.CallCount).To(Equal(1))
+		Expect(stateBootstrap.GetStateCall.CallCount).To(Equal(2))
+	})
+
+	It("does not prune while reading the state", func() {
+		_, err := bootstrap.GetState("some-state-dir")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(stateBackend.PruneCall.CallCount).To(Equal(0))
+	})
+
+	Describe("Prune", func() {
+		It("prunes the downloaded state directory to the downloaded files", func() {
+			stateBackend.DownloadCall.Returns.Files = map[string]bool{"bbl-state.json": true}
+			_, err := bootstrap.GetState("some-state-dir")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = bootstrap.Prune()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateBackend.DownloadCall.CallCount).To(Equal(1))
+			Expect(stateBackend.PruneCall.Receives.Dir).To(Equal("some-state-dir"))
+			Expect(stateBackend.PruneCall.Receives.Downloaded).To(Equal(map[string]bool{"bbl-state.json": true}))
+		})
+
+		It("does nothing when there was no remote state", func() {
+			_, err := bootstrap.GetState("some-state-dir")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = bootstrap.Prune()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stateBackend.PruneCall.CallCount).To(Equal(0))
+		})
+
+		It("returns an error when pruning fails", func() {
+			stateBackend.DownloadCall.Returns.Files = map[string]bool{"bbl-state.json": true}
+			stateBackend.PruneCall.Returns.Error = errors.New("lime")
+			_, err := bootstrap.GetState("some-state-dir")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = bootstrap.Prune()
+			Expect(err).To(MatchError("Prune state: lime"))
+		})
+	})
+
+	Context("when the download fails", func() {
+		BeforeEach(func() {
+			stateBackend.DownloadCall.Returns.Error = errors.New("papaya")
+		})
+
+		It("returns an error", func() {
+			_, err := bootstrap.GetState("some-state-dir")
+			Expect(err).To(MatchError("Download state: papaya"))
+			Expect(stateBootstrap.GetStateCall.CallCount).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,536 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	LOGS_DIR     = ".bbl-logs"
+	RunLogsLimit = 50
+
+	LogStart  = "start"
+	LogStep   = "step"
+	LogOutput = "output"
+	LogEnd    = "end"
+
+	runLogSuffix = ".jsonl"
+	redactedLog  = "REDACTED"
+)
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+	privateKeyBlock = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`)
+	secretField     = regexp.MustCompile(`(?i)([\w-]*(?:password|secret|private[_-]?key|token)[\w-]*["']?\s*[:=]\s*["']?)[^\s"',{}\[\](][^\s"',}\]]*`)
+)
+
+type runLogFs interface {
+	fileio.FileOpener
+	fileio.FileReader
+	fileio.DirReader
+	fileio.AllMkdirer
+	fileio.Remover
+}
+
+// LogEntry is a line of a run log. A run log starts with a start entry,
+// ends with an end entry, and has a step entry for each bbl step and an
+// output entry for each line that bbl, terraform or bosh printed.
+type LogEntry struct {
+	Time       time.Time `json:"time"`
+	Type       string    `json:"type"`
+	Source     string    `json:"source,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Command    string    `json:"command,omitempty"`
+	Args       []string  `json:"args,omitempty"`
+	BBLVersion string    `json:"bblVersion,omitempty"`
+	ExitCode   *int      `json:"exitCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Steps      []RunStep `json:"steps,omitempty"`
+}
+
+type RunStep struct {
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  float64   `json:"durationSeconds"`
+}
+
+// Run summarizes a run log. Finished is false when bbl was killed before
+// the run ended.
+type Run struct {
+	ID         string    `json:"id"`
+	Command    string    `json:"command"`
+	Args       []string  `json:"args"`
+	BBLVersion string    `json:"bblVersion"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Finished   bool      `json:"finished"`
+	ExitCode   int       `json:"exitCode"`
+	Error      string    `json:"error,omitempty"`
+	Steps      []RunStep `json:"steps"`
+}
+
+func (r Run) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// RunLog writes a log file in the state directory for each run of a
+// command that changes the environment. The writers it hands out can be
+// given to the loggers and commands before a run starts; what they receive
+// outside of a run is dropped.
+type RunLog struct {
+	dir     string
+	fs      runLogFs
+	version string
+	limit   int
+
+	mutex   sync.Mutex
+	file    io.WriteCloser
+	writers []*runLogWriter
+	secrets []string
+	steps   []RunStep
+	err     error
+}
+
+func NewRunLog(dir string, fs runLogFs, version string) *RunLog {
+	return &RunLog{
+		dir:     dir,
+		fs:      fs,
+		version: version,
+		limit:   RunLogsLimit,
+	}
+}
+
+// Writer returns a writer that logs each line it receives as output from
+// source. Lines printed by bbl that start with "step: " are logged as
+// steps, which the end entry times.
+func (r *RunLog) Writer(source string) io.Writer {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	writer := &runLogWriter{log: r, source: source}
+	r.writers = append(r.writers, writer)
+	return writer
+}
+
+// Start opens a new run log and removes the oldest ones beyond the limit.
+// The secrets in state and in the vars stores are redacted from everything
+// that is logged.
+func (r *RunLog) Start(command string, args []string, state State) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	varsStores, err := r.varsStores()
+	if err != nil {
+		return err
+	}
+
+	logsDir := filepath.Join(r.dir, LOGS_DIR)
+	err = r.fs.MkdirAll(logsDir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("Create %s: %s", LOGS_DIR, err)
+	}
+
+	startedAt := timeNow().UTC()
+	id := fmt.Sprintf("%s-%s", startedAt.Format("20060102T150405Z"), strings.Replace(command, " ", "-", -1))
+	for i := 2; ; i++ {
+		r.file, err = r.fs.OpenFile(filepath.Join(logsDir, id+runLogSuffix), os.O_WRONLY|os.O_CREATE|os.O_EXCL, StateMode)
+		if !os.IsExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%s-%d", startedAt.Format("20060102T150405Z"), strings.Replace(command, " ", "-", -1), i)
+	}
+	if err != nil {
+		return fmt.Errorf("Open run log: %s", err)
+	}
+
+	r.secrets = stateSecrets(state, varsStores)
+	r.steps = nil
+	r.err = nil
+	for _, writer := range r.writers {
+		writer.reset()
+	}
+
+	redactedArgs := []string{}
+	for _, arg := range args {
+		redactedArgs = append(redactedArgs, r.redact(arg))
+	}
+	r.write(LogEntry{Time: startedAt, Type: LogStart, Command: command, Args: redactedArgs, BBLVersion: r.version})
+
+	return r.prune(logsDir)
+}
+
+// Finish writes the end entry with the exit code and the duration of each
+// step, and closes the run log.
+func (r *RunLog) Finish(exitCode int, runErr error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+
+	for _, writer := range r.writers {
+		writer.flush()
+	}
+
+	now := timeNow().UTC()
+	r.endStep(now)
+
+	end := LogEntry{Time: now, Type: LogEnd, ExitCode: &exitCode, Steps: r.steps}
+	if runErr != nil {
+		end.Error = r.redact(runErr.Error())
+	}
+	r.write(end)
+
+	err := r.file.Close()
+	if err != nil && r.err == nil {
+		r.err = err
+	}
+	r.file = nil
+
+	if r.err != nil {
+		return fmt.Errorf("Write run log: %s", r.err)
+	}
+	return nil
+}
+
+// Runs returns the logged runs, newest first.
+func (r *RunLog) Runs() ([]Run, error) {
+	ids, err := r.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []Run{}
+	for i := len(ids) - 1; i >= 0; i-- {
+		run, _, err := r.Run(ids[i])
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt.After(runs[j].StartedAt) })
+	return runs, nil
+}
+
+// Run returns a logged run and its entries.
+func (r *RunLog) Run(id string) (Run, []LogEntry, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return Run{}, nil, fmt.Errorf("Run log %q does not exist.", id)
+	}
+
+	contents, err := r.fs.ReadFile(filepath.Join(r.dir, LOGS_DIR, id+runLogSuffix))
+	if os.IsNotExist(err) {
+		return Run{}, nil, fmt.Errorf("Run log %q does not exist.", id)
+	}
+	if err != nil {
+		return Run{}, nil, fmt.Errorf("Read run log %s: %s", id, err)
+	}
+
+	run := Run{ID: id, Args: []string{}, Steps: []RunStep{}}
+	entries := []LogEntry{}
+	for _, line := range bytes.Split(contents, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var entry LogEntry
+		err = json.Unmarshal(line, &entry)
+		if err != nil {
+			return Run{}, nil, fmt.Errorf("Read run log %s: %s", id, err)
+		}
+
+		switch entry.Type {
+		case LogStart:
+			run.Command, run.BBLVersion, run.StartedAt = entry.Command, entry.BBLVersion, entry.Time
+			if entry.Args != nil {
+				run.Args = entry.Args
+			}
+		case LogEnd:
+			run.Finished, run.FinishedAt, run.Error = true, entry.Time, entry.Error
+			if entry.ExitCode != nil {
+				run.ExitCode = *entry.ExitCode
+			}
+			if entry.Steps != nil {
+				run.Steps = entry.Steps
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	return run, entries, nil
+}
+
+// ids returns the IDs of the run logs, oldest first. IDs start with the
+// time the run started, so they sort in the order the runs started.
+func (r *RunLog) ids() ([]string, error) {
+	files, err := r.fs.ReadDir(filepath.Join(r.dir, LOGS_DIR))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Read %s: %s", LOGS_DIR, err)
+	}
+
+	ids := []string{}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), runLogSuffix) {
+			ids = append(ids, strings.TrimSuffix(file.Name(), runLogSuffix))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// varsStores returns the contents of the vars stores in the vars
+// directory. The fs bbl passes decrypts them when the state is encrypted.
+func (r *RunLog) varsStores() ([]string, error) {
+	varsDir := filepath.Join(r.dir, "vars")
+	files, err := r.fs.ReadDir(varsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Read vars directory: %s", err)
+	}
+
+	stores := []string{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), "-vars-store.yml") {
+			continue
+		}
+
+		contents, err := r.fs.ReadFile(filepath.Join(varsDir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("Read %s: %s", file.Name(), err)
+		}
+		stores = append(stores, string(contents))
+	}
+	return stores, nil
+}
+
+func (r *RunLog) prune(logsDir string) error {
+	ids, err := r.ids()
+	if err != nil {
+		return err
+	}
+
+	for len(ids) > r.limit {
+		err = r.fs.Remove(filepath.Join(logsDir, ids[0]+runLogSuffix))
+		if err != nil {
+			return fmt.Errorf("Remove run log %s: %s", ids[0], err)
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
+
+func (r *RunLog) write(entry LogEntry) {
+	if r.err != nil {
+		return
+	}
+
+	contents, err := json.Marshal(entry)
+	if err != nil {
+		r.err = err // not tested
+		return
+	}
+
+	_, r.err = r.file.Write(append(contents, '\n'))
+}
+
+func (r *RunLog) line(source, line string) {
+	now := timeNow().UTC()
+
+	if source == "bbl" && strings.HasPrefix(line, "step: ") {
+		name := strings.TrimPrefix(line, "step: ")
+		r.endStep(now)
+		r.steps = append(r.steps, RunStep{Name: name, StartedAt: now})
+		r.write(LogEntry{Time: now, Type: LogStep, Source: source, Message: name})
+		return
+	}
+
+	r.write(LogEntry{Time: now, Type: LogOutput, Source: source, Message: line})
+}
+
+func (r *RunLog) endStep(now time.Time) {
+	if len(r.steps) == 0 {
+		return
+	}
+
+	last := &r.steps[len(r.steps)-1]
+	last.Duration = now.Sub(last.StartedAt).Seconds()
+}
+
+func (r *RunLog) redact(line string) string {
+	for _, secret := range r.secrets {
+		line = strings.Replace(line, secret, redactedLog, -1)
+	}
+	line = privateKeyBlock.ReplaceAllString(line, redactedLog)
+	return secretField.ReplaceAllString(line, "${1}"+redactedLog)
+}
+
+type runLogWriter struct {
+	log          *RunLog
+	source       string
+	buffer       []byte
+	inPrivateKey bool
+}
+
+// Write never fails, so that a run log that cannot be written does not
+// stop the output it copies.
+func (w *runLogWriter) Write(p []byte) (int, error) {
+	w.log.mutex.Lock()
+	defer w.log.mutex.Unlock()
+
+	if w.log.file == nil {
+		return len(p), nil
+	}
+
+	w.buffer = append(w.buffer, p...)
+	for {
+		i := bytes.IndexByte(w.buffer, '\n')
+		if i < 0 {
+			break
+		}
+		w.line(string(w.buffer[:i]))
+		w.buffer = w.buffer[i+1:]
+	}
+
+	return len(p), nil
+}
+
+func (w *runLogWriter) line(line string) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// The logger prints a dot per poll while it waits.
+	if line != "" && strings.Trim(line, "•") == "" {
+		return
+	}
+
+	if w.inPrivateKey {
+		end := privateKeyEnd.FindStringIndex(line)
+		if end == nil {
+			return
+		}
+		w.inPrivateKey = false
+		line = line[end[1]:]
+		if strings.TrimSpace(line) == "" {
+			return
+		}
+	}
+
+	if begin := privateKeyBegin.FindStringIndex(line); begin != nil && !privateKeyEnd.MatchString(line[begin[1]:]) {
+		w.inPrivateKey = true
+		line = line[:begin[0]] + redactedLog
+	}
+
+	w.log.line(w.source, w.log.redact(line))
+}
+
+func (w *runLogWriter) flush() {
+	if len(w.buffer) > 0 {
+		w.line(string(w.buffer))
+	}
+	w.reset()
+}
+
+func (w *runLogWriter) reset() {
+	w.buffer = nil
+	w.inPrivateKey = false
+}
+
+// stateSecrets returns the credentials in state and the secrets in the
+// vars stores, longest first so that a secret that contains another is
+// redacted whole.
+func stateSecrets(state State, varsStores []string) []string {
+	values := []string{
+		state.AWS.SecretAccessKey,
+		state.Azure.ClientSecret,
+		state.GCP.ServiceAccountKey,
+		state.VSphere.VCenterPassword,
+		state.OpenStack.Password,
+		state.OpenStack.PrivateKey,
+		state.BOSH.DirectorPassword,
+		state.BOSH.DirectorSSLPrivateKey,
+		state.LB.Key,
+	}
+	for _, varsStore := range varsStores {
+		values = append(values, varsStoreSecrets(varsStore)...)
+	}
+
+	seen := map[string]bool{}
+	secrets := []string{}
+	for _, value := range values {
+		for _, secret := range secretLines(value) {
+			if !seen[secret] {
+				seen[secret] = true
+				secrets = append(secrets, secret)
+			}
+		}
+	}
+
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	return secrets
+}
+
+// secretLines splits a secret that spans lines, such as a service account
+// key, into the lines that are long enough to redact on their own.
+func secretLines(value string) []string {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "\n") {
+		if len(value) < 8 {
+			return nil
+		}
+		return []string{value}
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) >= 16 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// varsStoreSecrets returns the passwords and private keys in a vars store.
+// Certificates and public keys are left out: they are not secret.
+func varsStoreSecrets(variables string) []string {
+	var store map[string]interface{}
+	err := yaml.Unmarshal([]byte(variables), &store)
+	if err != nil {
+		return nil
+	}
+
+	secrets := []string{}
+	for _, value := range store {
+		switch v := value.(type) {
+		case string:
+			secrets = append(secrets, v)
+		case map[interface{}]interface{}:
+			for _, key := range []string{"private_key", "password"} {
+				if s, ok := v[key].(string); ok {
+					secrets = append(secrets, s)
+				}
+			}
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path"
+
+	awslib "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+type s3Client interface {
+	GetObject(*s3.GetObjectInput) (*s3.GetObjectOutput, error)
+	PutObject(*s3.PutObjectInput) (*s3.PutObjectOutput, error)
+	DeleteObject(*s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error)
+}
+
+type S3Backend struct {
+	client  s3Client
+	bucket  string
+	prefix  string
+	key     string
+	archive stateArchive
+}
+
+func NewS3Backend(client s3Client, bucket, prefix string, fs archiveFs, cipher Cipher) S3Backend {
+	return S3Backend{
+		client:  client,
+		bucket:  bucket,
+		prefix:  prefix,
+		key:     path.Join(prefix, BackendObjectName),
+		archive: stateArchive{fs: fs, cipher: cipher},
+	}
+}
+
+func (b S3Backend) Download(dir string) (map[string]bool, error) {
+	data, err := b.get(b.key)
+	if err != nil {
+		return nil, fmt.Errorf("Download state from %s/%s: %s", b.bucket, b.key, err)
+	}
+
+	if data == nil {
+		return nil, nil
+	}
+
+	return b.archive.Unpack(dir, data)
+}
+
+func (b S3Backend) Prune(dir string, downloaded map[string]bool) error {
+	return b.archive.Prune(dir, downloaded)
+}
+
+func (b S3Backend) Upload(dir string) error {
+	data, err := b.archive.Pack(dir)
+	if err != nil {
+		return err
+	}
+
+	err = b.put(b.key, data)
+	if err != nil {
+		return fmt.Errorf("Upload state to %s/%s: %s", b.bucket, b.key, err)
+	}
+
+	return nil
+}
+
+func (b S3Backend) Delete() error {
+	err := b.delete(b.key)
+	if err != nil {
+		return fmt.Errorf("Delete state from %s/%s: %s", b.bucket, b.key, err)
+	}
+
+	return nil
+}
+
+func (b S3Backend) ReadLock(dir, name string) ([]byte, error) {
+	return b.get(path.Join(b.prefix, name))
+}
+
+// WriteLock is best-effort: object stores do not offer an atomic
+// create-if-absent, so two writers racing within the same request
+// window can both believe they hold the lock.
+func (b S3Backend) WriteLock(dir, name string, contents []byte) error {
+	key := path.Join(b.prefix, name)
+	existing, err := b.get(key)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return ErrLockExists
+	}
+
+	return b.put(key, contents)
+}
+
+func (b S3Backend) DeleteLock(dir, name string) error {
+	return b.delete(path.Join(b.prefix, name))
+}
+
+func (b S3Backend) get(key string) ([]byte, error) {
+	output, err := b.client.GetObject(&s3.GetObjectInput{
+		Bucket: awslib.String(b.bucket),
+		Key:    awslib.String(key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	return ioutil.ReadAll(output.Body)
+}
+
+func (b S3Backend) put(key string, data []byte) error {
+	_, err := b.client.PutObject(&s3.PutObjectInput{
+		Bucket: awslib.String(b.bucket),
+		Key:    awslib.String(key),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (b S3Backend) delete(key string) error {
+	_, err := b.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: awslib.String(b.bucket),
+		Key:    awslib.String(key),
+	})
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	if awsErr, ok := err.(awserr.RequestFailure); ok {
+		return awsErr.StatusCode() == 404
+	}
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == s3.ErrCodeNoSuchKey
+	}
+	return false
+}
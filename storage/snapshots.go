@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+const (
+	SNAPSHOTS_DIR  = ".bbl-snapshots"
+	SnapshotsLimit = 20
+
+	snapshotMetadataFile = "snapshot.json"
+)
+
+type snapshotsFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.DirReader
+	fileio.Stater
+	fileio.AllMkdirer
+	fileio.Remover
+	fileio.AllRemover
+}
+
+type Snapshot struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Files     []string  `json:"files"`
+}
+
+// Snapshots keeps a bounded history of bbl-state.json and the bbl-managed
+// vars files. When state encryption is enabled the sensitive files are
+// encrypted in the snapshot even if a running command has unsealed them.
+type Snapshots struct {
+	fs     snapshotsFs
+	cipher Cipher
+	limit  int
+}
+
+func NewSnapshots(fs snapshotsFs, cipher Cipher) Snapshots {
+	return Snapshots{
+		fs:     fs,
+		cipher: cipher,
+		limit:  SnapshotsLimit,
+	}
+}
+
+func (s Snapshots) Take(dir string) error {
+	files, err := s.managedFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(files) == 0 {
+		return nil
+	}
+
+	snapshots, err := s.List(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) > 0 {
+		unchanged, err := s.matches(dir, snapshots[0], files)
+		if err != nil {
+			return err
+		}
+		if unchanged {
+			return nil
+		}
+	}
+
+	id := "1"
+	if len(snapshots) > 0 {
+		latest, _ := strconv.Atoi(snapshots[0].ID)
+		id = strconv.Itoa(latest + 1)
+	}
+
+	snapshotDir := filepath.Join(dir, SNAPSHOTS_DIR, id)
+	for _, file := range files {
+		err = s.copy(filepath.Join(dir, file), filepath.Join(snapshotDir, file), s.cipher.Enabled() && isSensitive(file))
+		if err != nil {
+			return err
+		}
+	}
+
+	metadata, err := json.Marshal(Snapshot{
+		ID:        id,
+		Timestamp: timeNow().UTC(),
+		Files:     files,
+	})
+	if err != nil {
+		return err // not tested
+	}
+
+	err = s.fs.WriteFile(filepath.Join(snapshotDir, snapshotMetadataFile), metadata, StateMode)
+	if err != nil {
+		return fmt.Errorf("Write snapshot %s: %s", id, err)
+	}
+
+	snapshots = append([]Snapshot{{ID: id}}, snapshots...)
+	if len(snapshots) > s.limit {
+		for _, old := range snapshots[s.limit:] {
+			err = s.fs.RemoveAll(filepath.Join(dir, SNAPSHOTS_DIR, old.ID))
+			if err != nil {
+				return fmt.Errorf("Remove snapshot %s: %s", old.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// List returns the snapshots in the state directory, newest first.
+func (s Snapshots) List(dir string) ([]Snapshot, error) {
+	entries, err := s.fs.ReadDir(filepath.Join(dir, SNAPSHOTS_DIR))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Snapshot{}, nil
+		}
+		return nil, fmt.Errorf("Read snapshots: %s", err)
+	}
+
+	snapshots := []Snapshot{}
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil || !entry.IsDir() {
+			continue
+		}
+
+		snapshot, err := s.get(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		a, _ := strconv.Atoi(snapshots[i].ID)
+		b, _ := strconv.Atoi(snapshots[j].ID)
+		return a > b
+	})
+
+	return snapshots, nil
+}
+
+// Restore replaces bbl-state.json and the bbl-managed vars files with the
+// contents of a snapshot. The current files are snapshotted first so that
+// a rollback can itself be undone.
+func (s Snapshots) Restore(dir, id string) error {
+	snapshot, err := s.get(dir, id)
+	if err != nil {
+		return err
+	}
+
+	restored := map[string][]byte{}
+	modes := map[string]os.FileMode{}
+	for _, file := range snapshot.Files {
+		path := filepath.Join(dir, SNAPSHOTS_DIR, id, file)
+
+		info, err := s.fs.Stat(path)
+		if err != nil {
+			return fmt.Errorf("Stat %s: %s", path, err)
+		}
+
+		contents, err := s.fs.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Read %s: %s", path, err)
+		}
+
+		restored[file], err = s.cipher.Decrypt(contents)
+		if err != nil {
+			return fmt.Errorf("Restore %s: %s", file, err)
+		}
+		modes[file] = info.Mode().Perm()
+	}
+
+	err = s.Take(dir)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.managedFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range current {
+		err = s.fs.Remove(filepath.Join(dir, file))
+		if err != nil {
+			return fmt.Errorf("Remove %s: %s", file, err)
+		}
+	}
+
+	for _, file := range snapshot.Files {
+		path := filepath.Join(dir, file)
+
+		err = s.fs.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("Create %s: %s", filepath.Dir(path), err)
+		}
+
+		err = s.fs.WriteFile(path, restored[file], modes[file])
+		if err != nil {
+			return fmt.Errorf("Write %s: %s", file, err)
+		}
+	}
+
+	return nil
+}
+
+func (s Snapshots) get(dir, id string) (Snapshot, error) {
+	if _, err := strconv.Atoi(id); err != nil {
+		return Snapshot{}, fmt.Errorf("Snapshot %q does not exist.", id)
+	}
+
+	contents, err := s.fs.ReadFile(filepath.Join(dir, SNAPSHOTS_DIR, id, snapshotMetadataFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Snapshot{}, fmt.Errorf("Snapshot %q does not exist.", id)
+		}
+		return Snapshot{}, fmt.Errorf("Read snapshot %s: %s", id, err)
+	}
+
+	var snapshot Snapshot
+	err = json.Unmarshal(contents, &snapshot)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("Read snapshot %s: %s", id, err)
+	}
+
+	return snapshot, nil
+}
+
+func (s Snapshots) managedFiles(dir string) ([]string, error) {
+	files := []string{}
+
+	_, err := s.fs.Stat(filepath.Join(dir, STATE_FILE))
+	if err == nil {
+		files = append(files, STATE_FILE)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Stat %s: %s", STATE_FILE, err)
+	}
+
+	vars, err := s.fs.ReadDir(filepath.Join(dir, "vars"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("Read vars dir: %s", err)
+	}
+
+	for _, file := range vars {
+		if _, ok := bblManaged[file.Name()]; ok && !file.IsDir() {
+			files = append(files, filepath.Join("vars", file.Name()))
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func (s Snapshots) matches(dir string, snapshot Snapshot, files []string) (bool, error) {
+	if len(snapshot.Files) != len(files) {
+		return false, nil
+	}
+
+	for i, file := range files {
+		if snapshot.Files[i] != file {
+			return false, nil
+		}
+
+		current, err := s.fs.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			return false, fmt.Errorf("Read %s: %s", file, err)
+		}
+
+		previous, err := s.fs.ReadFile(filepath.Join(dir, SNAPSHOTS_DIR, snapshot.ID, file))
+		if err != nil {
+			return false, nil
+		}
+
+		current, err = s.cipher.Decrypt(current)
+		if err != nil {
+			return false, nil
+		}
+
+		previous, err = s.cipher.Decrypt(previous)
+		if err != nil {
+			return false, nil
+		}
+
+		if !bytes.Equal(current, previous) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (s Snapshots) copy(source, destination string, encrypt bool) error {
+	info, err := s.fs.Stat(source)
+	if err != nil {
+		return fmt.Errorf("Stat %s: %s", source, err)
+	}
+
+	contents, err := s.fs.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("Read %s: %s", source, err)
+	}
+
+	if encrypt && !IsEncrypted(contents) {
+		contents, err = s.cipher.Encrypt(contents)
+		if err != nil {
+			return fmt.Errorf("Encrypt %s: %s", source, err)
+		}
+	}
+
+	err = s.fs.MkdirAll(filepath.Dir(destination), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("Create %s: %s", filepath.Dir(destination), err)
+	}
+
+	err = s.fs.WriteFile(destination, contents, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("Write %s: %s", destination, err)
+	}
+
+	return nil
+}
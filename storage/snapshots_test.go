@@ -0,0 +1,160 @@
+package storage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Snapshots", func() {
+	var (
+		tempDir   string
+		fs        *afero.Afero
+		snapshots storage.Snapshots
+		now       time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(tempDir, "vars"), os.ModePerm)).To(Succeed())
+
+		fs = &afero.Afero{Fs: afero.NewOsFs()}
+		snapshots = storage.NewSnapshots(fs, storage.Cipher{})
+
+		now = time.Date(2018, time.March, 1, 12, 0, 0, 0, time.UTC)
+		storage.SetTimeNow(func() time.Time { return now })
+	})
+
+	AfterEach(func() {
+		storage.ResetTimeNow()
+		os.RemoveAll(tempDir)
+	})
+
+	write := func(name, contents string) {
+		Expect(ioutil.WriteFile(filepath.Join(tempDir, name), []byte(contents), storage.StateMode)).To(Succeed())
+	}
+
+	read := func(name string) string {
+		contents, err := ioutil.ReadFile(filepath.Join(tempDir, name))
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	Describe("Take", func() {
+		BeforeEach(func() {
+			write("bbl-state.json", `{"envID": "some-env"}`)
+			write("vars/director-vars-store.yml", "admin_password: secret")
+			write("vars/user-ops-file.yml", "some-user-file")
+		})
+
+		It("copies the state and the bbl-managed vars files", func() {
+			Expect(snapshots.Take(tempDir)).To(Succeed())
+
+			list, err := snapshots.List(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list).To(Equal([]storage.Snapshot{{
+				ID:        "1",
+				Timestamp: now,
+				Files:     []string{"bbl-state.json", "vars/director-vars-store.yml"},
+			}}))
+
+			Expect(read(".bbl-snapshots/1/vars/director-vars-store.yml")).To(Equal("admin_password: secret"))
+			Expect(filepath.Join(tempDir, ".bbl-snapshots/1/vars/user-ops-file.yml")).NotTo(BeAnExistingFile())
+		})
+
+		It("skips the snapshot when nothing has changed", func() {
+			Expect(snapshots.Take(tempDir)).To(Succeed())
+			Expect(snapshots.Take(tempDir)).To(Succeed())
+
+			write("bbl-state.json", `{"envID": "some-other-env"}`)
+			Expect(snapshots.Take(tempDir)).To(Succeed())
+
+			list, err := snapshots.List(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list).To(HaveLen(2))
+			Expect(list[0].ID).To(Equal("2"))
+		})
+
+		It("keeps a bounded history", func() {
+			for i := 0; i < storage.SnapshotsLimit+3; i++ {
+				write("bbl-state.json", strconv.Itoa(i))
+				Expect(snapshots.Take(tempDir)).To(Succeed())
+			}
+
+			list, err := snapshots.List(tempDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list).To(HaveLen(storage.SnapshotsLimit))
+			Expect(list[0].ID).To(Equal(strconv.Itoa(storage.SnapshotsLimit + 3)))
+			Expect(filepath.Join(tempDir, ".bbl-snapshots", "3")).NotTo(BeADirectory())
+		})
+
+		Context("when the state directory is encrypted", func() {
+			It("encrypts the sensitive files in the snapshot", func() {
+				snapshots = storage.NewSnapshots(fs, storage.NewCipher([]byte("some-passphrase")))
+				Expect(snapshots.Take(tempDir)).To(Succeed())
+
+				Expect(storage.IsEncrypted([]byte(read(".bbl-snapshots/1/vars/director-vars-store.yml")))).To(BeTrue())
+				Expect(snapshots.Take(tempDir)).To(Succeed())
+
+				list, err := snapshots.List(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list).To(HaveLen(1))
+			})
+		})
+	})
+
+	Describe("Restore", func() {
+		BeforeEach(func() {
+			write("bbl-state.json", `{"envID": "some-env"}`)
+			write("vars/director-vars-store.yml", "admin_password: secret")
+			Expect(snapshots.Take(tempDir)).To(Succeed())
+
+			write("bbl-state.json", `{"envID": "half-created-env"}`)
+			write("vars/jumpbox-state.json", "some-jumpbox-state")
+		})
+
+		It("puts back the files from the snapshot", func() {
+			Expect(snapshots.Restore(tempDir, "1")).To(Succeed())
+
+			Expect(read("bbl-state.json")).To(Equal(`{"envID": "some-env"}`))
+			Expect(read("vars/director-vars-store.yml")).To(Equal("admin_password: secret"))
+			Expect(filepath.Join(tempDir, "vars/jumpbox-state.json")).NotTo(BeAnExistingFile())
+		})
+
+		It("snapshots the current files first", func() {
+			Expect(snapshots.Restore(tempDir, "1")).To(Succeed())
+			Expect(snapshots.Restore(tempDir, "2")).To(Succeed())
+
+			Expect(read("bbl-state.json")).To(Equal(`{"envID": "half-created-env"}`))
+			Expect(read("vars/jumpbox-state.json")).To(Equal("some-jumpbox-state"))
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the snapshot does not exist", func() {
+				Expect(snapshots.Restore(tempDir, "7")).To(MatchError(`Snapshot "7" does not exist.`))
+				Expect(snapshots.Restore(tempDir, "../..")).To(MatchError(`Snapshot "../.." does not exist.`))
+			})
+
+			It("returns an error when the snapshot is encrypted and no passphrase is given", func() {
+				snapshots = storage.NewSnapshots(fs, storage.NewCipher([]byte("some-passphrase")))
+				write("bbl-state.json", `{"envID": "encrypted-env"}`)
+				Expect(snapshots.Take(tempDir)).To(Succeed())
+
+				snapshots = storage.NewSnapshots(fs, storage.Cipher{})
+				err := snapshots.Restore(tempDir, "2")
+				Expect(err).To(MatchError(ContainSubstring(storage.ErrNoPassphrase.Error())))
+				Expect(read("bbl-state.json")).To(Equal(`{"envID": "encrypted-env"}`))
+			})
+		})
+	})
+})
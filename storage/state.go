@@ -1,5 +1,7 @@
 package storage
 
+import "time"
+
 type State struct {
 	Version        int       `json:"version"`
 	BBLVersion     string    `json:"bblVersion"`
@@ -17,4 +19,24 @@ type State struct {
 	TFState        string    `json:"tfState"`
 	LB             LB        `json:"lb"`
 	LatestTFOutput string    `json:"latestTFOutput"`
+
+	// TFOutputs caches the terraform outputs from the last apply. It is
+	// only valid while vars/terraform.tfstate matches TFOutputsChecksum.
+	TFOutputs         map[string]interface{} `json:"tfOutputs,omitempty"`
+	TFOutputsChecksum string                 `json:"tfOutputsChecksum,omitempty"`
+
+	// UpCheckpoint records the phases of a bbl up that did not finish, with
+	// a fingerprint of each phase's inputs, so that a rerun can resume.
+	UpCheckpoint map[string]string `json:"upCheckpoint,omitempty"`
+
+	// Rotations records the credentials bbl rotate replaced, oldest first.
+	Rotations []Rotation `json:"rotations,omitempty"`
+}
+
+// Rotation is one credential replaced by bbl rotate. Phase is 1 or 2 for
+// the credentials that are replaced over two runs.
+type Rotation struct {
+	Credential string    `json:"credential"`
+	Phase      int       `json:"phase,omitempty"`
+	RotatedAt  time.Time `json:"rotatedAt"`
 }
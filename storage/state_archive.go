@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+)
+
+var archivedDirs = []string{"vars", "terraform", "cloud-config"}
+
+type archiveFs interface {
+	fileio.FileReader
+	fileio.FileWriter
+	fileio.DirReader
+	fileio.Stater
+	fileio.AllMkdirer
+	fileio.Remover
+}
+
+type stateArchive struct {
+	fs     archiveFs
+	cipher Cipher
+}
+
+func (a stateArchive) Pack(dir string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err := a.addFile(tarWriter, dir, STATE_FILE)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subdir := range archivedDirs {
+		files, err := a.fs.ReadDir(filepath.Join(dir, subdir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("Read %s dir: %s", subdir, err)
+		}
+
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+
+			err = a.addFile(tarWriter, dir, filepath.Join(subdir, file.Name()))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = tarWriter.Close()
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	err = gzipWriter.Close()
+	if err != nil {
+		return nil, err // not tested
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (a stateArchive) addFile(tarWriter *tar.Writer, dir, name string) error {
+	path := filepath.Join(dir, name)
+
+	info, err := a.fs.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("Stat %s: %s", name, err)
+	}
+
+	contents, err := a.fs.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Read %s: %s", name, err)
+	}
+
+	if a.cipher.Enabled() && isSensitive(name) && !IsEncrypted(contents) {
+		contents, err = a.cipher.Encrypt(contents)
+		if err != nil {
+			return fmt.Errorf("Encrypt %s: %s", name, err)
+		}
+	}
+
+	err = tarWriter.WriteHeader(&tar.Header{
+		Name:    filepath.ToSlash(name),
+		Mode:    int64(info.Mode().Perm()),
+		Size:    int64(len(contents)),
+		ModTime: info.ModTime(),
+	})
+	if err != nil {
+		return fmt.Errorf("Archive %s: %s", name, err) // not tested
+	}
+
+	_, err = tarWriter.Write(contents)
+	if err != nil {
+		return fmt.Errorf("Archive %s: %s", name, err) // not tested
+	}
+
+	return nil
+}
+
+// Unpack writes the archived files to dir and returns their names, so that
+// Prune can later remove the files that were deleted remotely.
+func (a stateArchive) Unpack(dir string, data []byte) (map[string]bool, error) {
+	unpacked := map[string]bool{}
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("Read state archive: %s", err)
+	}
+
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Read state archive: %s", err)
+		}
+
+		name := filepath.FromSlash(header.Name)
+		if !isArchivable(name) {
+			return nil, fmt.Errorf("Unexpected file in state archive: %s", header.Name)
+		}
+
+		contents, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return nil, fmt.Errorf("Read %s from state archive: %s", header.Name, err)
+		}
+
+		path := filepath.Join(dir, name)
+		err = a.fs.MkdirAll(filepath.Dir(path), os.ModePerm)
+		if err != nil {
+			return nil, fmt.Errorf("Create %s: %s", filepath.Dir(path), err)
+		}
+
+		err = a.fs.WriteFile(path, contents, os.FileMode(header.Mode).Perm())
+		if err != nil {
+			return nil, fmt.Errorf("Write %s: %s", name, err)
+		}
+		unpacked[name] = true
+	}
+
+	return unpacked, nil
+}
+
+// Prune removes the state file and the files in the archived directories
+// that are not in unpacked.
+func (a stateArchive) Prune(dir string, unpacked map[string]bool) error {
+	names := []string{STATE_FILE}
+	for _, subdir := range archivedDirs {
+		files, err := a.fs.ReadDir(filepath.Join(dir, subdir))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("Read %s dir: %s", subdir, err)
+		}
+
+		for _, file := range files {
+			if !file.IsDir() {
+				names = append(names, filepath.Join(subdir, file.Name()))
+			}
+		}
+	}
+
+	for _, name := range names {
+		if unpacked[name] {
+			continue
+		}
+
+		err := a.fs.Remove(filepath.Join(dir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("Remove %s: %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+func isArchivable(name string) bool {
+	if name == STATE_FILE {
+		return true
+	}
+
+	if filepath.IsAbs(name) || strings.Contains(name, "..") {
+		return false
+	}
+
+	for _, subdir := range archivedDirs {
+		if filepath.Dir(name) == subdir {
+			return true
+		}
+	}
+
+	return false
+}
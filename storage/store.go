@@ -25,6 +25,8 @@ type Store struct {
 	dir              string
 	fs               fs
 	garbageCollector garbageCollector
+	backend          Backend
+	snapshots        snapshots
 	stateSchema      int
 }
 
@@ -41,11 +43,19 @@ type garbageCollector interface {
 	Remove(d string) error
 }
 
-func NewStore(dir string, fs fs, garbageCollector garbageCollector) Store {
+type snapshots interface {
+	Take(dir string) error
+	List(dir string) ([]Snapshot, error)
+	Restore(dir, id string) error
+}
+
+func NewStore(dir string, fs fs, garbageCollector garbageCollector, backend Backend, snapshots snapshots) Store {
 	return Store{
 		dir:              dir,
 		fs:               fs,
 		garbageCollector: garbageCollector,
+		backend:          backend,
+		snapshots:        snapshots,
 		stateSchema:      STATE_SCHEMA,
 	}
 }
@@ -61,6 +71,11 @@ func (s Store) Set(state State) error {
 		if err != nil {
 			return fmt.Errorf("Garbage collector clean up: %s", err)
 		}
+
+		err = s.backend.Delete()
+		if err != nil {
+			return fmt.Errorf("Delete remote state: %s", err)
+		}
 		return nil
 	}
 
@@ -85,6 +100,34 @@ func (s Store) Set(state State) error {
 		return err
 	}
 
+	err = s.snapshots.Take(s.dir)
+	if err != nil {
+		return fmt.Errorf("Snapshot state: %s", err)
+	}
+
+	err = s.backend.Upload(s.dir)
+	if err != nil {
+		return fmt.Errorf("Upload remote state: %s", err)
+	}
+
+	return nil
+}
+
+func (s Store) Snapshots() ([]Snapshot, error) {
+	return s.snapshots.List(s.dir)
+}
+
+func (s Store) Rollback(id string) error {
+	err := s.snapshots.Restore(s.dir, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.backend.Upload(s.dir)
+	if err != nil {
+		return fmt.Errorf("Upload remote state: %s", err)
+	}
+
 	return nil
 }
 
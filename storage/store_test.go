@@ -19,6 +19,8 @@ var _ = Describe("Store", func() {
 	var (
 		fileIO           *fakes.FileIO
 		garbageCollector *fakes.GarbageCollector
+		stateBackend     *fakes.StateBackend
+		snapshots        *fakes.Snapshots
 		store            storage.Store
 		tempDir          string
 	)
@@ -29,8 +31,10 @@ var _ = Describe("Store", func() {
 
 		fileIO = &fakes.FileIO{}
 		garbageCollector = &fakes.GarbageCollector{}
+		stateBackend = &fakes.StateBackend{}
+		snapshots = &fakes.Snapshots{}
 
-		store = storage.NewStore(tempDir, fileIO, garbageCollector)
+		store = storage.NewStore(tempDir, fileIO, garbageCollector, stateBackend, snapshots)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -187,6 +191,22 @@ var _ = Describe("Store", func() {
 			})
 		})
 
+		It("uploads the state dir to the state backend", func() {
+			err := store.Set(storage.State{EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(stateBackend.UploadCall.CallCount).To(Equal(1))
+			Expect(stateBackend.UploadCall.Receives.Dir).To(Equal(tempDir))
+		})
+
+		It("snapshots the state dir", func() {
+			err := store.Set(storage.State{EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(snapshots.TakeCall.CallCount).To(Equal(1))
+			Expect(snapshots.TakeCall.Receives.Dir).To(Equal(tempDir))
+		})
+
 		Context("when the state is empty", func() {
 			It("calls the garbage collector", func() {
 				err := store.Set(storage.State{})
@@ -196,6 +216,26 @@ var _ = Describe("Store", func() {
 				Expect(garbageCollector.RemoveCall.Receives.Directory).To(Equal(tempDir))
 			})
 
+			It("deletes the state from the state backend", func() {
+				err := store.Set(storage.State{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(stateBackend.DeleteCall.CallCount).To(Equal(1))
+				Expect(stateBackend.UploadCall.CallCount).To(Equal(0))
+				Expect(snapshots.TakeCall.CallCount).To(Equal(0))
+			})
+
+			Context("when the state backend fails to delete", func() {
+				BeforeEach(func() {
+					stateBackend.DeleteCall.Returns.Error = errors.New("kiwi")
+				})
+
+				It("returns the error", func() {
+					err := store.Set(storage.State{})
+					Expect(err).To(MatchError("Delete remote state: kiwi"))
+				})
+			})
+
 			Context("when the garbage collector fails to clean up", func() {
 				BeforeEach(func() {
 					garbageCollector.RemoveCall.Returns.Error = errors.New("banana")
@@ -241,7 +281,7 @@ var _ = Describe("Store", func() {
 				})
 
 				It("returns an error", func() {
-					store = storage.NewStore("non-valid-dir", fileIO, garbageCollector)
+					store = storage.NewStore("non-valid-dir", fileIO, garbageCollector, stateBackend, snapshots)
 					err := store.Set(storage.State{})
 					Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
 				})
@@ -257,6 +297,60 @@ var _ = Describe("Store", func() {
 					Expect(err).To(MatchError(ContainSubstring("permission denied")))
 				})
 			})
+
+			Context("when the state backend fails to upload", func() {
+				BeforeEach(func() {
+					stateBackend.UploadCall.Returns.Error = errors.New("mango")
+				})
+
+				It("returns an error", func() {
+					err := store.Set(storage.State{EnvID: "something"})
+					Expect(err).To(MatchError("Upload remote state: mango"))
+				})
+			})
+
+			Context("when the snapshot fails", func() {
+				BeforeEach(func() {
+					snapshots.TakeCall.Returns.Error = errors.New("papaya")
+				})
+
+				It("returns an error", func() {
+					err := store.Set(storage.State{EnvID: "something"})
+					Expect(err).To(MatchError("Snapshot state: papaya"))
+				})
+			})
+		})
+	})
+
+	Describe("Snapshots", func() {
+		It("lists the snapshots of the state dir", func() {
+			snapshots.ListCall.Returns.Snapshots = []storage.Snapshot{{ID: "2"}, {ID: "1"}}
+
+			list, err := store.Snapshots()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list).To(Equal([]storage.Snapshot{{ID: "2"}, {ID: "1"}}))
+			Expect(snapshots.ListCall.Receives.Dir).To(Equal(tempDir))
+		})
+	})
+
+	Describe("Rollback", func() {
+		It("restores the snapshot and uploads the state dir", func() {
+			err := store.Rollback("3")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(snapshots.RestoreCall.Receives.Dir).To(Equal(tempDir))
+			Expect(snapshots.RestoreCall.Receives.ID).To(Equal("3"))
+			Expect(stateBackend.UploadCall.CallCount).To(Equal(1))
+		})
+
+		Context("when the snapshot cannot be restored", func() {
+			It("does not upload the state dir", func() {
+				snapshots.RestoreCall.Returns.Error = errors.New("guava")
+
+				err := store.Rollback("3")
+				Expect(err).To(MatchError("guava"))
+				Expect(stateBackend.UploadCall.CallCount).To(Equal(0))
+			})
 		})
 	})
 
@@ -0,0 +1,122 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ResourceDrift is a resource that changed outside of terraform. Action is
+// what terraform saw happen to it: update or delete.
+type ResourceDrift struct {
+	Address string `json:"address"`
+	Action  string `json:"action"`
+}
+
+// tfState holds the managed resources of a terraform state file, in the
+// modules layout of terraform 0.11 and the resources layout of 0.12 on.
+type tfState struct {
+	Modules []struct {
+		Path      []string `json:"path"`
+		Resources map[string]struct {
+			Primary struct {
+				Attributes map[string]string `json:"attributes"`
+			} `json:"primary"`
+		} `json:"resources"`
+	} `json:"modules"`
+
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   interface{}            `json:"index_key"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// stateResources returns the attributes of every managed resource in the
+// terraform state, by address. Data sources are left out since a refresh
+// is expected to change them.
+func stateResources(contents []byte) (map[string]interface{}, error) {
+	var state tfState
+	err := json.Unmarshal(contents, &state)
+	if err != nil {
+		return nil, fmt.Errorf("Parse terraform state: %s", err)
+	}
+
+	resources := map[string]interface{}{}
+
+	for _, module := range state.Modules {
+		prefix := ""
+		for _, name := range module.Path {
+			if name != "root" {
+				prefix += fmt.Sprintf("module.%s.", name)
+			}
+		}
+		for key, resource := range module.Resources {
+			if strings.HasPrefix(key, "data.") {
+				continue
+			}
+			resources[prefix+key] = resource.Primary.Attributes
+		}
+	}
+
+	for _, resource := range state.Resources {
+		if resource.Mode != "managed" {
+			continue
+		}
+		address := fmt.Sprintf("%s.%s", resource.Type, resource.Name)
+		if resource.Module != "" {
+			address = fmt.Sprintf("%s.%s", resource.Module, address)
+		}
+		for _, instance := range resource.Instances {
+			switch key := instance.IndexKey.(type) {
+			case float64:
+				resources[fmt.Sprintf("%s[%d]", address, int(key))] = instance.Attributes
+			case string:
+				resources[fmt.Sprintf("%s[%q]", address, key)] = instance.Attributes
+			default:
+				resources[address] = instance.Attributes
+			}
+		}
+	}
+
+	return resources, nil
+}
+
+// stateDrift compares the last applied terraform state with a refreshed
+// copy of it. A resource that was deleted outside of terraform is dropped
+// by the refresh.
+func stateDrift(applied, refreshed []byte) ([]ResourceDrift, error) {
+	appliedResources, err := stateResources(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshedResources, err := stateResources(refreshed)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := []ResourceDrift{}
+	for address, attributes := range appliedResources {
+		refreshedAttributes, ok := refreshedResources[address]
+		switch {
+		case !ok:
+			drift = append(drift, ResourceDrift{Address: address, Action: "delete"})
+		case !reflect.DeepEqual(attributes, refreshedAttributes):
+			drift = append(drift, ResourceDrift{Address: address, Action: "update"})
+		}
+	}
+
+	sort.Slice(drift, func(i, j int) bool {
+		return drift[i].Address < drift[j].Address
+	})
+
+	return drift, nil
+}
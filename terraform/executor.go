@@ -2,6 +2,8 @@ package terraform
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/cloudfoundry/bosh-bootloader/fileio"
@@ -44,9 +47,12 @@ type stateStore interface {
 }
 
 type fs interface {
+	fileio.FileReader
 	fileio.FileWriter
 	fileio.DirReader
 	fileio.Stater
+	fileio.TempDirer
+	fileio.AllRemover
 }
 
 func NewExecutor(cmd terraformCmd, bufferingCmd terraformCmd, stateStore stateStore, fs fs, debug bool, out io.Writer) Executor {
@@ -95,8 +101,15 @@ func (e Executor) Setup(template string, input map[string]interface{}) error {
 }
 
 func formatVars(inputs map[string]interface{}) string {
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	formattedVars := ""
-	for name, value := range inputs {
+	for _, name := range names {
+		value := inputs[name]
 		if vString, ok := value.(string); ok {
 			vString = fmt.Sprintf(`"%s"`, vString)
 			if strings.Contains(vString, "\n") {
@@ -116,20 +129,39 @@ func (e Executor) runTFCommand(args []string) error {
 }
 
 func (e Executor) runTFCommandWithEnvs(args, envs []string) error {
-	varsDir, err := e.stateStore.GetVarsDir()
+	terraformDir, args, err := e.stateArgs(args)
 	if err != nil {
 		return err
 	}
 
+	err = e.cmd.RunWithEnv(e.out, terraformDir, args, envs)
+	if err != nil {
+		if e.debug {
+			return err
+		}
+		return fmt.Errorf(redactedError)
+	}
+
+	return nil
+}
+
+// stateArgs adds the terraform state and the vars files in the vars
+// directory to args, and returns the directory to run terraform in.
+func (e Executor) stateArgs(args []string) (string, []string, error) {
+	varsDir, err := e.stateStore.GetVarsDir()
+	if err != nil {
+		return "", nil, err
+	}
+
 	tfStatePath := filepath.Join(varsDir, "terraform.tfstate")
 
 	terraformDir, err := e.stateStore.GetTerraformDir()
 	if err != nil {
-		return err
+		return "", nil, err
 	}
 	relativeStatePath, err := filepath.Rel(terraformDir, tfStatePath)
 	if err != nil {
-		return fmt.Errorf("Get relative terraform state path: %s", err) //not tested
+		return "", nil, fmt.Errorf("Get relative terraform state path: %s", err) //not tested
 	}
 
 	args = append(args,
@@ -138,14 +170,14 @@ func (e Executor) runTFCommandWithEnvs(args, envs []string) error {
 
 	varsFiles, err := e.fs.ReadDir(varsDir)
 	if err != nil {
-		return fmt.Errorf("Read contents of vars directory: %s", err)
+		return "", nil, fmt.Errorf("Read contents of vars directory: %s", err)
 	}
 
 	for _, file := range varsFiles {
 		if strings.HasSuffix(file.Name(), ".tfvars") {
 			relativeFilePath, err := filepath.Rel(terraformDir, filepath.Join(varsDir, file.Name()))
 			if err != nil {
-				return fmt.Errorf("Get relative terraform vars path: %s", err) //not tested
+				return "", nil, fmt.Errorf("Get relative terraform vars path: %s", err) //not tested
 			}
 			args = append(args,
 				"-var-file", relativeFilePath,
@@ -153,15 +185,7 @@ func (e Executor) runTFCommandWithEnvs(args, envs []string) error {
 		}
 	}
 
-	err = e.cmd.RunWithEnv(e.out, terraformDir, args, envs)
-	if err != nil {
-		if e.debug {
-			return err
-		}
-		return fmt.Errorf(redactedError)
-	}
-
-	return nil
+	return terraformDir, args, nil
 }
 
 func (e Executor) Init() error {
@@ -196,6 +220,98 @@ func (e Executor) Destroy(credentials map[string]string) error {
 	return e.runTFCommandWithEnvs(args, []string{"TF_WARN_OUTPUT_ERRORS=1"})
 }
 
+// Plan runs terraform plan and returns its output when it would change
+// the infrastructure, or an empty string when it would not.
+func (e Executor) Plan(credentials map[string]string) (string, error) {
+	args := []string{"plan", "-detailed-exitcode", "-input=false", "-no-color"}
+	for key, value := range credentials {
+		arg := fmt.Sprintf("%s=%s", key, value)
+		args = append(args, "-var", arg)
+	}
+
+	terraformDir, args, err := e.stateArgs(args)
+	if err != nil {
+		return "", err
+	}
+
+	buffer := bytes.NewBuffer([]byte{})
+	err = e.cmd.RunWithEnv(buffer, terraformDir, args, []string{})
+	if exitErr, ok := err.(interface{ ExitCode() int }); ok && exitErr.ExitCode() == 2 {
+		return buffer.String(), nil
+	}
+	if err != nil {
+		if e.debug {
+			return "", err
+		}
+		return "", errors.New(redactedError)
+	}
+
+	return "", nil
+}
+
+// Drift refreshes a copy of the terraform state and compares it with the
+// state of the last apply, and returns the resources that changed outside
+// of terraform since. Changes to the template or the tfvars that were not
+// applied yet are not drift. The terraform state in the vars directory is
+// left as it is.
+func (e Executor) Drift(credentials map[string]string) ([]ResourceDrift, error) {
+	terraformDir, err := e.stateStore.GetTerraformDir()
+	if err != nil {
+		return nil, err
+	}
+
+	varsDir, err := e.stateStore.GetVarsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	readOnlyDir, err := e.readOnlyVarsDir(varsDir)
+	if err != nil {
+		return nil, err
+	}
+	defer e.fs.RemoveAll(readOnlyDir)
+
+	refreshedStatePath := filepath.Join(readOnlyDir, "terraform.tfstate")
+	_, err = e.fs.Stat(refreshedStatePath)
+	if err != nil {
+		return []ResourceDrift{}, nil
+	}
+
+	appliedState, err := e.fs.ReadFile(filepath.Join(varsDir, "terraform.tfstate"))
+	if err != nil {
+		return nil, fmt.Errorf("Read terraform state: %s", err)
+	}
+
+	args := []string{"refresh", "-input=false", "-no-color", "-state", refreshedStatePath}
+	files, err := e.fs.ReadDir(readOnlyDir)
+	if err != nil {
+		return nil, fmt.Errorf("Read contents of vars directory: %s", err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".tfvars") {
+			args = append(args, "-var-file", filepath.Join(readOnlyDir, file.Name()))
+		}
+	}
+	for key, value := range credentials {
+		args = append(args, "-var", fmt.Sprintf("%s=%s", key, value))
+	}
+
+	err = e.cmd.RunWithEnv(e.out, terraformDir, args, []string{})
+	if err != nil {
+		if e.debug {
+			return nil, err
+		}
+		return nil, errors.New(redactedError)
+	}
+
+	refreshedState, err := e.fs.ReadFile(refreshedStatePath)
+	if err != nil {
+		return nil, fmt.Errorf("Read refreshed terraform state: %s", err)
+	}
+
+	return stateDrift(appliedState, refreshedState)
+}
+
 func (e Executor) Version() (string, error) {
 	buffer := bytes.NewBuffer([]byte{})
 	err := e.bufferingCmd.Run(buffer, "/tmp", []string{"version"})
@@ -229,10 +345,16 @@ func (e Executor) Output(outputName string) (string, error) {
 		return "", fmt.Errorf("Run terraform init in terraform dir: %s", err)
 	}
 
+	readOnlyDir, err := e.readOnlyVarsDir(varsDir)
+	if err != nil {
+		return "", err
+	}
+	defer e.fs.RemoveAll(readOnlyDir)
+
 	args := []string{"output", outputName}
-	_, err = e.fs.Stat(filepath.Join(varsDir, "terraform.tfstate"))
+	_, err = e.fs.Stat(filepath.Join(readOnlyDir, "terraform.tfstate"))
 	if err == nil {
-		args = append(args, "-state", filepath.Join(varsDir, "terraform.tfstate"))
+		args = append(args, "-state", filepath.Join(readOnlyDir, "terraform.tfstate"))
 	}
 	buffer := bytes.NewBuffer([]byte{})
 	err = e.bufferingCmd.Run(buffer, terraformDir, args)
@@ -259,11 +381,17 @@ func (e Executor) Outputs() (map[string]interface{}, error) {
 		return map[string]interface{}{}, fmt.Errorf("Run terraform init in terraform dir: %s", err)
 	}
 
+	readOnlyDir, err := e.readOnlyVarsDir(varsDir)
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+	defer e.fs.RemoveAll(readOnlyDir)
+
 	buffer := bytes.NewBuffer([]byte{})
 	args := []string{"output", "--json"}
-	_, err = e.fs.Stat(filepath.Join(varsDir, "terraform.tfstate"))
+	_, err = e.fs.Stat(filepath.Join(readOnlyDir, "terraform.tfstate"))
 	if err == nil {
-		args = append(args, "-state", filepath.Join(varsDir, "terraform.tfstate"))
+		args = append(args, "-state", filepath.Join(readOnlyDir, "terraform.tfstate"))
 	}
 	err = e.bufferingCmd.Run(buffer, terraformDir, args)
 	if err != nil {
@@ -284,6 +412,60 @@ func (e Executor) Outputs() (map[string]interface{}, error) {
 	return outputs, nil
 }
 
+// StateChecksum identifies the contents of vars/terraform.tfstate without
+// running terraform. It is empty when there is no terraform state.
+func (e Executor) StateChecksum() (string, error) {
+	varsDir, err := e.stateStore.GetVarsDir()
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := e.fs.ReadFile(filepath.Join(varsDir, "terraform.tfstate"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("Read terraform state: %s", err)
+	}
+
+	sum := sha256.Sum256(contents)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readOnlyVarsDir copies the terraform state and the vars files to a new
+// temporary directory for terraform commands that only read them. The
+// copies are read through the state directory's file system, so they are
+// in plain text even while the state directory is encrypted.
+func (e Executor) readOnlyVarsDir(varsDir string) (string, error) {
+	readOnlyDir, err := e.fs.TempDir("", "bbl-terraform")
+	if err != nil {
+		return "", fmt.Errorf("Create temp dir: %s", err)
+	}
+
+	files, err := e.fs.ReadDir(varsDir)
+	if err != nil {
+		e.fs.RemoveAll(readOnlyDir)
+		return "", fmt.Errorf("Read contents of vars directory: %s", err)
+	}
+
+	for _, file := range files {
+		if file.Name() != "terraform.tfstate" && !strings.HasSuffix(file.Name(), ".tfvars") {
+			continue
+		}
+
+		contents, err := e.fs.ReadFile(filepath.Join(varsDir, file.Name()))
+		if err == nil {
+			err = e.fs.WriteFile(filepath.Join(readOnlyDir, file.Name()), contents, storage.StateMode)
+		}
+		if err != nil {
+			e.fs.RemoveAll(readOnlyDir)
+			return "", fmt.Errorf("Copy %s: %s", file.Name(), err)
+		}
+	}
+
+	return readOnlyDir, nil
+}
+
 func (e Executor) IsPaved() (bool, error) {
 	terraformDir, err := e.stateStore.GetTerraformDir()
 	if err != nil {
@@ -300,11 +482,17 @@ func (e Executor) IsPaved() (bool, error) {
 		return false, err
 	}
 
+	readOnlyDir, err := e.readOnlyVarsDir(varsDir)
+	if err != nil {
+		return false, err
+	}
+	defer e.fs.RemoveAll(readOnlyDir)
+
 	buffer := bytes.NewBuffer([]byte{})
 	args := []string{"show"}
-	_, err = e.fs.Stat(filepath.Join(varsDir, "terraform.tfstate"))
+	_, err = e.fs.Stat(filepath.Join(readOnlyDir, "terraform.tfstate"))
 	if err == nil {
-		args = append(args, filepath.Join(varsDir, "terraform.tfstate"))
+		args = append(args, filepath.Join(readOnlyDir, "terraform.tfstate"))
 	}
 
 	err = e.bufferingCmd.Run(buffer, terraformDir, args)
@@ -35,6 +35,8 @@ var _ = Describe("Executor", func() {
 
 		tfVarsPath       string
 		relativeVarsPath string
+
+		readOnlyDir string
 	)
 
 	BeforeEach(func() {
@@ -64,6 +66,9 @@ var _ = Describe("Executor", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		input = map[string]interface{}{"project_id": "some-project-id"}
+
+		readOnlyDir = "/some/read-only-dir"
+		fileIO.TempDirCall.Returns.Name = readOnlyDir
 	})
 
 	Describe("Init", func() {
@@ -120,6 +125,15 @@ var _ = Describe("Executor", func() {
 			Expect(bufferingCmd.RunCall.CallCount).To(Equal(0))
 		})
 
+		It("writes the terraform vars in a stable order", func() {
+			input = map[string]interface{}{"zone": "some-zone", "env_id": "some-env-id", "project_id": "some-project-id"}
+
+			err := executor.Setup("some-template", input)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(fileIO.WriteFileCall.Receives[2].Contents)).To(Equal("\nenv_id=\"some-env-id\"\nproject_id=\"some-project-id\"\nzone=\"some-zone\""))
+		})
+
 		Context("when an error occurs", func() {
 			Context("when getting terraform dir fails", func() {
 				BeforeEach(func() {
@@ -300,6 +314,217 @@ var _ = Describe("Executor", func() {
 		})
 	})
 
+	Describe("Plan", func() {
+		BeforeEach(func() {
+			fileIO.ReadDirCall.Returns.FileInfos = []os.FileInfo{
+				fakes.FileInfo{
+					FileName: "bbl.tfvars",
+				},
+			}
+			cmd.RunCall.Stub = func(stdout io.Writer) {
+				stdout.Write([]byte("some-plan"))
+			}
+		})
+
+		It("returns the plan when terraform would make changes", func() {
+			cmd.RunCall.Returns.Errors = []error{exitError(2)}
+
+			plan, err := executor.Plan(map[string]string{
+				"some-cert": "some-cert-value",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(Equal("some-plan"))
+
+			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(terraformDir))
+			Expect(cmd.RunCall.Receives.Args).To(ConsistOf([]string{
+				"plan",
+				"-detailed-exitcode",
+				"-input=false",
+				"-no-color",
+				"-var", "some-cert=some-cert-value",
+				"-state", relativeStatePath,
+				"-var-file", relativeVarsPath,
+			}))
+		})
+
+		It("returns nothing when terraform would not make changes", func() {
+			plan, err := executor.Plan(map[string]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(Equal(""))
+		})
+
+		It("returns an error when terraform fails", func() {
+			cmd.RunCall.Returns.Errors = []error{exitError(1)}
+
+			_, err := executor.Plan(map[string]string{})
+			Expect(err).To(MatchError("exit status 1"))
+		})
+
+		It("redacts the error without debug", func() {
+			cmd.RunCall.Returns.Errors = []error{exitError(1)}
+
+			_, err := debugFalse.Plan(map[string]string{})
+			Expect(err).To(MatchError("Some output has been redacted, use `bbl latest-error` to see it or run again with --debug for additional debug output"))
+		})
+	})
+
+	Describe("Drift", func() {
+		var (
+			statePath, varsPath      string
+			appliedState, freshState string
+		)
+
+		BeforeEach(func() {
+			statePath = filepath.Join(readOnlyDir, "terraform.tfstate")
+			varsPath = filepath.Join(readOnlyDir, "bbl.tfvars")
+
+			appliedState = `{
+				"version": 3,
+				"modules": [{
+					"path": ["root"],
+					"resources": {
+						"google_compute_network.some-network": {"primary": {"id": "some-network", "attributes": {"auto_create_subnetworks": "false"}}},
+						"google_compute_firewall.some-firewall": {"primary": {"id": "some-firewall", "attributes": {"name": "some-firewall"}}},
+						"google_compute_subnetwork.some-subnet": {"primary": {"id": "some-subnet", "attributes": {"name": "some-subnet"}}},
+						"data.google_compute_zones.available": {"primary": {"id": "some-zones", "attributes": {"names.#": "2"}}}
+					}
+				}, {
+					"path": ["root", "some-module"],
+					"resources": {
+						"google_compute_address.some-address": {"primary": {"id": "some-address", "attributes": {"address": "1.2.3.4"}}}
+					}
+				}]
+			}`
+			freshState = `{
+				"version": 3,
+				"modules": [{
+					"path": ["root"],
+					"resources": {
+						"google_compute_network.some-network": {"primary": {"id": "some-network", "attributes": {"auto_create_subnetworks": "true"}}},
+						"google_compute_subnetwork.some-subnet": {"primary": {"id": "some-subnet", "attributes": {"name": "some-subnet"}}},
+						"data.google_compute_zones.available": {"primary": {"id": "some-zones", "attributes": {"names.#": "3"}}}
+					}
+				}, {
+					"path": ["root", "some-module"],
+					"resources": {
+						"google_compute_address.some-address": {"primary": {"id": "some-address", "attributes": {"address": "5.6.7.8"}}}
+					}
+				}]
+			}`
+
+			fileIO.ReadDirCall.Returns.FileInfos = []os.FileInfo{
+				fakes.FileInfo{FileName: "terraform.tfstate"},
+				fakes.FileInfo{FileName: "bbl.tfvars"},
+			}
+			fileIO.ReadFileCall.Fake = func(filename string) ([]byte, error) {
+				switch filename {
+				case filepath.Join(varsDir, "terraform.tfstate"):
+					return []byte(appliedState), nil
+				case statePath:
+					return []byte(freshState), nil
+				}
+				return []byte{}, nil
+			}
+		})
+
+		It("compares a refreshed copy of the terraform state with the last applied state", func() {
+			drift, err := executor.Drift(map[string]string{
+				"some-cert": "some-cert-value",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift).To(Equal([]terraform.ResourceDrift{
+				{Address: "google_compute_firewall.some-firewall", Action: "delete"},
+				{Address: "google_compute_network.some-network", Action: "update"},
+				{Address: "module.some-module.google_compute_address.some-address", Action: "update"},
+			}))
+
+			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(terraformDir))
+			Expect(cmd.RunCall.ReceivedArgs).To(Equal([][]string{
+				{
+					"refresh", "-input=false", "-no-color",
+					"-state", statePath,
+					"-var-file", varsPath,
+					"-var", "some-cert=some-cert-value",
+				},
+			}))
+			Expect(fileIO.RemoveAllCall.Receives).To(Equal([]fakes.RemoveAllReceive{{Path: readOnlyDir}}))
+		})
+
+		It("does not report changes to the template or the tfvars that were not applied yet", func() {
+			freshState = appliedState
+
+			drift, err := executor.Drift(map[string]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift).To(BeEmpty())
+			Expect(cmd.RunCall.CallCount).To(Equal(1))
+		})
+
+		It("reads the terraform state of terraform 0.12 and later", func() {
+			appliedState = `{
+				"version": 4,
+				"resources": [
+					{"mode": "managed", "type": "aws_instance", "name": "some-instance", "instances": [{"attributes": {"instance_type": "t2.small"}}]},
+					{"module": "module.some-module", "mode": "managed", "type": "aws_eip", "name": "some-eip", "instances": [{"index_key": 0, "attributes": {"id": "eip-0"}}, {"index_key": 1, "attributes": {"id": "eip-1"}}]},
+					{"mode": "data", "type": "aws_ami", "name": "some-ami", "instances": [{"attributes": {"id": "ami-1"}}]}
+				]
+			}`
+			freshState = `{
+				"version": 4,
+				"resources": [
+					{"mode": "managed", "type": "aws_instance", "name": "some-instance", "instances": [{"attributes": {"instance_type": "t2.large"}}]},
+					{"module": "module.some-module", "mode": "managed", "type": "aws_eip", "name": "some-eip", "instances": [{"index_key": 0, "attributes": {"id": "eip-0"}}]},
+					{"mode": "data", "type": "aws_ami", "name": "some-ami", "instances": [{"attributes": {"id": "ami-2"}}]}
+				]
+			}`
+
+			drift, err := executor.Drift(map[string]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift).To(Equal([]terraform.ResourceDrift{
+				{Address: "aws_instance.some-instance", Action: "update"},
+				{Address: "module.some-module.aws_eip.some-eip[1]", Action: "delete"},
+			}))
+		})
+
+		It("returns no resources without running terraform when there is no terraform state", func() {
+			fileIO.StatCall.Returns.Error = errors.New("not found")
+
+			drift, err := executor.Drift(map[string]string{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift).To(BeEmpty())
+			Expect(cmd.RunCall.CallCount).To(Equal(0))
+		})
+
+		It("returns an error when the terraform state cannot be copied", func() {
+			fileIO.TempDirCall.Returns.Error = errors.New("failed")
+
+			_, err := executor.Drift(map[string]string{})
+			Expect(err).To(MatchError("Create temp dir: failed"))
+			Expect(cmd.RunCall.CallCount).To(Equal(0))
+		})
+
+		It("returns an error when terraform refresh fails", func() {
+			cmd.RunCall.Returns.Errors = []error{exitError(1)}
+
+			_, err := executor.Drift(map[string]string{})
+			Expect(err).To(MatchError("exit status 1"))
+			Expect(cmd.RunCall.CallCount).To(Equal(1))
+		})
+
+		It("returns an error when the refreshed terraform state cannot be parsed", func() {
+			freshState = "%%%"
+
+			_, err := executor.Drift(map[string]string{})
+			Expect(err).To(MatchError(ContainSubstring("Parse terraform state: ")))
+		})
+
+		It("redacts the error without debug", func() {
+			cmd.RunCall.Returns.Errors = []error{exitError(1)}
+
+			_, err := debugFalse.Drift(map[string]string{})
+			Expect(err).To(MatchError("Some output has been redacted, use `bbl latest-error` to see it or run again with --debug for additional debug output"))
+		})
+	})
+
 	Describe("Destroy", func() {
 		var credentials map[string]string
 
@@ -440,10 +665,31 @@ var _ = Describe("Executor", func() {
 			Expect(output).To(Equal("some-external-ip"))
 
 			Expect(bufferingCmd.RunCall.Receives.WorkingDirectory).To(Equal(terraformDir))
-			Expect(bufferingCmd.RunCall.Receives.Args).To(Equal([]string{"output", "external_ip", "-state", tfStatePath}))
+			Expect(bufferingCmd.RunCall.Receives.Args).To(Equal([]string{"output", "external_ip", "-state", filepath.Join(readOnlyDir, "terraform.tfstate")}))
 			Expect(cmd.RunCall.Receives.Args).To(Equal([]string{"init"}))
 		})
 
+		It("reads a decrypted copy of the terraform state and vars", func() {
+			fileIO.ReadDirCall.Returns.FileInfos = []os.FileInfo{
+				fakes.FileInfo{FileName: "terraform.tfstate"},
+				fakes.FileInfo{FileName: "bbl.tfvars"},
+				fakes.FileInfo{FileName: "director-vars-store.yml"},
+			}
+			fileIO.ReadFileCall.Fake = func(path string) ([]byte, error) {
+				return []byte("contents of " + filepath.Base(path)), nil
+			}
+
+			_, err := executor.Output("external_ip")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fileIO.ReadDirCall.Receives.Dirname).To(Equal(varsDir))
+			Expect(fileIO.WriteFileCall.Receives).To(Equal([]fakes.WriteFileReceive{
+				{Filename: filepath.Join(readOnlyDir, "terraform.tfstate"), Contents: []byte("contents of terraform.tfstate"), Mode: storage.StateMode},
+				{Filename: filepath.Join(readOnlyDir, "bbl.tfvars"), Contents: []byte("contents of bbl.tfvars"), Mode: storage.StateMode},
+			}))
+			Expect(fileIO.RemoveAllCall.Receives).To(Equal([]fakes.RemoveAllReceive{{Path: readOnlyDir}}))
+		})
+
 		Context("when an error occurs", func() {
 			Context("when it fails to get terraform dir", func() {
 				BeforeEach(func() {
@@ -478,6 +724,17 @@ var _ = Describe("Executor", func() {
 				})
 			})
 
+			Context("when the temp dir cannot be created", func() {
+				BeforeEach(func() {
+					fileIO.TempDirCall.Returns.Error = errors.New("failed")
+				})
+
+				It("returns an error", func() {
+					_, err := executor.Output("external_ip")
+					Expect(err).To(MatchError("Create temp dir: failed"))
+				})
+			})
+
 			Context("when it fails to call terraform command run", func() {
 				BeforeEach(func() {
 					bufferingCmd.RunCall.Returns.Errors = []error{errors.New("failed")}
@@ -520,7 +777,7 @@ var _ = Describe("Executor", func() {
 
 			Expect(bufferingCmd.RunCall.Receives.WorkingDirectory).To(Equal(terraformDir))
 			Expect(bufferingCmd.RunCall.Receives.Args).To(Equal([]string{
-				"output", "--json", "-state", tfStatePath,
+				"output", "--json", "-state", filepath.Join(readOnlyDir, "terraform.tfstate"),
 			}))
 			Expect(cmd.RunCall.Receives.WorkingDirectory).To(Equal(terraformDir))
 			Expect(cmd.RunCall.Receives.Args).To(Equal([]string{"init", varsDir}))
@@ -575,6 +832,42 @@ var _ = Describe("Executor", func() {
 		})
 	})
 
+	Describe("StateChecksum", func() {
+		It("returns a checksum of the terraform state", func() {
+			fileIO.ReadFileCall.Returns.Contents = []byte("some-tf-state")
+
+			checksum, err := executor.StateChecksum()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(checksum).To(Equal("c12feefe15b26c5f9048e186ae21bd8317551f53ce974f81571d25c7ee1de8ee"))
+			Expect(fileIO.ReadFileCall.Receives.Filename).To(Equal(tfStatePath))
+		})
+
+		It("returns an empty checksum when there is no terraform state", func() {
+			fileIO.ReadFileCall.Returns.Error = os.ErrNotExist
+
+			checksum, err := executor.StateChecksum()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(checksum).To(BeEmpty())
+		})
+
+		Context("failure cases", func() {
+			It("returns an error when the vars dir cannot be found", func() {
+				stateStore.GetVarsDirCall.Returns.Error = errors.New("failed")
+
+				_, err := executor.StateChecksum()
+				Expect(err).To(MatchError("failed"))
+			})
+
+			It("returns an error when the terraform state cannot be read", func() {
+				fileIO.ReadFileCall.Returns.Error = errors.New("failed")
+
+				_, err := executor.StateChecksum()
+				Expect(err).To(MatchError("Read terraform state: failed"))
+			})
+		})
+	})
+
 	Describe("IsPaved", func() {
 		Context("when the state store fails to get the terraform directory", func() {
 			It("returns an error", func() {
@@ -636,3 +929,13 @@ var _ = Describe("Executor", func() {
 		})
 	})
 })
+
+type exitError int
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func (e exitError) ExitCode() int {
+	return int(e)
+}
@@ -22,9 +22,12 @@ type executor interface {
 	Setup(terraformTemplate string, inputs map[string]interface{}) error
 	Init() error
 	Apply(credentials map[string]string) error
+	Plan(credentials map[string]string) (string, error)
+	Drift(credentials map[string]string) ([]ResourceDrift, error)
 	Destroy(credentials map[string]string) error
 	Outputs() (map[string]interface{}, error)
 	Output(string) (string, error)
+	StateChecksum() (string, error)
 	IsPaved() (bool, error)
 }
 
@@ -115,7 +118,43 @@ func (m Manager) Apply(bblState storage.State) (storage.State, error) {
 		return bblState, fmt.Errorf("Executor apply: %s", err)
 	}
 
-	return bblState, nil
+	return m.cacheOutputs(bblState), nil
+}
+
+// Plan returns what terraform apply would change, or an empty string when
+// the infrastructure is up to date.
+func (m Manager) Plan(bblState storage.State) (string, error) {
+	m.logger.Step("terraform init")
+	if err := m.executor.Init(); err != nil {
+		return "", fmt.Errorf("Executor init: %s", err)
+	}
+
+	m.logger.Step("terraform plan")
+	plan, err := m.executor.Plan(m.inputGenerator.Credentials(bblState))
+	readAndReset(m.terraformOutputBuffer)
+	if err != nil {
+		return "", fmt.Errorf("Executor plan: %s", err)
+	}
+
+	return plan, nil
+}
+
+// Drift returns the resources that changed outside of terraform since the
+// last bbl up.
+func (m Manager) Drift(bblState storage.State) ([]ResourceDrift, error) {
+	m.logger.Step("terraform init")
+	if err := m.executor.Init(); err != nil {
+		return nil, fmt.Errorf("Executor init: %s", err)
+	}
+
+	m.logger.Step("terraform refresh and plan")
+	drift, err := m.executor.Drift(m.inputGenerator.Credentials(bblState))
+	readAndReset(m.terraformOutputBuffer)
+	if err != nil {
+		return nil, fmt.Errorf("Executor drift: %s", err)
+	}
+
+	return drift, nil
 }
 
 func (m Manager) Destroy(bblState storage.State) (storage.State, error) {
@@ -123,6 +162,8 @@ func (m Manager) Destroy(bblState storage.State) (storage.State, error) {
 	err := m.executor.Destroy(m.inputGenerator.Credentials(bblState))
 
 	bblState.LatestTFOutput = readAndReset(m.terraformOutputBuffer)
+	bblState.TFOutputs = nil
+	bblState.TFOutputsChecksum = ""
 
 	if err != nil {
 		return bblState, fmt.Errorf("Executor destroy: %s", err)
@@ -141,10 +182,44 @@ func (m Manager) GetOutputs() (Outputs, error) {
 	return Outputs{Map: tfOutputs}, nil
 }
 
+// CachedOutputs returns the outputs saved in the state by the last apply,
+// and only runs terraform when the terraform state has changed since.
+func (m Manager) CachedOutputs(bblState storage.State) (Outputs, error) {
+	if bblState.TFOutputs != nil {
+		checksum, err := m.executor.StateChecksum()
+		if err == nil && checksum != "" && checksum == bblState.TFOutputsChecksum {
+			return Outputs{Map: bblState.TFOutputs}, nil
+		}
+	}
+
+	return m.GetOutputs()
+}
+
 func (m Manager) IsPaved() (bool, error) {
 	return m.executor.IsPaved()
 }
 
+// cacheOutputs saves the outputs in the state. A failure is not fatal:
+// the cache is dropped and read-only commands fall back to terraform.
+func (m Manager) cacheOutputs(bblState storage.State) storage.State {
+	bblState.TFOutputs = nil
+	bblState.TFOutputsChecksum = ""
+
+	checksum, err := m.executor.StateChecksum()
+	if err != nil || checksum == "" {
+		return bblState
+	}
+
+	outputs, err := m.executor.Outputs()
+	if err != nil {
+		return bblState
+	}
+
+	bblState.TFOutputs = outputs
+	bblState.TFOutputsChecksum = checksum
+	return bblState
+}
+
 func readAndReset(buf *bytes.Buffer) string {
 	contents := buf.Bytes()
 	buf.Reset()
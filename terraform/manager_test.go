@@ -121,6 +121,66 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("Plan", func() {
+		BeforeEach(func() {
+			inputGenerator.CredentialsCall.Returns.Credentials = map[string]string{
+				"some-credential": "some-credential-value",
+			}
+			executor.PlanCall.Returns.Plan = "some-plan"
+		})
+
+		It("runs terraform plan with the credentials", func() {
+			plan, err := manager.Plan(storage.State{EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(plan).To(Equal("some-plan"))
+
+			Expect(executor.InitCall.CallCount).To(Equal(1))
+			Expect(executor.PlanCall.Receives.Credentials).To(Equal(map[string]string{
+				"some-credential": "some-credential-value",
+			}))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"terraform init", "terraform plan"}))
+		})
+
+		It("returns an error when terraform plan fails", func() {
+			executor.PlanCall.Returns.Error = errors.New("pomelo")
+
+			_, err := manager.Plan(storage.State{})
+			Expect(err).To(MatchError("Executor plan: pomelo"))
+		})
+	})
+
+	Describe("Drift", func() {
+		BeforeEach(func() {
+			inputGenerator.CredentialsCall.Returns.Credentials = map[string]string{
+				"some-credential": "some-credential-value",
+			}
+			executor.DriftCall.Returns.Drift = []terraform.ResourceDrift{
+				{Address: "some-resource.some-name", Action: "update"},
+			}
+		})
+
+		It("refreshes and plans with the credentials", func() {
+			drift, err := manager.Drift(storage.State{EnvID: "some-env-id"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift).To(Equal([]terraform.ResourceDrift{
+				{Address: "some-resource.some-name", Action: "update"},
+			}))
+
+			Expect(executor.InitCall.CallCount).To(Equal(1))
+			Expect(executor.DriftCall.Receives.Credentials).To(Equal(map[string]string{
+				"some-credential": "some-credential-value",
+			}))
+			Expect(logger.StepCall.Messages).To(Equal([]string{"terraform init", "terraform refresh and plan"}))
+		})
+
+		It("returns an error when terraform plan fails", func() {
+			executor.DriftCall.Returns.Error = errors.New("pomelo")
+
+			_, err := manager.Drift(storage.State{})
+			Expect(err).To(MatchError("Executor drift: pomelo"))
+		})
+	})
+
 	Describe("Apply", func() {
 		var (
 			incomingState storage.State
@@ -155,6 +215,32 @@ var _ = Describe("Manager", func() {
 			}))
 		})
 
+		Context("when there is a terraform state", func() {
+			BeforeEach(func() {
+				incomingState.TFOutputs = map[string]interface{}{"stale": "output"}
+				executor.StateChecksumCall.Returns.Checksum = "some-checksum"
+				executor.OutputsCall.Returns.Outputs = map[string]interface{}{"external_ip": "1.2.3.4"}
+			})
+
+			It("caches the outputs in the state", func() {
+				state, err := manager.Apply(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(state.TFOutputs).To(Equal(map[string]interface{}{"external_ip": "1.2.3.4"}))
+				Expect(state.TFOutputsChecksum).To(Equal("some-checksum"))
+			})
+
+			It("drops the cache when the outputs cannot be read", func() {
+				executor.OutputsCall.Returns.Error = errors.New("kiwi")
+
+				state, err := manager.Apply(incomingState)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(state.TFOutputs).To(BeNil())
+				Expect(state.TFOutputsChecksum).To(BeEmpty())
+			})
+		})
+
 		Context("when executor apply fails", func() {
 			BeforeEach(func() {
 				executor.ApplyCall.Returns.Error = errors.New("grape")
@@ -205,6 +291,17 @@ var _ = Describe("Manager", func() {
 			Expect(newBBLState).To(Equal(expectedState))
 		})
 
+		It("drops the cached outputs", func() {
+			incomingState.TFOutputs = map[string]interface{}{"external_ip": "1.2.3.4"}
+			incomingState.TFOutputsChecksum = "some-checksum"
+
+			newBBLState, err := manager.Destroy(incomingState)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBBLState.TFOutputs).To(BeNil())
+			Expect(newBBLState.TFOutputsChecksum).To(BeEmpty())
+		})
+
 		Context("when executor destroy fails", func() {
 			BeforeEach(func() {
 				executor.DestroyCall.Returns.Error = errors.New("grape")
@@ -243,6 +340,53 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("CachedOutputs", func() {
+		var state storage.State
+
+		BeforeEach(func() {
+			state = storage.State{
+				TFOutputs:         map[string]interface{}{"external_ip": "1.2.3.4"},
+				TFOutputsChecksum: "some-checksum",
+			}
+			executor.StateChecksumCall.Returns.Checksum = "some-checksum"
+			executor.OutputsCall.Returns.Outputs = map[string]interface{}{"external_ip": "5.6.7.8"}
+		})
+
+		It("returns the cached outputs without running terraform", func() {
+			outputs, err := manager.CachedOutputs(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(outputs.GetString("external_ip")).To(Equal("1.2.3.4"))
+			Expect(executor.OutputsCall.CallCount).To(Equal(0))
+		})
+
+		It("runs terraform when the terraform state has changed", func() {
+			executor.StateChecksumCall.Returns.Checksum = "other-checksum"
+
+			outputs, err := manager.CachedOutputs(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(outputs.GetString("external_ip")).To(Equal("5.6.7.8"))
+		})
+
+		It("runs terraform when nothing is cached", func() {
+			outputs, err := manager.CachedOutputs(storage.State{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(outputs.GetString("external_ip")).To(Equal("5.6.7.8"))
+			Expect(executor.StateChecksumCall.CallCount).To(Equal(0))
+		})
+
+		It("runs terraform when the terraform state cannot be read", func() {
+			executor.StateChecksumCall.Returns.Error = errors.New("lime")
+
+			outputs, err := manager.CachedOutputs(state)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(outputs.GetString("external_ip")).To(Equal("5.6.7.8"))
+		})
+	})
+
 	Describe("Version", func() {
 		BeforeEach(func() {
 			executor.VersionCall.Returns.Version = "some-version"
@@ -0,0 +1,66 @@
+package tunnel
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+type Process struct{}
+
+// Daemonize starts bbl again with args and env in a new session, with its
+// output appended to logPath, and returns its pid.
+func (Process) Daemonize(args, env []string, logPath string) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("Find bbl executable: %s", err)
+	}
+
+	logFile, err := os.OpenFile(logPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("Open %s: %s", logPath, err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, args...)
+	cmd.Env = env
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = sysProcAttr()
+
+	err = cmd.Start()
+	if err != nil {
+		return 0, fmt.Errorf("Start tunnel: %s", err)
+	}
+
+	go cmd.Wait()
+
+	return cmd.Process.Pid, nil
+}
+
+func (Process) Running(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	return alive(process)
+}
+
+func (Process) Stop(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+
+	return terminate(process)
+}
+
+func (Process) WaitForInterrupt() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+	signal.Stop(signals)
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package tunnel
+
+import (
+	"os"
+	"syscall"
+)
+
+func sysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+func alive(process *os.Process) bool {
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+func terminate(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
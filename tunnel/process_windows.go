@@ -0,0 +1,19 @@
+package tunnel
+
+import (
+	"os"
+	"syscall"
+)
+
+func sysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{}
+}
+
+// os.FindProcess fails on windows when the process does not exist.
+func alive(process *os.Process) bool {
+	return true
+}
+
+func terminate(process *os.Process) error {
+	return process.Kill()
+}
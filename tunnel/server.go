@@ -0,0 +1,144 @@
+package tunnel
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	proxy "github.com/cloudfoundry/socks5-proxy"
+	socks5 "github.com/genevieve/go-socks5"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/context"
+)
+
+// keepAliveInterval is how often the tunnel checks its ssh connection to
+// the jumpbox. The check also keeps an idle connection from being dropped.
+const keepAliveInterval = 30 * time.Second
+
+type dialer interface {
+	Dialer(username, key, url string) (proxy.DialFunc, error)
+}
+
+// Server serves a SOCKS5 proxy on a local port. Connections are dialed
+// through the jumpbox by the same socks5-proxy that bbl uses to reach the
+// director.
+type Server struct {
+	dialer dialer
+	stderr io.Writer
+}
+
+func NewServer(dialer dialer, stderr io.Writer) Server {
+	return Server{
+		dialer: dialer,
+		stderr: stderr,
+	}
+}
+
+// Listen connects to the jumpbox and starts serving on port, or on a random
+// port when port is 0. It returns the address of the proxy.
+func (s Server) Listen(privateKey, jumpboxURL string, port int) (string, error) {
+	jumpbox := &jumpboxConnection{
+		connect: func() (proxy.DialFunc, error) {
+			return s.dialer.Dialer("", privateKey, jumpboxURL)
+		},
+		stderr: s.stderr,
+	}
+
+	err := jumpbox.reconnect(0)
+	if err != nil {
+		return "", fmt.Errorf("Connect to jumpbox: %s", err)
+	}
+
+	server, err := socks5.New(&socks5.Config{
+		Dial: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return jumpbox.Dial(network, addr)
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("Create socks5 server: %s", err)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		return "", fmt.Errorf("Listen on port %d: %s", port, err)
+	}
+
+	go server.Serve(listener)
+	go jumpbox.keepAlive(keepAliveInterval)
+
+	return listener.Addr().String(), nil
+}
+
+// jumpboxConnection dials through the ssh connection to the jumpbox, and
+// connects again when that connection has dropped.
+type jumpboxConnection struct {
+	connect func() (proxy.DialFunc, error)
+	stderr  io.Writer
+
+	mutex      sync.Mutex
+	dial       proxy.DialFunc
+	generation int
+}
+
+func (j *jumpboxConnection) Dial(network, addr string) (net.Conn, error) {
+	j.mutex.Lock()
+	dial, generation := j.dial, j.generation
+	j.mutex.Unlock()
+
+	conn, err := dial(network, addr)
+	if err == nil || !dropped(err) {
+		return conn, err
+	}
+
+	if j.reconnect(generation) != nil {
+		return nil, err
+	}
+
+	j.mutex.Lock()
+	dial = j.dial
+	j.mutex.Unlock()
+
+	return dial(network, addr)
+}
+
+// reconnect replaces the ssh connection unless another dial already did
+// since generation.
+func (j *jumpboxConnection) reconnect(generation int) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.generation != generation {
+		return nil
+	}
+
+	dial, err := j.connect()
+	if err != nil {
+		return err
+	}
+
+	j.dial = dial
+	j.generation++
+	return nil
+}
+
+// keepAlive opens a connection to the jumpbox's own ssh port through the
+// tunnel every interval, which reconnects when the tunnel has dropped.
+func (j *jumpboxConnection) keepAlive(interval time.Duration) {
+	for range time.Tick(interval) {
+		conn, err := j.Dial("tcp", "127.0.0.1:22")
+		if err != nil {
+			fmt.Fprintf(j.stderr, "Keep alive: %s\n", err)
+			continue
+		}
+		conn.Close()
+	}
+}
+
+// dropped reports whether err means the ssh connection to the jumpbox is
+// gone, rather than that the jumpbox could not reach the address.
+func dropped(err error) bool {
+	_, rejected := err.(*ssh.OpenChannelError)
+	return !rejected
+}
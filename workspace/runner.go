@@ -0,0 +1,56 @@
+package workspace
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// Runner runs a bbl command against one environment of a workspace.
+type Runner struct {
+	executable string
+}
+
+func NewRunner(executable string) Runner {
+	return Runner{
+		executable: executable,
+	}
+}
+
+// Run runs bbl with args in stateDir, writing its output to out, and
+// returns its exit code. It only returns an error when bbl cannot be
+// started.
+func (r Runner) Run(stateDir string, args []string, out io.Writer) (int, error) {
+	cmd := exec.Command(r.executable, append([]string{"--state-dir", stateDir}, args...)...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.Env = environ()
+
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus(), nil
+		}
+		return 1, nil // not tested
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return 0, nil
+}
+
+// environ drops the variables that select a state directory, which would
+// conflict with --state-dir.
+func environ() []string {
+	env := []string{}
+	for _, variable := range os.Environ() {
+		if strings.HasPrefix(variable, "BBL_ENV=") || strings.HasPrefix(variable, "BBL_STATE_DIRECTORY=") {
+			continue
+		}
+		env = append(env, variable)
+	}
+	return env
+}
@@ -0,0 +1,71 @@
+package workspace_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry/bosh-bootloader/workspace"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Runner", func() {
+	var (
+		executable string
+		runner     workspace.Runner
+	)
+
+	BeforeEach(func() {
+		dir, err := ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		executable = filepath.Join(dir, "bbl")
+		err = ioutil.WriteFile(executable, []byte(`#!/bin/sh
+echo "$@"
+echo "env=${BBL_ENV}${BBL_STATE_DIRECTORY}" >&2
+exit ${EXIT_CODE:-0}
+`), 0700)
+		Expect(err).NotTo(HaveOccurred())
+
+		os.Setenv("BBL_ENV", "some-env")
+		os.Setenv("BBL_STATE_DIRECTORY", "/some/state")
+
+		runner = workspace.NewRunner(executable)
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("BBL_ENV")
+		os.Unsetenv("BBL_STATE_DIRECTORY")
+		os.Unsetenv("EXIT_CODE")
+	})
+
+	It("runs bbl in the state directory without the variables that select another one", func() {
+		out := bytes.NewBuffer([]byte{})
+
+		code, err := runner.Run("/workspace/prod", []string{"print-env", "--json"}, out)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(code).To(Equal(0))
+		Expect(out.String()).To(Equal("--state-dir /workspace/prod print-env --json\nenv=\n"))
+	})
+
+	It("returns the exit code", func() {
+		os.Setenv("EXIT_CODE", "3")
+
+		code, err := runner.Run("/workspace/prod", []string{"drift"}, ioutil.Discard)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(code).To(Equal(3))
+	})
+
+	Context("when bbl cannot be started", func() {
+		It("returns an error", func() {
+			runner = workspace.NewRunner("/missing/bbl")
+
+			_, err := runner.Run("/workspace/prod", []string{"drift"}, ioutil.Discard)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,132 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/bosh-bootloader/fileio"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+)
+
+type stateBootstrap interface {
+	GetState(dir string) (storage.State, error)
+}
+
+type fs interface {
+	fileio.DirReader
+	fileio.FileReader
+	fileio.Stater
+}
+
+// Environment is one state directory in a workspace.
+type Environment struct {
+	Name            string             `json:"name"`
+	StateDir        string             `json:"stateDir"`
+	IAAS            string             `json:"iaas"`
+	EnvID           string             `json:"envID"`
+	BBLVersion      string             `json:"bblVersion"`
+	DirectorAddress string             `json:"directorAddress"`
+	Status          string             `json:"status"`
+	LastOperation   *storage.Operation `json:"lastOperation,omitempty"`
+	Error           string             `json:"error,omitempty"`
+}
+
+// Workspace is a directory whose subdirectories are the state directories
+// of named environments, so that bbl --env <name> can find them.
+type Workspace struct {
+	root           string
+	fs             fs
+	stateBootstrap stateBootstrap
+}
+
+func New(root string, fs fs, stateBootstrap stateBootstrap) Workspace {
+	return Workspace{
+		root:           root,
+		fs:             fs,
+		stateBootstrap: stateBootstrap,
+	}
+}
+
+// Environments returns the environments in the workspace sorted by name.
+// An environment whose state cannot be read is returned with its Error
+// set, so that one broken environment does not hide the others.
+func (w Workspace) Environments() ([]Environment, error) {
+	entries, err := w.fs.ReadDir(w.root)
+	if err != nil {
+		return nil, fmt.Errorf("Read workspace: %s", err)
+	}
+
+	environments := []Environment{}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		stateDir := filepath.Join(w.root, entry.Name())
+		if _, err := w.fs.Stat(filepath.Join(stateDir, storage.STATE_FILE)); err != nil {
+			continue
+		}
+
+		environments = append(environments, w.environment(entry.Name(), stateDir))
+	}
+
+	sort.Slice(environments, func(i, j int) bool { return environments[i].Name < environments[j].Name })
+	return environments, nil
+}
+
+func (w Workspace) environment(name, stateDir string) Environment {
+	environment := Environment{Name: name, StateDir: stateDir}
+
+	state, err := w.stateBootstrap.GetState(stateDir)
+	if err != nil {
+		environment.Error = err.Error()
+		environment.Status = "unreadable"
+		return environment
+	}
+
+	environment.IAAS = state.IAAS
+	environment.EnvID = state.EnvID
+	environment.BBLVersion = state.BBLVersion
+	environment.DirectorAddress = state.BOSH.DirectorAddress
+
+	operation, err := storage.ReadOperation(w.fs, stateDir)
+	if err != nil {
+		environment.Error = err.Error()
+	}
+	if !operation.Empty() {
+		environment.LastOperation = &operation
+	}
+
+	environment.Status = status(w.lock(stateDir), operation)
+	return environment
+}
+
+func (w Workspace) lock(stateDir string) storage.Lock {
+	var lock storage.Lock
+
+	contents, err := w.fs.ReadFile(filepath.Join(stateDir, storage.LOCK_FILE))
+	if err == nil {
+		json.Unmarshal(contents, &lock)
+	}
+
+	return lock
+}
+
+// status describes the command running in the environment, or else the
+// outcome of the last one.
+func status(lock storage.Lock, operation storage.Operation) string {
+	switch {
+	case !lock.Empty() && !lock.Stale():
+		return fmt.Sprintf("running %s", lock.Command)
+	case !lock.Empty():
+		return fmt.Sprintf("%s interrupted", lock.Command)
+	case operation.Empty():
+		return "unknown"
+	case operation.Succeeded:
+		return fmt.Sprintf("%s succeeded", operation.Command)
+	}
+	return fmt.Sprintf("%s failed", operation.Command)
+}
@@ -0,0 +1,105 @@
+package workspace_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry/bosh-bootloader/fakes"
+	"github.com/cloudfoundry/bosh-bootloader/storage"
+	"github.com/cloudfoundry/bosh-bootloader/workspace"
+	"github.com/spf13/afero"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Workspace", func() {
+	var (
+		fs             *afero.Afero
+		stateBootstrap *fakes.StateBootstrap
+		ws             workspace.Workspace
+	)
+
+	BeforeEach(func() {
+		fs = &afero.Afero{Fs: afero.NewMemMapFs()}
+		fs.WriteFile("/workspace/prod/bbl-state.json", []byte("{}"), 0600)
+		fs.WriteFile("/workspace/prod/bbl-operation.json", []byte(`{"command": "up", "succeeded": true}`), 0600)
+		fs.WriteFile("/workspace/dev/bbl-state.json", []byte("{}"), 0600)
+		fs.WriteFile("/workspace/dev/bbl-operation.json", []byte(`{"command": "up", "succeeded": false}`), 0600)
+		fs.WriteFile("/workspace/staging/bbl-state.json", []byte("{}"), 0600)
+		fs.WriteFile("/workspace/staging/bbl.lock", []byte(fmt.Sprintf(`{"command": "destroy", "host": "some-other-host", "timestamp": %q}`, time.Now().UTC().Format(time.RFC3339))), 0600)
+		fs.WriteFile("/workspace/notes/README.md", []byte("not an environment"), 0600)
+		fs.WriteFile("/workspace/.bbl-snapshots/1/bbl-state.json", []byte("{}"), 0600)
+		fs.WriteFile("/workspace/bbl.yml", []byte(""), 0600)
+
+		stateBootstrap = &fakes.StateBootstrap{}
+		stateBootstrap.GetStateCall.Fake = func(dir string) (storage.State, error) {
+			if dir == "/workspace/dev" {
+				return storage.State{}, errors.New("cipher: message authentication failed")
+			}
+			return storage.State{
+				IAAS:       "aws",
+				EnvID:      "some-env-id",
+				BBLVersion: "7.0.0",
+				BOSH:       storage.BOSH{DirectorAddress: "https://10.0.0.6:25555"},
+			}, nil
+		}
+
+		ws = workspace.New("/workspace", fs, stateBootstrap)
+	})
+
+	It("returns the environments with their state and last operation", func() {
+		environments, err := ws.Environments()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(environments).To(HaveLen(3))
+		Expect(environments[0]).To(Equal(workspace.Environment{
+			Name:     "dev",
+			StateDir: "/workspace/dev",
+			Status:   "unreadable",
+			Error:    "cipher: message authentication failed",
+		}))
+		Expect(environments[1]).To(Equal(workspace.Environment{
+			Name:            "prod",
+			StateDir:        "/workspace/prod",
+			IAAS:            "aws",
+			EnvID:           "some-env-id",
+			BBLVersion:      "7.0.0",
+			DirectorAddress: "https://10.0.0.6:25555",
+			Status:          "up succeeded",
+			LastOperation:   &storage.Operation{Command: "up", Succeeded: true},
+		}))
+		Expect(environments[2].Name).To(Equal("staging"))
+		Expect(environments[2].Status).To(Equal("running destroy"))
+	})
+
+	It("reports failed and interrupted operations", func() {
+		stateBootstrap.GetStateCall.Fake = nil
+		fs.WriteFile("/workspace/staging/bbl.lock", []byte(`{"command": "destroy", "host": "some-other-host", "timestamp": "2018-03-01T12:00:00Z"}`), 0600)
+
+		environments, err := ws.Environments()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(environments[0].Status).To(Equal("up failed"))
+		Expect(environments[2].Status).To(Equal("destroy interrupted"))
+	})
+
+	It("reports environments without a recorded operation", func() {
+		stateBootstrap.GetStateCall.Fake = nil
+		fs.Remove("/workspace/staging/bbl.lock")
+
+		environments, err := ws.Environments()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(environments[2].Status).To(Equal("unknown"))
+	})
+
+	Context("when the workspace cannot be read", func() {
+		It("returns an error", func() {
+			ws = workspace.New("/missing", fs, stateBootstrap)
+
+			_, err := ws.Environments()
+			Expect(err).To(MatchError(ContainSubstring("Read workspace:")))
+		})
+	})
+})